@@ -25,6 +25,9 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// OnStarted is called once all plugins are initialized and started
+	OnStarted func()
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -171,6 +174,10 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.OnStarted != nil {
+		a.OnStarted()
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
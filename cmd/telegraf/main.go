@@ -254,6 +254,9 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 			testWait:                cCtx.Int("test-wait"),
 			configURLRetryAttempts:  cCtx.Int("config-url-retry-attempts"),
 			configURLWatchInterval:  cCtx.Duration("config-url-watch-interval"),
+			configURLPublicKeys:     cCtx.StringSlice("config-url-public-key"),
+			configURLSigSuffix:      cCtx.String("config-url-signature-suffix"),
+			configURLCacheDir:       cCtx.String("config-url-cache-directory"),
 			watchConfig:             cCtx.String("watch-config"),
 			watchInterval:           cCtx.Duration("watch-interval"),
 			watchDebounceInterval:   cCtx.Duration("watch-debounce-interval"),
@@ -328,6 +331,17 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 					DefaultText: "0s",
 					Value:       0,
 				},
+				&cli.StringFlag{
+					Name: "config-url-signature-suffix",
+					Usage: "Suffix appended to the path of URL based configuration files to fetch " +
+						"the detached signature",
+					Value: ".sig",
+				},
+				&cli.StringFlag{
+					Name: "config-url-cache-directory",
+					Usage: "Directory to keep the last-known-good version of URL based configuration " +
+						"files for rolling back if a new configuration fails to load or start",
+				},
 				&cli.StringFlag{
 					Name:  "pidfile",
 					Usage: "file to write our pid to",
@@ -367,6 +381,11 @@ func runApp(args []string, outputBuffer io.Writer, pprof Server, c TelegrafConfi
 					Usage: "enable test mode: gather metrics, print them out, and exit. " +
 						"Note: Test mode only runs inputs, processors, and aggregators, but not outputs",
 				},
+				&cli.StringSliceFlag{
+					Name: "config-url-public-key",
+					Usage: "Public key file (minisign or PEM encoded ed25519) to verify the signature " +
+						"of URL based configuration files. Unsigned configurations are rejected if set.",
+				},
 				&cli.StringSliceFlag{
 					Name: "select",
 					Usage: "enable only plugins with labels matching the given key-value selection. " +
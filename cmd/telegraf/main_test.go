@@ -614,6 +614,8 @@ func TestFlagsAreSet(t *testing.T) {
 		"--test-wait", strconv.Itoa(expectedInt),
 		"--watch-config", expectedString,
 		"--pidfile", expectedString,
+		"--config-url-public-key", expectedString,
+		"--config-url-cache-directory", expectedString,
 	}
 
 	buf := new(bytes.Buffer)
@@ -632,4 +634,7 @@ func TestFlagsAreSet(t *testing.T) {
 	require.Equal(t, expectedInt, m.testWait)
 	require.Equal(t, expectedString, m.watchConfig)
 	require.Equal(t, expectedString, m.pidFile)
+	require.Equal(t, []string{expectedString}, m.configURLPublicKeys)
+	require.Equal(t, ".sig", m.configURLSigSuffix)
+	require.Equal(t, expectedString, m.configURLCacheDir)
 }
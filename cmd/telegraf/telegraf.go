@@ -39,6 +39,9 @@ type GlobalFlags struct {
 	testWait                int
 	configURLRetryAttempts  int
 	configURLWatchInterval  time.Duration
+	configURLPublicKeys     []string
+	configURLSigSuffix      string
+	configURLCacheDir       string
 	watchConfig             string
 	watchInterval           time.Duration
 	watchDebounceInterval   time.Duration
@@ -83,6 +86,11 @@ type Telegraf struct {
 
 	cfg *config.Config
 
+	// useLastKnownGood loads the last-known-good version of remote configs
+	useLastKnownGood bool
+	// agentStarted is set once the agent started all plugins successfully
+	agentStarted bool
+
 	GlobalFlags
 	WindowFlags
 }
@@ -206,6 +214,13 @@ func (t *Telegraf) reloadLoop() error {
 		}()
 
 		err := t.runAgent(ctx, reloadConfig)
+		if err != nil && !errors.Is(err, context.Canceled) && !t.agentStarted && t.canRollback() {
+			log.Printf("E! Starting agent failed: %v", err)
+			log.Println("W! Rolling back to last-known-good remote configuration")
+			t.useLastKnownGood = true
+			err = t.runAgent(ctx, true)
+		}
+		t.useLastKnownGood = false
 		if err != nil && !errors.Is(err, context.Canceled) {
 			return fmt.Errorf("[telegraf] Error running agent: %w", err)
 		}
@@ -403,16 +418,34 @@ func (t *Telegraf) loadConfiguration() (*config.Config, error) {
 	c.InputFilters = t.inputFilters
 	c.SecretStoreFilters = t.secretstoreFilters
 	c.TestMode = !t.once && (t.test || t.testWait != 0)
+	c.RemoteConfig = config.RemoteConfigOptions{
+		PublicKeys:       t.configURLPublicKeys,
+		SignatureSuffix:  t.configURLSigSuffix,
+		CacheDir:         t.configURLCacheDir,
+		UseLastKnownGood: t.useLastKnownGood,
+	}
 
 	if err := t.getConfigFiles(); err != nil {
 		return c, err
 	}
 	if err := c.LoadAll(t.configFiles...); err != nil {
-		return c, err
+		if !t.canRollback() {
+			return c, err
+		}
+		log.Printf("E! Loading configuration failed: %v", err)
+		log.Println("W! Rolling back to last-known-good remote configuration")
+		t.useLastKnownGood = true
+		return t.loadConfiguration()
 	}
 	return c, nil
 }
 
+// canRollback checks if we can fall back to the last-known-good version of
+// the remote configurations
+func (t *Telegraf) canRollback() bool {
+	return !t.useLastKnownGood && config.HasLastKnownGoodRemoteConfigs(t.configURLCacheDir, t.configFiles)
+}
+
 func (t *Telegraf) getConfigFiles() error {
 	var configFiles []string
 
@@ -530,6 +563,13 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		}
 	}
 	ag := agent.NewAgent(c)
+	t.agentStarted = false
+	ag.OnStarted = func() {
+		t.agentStarted = true
+		if err := c.CommitRemoteConfigs(); err != nil {
+			log.Printf("W! Storing last-known-good remote configuration failed: %v", err)
+		}
+	}
 
 	// Notify systemd that telegraf is ready
 	// SdNotify() only tries to notify if the NOTIFY_SOCKET environment is set, so it's safe to call when systemd isn't present.
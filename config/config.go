@@ -91,6 +91,11 @@ type Config struct {
 	// TestMode keeps output parsing in place while avoiding resources only
 	// needed when outputs are actually used.
 	TestMode bool
+	// RemoteConfig controls verification and caching of remote configurations
+	RemoteConfig RemoteConfigOptions
+	// remotePending contains the remote configurations loaded but not yet
+	// committed as last-known-good
+	remotePending map[string][]byte
 
 	SecretStores      map[string]telegraf.SecretStore
 	secretStoreSource map[string][]string
@@ -565,11 +570,26 @@ func (c *Config) LoadConfig(path string) error {
 		log.Printf("I! Loading config: %s", path)
 	}
 
-	data, _, err := LoadConfigFileWithRetries(path, c.Agent.ConfigURLRetryAttempts)
+	var data []byte
+	var remote bool
+	var err error
+	if c.RemoteConfig.isActive() && fetchURLRe.MatchString(path) {
+		data, err = c.loadRemoteConfigFile(path)
+		remote = true
+	} else {
+		data, remote, err = LoadConfigFileWithRetries(path, c.Agent.ConfigURLRetryAttempts)
+	}
 	if err != nil {
 		return fmt.Errorf("loading config file %s failed: %w", path, err)
 	}
 
+	if remote && isBundle(data) {
+		if err := c.loadBundle(data, path); err != nil {
+			return fmt.Errorf("loading config bundle %s failed: %w", path, err)
+		}
+		return nil
+	}
+
 	if err = c.LoadConfigData(data, path); err != nil {
 		return fmt.Errorf("loading config file %s failed: %w", path, err)
 	}
@@ -0,0 +1,408 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// maxBundleEntrySize limits the size of a single file in a configuration
+// bundle to protect against decompression bombs
+const maxBundleEntrySize = 16 * 1024 * 1024
+
+// RemoteConfigOptions controls verification and caching of configurations
+// fetched from URLs
+type RemoteConfigOptions struct {
+	// PublicKeys contains the files of the keys used to verify the detached
+	// signature of remote configurations. If set, remote configurations
+	// without a valid signature are rejected.
+	PublicKeys []string
+	// SignatureSuffix is appended to the URL path to fetch the signature
+	SignatureSuffix string
+	// CacheDir is the directory to keep the last-known-good versions of
+	// remote configurations in
+	CacheDir string
+	// UseLastKnownGood loads remote configurations from the cache instead
+	// of fetching them from their URL
+	UseLastKnownGood bool
+}
+
+// verificationKey is a ed25519 public key with an optional minisign key ID
+type verificationKey struct {
+	id  []byte
+	key ed25519.PublicKey
+}
+
+func (opts *RemoteConfigOptions) isActive() bool {
+	return len(opts.PublicKeys) > 0 || opts.CacheDir != ""
+}
+
+func (c *Config) loadRemoteConfigFile(config string) ([]byte, error) {
+	u, err := url.Parse(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "https", "http":
+	default:
+		return nil, fmt.Errorf("scheme %q not supported", u.Scheme)
+	}
+
+	data, err := c.loadRemoteConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	sourcesMu.Lock()
+	sources = append(sources, u.Redacted())
+	sourcesMu.Unlock()
+
+	return data, nil
+}
+
+// loadRemoteConfig fetches the configuration from the given URL, verifies
+// its signature if keys are configured and remembers the content for
+// committing it as last-known-good later. If the last-known-good version is
+// requested, the cached version is returned instead.
+func (c *Config) loadRemoteConfig(u *url.URL) ([]byte, error) {
+	opts := c.RemoteConfig
+	if opts.UseLastKnownGood {
+		if opts.CacheDir == "" {
+			return nil, errors.New("no cache directory for last-known-good configurations")
+		}
+		data, err := os.ReadFile(remoteCacheFilename(opts.CacheDir, u))
+		if err != nil {
+			return nil, fmt.Errorf("reading last-known-good configuration failed: %w", err)
+		}
+		log.Printf("I! Using last-known-good configuration for %s", u.Redacted())
+		return data, nil
+	}
+
+	data, err := fetchConfig(u, c.Agent.ConfigURLRetryAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(opts.PublicKeys) > 0 {
+		keys, err := loadVerificationKeys(opts.PublicKeys)
+		if err != nil {
+			return nil, err
+		}
+		suffix := opts.SignatureSuffix
+		if suffix == "" {
+			suffix = ".sig"
+		}
+		su := *u
+		su.Path += suffix
+		su.RawPath = ""
+		signature, err := fetchSignature(&su)
+		if err != nil {
+			return nil, fmt.Errorf("fetching signature failed: %w", err)
+		}
+		if err := verifySignature(data, signature, keys); err != nil {
+			return nil, fmt.Errorf("verifying signature of %s failed: %w", u.Redacted(), err)
+		}
+	}
+
+	if opts.CacheDir != "" {
+		if c.remotePending == nil {
+			c.remotePending = make(map[string][]byte)
+		}
+		c.remotePending[remoteCacheFilename(opts.CacheDir, u)] = data
+	}
+
+	return data, nil
+}
+
+// CommitRemoteConfigs stores the remote configurations loaded into this
+// config as last-known-good versions. This should be called after the agent
+// successfully started with the configuration.
+func (c *Config) CommitRemoteConfigs() error {
+	if c.RemoteConfig.CacheDir == "" || c.RemoteConfig.UseLastKnownGood {
+		return nil
+	}
+
+	if err := os.MkdirAll(c.RemoteConfig.CacheDir, 0700); err != nil {
+		return fmt.Errorf("creating cache directory failed: %w", err)
+	}
+
+	for fn, data := range c.remotePending {
+		// Write the file atomically to not end up with a corrupted
+		// last-known-good configuration
+		f, err := os.CreateTemp(c.RemoteConfig.CacheDir, ".pending-*")
+		if err != nil {
+			return fmt.Errorf("creating temporary file failed: %w", err)
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return fmt.Errorf("writing %q failed: %w", f.Name(), err)
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(f.Name())
+			return fmt.Errorf("closing %q failed: %w", f.Name(), err)
+		}
+		if err := os.Rename(f.Name(), fn); err != nil {
+			os.Remove(f.Name())
+			return fmt.Errorf("replacing %q failed: %w", fn, err)
+		}
+	}
+	c.remotePending = nil
+
+	return nil
+}
+
+// HasLastKnownGoodRemoteConfigs returns true if a last-known-good version is
+// available in the cache directory for all remote configurations in the list
+func HasLastKnownGoodRemoteConfigs(dir string, configs []string) bool {
+	if dir == "" {
+		return false
+	}
+
+	var found bool
+	for _, cfg := range configs {
+		if !fetchURLRe.MatchString(cfg) {
+			continue
+		}
+		u, err := url.Parse(cfg)
+		if err != nil {
+			return false
+		}
+		if _, err := os.Stat(remoteCacheFilename(dir, u)); err != nil {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+func remoteCacheFilename(dir string, u *url.URL) string {
+	h := sha256.Sum256([]byte(u.String()))
+	return filepath.Join(dir, hex.EncodeToString(h[:])+".conf")
+}
+
+func fetchSignature(u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, exists := os.LookupEnv("TELEGRAF_CONTROLLER_TOKEN"); exists {
+		req.Header.Add("Authorization", "Bearer "+v)
+	} else if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+		req.Header.Add("Authorization", "Token "+v)
+	}
+	req.Header.Set("User-Agent", internal.ProductToken())
+
+	return requestURLConfig(req)
+}
+
+// loadVerificationKeys reads the given public key files. Both minisign public
+// keys and PEM encoded ed25519 keys in PKIX format are supported.
+func loadVerificationKeys(files []string) ([]verificationKey, error) {
+	keys := make([]verificationKey, 0, len(files))
+	for _, fn := range files {
+		buf, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key failed: %w", err)
+		}
+		key, err := parseVerificationKey(buf)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %q failed: %w", fn, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func parseVerificationKey(buf []byte) (verificationKey, error) {
+	if block, _ := pem.Decode(buf); block != nil {
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		key, ok := k.(ed25519.PublicKey)
+		if !ok {
+			return verificationKey{}, fmt.Errorf("unsupported key type %T", k)
+		}
+		return verificationKey{key: key}, nil
+	}
+
+	// Minisign public key, the first line is an optional untrusted comment
+	raw, err := decodeMinisignLine(buf)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	if len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return verificationKey{}, errors.New("invalid minisign public key")
+	}
+	return verificationKey{id: raw[2:10], key: ed25519.PublicKey(raw[10:])}, nil
+}
+
+// verifySignature checks the data against the given detached signature. The
+// signature is either in minisign format or a raw ed25519 signature, in which
+// case it might be base64 encoded.
+func verifySignature(data, signature []byte, keys []verificationKey) error {
+	if bytes.HasPrefix(signature, []byte("untrusted comment:")) {
+		return verifyMinisign(data, signature, keys)
+	}
+
+	sig := signature
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			return fmt.Errorf("decoding signature failed: %w", err)
+		}
+		sig = decoded
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature size %d", len(sig))
+	}
+
+	for _, k := range keys {
+		if ed25519.Verify(k.key, data, sig) {
+			return nil
+		}
+	}
+	return errors.New("signature does not match any key")
+}
+
+func verifyMinisign(data, signature []byte, keys []verificationKey) error {
+	raw, err := decodeMinisignLine(signature)
+	if err != nil {
+		return err
+	}
+	if len(raw) != 2+8+ed25519.SignatureSize {
+		return errors.New("invalid minisign signature")
+	}
+	alg, id, sig := string(raw[:2]), raw[2:10], raw[10:]
+
+	lines := strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("missing trusted comment in minisign signature")
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return errors.New("invalid global signature in minisign signature")
+	}
+
+	// Compute the message depending on the (pre-hashing) algorithm
+	msg := data
+	switch alg {
+	case "Ed":
+	case "ED":
+		h := blake2b.Sum512(data)
+		msg = h[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", alg)
+	}
+
+	for _, k := range keys {
+		if k.id != nil && !bytes.Equal(k.id, id) {
+			continue
+		}
+		if !ed25519.Verify(k.key, msg, sig) {
+			continue
+		}
+		if !ed25519.Verify(k.key, append(bytes.Clone(sig), trusted...), global) {
+			return errors.New("invalid global signature")
+		}
+		return nil
+	}
+	return errors.New("signature does not match any key")
+}
+
+// decodeMinisignLine base64-decodes the first non-comment line of a minisign file
+func decodeMinisignLine(buf []byte) ([]byte, error) {
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		return base64.StdEncoding.DecodeString(line)
+	}
+	return nil, errors.New("invalid minisign format")
+}
+
+// isBundle checks if the data is a (gzip compressed) tar archive
+func isBundle(data []byte) bool {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		return true
+	}
+	return len(data) > 262 && string(data[257:262]) == "ustar"
+}
+
+// loadBundle loads all configuration files contained in the given tar bundle
+// in lexical order, equivalent to a configuration directory
+func (c *Config) loadBundle(data []byte, source string) error {
+	var r io.Reader = bytes.NewReader(data)
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("decompressing bundle failed: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading bundle failed: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Ext(hdr.Name) != ".conf" {
+			continue
+		}
+		if hdr.Size > maxBundleEntrySize {
+			return fmt.Errorf("file %q in bundle exceeds the maximum size", hdr.Name)
+		}
+		buf, err := io.ReadAll(io.LimitReader(tr, maxBundleEntrySize))
+		if err != nil {
+			return fmt.Errorf("reading %q from bundle failed: %w", hdr.Name, err)
+		}
+		files[path.Clean(hdr.Name)] = buf
+	}
+	if len(files) == 0 {
+		return errors.New("no configuration files found in bundle")
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := c.LoadConfigData(files[name], source+"#"+name); err != nil {
+			return fmt.Errorf("loading %q from bundle failed: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestRemoteConfigSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keyfile := writePEMPublicKey(t, pub)
+	content := []byte("[global_tags]\n  dc = \"us-east-1\"\n")
+
+	tests := []struct {
+		name      string
+		signature []byte
+		expected  string
+	}{
+		{
+			name:      "raw signature",
+			signature: ed25519.Sign(priv, content),
+		},
+		{
+			name:      "base64 signature",
+			signature: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content)) + "\n"),
+		},
+		{
+			name:      "minisign signature",
+			signature: minisign(priv, nil, content, false),
+		},
+		{
+			name:      "minisign prehashed signature",
+			signature: minisign(priv, nil, content, true),
+		},
+		{
+			name:      "wrong key",
+			signature: ed25519.Sign(otherPriv, content),
+			expected:  "signature does not match any key",
+		},
+		{
+			name:      "garbage",
+			signature: []byte("garbage"),
+			expected:  "decoding signature failed",
+		},
+		{
+			name:     "missing signature",
+			expected: "fetching signature failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/telegraf.conf":
+					_, _ = w.Write(content)
+				case "/telegraf.conf.sig":
+					if tt.signature == nil {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write(tt.signature)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+
+			c := NewConfig()
+			c.Agent.ConfigURLRetryAttempts = 1
+			c.RemoteConfig.PublicKeys = []string{keyfile}
+			err := c.LoadConfig(ts.URL + "/telegraf.conf")
+			if tt.expected != "" {
+				require.ErrorContains(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "us-east-1", c.Tags["dc"])
+		})
+	}
+}
+
+func TestRemoteConfigMinisignKey(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	id := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	raw := append([]byte("Ed"), id...)
+	raw = append(raw, pub...)
+	keyfile := filepath.Join(t.TempDir(), "minisign.pub")
+	keydata := "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+	require.NoError(t, os.WriteFile(keyfile, []byte(keydata), 0600))
+
+	keys, err := loadVerificationKeys([]string{keyfile})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+
+	content := []byte("[agent]\n")
+	require.NoError(t, verifySignature(content, minisign(priv, id, content, false), keys))
+
+	// Signatures with a different key ID must not be accepted
+	otherID := []byte{8, 7, 6, 5, 4, 3, 2, 1}
+	require.ErrorContains(t, verifySignature(content, minisign(priv, otherID, content, false), keys), "does not match")
+
+	// Modified trusted comments must be detected
+	sig := bytes.Replace(minisign(priv, id, content, false), []byte("timestamp:0"), []byte("timestamp:1"), 1)
+	require.ErrorContains(t, verifySignature(content, sig, keys), "invalid global signature")
+}
+
+func TestRemoteConfigBundle(t *testing.T) {
+	files := map[string]string{
+		"telegraf.d/10-tags.conf":  "[global_tags]\n  dc = \"us-east-1\"\n",
+		"telegraf.d/20-tags.conf":  "[global_tags]\n  rack = \"42\"\n",
+		"telegraf.d/README.md":     "not a config",
+		"telegraf.d/00-agent.conf": "[agent]\n  interval = \"3s\"\n",
+	}
+
+	for _, compressed := range []bool{false, true} {
+		name := "tar"
+		if compressed {
+			name = "tar.gz"
+		}
+		t.Run(name, func(t *testing.T) {
+			bundle := createBundle(t, files, compressed)
+			require.True(t, isBundle(bundle))
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write(bundle)
+			}))
+			defer ts.Close()
+
+			c := NewConfig()
+			c.Agent.ConfigURLRetryAttempts = 1
+			require.NoError(t, c.LoadConfig(ts.URL+"/bundle."+name))
+			require.Equal(t, "us-east-1", c.Tags["dc"])
+			require.Equal(t, "42", c.Tags["rack"])
+			require.Equal(t, Duration(3_000_000_000), c.Agent.Interval)
+		})
+	}
+}
+
+func TestRemoteConfigLastKnownGood(t *testing.T) {
+	content := []byte("[global_tags]\n  version = \"1\"\n")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer ts.Close()
+
+	cachedir := filepath.Join(t.TempDir(), "cache")
+	configs := []string{ts.URL + "/telegraf.conf"}
+	require.False(t, HasLastKnownGoodRemoteConfigs(cachedir, configs))
+
+	// Load the configuration without committing it
+	c := NewConfig()
+	c.Agent.ConfigURLRetryAttempts = 1
+	c.RemoteConfig.CacheDir = cachedir
+	require.NoError(t, c.LoadAll(configs...))
+	require.False(t, HasLastKnownGoodRemoteConfigs(cachedir, configs))
+
+	// Commit the configuration and modify the remote
+	require.NoError(t, c.CommitRemoteConfigs())
+	require.True(t, HasLastKnownGoodRemoteConfigs(cachedir, configs))
+	content = []byte("[global_tags]\n  version = \"2\"\n")
+
+	c = NewConfig()
+	c.Agent.ConfigURLRetryAttempts = 1
+	c.RemoteConfig.CacheDir = cachedir
+	require.NoError(t, c.LoadAll(configs...))
+	require.Equal(t, "2", c.Tags["version"])
+
+	// Rolling back should give us the committed version
+	c = NewConfig()
+	c.Agent.ConfigURLRetryAttempts = 1
+	c.RemoteConfig.CacheDir = cachedir
+	c.RemoteConfig.UseLastKnownGood = true
+	require.NoError(t, c.LoadAll(configs...))
+	require.Equal(t, "1", c.Tags["version"])
+}
+
+func writePEMPublicKey(t *testing.T, pub ed25519.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	fn := filepath.Join(t.TempDir(), "key.pem")
+	buf := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(fn, buf, 0600))
+	return fn
+}
+
+func minisign(priv ed25519.PrivateKey, id, data []byte, prehash bool) []byte {
+	if id == nil {
+		id = make([]byte, 8)
+	}
+	alg := "Ed"
+	msg := data
+	if prehash {
+		alg = "ED"
+		h := blake2b.Sum512(data)
+		msg = h[:]
+	}
+	sig := ed25519.Sign(priv, msg)
+	trusted := "timestamp:0\tfile:telegraf.conf"
+	global := ed25519.Sign(priv, append(bytes.Clone(sig), trusted...))
+
+	raw := append([]byte(alg), id...)
+	raw = append(raw, sig...)
+
+	var buf bytes.Buffer
+	buf.WriteString("untrusted comment: signature from minisign secret key\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(raw) + "\n")
+	buf.WriteString("trusted comment: " + trusted + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(global) + "\n")
+	return buf.Bytes()
+}
+
+func createBundle(t *testing.T, files map[string]string, compressed bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var gw *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compressed {
+		gw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gw)
+	}
+	for name, content := range files {
+		hdr := &tar.Header{
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gw != nil {
+		require.NoError(t, gw.Close())
+	}
+	return buf.Bytes()
+}
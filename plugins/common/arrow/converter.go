@@ -0,0 +1,256 @@
+package arrow
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Converter creates Apache Arrow schemas and record batches from metrics.
+// Fields and tags are mapped to columns where fields take precedence over
+// tags of the same name. The metric time is stored as nanosecond timestamp in
+// the column given by TimestampColumn unless empty.
+type Converter struct {
+	TimestampColumn string
+	Log             telegraf.Logger
+}
+
+// Schema creates a schema containing the columns of all given metrics sorted
+// by name followed by the timestamp column
+func (c *Converter) Schema(metrics []telegraf.Metric) (*arrow.Schema, error) {
+	rawFields, err := c.collectColumns(metrics, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]arrow.Field, 0, len(rawFields)+1)
+	fields = append(fields, rawFields...)
+	if c.TimestampColumn != "" {
+		fields = append(fields, arrow.Field{
+			Name: c.TimestampColumn,
+			Type: arrow.PrimitiveTypes.Int64,
+		})
+	}
+
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// MergeSchema returns the given schema extended by the columns of the metrics
+// not yet part of the schema and a flag if any column was added
+func (c *Converter) MergeSchema(schema *arrow.Schema, metrics []telegraf.Metric) (*arrow.Schema, bool, error) {
+	rawFields, err := c.collectColumns(metrics, schema)
+	if err != nil {
+		return schema, false, err
+	}
+	if len(rawFields) == 0 {
+		return schema, false, nil
+	}
+
+	fields := make([]arrow.Field, 0, schema.NumFields()+len(rawFields))
+	fields = append(fields, schema.Fields()...)
+	fields = append(fields, rawFields...)
+
+	return arrow.NewSchema(fields, nil), true, nil
+}
+
+// RecordBatch creates a record batch for the metrics using the given builder.
+// Columns not present in a metric are stored as null value.
+func (c *Converter) RecordBatch(builder *array.RecordBuilder, metrics []telegraf.Metric) arrow.RecordBatch {
+	for index, col := range builder.Schema().Fields() {
+		for _, m := range metrics {
+			if c.TimestampColumn != "" && col.Name == c.TimestampColumn {
+				builder.Field(index).(*array.Int64Builder).Append(m.Time().UnixNano())
+				continue
+			}
+
+			// Try to get the value from a field first, then from a tag.
+			var value any
+			var ok bool
+			value, ok = m.GetField(col.Name)
+			if !ok {
+				value, ok = m.GetTag(col.Name)
+			}
+
+			// if neither field nor tag exists, append a null value
+			if !ok {
+				builder.Field(index).AppendNull()
+				continue
+			}
+
+			// Values with a type not matching the column, e.g. due to a type
+			// conflict between metrics, are stored as null value
+			if !appendValue(builder.Field(index), value) {
+				if c.Log != nil {
+					c.Log.Debugf("Type %T of %q not matching column type %s, storing null", value, col.Name, col.Type)
+				}
+				builder.Field(index).AppendNull()
+			}
+		}
+	}
+
+	return builder.NewRecordBatch()
+}
+
+// collectColumns returns the columns of the metrics not existing in the given
+// schema sorted by name
+func (c *Converter) collectColumns(metrics []telegraf.Metric, schema *arrow.Schema) ([]arrow.Field, error) {
+	rawFields := make(map[string]arrow.DataType, 0)
+	for _, metric := range metrics {
+		for _, field := range metric.FieldList() {
+			if _, ok := rawFields[field.Key]; ok || hasColumn(schema, field.Key) {
+				continue
+			}
+			arrowType, err := GoToArrowType(field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("error converting '%s=%s' field to arrow type: %w", field.Key, field.Value, err)
+			}
+			rawFields[field.Key] = arrowType
+		}
+		for _, tag := range metric.TagList() {
+			if _, ok := rawFields[tag.Key]; ok || hasColumn(schema, tag.Key) {
+				continue
+			}
+			rawFields[tag.Key] = arrow.BinaryTypes.String
+		}
+	}
+	if c.TimestampColumn != "" {
+		delete(rawFields, c.TimestampColumn)
+	}
+
+	fields := make([]arrow.Field, 0, len(rawFields))
+	for key, value := range rawFields {
+		fields = append(fields, arrow.Field{
+			Name: key,
+			Type: value,
+		})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	return fields, nil
+}
+
+func hasColumn(schema *arrow.Schema, name string) bool {
+	return schema != nil && schema.HasField(name)
+}
+
+// GoToArrowType returns the Arrow data type for the given field value
+func GoToArrowType(value interface{}) (arrow.DataType, error) {
+	switch value.(type) {
+	case int8:
+		return arrow.PrimitiveTypes.Int8, nil
+	case int16:
+		return arrow.PrimitiveTypes.Int16, nil
+	case int32:
+		return arrow.PrimitiveTypes.Int32, nil
+	case int64, int:
+		return arrow.PrimitiveTypes.Int64, nil
+	case uint8:
+		return arrow.PrimitiveTypes.Uint8, nil
+	case uint16:
+		return arrow.PrimitiveTypes.Uint16, nil
+	case uint32:
+		return arrow.PrimitiveTypes.Uint32, nil
+	case uint64, uint:
+		return arrow.PrimitiveTypes.Uint64, nil
+	case float32:
+		return arrow.PrimitiveTypes.Float32, nil
+	case float64:
+		return arrow.PrimitiveTypes.Float64, nil
+	case string:
+		return arrow.BinaryTypes.String, nil
+	case bool:
+		return arrow.FixedWidthTypes.Boolean, nil
+	default:
+		return nil, fmt.Errorf("unsupported type: %T", value)
+	}
+}
+
+func appendValue(builder array.Builder, value any) bool {
+	switch b := builder.(type) {
+	case *array.Int8Builder:
+		v, ok := value.(int8)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Int16Builder:
+		v, ok := value.(int16)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Int32Builder:
+		v, ok := value.(int32)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Int64Builder:
+		switch v := value.(type) {
+		case int64:
+			b.Append(v)
+		case int:
+			b.Append(int64(v))
+		default:
+			return false
+		}
+		return true
+	case *array.Uint8Builder:
+		v, ok := value.(uint8)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Uint16Builder:
+		v, ok := value.(uint16)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Uint32Builder:
+		v, ok := value.(uint32)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Uint64Builder:
+		switch v := value.(type) {
+		case uint64:
+			b.Append(v)
+		case uint:
+			b.Append(uint64(v))
+		default:
+			return false
+		}
+		return true
+	case *array.Float32Builder:
+		v, ok := value.(float32)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.Float64Builder:
+		v, ok := value.(float64)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.StringBuilder:
+		v, ok := value.(string)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	case *array.BooleanBuilder:
+		v, ok := value.(bool)
+		if ok {
+			b.Append(v)
+		}
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package arrow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestGoToArrowType(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected arrow.DataType
+	}{
+		{name: "int8", value: int8(1), expected: arrow.PrimitiveTypes.Int8},
+		{name: "int16", value: int16(1), expected: arrow.PrimitiveTypes.Int16},
+		{name: "int32", value: int32(1), expected: arrow.PrimitiveTypes.Int32},
+		{name: "int64", value: int64(1), expected: arrow.PrimitiveTypes.Int64},
+		{name: "int", value: 1, expected: arrow.PrimitiveTypes.Int64},
+		{name: "uint8", value: uint8(1), expected: arrow.PrimitiveTypes.Uint8},
+		{name: "uint16", value: uint16(1), expected: arrow.PrimitiveTypes.Uint16},
+		{name: "uint32", value: uint32(1), expected: arrow.PrimitiveTypes.Uint32},
+		{name: "uint64", value: uint64(1), expected: arrow.PrimitiveTypes.Uint64},
+		{name: "uint", value: uint(1), expected: arrow.PrimitiveTypes.Uint64},
+		{name: "float32", value: float32(1), expected: arrow.PrimitiveTypes.Float32},
+		{name: "float64", value: float64(1), expected: arrow.PrimitiveTypes.Float64},
+		{name: "string", value: "a", expected: arrow.BinaryTypes.String},
+		{name: "bool", value: true, expected: arrow.FixedWidthTypes.Boolean},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := GoToArrowType(tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestGoToArrowTypeUnsupported(t *testing.T) {
+	_, err := GoToArrowType([]byte("a"))
+	require.ErrorContains(t, err, "unsupported type: []uint8")
+}
+
+func TestSchema(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{"host": "a", "value": "tag"},
+			map[string]interface{}{"value": 1.0, "count": int64(1)},
+			time.Unix(1, 0),
+		),
+		metric.New("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"active": true, "timestamp": "ignored"},
+			time.Unix(2, 0),
+		),
+	}
+
+	c := &Converter{TimestampColumn: "timestamp"}
+	schema, err := c.Schema(metrics)
+	require.NoError(t, err)
+
+	// Columns are sorted by name, fields take precedence over tags and the
+	// timestamp column is always the last one
+	expected := arrow.NewSchema([]arrow.Field{
+		{Name: "active", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "cpu", Type: arrow.BinaryTypes.String},
+		{Name: "host", Type: arrow.BinaryTypes.String},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	require.True(t, expected.Equal(schema), "expected %s but got %s", expected, schema)
+}
+
+func TestSchemaWithoutTimestamp(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	}
+
+	c := &Converter{}
+	schema, err := c.Schema(metrics)
+	require.NoError(t, err)
+
+	expected := arrow.NewSchema([]arrow.Field{
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+	}, nil)
+	require.True(t, expected.Equal(schema), "expected %s but got %s", expected, schema)
+}
+
+func TestMergeSchema(t *testing.T) {
+	c := &Converter{TimestampColumn: "timestamp"}
+
+	schema, err := c.Schema([]telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+	})
+	require.NoError(t, err)
+
+	// Metrics with known columns only do not change the schema
+	merged, changed, err := c.MergeSchema(schema, []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(2)}, time.Unix(2, 0)),
+	})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Same(t, schema, merged)
+
+	// New columns are appended to the existing ones sorted by name
+	merged, changed, err = c.MergeSchema(schema, []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{"host": "c", "cpu": "cpu0"},
+			map[string]interface{}{"value": 3.0, "active": true},
+			time.Unix(3, 0),
+		),
+	})
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	expected := arrow.NewSchema([]arrow.Field{
+		{Name: "host", Type: arrow.BinaryTypes.String},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64},
+		{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "active", Type: arrow.FixedWidthTypes.Boolean},
+		{Name: "cpu", Type: arrow.BinaryTypes.String},
+	}, nil)
+	require.True(t, expected.Equal(merged), "expected %s but got %s", expected, merged)
+}
+
+func TestRecordBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu",
+			map[string]string{"host": "a", "value": "tag"},
+			map[string]interface{}{"value": 1.0},
+			time.Unix(1, 0),
+		),
+		metric.New("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": "conflict"},
+			time.Unix(2, 0),
+		),
+	}
+
+	c := &Converter{TimestampColumn: "timestamp", Log: testutil.Logger{}}
+	schema, err := c.Schema(metrics[:1])
+	require.NoError(t, err)
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	record := c.RecordBatch(builder, metrics)
+	defer record.Release()
+	require.EqualValues(t, 2, record.NumRows())
+
+	// Missing tags are stored as null
+	host := record.Column(0).(*array.String)
+	require.Equal(t, "a", host.Value(0))
+	require.True(t, host.IsNull(1))
+
+	// The field value is used over the tag value and values with a type
+	// conflicting with the column type are stored as null
+	value := record.Column(1).(*array.Float64)
+	require.InDelta(t, 1.0, value.Value(0), 0)
+	require.True(t, value.IsNull(1))
+
+	timestamp := record.Column(2).(*array.Int64)
+	require.Equal(t, time.Unix(1, 0).UnixNano(), timestamp.Value(0))
+	require.Equal(t, time.Unix(2, 0).UnixNano(), timestamp.Value(1))
+}
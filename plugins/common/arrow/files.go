@@ -0,0 +1,37 @@
+package arrow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Rotation defines when files written by the Arrow based outputs are closed
+// and a new file is started. Zero values disable the respective condition.
+type Rotation struct {
+	Interval time.Duration
+	MaxSize  int64
+}
+
+// Due checks if a file opened at the given time and with the given size needs
+// to be rotated
+func (r *Rotation) Due(opened time.Time, size int64, now time.Time) bool {
+	expired := r.Interval > 0 && now.Sub(opened) >= r.Interval
+	oversized := r.MaxSize > 0 && size >= r.MaxSize
+	return expired || oversized
+}
+
+// NewFilename returns a filename for the measurement with the given extension
+// in the directory not used by any other file
+func NewFilename(directory, name, extension string, now time.Time) string {
+	base := fmt.Sprintf("%s-%s-%s", name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
+	filename := filepath.Join(directory, base+extension)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			return filename
+		}
+		filename = filepath.Join(directory, fmt.Sprintf("%s-%d%s", base, i, extension))
+	}
+}
@@ -0,0 +1,76 @@
+package arrow
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationDue(t *testing.T) {
+	opened := time.Unix(100, 0)
+
+	tests := []struct {
+		name     string
+		rotation Rotation
+		size     int64
+		now      time.Time
+		expected bool
+	}{
+		{
+			name: "disabled",
+			size: 1000,
+			now:  opened.Add(time.Hour),
+		},
+		{
+			name:     "interval not reached",
+			rotation: Rotation{Interval: time.Minute},
+			now:      opened.Add(59 * time.Second),
+		},
+		{
+			name:     "interval reached",
+			rotation: Rotation{Interval: time.Minute},
+			now:      opened.Add(time.Minute),
+			expected: true,
+		},
+		{
+			name:     "size not reached",
+			rotation: Rotation{MaxSize: 100},
+			size:     99,
+			now:      opened,
+		},
+		{
+			name:     "size reached",
+			rotation: Rotation{MaxSize: 100},
+			size:     100,
+			now:      opened,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, tt.rotation.Due(opened, tt.size, tt.now))
+		})
+	}
+}
+
+func TestNewFilename(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	base := "cpu-" + now.Format("2006-01-02") + "-1700000000"
+
+	filename := NewFilename(dir, "cpu", ".arrow", now)
+	require.Equal(t, filepath.Join(dir, base+".arrow"), filename)
+
+	// Existing files must not be overwritten
+	require.NoError(t, os.WriteFile(filename, nil, 0600))
+	filename = NewFilename(dir, "cpu", ".arrow", now)
+	require.Equal(t, filepath.Join(dir, base+"-1.arrow"), filename)
+
+	require.NoError(t, os.WriteFile(filename, nil, 0600))
+	filename = NewFilename(dir, "cpu", ".arrow", now)
+	require.Equal(t, filepath.Join(dir, base+"-2.arrow"), filename)
+}
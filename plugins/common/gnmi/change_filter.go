@@ -0,0 +1,123 @@
+package gnmi
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// changeFilter suppresses values of ON_CHANGE subscriptions not changed since
+// the last emitted value. Unchanged values are emitted again after the
+// heartbeat interval of the subscription if any.
+type changeFilter struct {
+	heartbeats map[*pathInfo]time.Duration
+	series     map[string]map[string]*changeEntry
+	sync.Mutex
+}
+
+type changeEntry struct {
+	value   interface{}
+	emitted time.Time
+}
+
+func newChangeFilter() *changeFilter {
+	return &changeFilter{
+		heartbeats: make(map[*pathInfo]time.Duration),
+		series:     make(map[string]map[string]*changeEntry),
+	}
+}
+
+// AddChangeFilterFromSubscription enables the suppression of unchanged values
+// for the given subscription if requested by the user
+func (h *Handler) AddChangeFilterFromSubscription(s Subscription) error {
+	if !s.SuppressUnchanged {
+		return nil
+	}
+
+	path, err := ParsePath(s.Origin, s.Path, "")
+	if err != nil {
+		return err
+	}
+	info := newInfoFromPathWithoutKeys(path)
+	if h.enforceFirstNamespaceAsOrigin {
+		info.enforceFirstNamespaceAsOrigin()
+	}
+
+	if h.changeFilter == nil {
+		h.changeFilter = newChangeFilter()
+	}
+	h.changeFilter.heartbeats[info] = time.Duration(s.HeartbeatInterval)
+
+	return nil
+}
+
+// keep returns true if the value of the field differs from the last emitted
+// value or if the heartbeat interval elapsed since then
+func (f *changeFilter) keep(path *pathInfo, name string, tags map[string]string, key string, value interface{}, t time.Time) bool {
+	// Find the most specific subscription of the field
+	var heartbeat time.Duration
+	var matchLength int
+	var found bool
+	for info, hb := range f.heartbeats {
+		if !info.isSubPathOf(path) || (found && len(info.segments) <= matchLength) {
+			continue
+		}
+		heartbeat, matchLength, found = hb, len(info.segments), true
+	}
+	if !found {
+		return true
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	id := seriesID(name, tags)
+	fields, ok := f.series[id]
+	if !ok {
+		fields = make(map[string]*changeEntry)
+		f.series[id] = fields
+	}
+
+	entry, ok := fields[key]
+	if !ok {
+		fields[key] = &changeEntry{value: value, emitted: t}
+		return true
+	}
+
+	if reflect.DeepEqual(entry.value, value) && (heartbeat <= 0 || t.Sub(entry.emitted) < heartbeat) {
+		return false
+	}
+	entry.value = value
+	entry.emitted = t
+
+	return true
+}
+
+// remove forgets the values of the given series e.g. after a delete
+// notification so the next value will be emitted
+func (f *changeFilter) remove(name string, tags map[string]string) {
+	f.Lock()
+	defer f.Unlock()
+
+	delete(f.series, seriesID(name, tags))
+}
+
+func seriesID(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString(",")
+		b.WriteString(k)
+		b.WriteString("=")
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
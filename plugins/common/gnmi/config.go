@@ -30,6 +30,7 @@ type HandlerConfig struct {
 	GuessPathStrategy   string   `toml:"path_guessing_strategy"`
 	VendorExt           []string `toml:"vendor_specific"`
 	YangModelPaths      []string `toml:"yang_model_paths"`
+	YangDecodeAll       bool     `toml:"yang_decode_all_encodings"`
 }
 
 func (cfg *HandlerConfig) Handler(log telegraf.Logger, options ...Option) (*Handler, error) {
@@ -0,0 +1,117 @@
+package gnmi
+
+import (
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/gnmi/nokia"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+type serverImplementation interface {
+	Register(*grpc.Server)
+}
+
+// DialoutConfig contains the settings for receiving telemetry data from
+// devices connecting to Telegraf (dial-out mode)
+type DialoutConfig struct {
+	Address  string `toml:"address"`
+	Protocol string `toml:"protocol"`
+	common_tls.ServerConfig
+}
+
+// DialoutServer is a GRPC server receiving dial-out telemetry data and
+// passing it to the response handler
+type DialoutServer struct {
+	address  string
+	protocol string
+	options  []grpc.ServerOption
+	handler  *Handler
+	log      telegraf.Logger
+
+	server *grpc.Server
+	addr   string
+}
+
+func (cfg *DialoutConfig) Server(handler *Handler, log telegraf.Logger) (*DialoutServer, error) {
+	// Defaults
+	if cfg.Address == "" {
+		cfg.Address = "localhost:57400"
+	}
+
+	// Check user settings
+	switch cfg.Protocol {
+	case "":
+		cfg.Protocol = "nokia"
+	case "nokia":
+		// Do nothing, those are valid
+	default:
+		return nil, fmt.Errorf("invalid 'protocol' %q", cfg.Protocol)
+	}
+
+	s := &DialoutServer{
+		address:  cfg.Address,
+		protocol: cfg.Protocol,
+		handler:  handler,
+		log:      log,
+	}
+
+	// Fill the server options depending on the user settings
+	if tlsCfg, err := cfg.ServerConfig.TLSConfig(); err != nil {
+		return nil, fmt.Errorf("creating TLS configuration failed: %w", err)
+	} else if tlsCfg != nil {
+		s.options = append(s.options, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	if log.Level().Includes(telegraf.Trace) {
+		s.options = append(s.options, grpc.InTapHandle(s.logCalls))
+	}
+
+	return s, nil
+}
+
+// Start listening for device connections
+func (s *DialoutServer) Start(acc telegraf.Accumulator) error {
+	// Create the protocol implementation
+	var impl serverImplementation
+	switch s.protocol {
+	case "nokia":
+		impl = nokia.New(acc, s.handler, s.log)
+	default:
+		return fmt.Errorf("invalid 'protocol' %q", s.protocol)
+	}
+
+	// Create a listener or wrap it for debugging
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return fmt.Errorf("listening on %q failed: %w", s.address, err)
+	}
+	s.addr = listener.Addr().String()
+
+	// Start the server
+	s.server = grpc.NewServer(s.options...)
+	impl.Register(s.server)
+	go func() {
+		if err := s.server.Serve(listener); err != nil {
+			s.log.Errorf("Stopping GRPC server on %q due to error: %v", s.addr, err)
+		}
+	}()
+
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *DialoutServer) Addr() string {
+	return s.addr
+}
+
+// Stop the server and wait for the active connections to terminate
+func (s *DialoutServer) Stop() {
+	if s.server != nil {
+		s.server.GracefulStop()
+	}
+}
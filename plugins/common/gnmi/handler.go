@@ -38,6 +38,7 @@ type Handler struct {
 	aliases            map[*pathInfo]string
 	decoder            *yangmodel.Decoder
 	tagStore           *tagStore
+	changeFilter       *changeFilter
 	emptyNameWarnShown bool
 
 	tagSubscriptions []*TagSubscription
@@ -272,6 +273,11 @@ func (h *Handler) handleUpdates(acc telegraf.Accumulator, updates []*gnmi.Update
 			h.log.Errorf("Invalid empty path %q with alias %q", field.path.String(), aliasPath)
 			continue
 		}
+
+		// Suppress unchanged values of ON_CHANGE subscriptions
+		if h.changeFilter != nil && !h.changeFilter.keep(field.path, name, tags, key, field.value, timestamp) {
+			continue
+		}
 		grouper.Add(name, tags, timestamp, key, field.value)
 	}
 
@@ -364,6 +370,10 @@ func (h *Handler) handleDeletes(acc telegraf.Accumulator, deletes []*gnmi.Path,
 			tags["path"] = aliasInfo.String()
 		}
 
+		if h.changeFilter != nil {
+			h.changeFilter.remove(name, tags)
+		}
+
 		fields := map[string]interface{}{"operation": "delete"}
 		acc.AddFields(name, fields, tags, timestamp)
 	}
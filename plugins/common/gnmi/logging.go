@@ -1,4 +1,4 @@
-package gnmi_listener
+package gnmi
 
 import (
 	"context"
@@ -7,7 +7,7 @@ import (
 	"google.golang.org/grpc/tap"
 )
 
-func (g *GNMIListener) logCalls(ctx context.Context, info *tap.Info) (context.Context, error) {
+func (s *DialoutServer) logCalls(ctx context.Context, info *tap.Info) (context.Context, error) {
 	p, ok := peer.FromContext(ctx)
 	if !ok {
 		return ctx, nil
@@ -17,7 +17,7 @@ func (g *GNMIListener) logCalls(ctx context.Context, info *tap.Info) (context.Co
 	if v := info.Header.Get("user-agent"); len(v) > 0 {
 		agent = v[0]
 	}
-	g.Log.Tracef("%s calling %q (user-agent: %s)", p.Addr.String(), info.FullMethodName, agent)
+	s.log.Tracef("%s calling %q (user-agent: %s)", p.Addr.String(), info.FullMethodName, agent)
 
 	return ctx, nil
 }
@@ -31,7 +31,7 @@ const file_nokia_dialout_telemetry_proto_rawDesc = "" +
 	"Nokia.SROS\x1a\n" +
 	"gnmi.proto2R\n" +
 	"\x10DialoutTelemetry\x12>\n" +
-	"\aPublish\x12\x17.gnmi.SubscribeResponse\x1a\x16.gnmi.SubscribeRequest(\x010\x01BBZ@github.com/influxdata/telegraf/plugins/inputs/gnmi_dialout/nokiab\x06proto3"
+	"\aPublish\x12\x17.gnmi.SubscribeResponse\x1a\x16.gnmi.SubscribeRequest(\x010\x01B:Z8github.com/influxdata/telegraf/plugins/common/gnmi/nokiab\x06proto3"
 
 var file_nokia_dialout_telemetry_proto_goTypes = []any{
 	(*gnmi.SubscribeResponse)(nil), // 0: gnmi.SubscribeResponse
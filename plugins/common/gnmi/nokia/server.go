@@ -12,22 +12,26 @@ import (
 	"google.golang.org/grpc/peer"
 
 	"github.com/influxdata/telegraf"
-	common_gnmi "github.com/influxdata/telegraf/plugins/common/gnmi"
 )
 
+// Processor handles the received gNMI responses
+type Processor interface {
+	Process(acc telegraf.Accumulator, source string, response *gnmi.SubscribeResponse)
+}
+
 // Make sure we implement the GRPC interface
 var _ DialoutTelemetryServer = &server{}
 
 type server struct {
 	acc     telegraf.Accumulator
-	handler *common_gnmi.Handler
+	handler Processor
 	log     telegraf.Logger
 
 	UnimplementedDialoutTelemetryServer
 }
 
 // New creates a new GRPC server for Nokia devices
-func New(acc telegraf.Accumulator, handler *common_gnmi.Handler, log telegraf.Logger) *server {
+func New(acc telegraf.Accumulator, handler Processor, log telegraf.Logger) *server {
 	return &server{
 		acc:     acc,
 		handler: handler,
@@ -19,6 +19,7 @@ type Subscription struct {
 	SampleInterval    config.Duration `toml:"sample_interval"`
 	SuppressRedundant bool            `toml:"suppress_redundant"`
 	HeartbeatInterval config.Duration `toml:"heartbeat_interval"`
+	SuppressUnchanged bool            `toml:"suppress_unchanged"`
 
 	fullPath *gnmi.Path
 }
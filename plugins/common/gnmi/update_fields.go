@@ -31,7 +31,7 @@ func (h *Handler) newFieldsFromUpdate(path *pathInfo, update *gnmi.Update) ([]up
 	// Apply some special handling for special types
 	switch v := update.Val.Value.(type) {
 	case *gnmi.TypedValue_AsciiVal: // not handled in ToScalar
-		return []updateField{{path, v.AsciiVal}}, nil
+		return []updateField{{path, h.decodeTypedOptional(path, v.AsciiVal)}}, nil
 	case *gnmi.TypedValue_BytesVal:
 		// Try to decode the bytes as float if we do have the right amount of
 		// data. Otherwise, or if the decoding fails, encode the data as base64
@@ -51,7 +51,33 @@ func (h *Handler) newFieldsFromUpdate(path *pathInfo, update *gnmi.Update) ([]up
 	if err != nil {
 		return nil, err
 	}
-	return []updateField{{path, nativeType}}, nil
+	return []updateField{{path, h.decodeTypedOptional(path, nativeType)}}, nil
+}
+
+// decodeTypedOptional converts values of encodings other than JSON-IETF
+// according to the YANG model only if enabled by the user
+func (h *Handler) decodeTypedOptional(path *pathInfo, v interface{}) interface{} {
+	if !h.YangDecodeAll {
+		return v
+	}
+	return h.decodeTyped(path, v)
+}
+
+// decodeTyped converts the value according to the type of the YANG model
+// leaf referenced by the path if any, e.g. to get integer fields for counters
+// sent as strings
+func (h *Handler) decodeTyped(path *pathInfo, v interface{}) interface{} {
+	if h.decoder == nil {
+		return v
+	}
+
+	origin, fieldPath := path.path()
+	decoded, err := h.decoder.DecodePathElement(origin, fieldPath, v)
+	if err != nil {
+		h.log.Debugf("Decoding %s failed: %v", path, err)
+		return v
+	}
+	return decoded
 }
 
 func (h *Handler) processJSON(path *pathInfo, data []byte) ([]updateField, error) {
@@ -73,7 +99,7 @@ func (h *Handler) processJSON(path *pathInfo, data []byte) ([]updateField, error
 
 		fields = append(fields, updateField{
 			path:  p,
-			value: entry.value,
+			value: h.decodeTypedOptional(p, entry.value),
 		})
 	}
 
@@ -125,21 +151,12 @@ func (h *Handler) processJSONIETF(path *pathInfo, data []byte) ([]updateField, e
 			p.enforceFirstNamespaceAsOrigin()
 		}
 
-		// Try to lookup the full path to decode the field according to the
+		// Create an update-field with the complete path for all entries and
+		// try to lookup the full path to decode the field according to the
 		// YANG model if any
-		if h.decoder != nil {
-			origin, fieldPath := p.path()
-			if decoded, err := h.decoder.DecodePathElement(origin, fieldPath, entry.value); err != nil {
-				h.log.Debugf("Decoding %s failed: %v", p, err)
-			} else {
-				entry.value = decoded
-			}
-		}
-
-		// Create an update-field with the complete path for all entries
 		fields = append(fields, updateField{
 			path:  p,
-			value: entry.value,
+			value: h.decodeTyped(p, entry.value),
 		})
 	}
 
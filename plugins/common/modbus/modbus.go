@@ -0,0 +1,26 @@
+// Package modbus contains the data-type conversions shared by the modbus
+// plugins to decode register values into field values and vice versa.
+package modbus
+
+import "fmt"
+
+// FieldConverterFunc converts the raw register bytes into a field value
+type FieldConverterFunc func(bytes []byte) interface{}
+
+// FieldEncoderFunc converts a field value into the raw register bytes
+type FieldEncoderFunc func(value interface{}) ([]byte, error)
+
+// NormalizeByteOrder maps the byte-order aliases to their canonical form
+func NormalizeByteOrder(byteOrder string) (string, error) {
+	switch byteOrder {
+	case "ABCD", "MSW-BE", "MSW": // Big endian (Motorola)
+		return "ABCD", nil
+	case "BADC", "MSW-LE": // Big endian with bytes swapped
+		return "BADC", nil
+	case "CDAB", "LSW-BE": // Little endian with bytes swapped
+		return "CDAB", nil
+	case "DCBA", "LSW-LE", "LSW": // Little endian (Intel)
+		return "DCBA", nil
+	}
+	return "unknown", fmt.Errorf("unknown byte-order %q", byteOrder)
+}
@@ -1,6 +1,6 @@
 package modbus
 
-func determineConverterBit(byteOrder string, bit uint8) (fieldConverterFunc, error) {
+func determineConverterBit(byteOrder string, bit uint8) (FieldConverterFunc, error) {
 	tohost, err := endiannessConverter16(byteOrder)
 	if err != nil {
 		return nil, err
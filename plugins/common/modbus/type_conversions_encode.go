@@ -0,0 +1,386 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/x448/float16"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+type put16 func([]byte, uint16)
+type put32 func([]byte, uint32)
+type put64 func([]byte, uint64)
+
+func putMSWLEU32(b []byte, v uint32) {
+	_ = b[3] // bounds check hint to compiler; see golang.org/issue/14808
+	binary.LittleEndian.PutUint16(b[0:], uint16(v>>16))
+	binary.LittleEndian.PutUint16(b[2:], uint16(v))
+}
+
+func putLSWBEU32(b []byte, v uint32) {
+	_ = b[3] // bounds check hint to compiler; see golang.org/issue/14808
+	binary.BigEndian.PutUint16(b[2:], uint16(v>>16))
+	binary.BigEndian.PutUint16(b[0:], uint16(v))
+}
+
+func putMSWLEU64(b []byte, v uint64) {
+	_ = b[7] // bounds check hint to compiler; see golang.org/issue/14808
+	binary.LittleEndian.PutUint16(b[0:], uint16(v>>48))
+	binary.LittleEndian.PutUint16(b[2:], uint16(v>>32))
+	binary.LittleEndian.PutUint16(b[4:], uint16(v>>16))
+	binary.LittleEndian.PutUint16(b[6:], uint16(v))
+}
+
+func putLSWBEU64(b []byte, v uint64) {
+	_ = b[7] // bounds check hint to compiler; see golang.org/issue/14808
+	binary.BigEndian.PutUint16(b[6:], uint16(v>>48))
+	binary.BigEndian.PutUint16(b[4:], uint16(v>>32))
+	binary.BigEndian.PutUint16(b[2:], uint16(v>>16))
+	binary.BigEndian.PutUint16(b[0:], uint16(v))
+}
+
+func endiannessEncoder16(byteOrder string) (put16, error) {
+	switch byteOrder {
+	case "ABCD", "CDAB": // Big endian (Motorola)
+		return binary.BigEndian.PutUint16, nil
+	case "DCBA", "BADC": // Little endian (Intel)
+		return binary.LittleEndian.PutUint16, nil
+	}
+	return nil, fmt.Errorf("invalid byte-order: %s", byteOrder)
+}
+
+func endiannessEncoder32(byteOrder string) (put32, error) {
+	switch byteOrder {
+	case "ABCD": // Big endian (Motorola)
+		return binary.BigEndian.PutUint32, nil
+	case "BADC": // Big endian with bytes swapped
+		return putMSWLEU32, nil
+	case "CDAB": // Little endian with bytes swapped
+		return putLSWBEU32, nil
+	case "DCBA": // Little endian (Intel)
+		return binary.LittleEndian.PutUint32, nil
+	}
+	return nil, fmt.Errorf("invalid byte-order: %s", byteOrder)
+}
+
+func endiannessEncoder64(byteOrder string) (put64, error) {
+	switch byteOrder {
+	case "ABCD": // Big endian (Motorola)
+		return binary.BigEndian.PutUint64, nil
+	case "BADC": // Big endian with bytes swapped
+		return putMSWLEU64, nil
+	case "CDAB": // Little endian with bytes swapped
+		return putLSWBEU64, nil
+	case "DCBA": // Little endian (Intel)
+		return binary.LittleEndian.PutUint64, nil
+	}
+	return nil, fmt.Errorf("invalid byte-order: %s", byteOrder)
+}
+
+// DetermineUntypedEncoder returns the encoder for coils, i.e. converting the
+// value into a single byte being zero for "off" and one for "on"
+func DetermineUntypedEncoder() FieldEncoderFunc {
+	return func(v interface{}) ([]byte, error) {
+		b, err := internal.ToBool(v)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	}
+}
+
+// DetermineEncoder returns the inverse of the converter returned by
+// DetermineConverter, i.e. the value is divided by the given scale and then
+// converted into the register bytes of the given type and byte-order.
+// For strings, length denotes the number of registers to fill.
+// Single bits cannot be encoded as they require a read-modify-write cycle,
+// use BitMask instead.
+func DetermineEncoder(inType, byteOrder string, scale float64, length uint16, strloc string) (FieldEncoderFunc, error) {
+	switch inType {
+	case "STRING":
+		return determineEncoderString(byteOrder, length, strloc)
+	case "BIT":
+		return nil, errors.New("cannot encode single bits")
+	case "INT8L", "INT8H", "UINT8L", "UINT8H":
+		return determineEncoder8(inType, byteOrder, scale)
+	case "INT16", "UINT16", "FLOAT16":
+		return determineEncoder16(inType, byteOrder, scale)
+	case "INT32", "UINT32", "FLOAT32":
+		return determineEncoder32(inType, byteOrder, scale)
+	case "INT64", "UINT64", "FLOAT64":
+		return determineEncoder64(inType, byteOrder, scale)
+	}
+	return nil, fmt.Errorf("invalid input data-type: %s", inType)
+}
+
+// BitMask returns the mask for the given bit in the register as transmitted
+// on the wire with the given byte-order
+func BitMask(byteOrder string, bit uint8) (uint16, error) {
+	if bit > 15 {
+		return 0, fmt.Errorf("invalid bit %d", bit)
+	}
+	switch byteOrder {
+	case "ABCD", "CDAB":
+		return 1 << bit, nil
+	case "DCBA", "BADC":
+		return 1 << ((bit + 8) % 16), nil
+	}
+	return 0, fmt.Errorf("invalid byte-order: %s", byteOrder)
+}
+
+// ByteMask returns the mask of the register byte holding the value of the
+// given 8-bit type to allow writing the byte without modifying the other byte
+// of the register
+func ByteMask(inType, byteOrder string) (uint16, error) {
+	idx, err := endiannessIndex8(byteOrder, inType == "INT8L" || inType == "UINT8L")
+	if err != nil {
+		return 0, err
+	}
+	if idx == 0 {
+		return 0xff00, nil
+	}
+	return 0x00ff, nil
+}
+
+// unscale reverses the scaling applied when decoding the value
+func unscale(v interface{}, scale float64) (interface{}, error) {
+	if scale == 0.0 {
+		return v, nil
+	}
+	f, err := internal.ToFloat64(v)
+	if err != nil {
+		return nil, err
+	}
+	return f / scale, nil
+}
+
+// unscaleInteger reverses the scaling and rounds the result to the nearest
+// integer to avoid truncation errors e.g. for a scale of 0.1
+func unscaleInteger(v interface{}, scale float64) (interface{}, error) {
+	if scale == 0.0 {
+		return v, nil
+	}
+	f, err := internal.ToFloat64(v)
+	if err != nil {
+		return nil, err
+	}
+	return math.Round(f / scale), nil
+}
+
+func determineEncoder8(inType, byteOrder string, scale float64) (FieldEncoderFunc, error) {
+	idx, err := endiannessIndex8(byteOrder, inType == "INT8L" || inType == "UINT8L")
+	if err != nil {
+		return nil, err
+	}
+
+	signed := inType == "INT8L" || inType == "INT8H"
+	return func(v interface{}) ([]byte, error) {
+		raw, err := unscaleInteger(v, scale)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, 2)
+		if signed {
+			x, err := internal.ToInt8(raw)
+			if err != nil {
+				return nil, err
+			}
+			b[idx] = byte(x)
+		} else {
+			x, err := internal.ToUint8(raw)
+			if err != nil {
+				return nil, err
+			}
+			b[idx] = x
+		}
+		return b, nil
+	}, nil
+}
+
+func determineEncoder16(inType, byteOrder string, scale float64) (FieldEncoderFunc, error) {
+	fromhost, err := endiannessEncoder16(byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v interface{}) ([]byte, error) {
+		var raw uint16
+		switch inType {
+		case "INT16":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			i, err := internal.ToInt16(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = uint16(i)
+		case "UINT16":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			if raw, err = internal.ToUint16(x); err != nil {
+				return nil, err
+			}
+		case "FLOAT16":
+			x, err := unscale(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			f, err := internal.ToFloat32(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = float16.Fromfloat32(f).Bits()
+		}
+		b := make([]byte, 2)
+		fromhost(b, raw)
+		return b, nil
+	}, nil
+}
+
+func determineEncoder32(inType, byteOrder string, scale float64) (FieldEncoderFunc, error) {
+	fromhost, err := endiannessEncoder32(byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v interface{}) ([]byte, error) {
+		var raw uint32
+		switch inType {
+		case "INT32":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			i, err := internal.ToInt32(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = uint32(i)
+		case "UINT32":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			if raw, err = internal.ToUint32(x); err != nil {
+				return nil, err
+			}
+		case "FLOAT32":
+			x, err := unscale(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			f, err := internal.ToFloat32(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = math.Float32bits(f)
+		}
+		b := make([]byte, 4)
+		fromhost(b, raw)
+		return b, nil
+	}, nil
+}
+
+func determineEncoder64(inType, byteOrder string, scale float64) (FieldEncoderFunc, error) {
+	fromhost, err := endiannessEncoder64(byteOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v interface{}) ([]byte, error) {
+		var raw uint64
+		switch inType {
+		case "INT64":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			i, err := internal.ToInt64(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = uint64(i)
+		case "UINT64":
+			x, err := unscaleInteger(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			if raw, err = internal.ToUint64(x); err != nil {
+				return nil, err
+			}
+		case "FLOAT64":
+			x, err := unscale(v, scale)
+			if err != nil {
+				return nil, err
+			}
+			f, err := internal.ToFloat64(x)
+			if err != nil {
+				return nil, err
+			}
+			raw = math.Float64bits(f)
+		}
+		b := make([]byte, 8)
+		fromhost(b, raw)
+		return b, nil
+	}, nil
+}
+
+func determineEncoderString(byteOrder string, length uint16, strloc string) (FieldEncoderFunc, error) {
+	fromhost, err := endiannessEncoder16(byteOrder)
+	if err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, errors.New("invalid length for string")
+	}
+
+	// Determine the number of characters per register
+	perRegister := 2
+	switch strloc {
+	case "", "both":
+	case "lower", "upper":
+		perRegister = 1
+	default:
+		return nil, fmt.Errorf("invalid string register location %q", strloc)
+	}
+
+	return func(v interface{}) ([]byte, error) {
+		s, err := internal.ToString(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(s) > int(length)*perRegister {
+			return nil, fmt.Errorf("string of length %d exceeds %d registers", len(s), length)
+		}
+
+		// Pad the string with null-characters to fill all registers
+		chars := make([]byte, int(length)*perRegister)
+		copy(chars, s)
+
+		b := make([]byte, 2*int(length))
+		for i := 0; i < int(length); i++ {
+			var raw uint16
+			switch strloc {
+			case "lower":
+				raw = uint16(chars[i])
+			case "upper":
+				raw = uint16(chars[i]) << 8
+			default:
+				raw = uint16(chars[2*i])<<8 | uint16(chars[2*i+1])
+			}
+			fromhost(b[2*i:2*i+2], raw)
+		}
+		return b, nil
+	}, nil
+}
@@ -0,0 +1,181 @@
+package modbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeRoundtrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		inType  string
+		outType string
+		scale   float64
+		length  uint16
+		strloc  string
+		value   interface{}
+		encoded []byte
+	}{
+		{
+			name:    "int8 low",
+			inType:  "INT8L",
+			outType: "INT64",
+			value:   int64(-42),
+		},
+		{
+			name:    "uint8 high",
+			inType:  "UINT8H",
+			outType: "UINT64",
+			value:   uint64(200),
+		},
+		{
+			name:    "int16",
+			inType:  "INT16",
+			outType: "INT64",
+			value:   int64(-1234),
+			encoded: []byte{0xfb, 0x2e},
+		},
+		{
+			name:    "uint16 scaled",
+			inType:  "UINT16",
+			outType: "FLOAT64",
+			scale:   0.1,
+			value:   float64(230.5),
+			encoded: []byte{0x09, 0x01},
+		},
+		{
+			name:    "float16",
+			inType:  "FLOAT16",
+			outType: "FLOAT64",
+			value:   float64(1.5),
+			encoded: []byte{0x3e, 0x00},
+		},
+		{
+			name:    "int32",
+			inType:  "INT32",
+			outType: "INT64",
+			value:   int64(-123456789),
+			encoded: []byte{0xf8, 0xa4, 0x32, 0xeb},
+		},
+		{
+			name:    "uint32 scaled",
+			inType:  "UINT32",
+			outType: "FLOAT64",
+			scale:   0.001,
+			value:   float64(1234.567),
+			encoded: []byte{0x00, 0x12, 0xd6, 0x87},
+		},
+		{
+			name:    "float32",
+			inType:  "FLOAT32",
+			outType: "FLOAT64",
+			value:   float64(3.25),
+			encoded: []byte{0x40, 0x50, 0x00, 0x00},
+		},
+		{
+			name:    "int64",
+			inType:  "INT64",
+			outType: "INT64",
+			value:   int64(-1234567890123),
+			encoded: []byte{0xff, 0xff, 0xfe, 0xe0, 0x8e, 0x04, 0xfb, 0x35},
+		},
+		{
+			name:    "uint64",
+			inType:  "UINT64",
+			outType: "UINT64",
+			value:   uint64(1234567890123),
+			encoded: []byte{0x00, 0x00, 0x01, 0x1f, 0x71, 0xfb, 0x04, 0xcb},
+		},
+		{
+			name:    "float64 scaled",
+			inType:  "FLOAT64",
+			outType: "FLOAT64",
+			scale:   2,
+			value:   float64(-7),
+			encoded: []byte{0xc0, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:    "string",
+			inType:  "STRING",
+			length:  3,
+			value:   "hello",
+			encoded: []byte{'h', 'e', 'l', 'l', 'o', 0x00},
+		},
+		{
+			name:    "string lower",
+			inType:  "STRING",
+			length:  4,
+			strloc:  "lower",
+			value:   "abcd",
+			encoded: []byte{0x00, 'a', 0x00, 'b', 0x00, 'c', 0x00, 'd'},
+		},
+		{
+			name:    "string upper",
+			inType:  "STRING",
+			length:  4,
+			strloc:  "upper",
+			value:   "abcd",
+			encoded: []byte{'a', 0x00, 'b', 0x00, 'c', 0x00, 'd', 0x00},
+		},
+	}
+
+	for _, tt := range tests {
+		orders := []string{"ABCD", "DCBA", "BADC", "CDAB"}
+		if tt.inType == "INT8L" || tt.inType == "UINT8H" {
+			orders = []string{"ABCD", "DCBA"}
+		}
+		for _, order := range orders {
+			t.Run(tt.name+" "+order, func(t *testing.T) {
+				encoder, err := DetermineEncoder(tt.inType, order, tt.scale, tt.length, tt.strloc)
+				require.NoError(t, err)
+				decoder, err := DetermineConverter(tt.inType, order, tt.outType, tt.scale, 0, tt.strloc)
+				require.NoError(t, err)
+
+				b, err := encoder(tt.value)
+				require.NoError(t, err)
+				if tt.encoded != nil && order == "ABCD" {
+					require.Equal(t, tt.encoded, b)
+				}
+				if s, ok := tt.value.(string); ok {
+					require.Equal(t, s, decoder(b))
+				} else {
+					require.InDelta(t, tt.value, decoder(b), 1e-9)
+				}
+			})
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	encoder, err := DetermineEncoder("INT16", "ABCD", 0, 0, "")
+	require.NoError(t, err)
+	_, err = encoder(int64(40000))
+	require.Error(t, err)
+
+	encoder, err = DetermineEncoder("UINT16", "ABCD", 0.1, 0, "")
+	require.NoError(t, err)
+	_, err = encoder(-1.0)
+	require.Error(t, err)
+
+	encoder, err = DetermineEncoder("STRING", "ABCD", 0, 2, "")
+	require.NoError(t, err)
+	_, err = encoder("too long")
+	require.ErrorContains(t, err, "exceeds 2 registers")
+
+	_, err = DetermineEncoder("BIT", "ABCD", 0, 0, "")
+	require.Error(t, err)
+
+	_, err = DetermineEncoder("FOO", "ABCD", 0, 0, "")
+	require.ErrorContains(t, err, "invalid input data-type")
+}
+
+func TestBitMask(t *testing.T) {
+	for _, order := range []string{"ABCD", "DCBA", "BADC", "CDAB"} {
+		decoder, err := DetermineConverter("BIT", order, "", 0, 3, "")
+		require.NoError(t, err)
+		mask, err := BitMask(order, 3)
+		require.NoError(t, err)
+		require.Equal(t, uint8(1), decoder([]byte{byte(mask >> 8), byte(mask)}), order)
+	}
+}
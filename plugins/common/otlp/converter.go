@@ -0,0 +1,110 @@
+package otlp
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"github.com/influxdata/influxdb-observability/influx2otel"
+	"github.com/influxdata/influxdb-observability/otel2influx"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+)
+
+var metricsSchemata = map[string]common.MetricsSchema{
+	"prometheus-v1": common.MetricsSchemaTelegrafPrometheusV1,
+	"prometheus-v2": common.MetricsSchemaTelegrafPrometheusV2,
+}
+
+// NewTracesConverter creates a converter for OTLP traces writing the
+// resulting metrics to the given writer
+func NewTracesConverter(log telegraf.Logger, w *Writer, spanDimensions []string) (*otel2influx.OtelTracesToLineProtocol, error) {
+	cfg := otel2influx.DefaultOtelTracesToLineProtocolConfig()
+	cfg.Logger = &Logger{log}
+	cfg.Writer = w
+	if spanDimensions != nil {
+		cfg.SpanDimensions = spanDimensions
+	}
+	return otel2influx.NewOtelTracesToLineProtocol(cfg)
+}
+
+// NewMetricsConverter creates a converter for OTLP metrics using the given
+// schema and writing the resulting metrics to the given writer
+func NewMetricsConverter(log telegraf.Logger, w *Writer, schema string) (*otel2influx.OtelMetricsToLineProtocol, error) {
+	ms, found := metricsSchemata[schema]
+	if !found {
+		return nil, fmt.Errorf("invalid metrics schema %q", schema)
+	}
+
+	cfg := otel2influx.DefaultOtelMetricsToLineProtocolConfig()
+	cfg.Logger = &Logger{log}
+	cfg.Writer = w
+	cfg.Schema = ms
+	return otel2influx.NewOtelMetricsToLineProtocol(cfg)
+}
+
+// NewLogsConverter creates a converter for OTLP logs writing the resulting
+// metrics to the given writer
+func NewLogsConverter(log telegraf.Logger, w *Writer, logRecordDimensions []string) (*otel2influx.OtelLogsToLineProtocol, error) {
+	cfg := otel2influx.DefaultOtelLogsToLineProtocolConfig()
+	cfg.Logger = &Logger{log}
+	cfg.Writer = w
+	if logRecordDimensions != nil {
+		cfg.LogRecordDimensions = logRecordDimensions
+	}
+	return otel2influx.NewOtelLogsToLineProtocol(cfg)
+}
+
+// MetricsEncoder converts Telegraf metrics to OTLP metrics
+type MetricsEncoder struct {
+	log       telegraf.Logger
+	converter *influx2otel.LineProtocolToOtelMetrics
+}
+
+// NewMetricsEncoder creates an encoder for converting Telegraf metrics to OTLP
+func NewMetricsEncoder(log telegraf.Logger) (*MetricsEncoder, error) {
+	converter, err := influx2otel.NewLineProtocolToOtelMetrics(&Logger{log})
+	if err != nil {
+		return nil, err
+	}
+	return &MetricsEncoder{log: log, converter: converter}, nil
+}
+
+// Encode converts the given metrics to an OTLP export request adding the
+// given attributes to all resources. Metrics that cannot be converted are
+// skipped with a warning.
+func (e *MetricsEncoder) Encode(metrics []telegraf.Metric, attributes map[string]string) pmetricotlp.ExportRequest {
+	batch := e.converter.NewBatch()
+	for _, metric := range metrics {
+		var vType common.InfluxMetricValueType
+		switch metric.Type() {
+		case telegraf.Gauge:
+			vType = common.InfluxMetricValueTypeGauge
+		case telegraf.Untyped:
+			vType = common.InfluxMetricValueTypeUntyped
+		case telegraf.Counter:
+			vType = common.InfluxMetricValueTypeSum
+		case telegraf.Histogram:
+			vType = common.InfluxMetricValueTypeHistogram
+		case telegraf.Summary:
+			vType = common.InfluxMetricValueTypeSummary
+		default:
+			e.log.Warnf("Unrecognized metric type %v", metric.Type())
+			continue
+		}
+		err := batch.AddPoint(metric.Name(), metric.Tags(), metric.Fields(), metric.Time(), vType)
+		if err != nil {
+			e.log.Warnf("Failed to add point: %v", err)
+			continue
+		}
+	}
+
+	req := pmetricotlp.NewExportRequestFromMetrics(batch.GetMetrics())
+	resourceMetrics := req.Metrics().ResourceMetrics()
+	for i := 0; i < resourceMetrics.Len(); i++ {
+		for k, v := range attributes {
+			resourceMetrics.At(i).Resource().Attributes().PutStr(k, v)
+		}
+	}
+	return req
+}
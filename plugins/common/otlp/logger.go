@@ -0,0 +1,22 @@
+package otlp
+
+import (
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Logger adapts a Telegraf logger to the logger interface used by the
+// InfluxDB observability converters
+type Logger struct {
+	telegraf.Logger
+}
+
+// Debug logs a debug message, patterned after log.Print.
+func (l Logger) Debug(msg string, kv ...interface{}) {
+	if l.Logger == nil {
+		return
+	}
+	format := msg + strings.Repeat(" %s=%q", len(kv)/2)
+	l.Logger.Debugf(format, kv...)
+}
@@ -0,0 +1,67 @@
+package otlp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"github.com/influxdata/influxdb-observability/otel2influx"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	_ otel2influx.InfluxWriter      = (*Writer)(nil)
+	_ otel2influx.InfluxWriterBatch = (*Writer)(nil)
+)
+
+// Writer converts the points produced by the OpenTelemetry converters to
+// Telegraf metrics and passes them to the given function
+type Writer struct {
+	add func(telegraf.Metric)
+}
+
+// NewWriter creates a writer passing all converted metrics to the given function
+func NewWriter(add func(telegraf.Metric)) *Writer {
+	return &Writer{add: add}
+}
+
+// NewBatch creates a new batch for writing telemetry data.
+func (w *Writer) NewBatch() otel2influx.InfluxWriterBatch {
+	return w
+}
+
+// EnqueuePoint converts a telemetry data point to a metric.
+func (w *Writer) EnqueuePoint(
+	_ context.Context,
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	ts time.Time,
+	vType common.InfluxMetricValueType,
+) error {
+	var mtype telegraf.ValueType
+	switch vType {
+	case common.InfluxMetricValueTypeUntyped:
+		mtype = telegraf.Untyped
+	case common.InfluxMetricValueTypeGauge:
+		mtype = telegraf.Gauge
+	case common.InfluxMetricValueTypeSum:
+		mtype = telegraf.Counter
+	case common.InfluxMetricValueTypeHistogram:
+		mtype = telegraf.Histogram
+	case common.InfluxMetricValueTypeSummary:
+		mtype = telegraf.Summary
+	default:
+		return fmt.Errorf("unrecognized InfluxMetricValueType %q", vType)
+	}
+	w.add(metric.New(measurement, tags, fields, ts, mtype))
+	return nil
+}
+
+// WriteBatch does nothing.
+func (*Writer) WriteBatch(context.Context) error {
+	return nil
+}
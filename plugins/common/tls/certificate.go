@@ -0,0 +1,152 @@
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeyPairFunc returns the PEM-encoded certificate and private key, e.g. read
+// from a secret-store. The function is called on each handshake so it should
+// return the current credentials to support rotation.
+type KeyPairFunc func() (cert, key []byte, err error)
+
+// certificateSource provides the certificate and key on demand for use in the
+// GetCertificate and GetClientCertificate callbacks of a TLS config. The
+// certificate is re-read whenever the underlying files or key-pair change so
+// that rotated certificates are picked up without restarting Telegraf.
+type certificateSource struct {
+	certFile string
+	keyFile  string
+	keyPair  KeyPairFunc
+	password string
+
+	cert     *tls.Certificate
+	certStat fileStamp
+	keyStat  fileStamp
+	digest   []byte
+
+	sync.Mutex
+}
+
+// fileStamp is used to detect changes of a file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func newCertificateSource(certFile, keyFile string, keyPair KeyPairFunc, password string) (*certificateSource, error) {
+	if (certFile != "" || keyFile != "") && keyPair != nil {
+		return nil, errors.New("certificate and key can either be given as file or as secret but not both")
+	}
+
+	src := &certificateSource{
+		certFile: certFile,
+		keyFile:  keyFile,
+		keyPair:  keyPair,
+		password: password,
+	}
+
+	// Load the certificate once to return errors as early as possible
+	if _, err := src.get(); err != nil {
+		return nil, err
+	}
+
+	return src, nil
+}
+
+// getCertificate implements the tls.Config.GetCertificate callback
+func (src *certificateSource) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return src.get()
+}
+
+// getClientCertificate implements the tls.Config.GetClientCertificate callback
+func (src *certificateSource) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return src.get()
+}
+
+// get returns the current certificate, reloading it if the source changed.
+// In case reloading fails, e.g. because the files are currently rewritten,
+// the last successfully loaded certificate is returned and the reload is
+// retried on the next call.
+func (src *certificateSource) get() (*tls.Certificate, error) {
+	src.Lock()
+	defer src.Unlock()
+
+	var err error
+	if src.keyPair != nil {
+		err = src.reloadFromKeyPair()
+	} else {
+		err = src.reloadFromFiles()
+	}
+	if err != nil && src.cert == nil {
+		return nil, err
+	}
+
+	return src.cert, nil
+}
+
+func (src *certificateSource) reloadFromFiles() error {
+	certStat, err := stampFile(src.certFile)
+	if err != nil {
+		return fmt.Errorf("could not load certificate %q: %w", src.certFile, err)
+	}
+	keyStat, err := stampFile(src.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load private key %q: %w", src.keyFile, err)
+	}
+
+	if src.cert != nil && certStat == src.certStat && keyStat == src.keyStat {
+		return nil
+	}
+
+	var cfg tls.Config
+	if err := loadCertificate(&cfg, src.certFile, src.keyFile, src.password); err != nil {
+		return err
+	}
+	src.cert = &cfg.Certificates[0]
+	src.certStat = certStat
+	src.keyStat = keyStat
+
+	return nil
+}
+
+func (src *certificateSource) reloadFromKeyPair() error {
+	certPEM, keyPEM, err := src.keyPair()
+	if err != nil {
+		return err
+	}
+
+	// Only keep a digest of the key-pair to detect changes instead of keeping
+	// a copy of the private key around
+	h := sha256.New()
+	h.Write(certPEM)
+	h.Write(keyPEM)
+	digest := h.Sum(nil)
+
+	if src.cert != nil && bytes.Equal(digest, src.digest) {
+		return nil
+	}
+
+	cert, err := parseCertificate(certPEM, keyPEM, src.password)
+	if err != nil {
+		return err
+	}
+	src.cert = &cert
+	src.digest = digest
+
+	return nil
+}
+
+func stampFile(filename string) (fileStamp, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}, nil
+}
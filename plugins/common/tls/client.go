@@ -11,12 +11,21 @@ type ClientConfig struct {
 	TLSCert             string   `toml:"tls_cert"`
 	TLSKey              string   `toml:"tls_key"`
 	TLSKeyPwd           string   `toml:"tls_key_pwd"`
+	TLSCertReload       bool     `toml:"tls_cert_reload"`
 	TLSMinVersion       string   `toml:"tls_min_version"`
 	TLSCipherSuites     []string `toml:"tls_cipher_suites"`
 	InsecureSkipVerify  bool     `toml:"insecure_skip_verify"`
 	ServerName          string   `toml:"tls_server_name"`
 	RenegotiationMethod string   `toml:"tls_renegotiation_method"`
 	Enable              *bool    `toml:"tls_enable"`
+
+	keyPair KeyPairFunc
+}
+
+// SetKeyPairFunc sets a function providing the certificate and key on demand,
+// e.g. from secrets, as an alternative to the certificate and key files.
+func (c *ClientConfig) SetKeyPairFunc(f KeyPairFunc) {
+	c.keyPair = f
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
@@ -30,12 +39,13 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// This check returns a nil (aka "disabled") or an empty config
 	// (aka, "use the default") if no field is set that would have an effect on
 	// a TLS connection. That is, any of:
-	//     * client certificate settings,
+	//     * client certificate settings (files or key-pair function),
 	//     * peer certificate authorities,
 	//     * disabled security,
 	//     * an SNI server name, or
 	//     * empty/never renegotiation method
 	empty := c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == ""
+	empty = empty && c.keyPair == nil
 	empty = empty && !c.InsecureSkipVerify && c.ServerName == ""
 	empty = empty && (c.RenegotiationMethod == "" || c.RenegotiationMethod == "never")
 
@@ -76,7 +86,15 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.RootCAs = pool
 	}
 
-	if c.TLSCert != "" && c.TLSKey != "" {
+	// Certificates provided via secrets or files that should be reloaded
+	// on change are provided on demand, otherwise we load them once.
+	if c.keyPair != nil || (c.TLSCertReload && c.TLSCert != "" && c.TLSKey != "") {
+		src, err := newCertificateSource(c.TLSCert, c.TLSKey, c.keyPair, c.TLSKeyPwd)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = src.getClientCertificate
+	} else if c.TLSCert != "" && c.TLSKey != "" {
 		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey, c.TLSKeyPwd)
 		if err != nil {
 			return nil, err
@@ -51,44 +51,54 @@ func loadCertificate(config *tls.Config, certFile, keyFile, privateKeyPassphrase
 		return fmt.Errorf("could not load private key %q: %w", keyFile, err)
 	}
 
+	cert, err := parseCertificate(certBytes, keyBytes, privateKeyPassphrase)
+	if err != nil {
+		return err
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return nil
+}
+
+func parseCertificate(certBytes, keyBytes []byte, privateKeyPassphrase string) (tls.Certificate, error) {
 	keyPEMBlock, _ := pem.Decode(keyBytes)
 	if keyPEMBlock == nil {
-		return errors.New("failed to decode private key: no PEM data found")
+		return tls.Certificate{}, errors.New("failed to decode private key: no PEM data found")
 	}
 
-	var cert tls.Certificate
 	if keyPEMBlock.Type == "ENCRYPTED PRIVATE KEY" {
 		if privateKeyPassphrase == "" {
-			return errors.New("missing password for PKCS#8 encrypted private key")
+			return tls.Certificate{}, errors.New("missing password for PKCS#8 encrypted private key")
 		}
 		rawDecryptedKey, err := pemutil.DecryptPKCS8PrivateKey(keyPEMBlock.Bytes, []byte(privateKeyPassphrase))
 		if err != nil {
-			return fmt.Errorf("failed to decrypt PKCS#8 private key: %w", err)
+			return tls.Certificate{}, fmt.Errorf("failed to decrypt PKCS#8 private key: %w", err)
 		}
 		decryptedKey, err := x509.ParsePKCS8PrivateKey(rawDecryptedKey)
 		if err != nil {
-			return fmt.Errorf("failed to parse decrypted PKCS#8 private key: %w", err)
+			return tls.Certificate{}, fmt.Errorf("failed to parse decrypted PKCS#8 private key: %w", err)
 		}
 		privateKey, ok := decryptedKey.(*rsa.PrivateKey)
 		if !ok {
-			return fmt.Errorf("decrypted key is not a RSA private key: %T", decryptedKey)
+			return tls.Certificate{}, fmt.Errorf("decrypted key is not a RSA private key: %T", decryptedKey)
 		}
-		cert, err = tls.X509KeyPair(certBytes, pem.EncodeToMemory(&pem.Block{Type: keyPEMBlock.Type, Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
+		cert, err := tls.X509KeyPair(certBytes, pem.EncodeToMemory(&pem.Block{Type: keyPEMBlock.Type, Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}))
 		if err != nil {
-			return fmt.Errorf("failed to load cert/key pair: %w", err)
+			return tls.Certificate{}, fmt.Errorf("failed to load cert/key pair: %w", err)
 		}
-	} else if keyPEMBlock.Headers["Proc-Type"] == "4,ENCRYPTED" {
+		return cert, nil
+	}
+
+	if keyPEMBlock.Headers["Proc-Type"] == "4,ENCRYPTED" {
 		// The key is an encrypted private key with the DEK-Info header.
 		// This is currently unsupported because of the deprecation of x509.IsEncryptedPEMBlock and x509.DecryptPEMBlock.
-		return errors.New("password-protected keys in pkcs#1 format are not supported")
-	} else {
-		cert, err = tls.X509KeyPair(certBytes, keyBytes)
-		if err != nil {
-			return fmt.Errorf("failed to load cert/key pair: %w", err)
-		}
+		return tls.Certificate{}, errors.New("password-protected keys in pkcs#1 format are not supported")
 	}
-	config.Certificates = []tls.Certificate{cert}
-	return nil
+
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to load cert/key pair: %w", err)
+	}
+	return cert, nil
 }
 
 func init() {
@@ -1,8 +1,11 @@
 package tls_test
 
 import (
+	cryptotls "crypto/tls"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -52,3 +55,121 @@ func TestConnect(t *testing.T) {
 	defer resp.Body.Close()
 	require.Equal(t, 200, resp.StatusCode)
 }
+
+func TestConnectWithKeyPairFunc(t *testing.T) {
+	clientConfig := tls.ClientConfig{
+		TLSCA: pki.CACertPath(),
+	}
+	clientConfig.SetKeyPairFunc(func() (cert, key []byte, err error) {
+		return []byte(pki.ReadClientCert()), []byte(pki.ReadClientKey()), nil
+	})
+
+	serverConfig := tls.ServerConfig{
+		TLSAllowedCACerts:  []string{pki.CACertPath()},
+		TLSAllowedDNSNames: []string{"localhost", "127.0.0.1"},
+	}
+	serverConfig.SetKeyPairFunc(func() (cert, key []byte, err error) {
+		return []byte(pki.ReadServerCert()), []byte(pki.ReadServerKey()), nil
+	})
+
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Empty(t, serverTLSConfig.Certificates)
+	require.NotNil(t, serverTLSConfig.GetCertificate)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// Do not use StartTLS as it injects its own certificate if the config
+	// does not contain a static one
+	ts.Listener = cryptotls.NewListener(ts.Listener, serverTLSConfig)
+	ts.Start()
+	defer ts.Close()
+
+	clientTLSConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Empty(t, clientTLSConfig.Certificates)
+	require.NotNil(t, clientTLSConfig.GetClientCertificate)
+
+	client := http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: clientTLSConfig,
+		},
+		Timeout: 10 * time.Second,
+	}
+
+	resp, err := client.Get("https://" + ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+	require.Equal(t, 200, resp.StatusCode)
+}
+
+func TestCertificateFileAndKeyPairFuncConflict(t *testing.T) {
+	keyPair := func() (cert, key []byte, err error) {
+		return []byte(pki.ReadServerCert()), []byte(pki.ReadServerKey()), nil
+	}
+
+	clientConfig := tls.ClientConfig{
+		TLSCert: pki.ClientCertPath(),
+		TLSKey:  pki.ClientKeyPath(),
+	}
+	clientConfig.SetKeyPairFunc(keyPair)
+	_, err := clientConfig.TLSConfig()
+	require.ErrorContains(t, err, "either be given as file or as secret")
+
+	serverConfig := tls.ServerConfig{
+		TLSKey: pki.ServerKeyPath(),
+	}
+	serverConfig.SetKeyPairFunc(keyPair)
+	_, err = serverConfig.TLSConfig()
+	require.ErrorContains(t, err, "either be given as file or as secret")
+}
+
+func TestCertificateReload(t *testing.T) {
+	// Copy the server certificate to a temporary location to be able to
+	// replace it later
+	tmpdir := t.TempDir()
+	certFile := filepath.Join(tmpdir, "cert.pem")
+	keyFile := filepath.Join(tmpdir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, []byte(pki.ReadServerCert()), 0640))
+	require.NoError(t, os.WriteFile(keyFile, []byte(pki.ReadServerKey()), 0640))
+
+	serverConfig := tls.ServerConfig{
+		TLSCert:       certFile,
+		TLSKey:        keyFile,
+		TLSCertReload: true,
+	}
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+	require.Empty(t, serverTLSConfig.Certificates)
+	require.NotNil(t, serverTLSConfig.GetCertificate)
+
+	expected, err := cryptotls.LoadX509KeyPair(pki.ServerCertPath(), pki.ServerKeyPath())
+	require.NoError(t, err)
+	cert, err := serverTLSConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected.Certificate, cert.Certificate)
+
+	// Replace the certificate and make sure the modification time differs
+	require.NoError(t, os.WriteFile(certFile, []byte(pki.ReadClientCert()), 0640))
+	require.NoError(t, os.WriteFile(keyFile, []byte(pki.ReadClientKey()), 0640))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	expected, err = cryptotls.LoadX509KeyPair(pki.ClientCertPath(), pki.ClientKeyPath())
+	require.NoError(t, err)
+	cert, err = serverTLSConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected.Certificate, cert.Certificate)
+
+	// Invalid content should keep the last valid certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("garbage"), 0640))
+	future = future.Add(time.Minute)
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+
+	cert, err = serverTLSConfig.GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, expected.Certificate, cert.Certificate)
+}
@@ -0,0 +1,58 @@
+// Package secret provides the TLS certificate and private key from secrets.
+// It is separate from the common TLS package as it depends on the config
+// package which must not be imported there.
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf/config"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+// KeyPair provides the PEM-encoded certificate and key from secrets, e.g.
+// stored in a secret-store. Embed it into a plugin next to the TLS client or
+// server configuration and call Setup on initialization.
+type KeyPair struct {
+	TLSCertSecret config.Secret `toml:"tls_cert_secret"`
+	TLSKeySecret  config.Secret `toml:"tls_key_secret"`
+}
+
+// keyPairSetter is implemented by the common TLS client and server config
+type keyPairSetter interface {
+	SetKeyPairFunc(common_tls.KeyPairFunc)
+}
+
+// Setup checks the secrets and, if set, uses them as the source of the
+// certificate and key of the given TLS configuration
+func (k *KeyPair) Setup(cfg keyPairSetter) error {
+	hasCert, hasKey := !k.TLSCertSecret.Empty(), !k.TLSKeySecret.Empty()
+	if !hasCert && !hasKey {
+		return nil
+	}
+	if !hasCert || !hasKey {
+		return errors.New("both 'tls_cert_secret' and 'tls_key_secret' must be set")
+	}
+	cfg.SetKeyPairFunc(k.get)
+
+	return nil
+}
+
+// get returns the current certificate and key from the secrets
+func (k *KeyPair) get() (cert, key []byte, err error) {
+	certRaw, err := k.TLSCertSecret.Get()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting certificate secret failed: %w", err)
+	}
+	defer certRaw.Destroy()
+
+	keyRaw, err := k.TLSKeySecret.Get()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting private key secret failed: %w", err)
+	}
+	defer keyRaw.Destroy()
+
+	return bytes.Clone(certRaw.Bytes()), bytes.Clone(keyRaw.Bytes()), nil
+}
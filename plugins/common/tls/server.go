@@ -12,17 +12,26 @@ type ServerConfig struct {
 	TLSCert            string   `toml:"tls_cert"`
 	TLSKey             string   `toml:"tls_key"`
 	TLSKeyPwd          string   `toml:"tls_key_pwd"`
+	TLSCertReload      bool     `toml:"tls_cert_reload"`
 	TLSAllowedCACerts  []string `toml:"tls_allowed_cacerts"`
 	TLSCipherSuites    []string `toml:"tls_cipher_suites"`
 	TLSMinVersion      string   `toml:"tls_min_version"`
 	TLSMaxVersion      string   `toml:"tls_max_version"`
 	TLSAllowedDNSNames []string `toml:"tls_allowed_dns_names"`
+
+	keyPair KeyPairFunc
+}
+
+// SetKeyPairFunc sets a function providing the certificate and key on demand,
+// e.g. from secrets, as an alternative to the certificate and key files.
+func (c *ServerConfig) SetKeyPairFunc(f KeyPairFunc) {
+	c.keyPair = f
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
 // configured.
 func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
-	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 {
+	if c.TLSCert == "" && c.TLSKey == "" && c.keyPair == nil && len(c.TLSAllowedCACerts) == 0 {
 		return nil, nil
 	}
 
@@ -37,7 +46,15 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
-	if c.TLSCert != "" && c.TLSKey != "" {
+	// Certificates provided via secrets or files that should be reloaded
+	// on change are provided on demand, otherwise we load them once.
+	if c.keyPair != nil || (c.TLSCertReload && c.TLSCert != "" && c.TLSKey != "") {
+		src, err := newCertificateSource(c.TLSCert, c.TLSKey, c.keyPair, c.TLSKeyPwd)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetCertificate = src.getCertificate
+	} else if c.TLSCert != "" && c.TLSKey != "" {
 		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey, c.TLSKeyPwd)
 		if err != nil {
 			return nil, err
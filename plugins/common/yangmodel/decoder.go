@@ -149,7 +149,22 @@ func (d *Decoder) FindLeaf(name, identifier string) (*yang.Leaf, error) {
 }
 
 func DecodeLeafValue(leaf *yang.Leaf, value interface{}) (interface{}, error) {
-	schema := leaf.Type.YangType
+	return decodeValue(leaf.Type.YangType, value)
+}
+
+func decodeValue(schema *yang.YangType, value interface{}) (interface{}, error) {
+	// Unions are decoded using the first matching member type
+	if schema.Kind == yang.Yunion {
+		for _, member := range schema.Type {
+			if member.Kind == yang.Ystring {
+				break
+			}
+			if v, err := decodeValue(member, value); err == nil {
+				return v, nil
+			}
+		}
+		return value, nil
+	}
 
 	// Ignore all non-string values as the types seem already converted...
 	s, ok := value.(string)
@@ -174,6 +189,12 @@ func DecodeLeafValue(leaf *yang.Leaf, value interface{}) (interface{}, error) {
 		default:
 			return raw, nil
 		}
+	case yang.Ybool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return value, fmt.Errorf("parsing %s %q failed: %w", yang.TypeKindToName[schema.Kind], s, err)
+		}
+		return v, nil
 	case yang.Yint8:
 		v, err := strconv.ParseInt(s, 10, 8)
 		if err != nil {
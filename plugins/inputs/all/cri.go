@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.cri
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/cri" // register plugin
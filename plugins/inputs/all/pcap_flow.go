@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.pcap_flow
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/pcap_flow" // register plugin
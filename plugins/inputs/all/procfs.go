@@ -0,0 +1,5 @@
+//go:build !custom || inputs || inputs.procfs
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/inputs/procfs" // register plugin
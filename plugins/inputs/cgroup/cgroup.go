@@ -3,6 +3,8 @@ package cgroup
 
 import (
 	_ "embed"
+	"fmt"
+	"slices"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -11,9 +13,18 @@ import (
 //go:embed sample.conf
 var sampleConfig string
 
+// Controllers supported in structured mode
+var structuredControllers = []string{"cpu", "memory", "io", "pids", "pressure"}
+
 type CGroup struct {
-	Paths []string `toml:"paths"`
-	Files []string `toml:"files"`
+	Paths       []string `toml:"paths"`
+	Files       []string `toml:"files"`
+	Mode        string   `toml:"mode"`
+	Root        string   `toml:"root"`
+	CGroups     []string `toml:"cgroups"`
+	MaxDepth    int      `toml:"max_depth"`
+	Rollup      bool     `toml:"rollup"`
+	Controllers []string `toml:"controllers"`
 
 	logged map[string]bool
 }
@@ -25,6 +36,28 @@ func (*CGroup) SampleConfig() string {
 func (cg *CGroup) Init() error {
 	cg.logged = make(map[string]bool)
 
+	switch cg.Mode {
+	case "", "files":
+		cg.Mode = "files"
+	case "structured":
+		if cg.Root == "" {
+			cg.Root = "/sys/fs/cgroup"
+		}
+		if len(cg.CGroups) == 0 {
+			cg.CGroups = []string{"*.slice/*"}
+		}
+		if len(cg.Controllers) == 0 {
+			cg.Controllers = structuredControllers
+		}
+		for _, controller := range cg.Controllers {
+			if !slices.Contains(structuredControllers, controller) {
+				return fmt.Errorf("unknown controller %q", controller)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid mode %q", cg.Mode)
+	}
+
 	return nil
 }
 
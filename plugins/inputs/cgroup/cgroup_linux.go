@@ -18,6 +18,10 @@ import (
 const metricName = "cgroup"
 
 func (cg *CGroup) Gather(acc telegraf.Accumulator) error {
+	if cg.Mode == "structured" {
+		return cg.gatherStructured(acc)
+	}
+
 	list := make(chan pathInfo)
 	go cg.generateDirs(list)
 
@@ -0,0 +1,329 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// cgroupInfo describes a cgroup reported in structured mode together with
+// the descendants rolled up into it
+type cgroupInfo struct {
+	path   string
+	name   string
+	rolled []string
+}
+
+func (cg *CGroup) gatherStructured(acc telegraf.Accumulator) error {
+	groups, err := cg.discover()
+	if err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		tags := cgroupTags(group.name)
+		for _, controller := range cg.Controllers {
+			var err error
+			switch controller {
+			case "cpu":
+				err = cg.gatherCPU(acc, group, tags)
+			case "memory":
+				err = cg.gatherMemory(acc, group, tags)
+			case "io":
+				err = cg.gatherIO(acc, group, tags)
+			case "pids":
+				err = cg.gatherPids(acc, group, tags)
+			case "pressure":
+				err = cg.gatherPressure(acc, group, tags)
+			}
+			if err != nil {
+				acc.AddError(fmt.Errorf("gathering %s of %q failed: %w", controller, group.name, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// discover returns the cgroups matching the configured patterns and their
+// descendants up to the maximum depth
+func (cg *CGroup) discover() ([]*cgroupInfo, error) {
+	var groups []*cgroupInfo
+	seen := make(map[string]bool)
+	for _, pattern := range cg.CGroups {
+		matches, err := filepath.Glob(filepath.Join(cg.Root, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cgroup pattern %q: %w", pattern, err)
+		}
+		for _, match := range matches {
+			ok, err := isDir(match)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				groups = cg.walk(match, 0, nil, seen, groups)
+			}
+		}
+	}
+	return groups, nil
+}
+
+func (cg *CGroup) walk(dir string, depth int, owner *cgroupInfo, seen map[string]bool, groups []*cgroupInfo) []*cgroupInfo {
+	if seen[dir] {
+		return groups
+	}
+	seen[dir] = true
+
+	if cg.MaxDepth < 0 || depth <= cg.MaxDepth {
+		name, err := filepath.Rel(cg.Root, dir)
+		if err != nil {
+			name = dir
+		}
+		owner = &cgroupInfo{path: dir, name: filepath.ToSlash(name)}
+		groups = append(groups, owner)
+	} else {
+		owner.rolled = append(owner.rolled, dir)
+	}
+
+	// Only descend further if the children are reported or rolled up
+	if depth == cg.MaxDepth && !cg.Rollup {
+		return groups
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return groups
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			groups = cg.walk(filepath.Join(dir, entry.Name()), depth+1, owner, seen, groups)
+		}
+	}
+	return groups
+}
+
+// cgroupTags determines the systemd slice, service and scope from the
+// innermost elements of the cgroup name
+func cgroupTags(name string) map[string]string {
+	if name == "." {
+		name = "/"
+	}
+	tags := map[string]string{"cgroup": name}
+	for _, element := range strings.Split(name, "/") {
+		switch {
+		case strings.HasSuffix(element, ".slice"):
+			tags["slice"] = element
+		case strings.HasSuffix(element, ".service"):
+			tags["service"] = element
+		case strings.HasSuffix(element, ".scope"):
+			tags["scope"] = element
+		}
+	}
+	return tags
+}
+
+func (cg *CGroup) gatherCPU(acc telegraf.Accumulator, group *cgroupInfo, tags map[string]string) error {
+	fields := make(map[string]interface{})
+	if err := cg.readFlatKeyed(group.path, "cpu.stat", "", fields); err != nil {
+		return err
+	}
+	data, err := cg.read(group.path, "cpu.max")
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		quota, period, found := strings.Cut(strings.TrimSpace(string(data)), " ")
+		if !found {
+			return fmt.Errorf("invalid format of cpu.max: %q", string(data))
+		}
+		fields["max_quota_usec"] = numberOrString(quota)
+		fields["max_period_usec"] = numberOrString(period)
+	}
+	if err := cg.readSingle(group.path, "cpu.weight", "weight", fields); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("cgroup_cpu", fields, tags)
+	}
+	return nil
+}
+
+func (cg *CGroup) gatherMemory(acc telegraf.Accumulator, group *cgroupInfo, tags map[string]string) error {
+	fields := make(map[string]interface{})
+	single := map[string]string{
+		"memory.current":      "current",
+		"memory.min":          "min",
+		"memory.low":          "low",
+		"memory.high":         "high",
+		"memory.max":          "max",
+		"memory.peak":         "peak",
+		"memory.swap.current": "swap_current",
+		"memory.swap.max":     "swap_max",
+	}
+	for file, field := range single {
+		if err := cg.readSingle(group.path, file, field, fields); err != nil {
+			return err
+		}
+	}
+	if err := cg.readFlatKeyed(group.path, "memory.stat", "", fields); err != nil {
+		return err
+	}
+	if err := cg.readFlatKeyed(group.path, "memory.events", "events_", fields); err != nil {
+		return err
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields("cgroup_memory", fields, tags)
+	}
+	return nil
+}
+
+func (cg *CGroup) gatherIO(acc telegraf.Accumulator, group *cgroupInfo, tags map[string]string) error {
+	devices, err := cg.readNestedKeyed(group.path, "io.stat")
+	if err != nil {
+		return err
+	}
+	for device, fields := range devices {
+		dtags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			dtags[k] = v
+		}
+		dtags["device"] = device
+		acc.AddFields("cgroup_io", fields, dtags)
+	}
+	return nil
+}
+
+func (cg *CGroup) gatherPids(acc telegraf.Accumulator, group *cgroupInfo, tags map[string]string) error {
+	fields := make(map[string]interface{})
+	for _, name := range []string{"current", "max", "peak"} {
+		if err := cg.readSingle(group.path, "pids."+name, name, fields); err != nil {
+			return err
+		}
+	}
+	if err := cg.readFlatKeyed(group.path, "pids.events", "events_", fields); err != nil {
+		return err
+	}
+
+	// The process list only contains the processes directly in the cgroup so
+	// add the ones of the descendants when rolling up
+	var processes int64
+	for _, dir := range append([]string{group.path}, group.rolled...) {
+		data, err := cg.read(dir, "cgroup.procs")
+		if err != nil {
+			return err
+		}
+		processes += int64(bytes.Count(data, []byte("\n")))
+	}
+	fields["nr_processes"] = processes
+	if cg.Rollup {
+		fields["nr_rolled_up"] = int64(len(group.rolled))
+	}
+
+	acc.AddFields("cgroup_pids", fields, tags)
+	return nil
+}
+
+func (cg *CGroup) gatherPressure(acc telegraf.Accumulator, group *cgroupInfo, tags map[string]string) error {
+	for _, resource := range []string{"cpu", "memory", "io", "irq"} {
+		lines, err := cg.readNestedKeyed(group.path, resource+".pressure")
+		if err != nil {
+			return err
+		}
+		for kind, fields := range lines {
+			ptags := make(map[string]string, len(tags)+2)
+			for k, v := range tags {
+				ptags[k] = v
+			}
+			ptags["resource"] = resource
+			ptags["type"] = kind
+			acc.AddFields("cgroup_pressure", fields, ptags)
+		}
+	}
+	return nil
+}
+
+// read returns the content of the given cgroup file or nil if the file does
+// not exist, e.g. if the controller is not enabled for the cgroup
+func (*CGroup) read(dir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// readSingle parses files containing a single value
+func (cg *CGroup) readSingle(dir, name, field string, fields map[string]interface{}) error {
+	data, err := cg.read(dir, name)
+	if err != nil || data == nil {
+		return err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || strings.ContainsAny(value, " \n") {
+		return cg.formatError(dir, name)
+	}
+	fields[field] = numberOrString(value)
+	return nil
+}
+
+// readFlatKeyed parses files in the "KEY VALUE" per line format
+func (cg *CGroup) readFlatKeyed(dir, name, prefix string, fields map[string]interface{}) error {
+	data, err := cg.read(dir, name)
+	if err != nil || data == nil {
+		return err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.Fields(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if len(line) != 2 {
+			return cg.formatError(dir, name)
+		}
+		fields[prefix+line[0]] = numberOrString(line[1])
+	}
+	return scanner.Err()
+}
+
+// readNestedKeyed parses files in the "NAME KEY=VALUE..." per line format
+// returning the fields per name
+func (cg *CGroup) readNestedKeyed(dir, name string) (map[string]map[string]interface{}, error) {
+	data, err := cg.read(dir, name)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	result := make(map[string]map[string]interface{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.Fields(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		fields := make(map[string]interface{}, len(line)-1)
+		for _, kv := range line[1:] {
+			k, v, found := strings.Cut(kv, "=")
+			if !found {
+				return nil, cg.formatError(dir, name)
+			}
+			fields[k] = numberOrString(v)
+		}
+		if len(fields) > 0 {
+			result[line[0]] = fields
+		}
+	}
+	return result, scanner.Err()
+}
+
+func (*CGroup) formatError(dir, name string) error {
+	return fmt.Errorf("invalid format of %q", filepath.Join(dir, name))
+}
@@ -0,0 +1,288 @@
+//go:build linux
+
+package cgroup
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestStructuredControllers(t *testing.T) {
+	var acc testutil.Accumulator
+	var cg = &CGroup{
+		Mode:    "structured",
+		Root:    "testdata/hierarchy",
+		CGroups: []string{"system.slice/nginx.service"},
+	}
+	require.NoError(t, cg.Init())
+
+	tags := map[string]string{
+		"cgroup":  "system.slice/nginx.service",
+		"slice":   "system.slice",
+		"service": "nginx.service",
+	}
+	expected := []telegraf.Metric{
+		metric.New(
+			"cgroup_cpu",
+			tags,
+			map[string]interface{}{
+				"usage_usec":      int64(2500),
+				"user_usec":       int64(1500),
+				"system_usec":     int64(1000),
+				"nr_periods":      int64(10),
+				"nr_throttled":    int64(2),
+				"throttled_usec":  int64(300),
+				"max_quota_usec":  int64(50000),
+				"max_period_usec": int64(100000),
+				"weight":          int64(100),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_memory",
+			tags,
+			map[string]interface{}{
+				"current":         int64(1048576),
+				"max":             int64(math.MaxInt64),
+				"min":             int64(0),
+				"anon":            int64(524288),
+				"file":            int64(262144),
+				"events_low":      int64(0),
+				"events_high":     int64(0),
+				"events_max":      int64(1),
+				"events_oom":      int64(0),
+				"events_oom_kill": int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_io",
+			withTags(tags, map[string]string{"device": "8:0"}),
+			map[string]interface{}{
+				"rbytes": int64(4096),
+				"wbytes": int64(8192),
+				"rios":   int64(1),
+				"wios":   int64(2),
+				"dbytes": int64(0),
+				"dios":   int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_io",
+			withTags(tags, map[string]string{"device": "259:0"}),
+			map[string]interface{}{
+				"rbytes": int64(100),
+				"wbytes": int64(200),
+				"rios":   int64(3),
+				"wios":   int64(4),
+				"dbytes": int64(0),
+				"dios":   int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_pids",
+			tags,
+			map[string]interface{}{
+				"current":      int64(3),
+				"max":          int64(100),
+				"events_max":   int64(0),
+				"nr_processes": int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_pressure",
+			withTags(tags, map[string]string{"resource": "cpu", "type": "some"}),
+			map[string]interface{}{
+				"avg10":  float64(0.5),
+				"avg60":  float64(0.25),
+				"avg300": float64(0.1),
+				"total":  int64(12345),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_pressure",
+			withTags(tags, map[string]string{"resource": "cpu", "type": "full"}),
+			map[string]interface{}{
+				"avg10":  float64(0),
+				"avg60":  float64(0),
+				"avg300": float64(0),
+				"total":  int64(100),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_pressure",
+			withTags(tags, map[string]string{"resource": "memory", "type": "some"}),
+			map[string]interface{}{
+				"avg10":  float64(0),
+				"avg60":  float64(0),
+				"avg300": float64(0),
+				"total":  int64(0),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cgroup_pressure",
+			withTags(tags, map[string]string{"resource": "memory", "type": "full"}),
+			map[string]interface{}{
+				"avg10":  float64(0),
+				"avg60":  float64(0),
+				"avg300": float64(0),
+				"total":  int64(0),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	require.NoError(t, acc.GatherError(cg.Gather))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestStructuredDepthAndRollup(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		rollup   bool
+		expected map[string]int64
+	}{
+		{
+			name:     "matched only",
+			expected: map[string]int64{"system.slice/app.service": 0},
+		},
+		{
+			name:     "rollup",
+			rollup:   true,
+			expected: map[string]int64{"system.slice/app.service": 4},
+		},
+		{
+			name:     "depth",
+			maxDepth: 1,
+			expected: map[string]int64{
+				"system.slice/app.service":        0,
+				"system.slice/app.service/worker": 3,
+			},
+		},
+		{
+			name:     "depth with rollup",
+			maxDepth: 1,
+			rollup:   true,
+			expected: map[string]int64{
+				"system.slice/app.service":        0,
+				"system.slice/app.service/worker": 4,
+			},
+		},
+		{
+			name:     "unlimited depth",
+			maxDepth: -1,
+			expected: map[string]int64{
+				"system.slice/app.service":            0,
+				"system.slice/app.service/worker":     3,
+				"system.slice/app.service/worker/sub": 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc testutil.Accumulator
+			var cg = &CGroup{
+				Mode:        "structured",
+				Root:        "testdata/hierarchy",
+				CGroups:     []string{"system.slice/app.*"},
+				MaxDepth:    tt.maxDepth,
+				Rollup:      tt.rollup,
+				Controllers: []string{"pids"},
+			}
+			require.NoError(t, cg.Init())
+			require.NoError(t, acc.GatherError(cg.Gather))
+
+			actual := make(map[string]int64)
+			for _, m := range acc.GetTelegrafMetrics() {
+				require.Equal(t, "app.service", m.Tags()["service"])
+				v, found := m.GetField("nr_processes")
+				require.True(t, found)
+				actual[m.Tags()["cgroup"]] = v.(int64)
+				_, found = m.GetField("nr_rolled_up")
+				require.Equal(t, tt.rollup, found)
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestStructuredTags(t *testing.T) {
+	var acc testutil.Accumulator
+	var cg = &CGroup{
+		Mode:        "structured",
+		Root:        "testdata/hierarchy",
+		CGroups:     []string{"user.slice/*/*"},
+		Controllers: []string{"cpu"},
+	}
+	require.NoError(t, cg.Init())
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"cgroup_cpu",
+			map[string]string{
+				"cgroup": "user.slice/user-1000.slice/session-2.scope",
+				"slice":  "user-1000.slice",
+				"scope":  "session-2.scope",
+			},
+			map[string]interface{}{
+				"usage_usec":  int64(700),
+				"user_usec":   int64(400),
+				"system_usec": int64(300),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	require.NoError(t, acc.GatherError(cg.Gather))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestStructuredInvalidFormat(t *testing.T) {
+	var acc testutil.Accumulator
+	var cg = &CGroup{
+		Mode:        "structured",
+		Root:        "testdata/hierarchy",
+		CGroups:     []string{"user.slice"},
+		Controllers: []string{"cpu"},
+	}
+	require.NoError(t, cg.Init())
+
+	require.NoError(t, cg.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.Errors[0], "invalid format")
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestStructuredInvalidSettings(t *testing.T) {
+	cg := &CGroup{Mode: "unknown"}
+	require.ErrorContains(t, cg.Init(), "invalid mode")
+
+	cg = &CGroup{Mode: "structured", Controllers: []string{"rdma"}}
+	require.ErrorContains(t, cg.Init(), "unknown controller")
+}
+
+func withTags(tags, additional map[string]string) map[string]string {
+	result := make(map[string]string, len(tags)+len(additional))
+	for k, v := range tags {
+		result[k] = v
+	}
+	for k, v := range additional {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,295 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package cri
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// Well-known labels set by the kubelet on containers and pod sandboxes
+const (
+	labelPodName       = "io.kubernetes.pod.name"
+	labelPodNamespace  = "io.kubernetes.pod.namespace"
+	labelPodUID        = "io.kubernetes.pod.uid"
+	labelContainerName = "io.kubernetes.container.name"
+)
+
+type CRI struct {
+	Endpoint             string          `toml:"endpoint"`
+	Timeout              config.Duration `toml:"timeout"`
+	Collect              []string        `toml:"collect"`
+	NamespaceInclude     []string        `toml:"namespace_include"`
+	NamespaceExclude     []string        `toml:"namespace_exclude"`
+	ContainerNameInclude []string        `toml:"container_name_include"`
+	ContainerNameExclude []string        `toml:"container_name_exclude"`
+	LabelInclude         []string        `toml:"label_include"`
+	LabelExclude         []string        `toml:"label_exclude"`
+	Log                  telegraf.Logger `toml:"-"`
+
+	namespaceFilter filter.Filter
+	containerFilter filter.Filter
+	labelFilter     filter.Filter
+
+	conn    *grpc.ClientConn
+	client  runtimeapi.RuntimeServiceClient
+	runtime string
+}
+
+func (*CRI) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CRI) Init() error {
+	if c.Endpoint == "" {
+		c.Endpoint = "unix:///run/containerd/containerd.sock"
+	}
+	if len(c.Collect) == 0 {
+		c.Collect = []string{"containers", "pods"}
+	}
+	for _, collect := range c.Collect {
+		switch collect {
+		case "containers", "pods":
+		default:
+			return fmt.Errorf("invalid 'collect' setting %q", collect)
+		}
+	}
+
+	var err error
+	c.namespaceFilter, err = filter.NewIncludeExcludeFilter(c.NamespaceInclude, c.NamespaceExclude)
+	if err != nil {
+		return fmt.Errorf("creating namespace filter failed: %w", err)
+	}
+	c.containerFilter, err = filter.NewIncludeExcludeFilter(c.ContainerNameInclude, c.ContainerNameExclude)
+	if err != nil {
+		return fmt.Errorf("creating container name filter failed: %w", err)
+	}
+	// Labels are only added as tags if explicitly requested
+	if len(c.LabelInclude) > 0 {
+		c.labelFilter, err = filter.NewIncludeExcludeFilter(c.LabelInclude, c.LabelExclude)
+		if err != nil {
+			return fmt.Errorf("creating label filter failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *CRI) Start(telegraf.Accumulator) error {
+	// The connection is established lazily so the runtime does not need to be
+	// available at startup
+	conn, err := grpc.NewClient(c.Endpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("creating client for %q failed: %w", c.Endpoint, err)
+	}
+	c.conn = conn
+	c.client = runtimeapi.NewRuntimeServiceClient(conn)
+
+	return nil
+}
+
+func (c *CRI) Gather(acc telegraf.Accumulator) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.Timeout))
+	defer cancel()
+
+	// Determine the runtime name once the runtime is reachable
+	if c.runtime == "" {
+		version, err := c.client.Version(ctx, &runtimeapi.VersionRequest{})
+		if err != nil {
+			return fmt.Errorf("querying runtime version failed: %w", err)
+		}
+		c.runtime = version.RuntimeName
+	}
+
+	if slices.Contains(c.Collect, "containers") {
+		resp, err := c.client.ListContainerStats(ctx, &runtimeapi.ListContainerStatsRequest{})
+		if err != nil {
+			acc.AddError(fmt.Errorf("listing container stats failed: %w", err))
+		} else {
+			for _, stats := range resp.Stats {
+				c.gatherContainer(acc, stats)
+			}
+		}
+	}
+
+	if slices.Contains(c.Collect, "pods") {
+		resp, err := c.client.ListPodSandboxStats(ctx, &runtimeapi.ListPodSandboxStatsRequest{})
+		if err != nil {
+			acc.AddError(fmt.Errorf("listing pod sandbox stats failed: %w", err))
+		} else {
+			for _, stats := range resp.Stats {
+				c.gatherPod(acc, stats)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *CRI) Stop() {
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
+
+func (c *CRI) gatherContainer(acc telegraf.Accumulator, stats *runtimeapi.ContainerStats) {
+	attr := stats.GetAttributes()
+	labels := attr.GetLabels()
+
+	name := attr.GetMetadata().GetName()
+	if name == "" {
+		name = labels[labelContainerName]
+	}
+	if !c.namespaceFilter.Match(labels[labelPodNamespace]) || !c.containerFilter.Match(name) {
+		return
+	}
+
+	tags := c.tags(labels)
+	tags["container_name"] = name
+	tags["container_id"] = attr.GetId()
+	setTag(tags, "pod_name", labels[labelPodName])
+	setTag(tags, "namespace", labels[labelPodNamespace])
+	setTag(tags, "pod_uid", labels[labelPodUID])
+
+	fields := make(map[string]interface{})
+	addCPU(fields, stats.GetCpu())
+	addMemory(fields, stats.GetMemory())
+	if fs := stats.GetWritableLayer(); fs != nil {
+		addValue(fields, "fs_used_bytes", fs.GetUsedBytes())
+		addValue(fields, "fs_inodes_used", fs.GetInodesUsed())
+	}
+	if swap := stats.GetSwap(); swap != nil {
+		addValue(fields, "swap_usage_bytes", swap.GetSwapUsageBytes())
+		addValue(fields, "swap_available_bytes", swap.GetSwapAvailableBytes())
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	acc.AddFields("cri_container", fields, tags)
+}
+
+func (c *CRI) gatherPod(acc telegraf.Accumulator, stats *runtimeapi.PodSandboxStats) {
+	attr := stats.GetAttributes()
+	meta := attr.GetMetadata()
+	if !c.namespaceFilter.Match(meta.GetNamespace()) {
+		return
+	}
+
+	tags := c.tags(attr.GetLabels())
+	tags["pod_id"] = attr.GetId()
+	setTag(tags, "pod_name", meta.GetName())
+	setTag(tags, "namespace", meta.GetNamespace())
+	setTag(tags, "pod_uid", meta.GetUid())
+
+	// Only Linux sandboxes provide the full set of statistics
+	linux := stats.GetLinux()
+	if linux == nil {
+		c.Log.Debugf("No Linux statistics for pod sandbox %q", attr.GetId())
+		return
+	}
+
+	fields := make(map[string]interface{})
+	addCPU(fields, linux.GetCpu())
+	addMemory(fields, linux.GetMemory())
+	if process := linux.GetProcess(); process != nil {
+		addValue(fields, "process_count", process.GetProcessCount())
+	}
+	if len(fields) > 0 {
+		acc.AddFields("cri_pod", fields, tags)
+	}
+
+	network := linux.GetNetwork()
+	if network == nil {
+		return
+	}
+	interfaces := network.GetInterfaces()
+	if iface := network.GetDefaultInterface(); iface != nil {
+		interfaces = append([]*runtimeapi.NetworkInterfaceUsage{iface}, interfaces...)
+	}
+	for _, iface := range interfaces {
+		fields := make(map[string]interface{}, 4)
+		addValue(fields, "rx_bytes", iface.GetRxBytes())
+		addValue(fields, "rx_errors", iface.GetRxErrors())
+		addValue(fields, "tx_bytes", iface.GetTxBytes())
+		addValue(fields, "tx_errors", iface.GetTxErrors())
+		if len(fields) == 0 {
+			continue
+		}
+
+		ntags := make(map[string]string, len(tags)+1)
+		for k, v := range tags {
+			ntags[k] = v
+		}
+		ntags["interface"] = iface.GetName()
+		acc.AddFields("cri_pod_network", fields, ntags)
+	}
+}
+
+func (c *CRI) tags(labels map[string]string) map[string]string {
+	tags := map[string]string{"runtime": c.runtime}
+	if c.labelFilter == nil {
+		return tags
+	}
+	for k, v := range labels {
+		if c.labelFilter.Match(k) {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+func setTag(tags map[string]string, key, value string) {
+	if value != "" {
+		tags[key] = value
+	}
+}
+
+func addCPU(fields map[string]interface{}, cpu *runtimeapi.CpuUsage) {
+	if cpu == nil {
+		return
+	}
+	addValue(fields, "cpu_usage_core_nanoseconds", cpu.GetUsageCoreNanoSeconds())
+	addValue(fields, "cpu_usage_nanocores", cpu.GetUsageNanoCores())
+}
+
+func addMemory(fields map[string]interface{}, mem *runtimeapi.MemoryUsage) {
+	if mem == nil {
+		return
+	}
+	addValue(fields, "memory_working_set_bytes", mem.GetWorkingSetBytes())
+	addValue(fields, "memory_available_bytes", mem.GetAvailableBytes())
+	addValue(fields, "memory_usage_bytes", mem.GetUsageBytes())
+	addValue(fields, "memory_rss_bytes", mem.GetRssBytes())
+	addValue(fields, "memory_page_faults", mem.GetPageFaults())
+	addValue(fields, "memory_major_page_faults", mem.GetMajorPageFaults())
+}
+
+// addValue adds the given optional value as field if it is set
+func addValue(fields map[string]interface{}, name string, v *runtimeapi.UInt64Value) {
+	if v != nil {
+		fields[name] = v.GetValue()
+	}
+}
+
+func init() {
+	inputs.Add("cri", func() telegraf.Input {
+		return &CRI{
+			Timeout: config.Duration(5 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,324 @@
+package cri
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestGather(t *testing.T) {
+	endpoint := startServer(t)
+
+	plugin := &CRI{
+		Endpoint: endpoint,
+		Timeout:  config.Duration(5 * time.Second),
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	podTags := map[string]string{
+		"runtime":   "fake-runtime",
+		"pod_id":    "sandbox-1",
+		"pod_name":  "web",
+		"namespace": "default",
+		"pod_uid":   "uid-1",
+	}
+	expected := []telegraf.Metric{
+		metric.New(
+			"cri_container",
+			map[string]string{
+				"runtime":        "fake-runtime",
+				"container_name": "nginx",
+				"container_id":   "container-1",
+				"pod_name":       "web",
+				"namespace":      "default",
+				"pod_uid":        "uid-1",
+			},
+			map[string]interface{}{
+				"cpu_usage_core_nanoseconds": uint64(1500000000),
+				"cpu_usage_nanocores":        uint64(2500000),
+				"memory_working_set_bytes":   uint64(6291456),
+				"memory_usage_bytes":         uint64(8388608),
+				"memory_rss_bytes":           uint64(4194304),
+				"fs_used_bytes":              uint64(16384),
+				"fs_inodes_used":             uint64(42),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cri_container",
+			map[string]string{
+				"runtime":        "fake-runtime",
+				"container_name": "coredns",
+				"container_id":   "container-2",
+				"pod_name":       "dns",
+				"namespace":      "kube-system",
+				"pod_uid":        "uid-2",
+			},
+			map[string]interface{}{
+				"cpu_usage_core_nanoseconds": uint64(300000000),
+				"memory_working_set_bytes":   uint64(1048576),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cri_pod",
+			podTags,
+			map[string]interface{}{
+				"cpu_usage_core_nanoseconds": uint64(1600000000),
+				"memory_working_set_bytes":   uint64(7340032),
+				"process_count":              uint64(3),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cri_pod_network",
+			withTag(podTags, "interface", "eth0"),
+			map[string]interface{}{
+				"rx_bytes":  uint64(123456),
+				"rx_errors": uint64(0),
+				"tx_bytes":  uint64(654321),
+				"tx_errors": uint64(1),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cri_pod_network",
+			withTag(podTags, "interface", "eth1"),
+			map[string]interface{}{
+				"rx_bytes": uint64(100),
+				"tx_bytes": uint64(200),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestGatherFiltered(t *testing.T) {
+	endpoint := startServer(t)
+
+	plugin := &CRI{
+		Endpoint:             endpoint,
+		Timeout:              config.Duration(5 * time.Second),
+		Collect:              []string{"containers"},
+		NamespaceExclude:     []string{"kube-*"},
+		ContainerNameInclude: []string{"ngin*"},
+		LabelInclude:         []string{"app"},
+		Log:                  testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "cri_container", metrics[0].Name())
+	require.Equal(t, map[string]string{
+		"runtime":        "fake-runtime",
+		"container_name": "nginx",
+		"container_id":   "container-1",
+		"pod_name":       "web",
+		"namespace":      "default",
+		"pod_uid":        "uid-1",
+		"app":            "frontend",
+	}, metrics[0].Tags())
+}
+
+func TestGatherUnavailable(t *testing.T) {
+	plugin := &CRI{
+		Endpoint: "unix://" + filepath.Join(t.TempDir(), "missing.sock"),
+		Timeout:  config.Duration(time.Second),
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+	require.ErrorContains(t, plugin.Gather(&acc), "querying runtime version failed")
+}
+
+func TestInvalidCollect(t *testing.T) {
+	plugin := &CRI{Collect: []string{"images"}}
+	require.ErrorContains(t, plugin.Init(), "invalid 'collect' setting")
+}
+
+type fakeRuntime struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+}
+
+func (*fakeRuntime) Version(context.Context, *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{
+		Version:           "0.1.0",
+		RuntimeName:       "fake-runtime",
+		RuntimeVersion:    "1.0.0",
+		RuntimeApiVersion: "v1",
+	}, nil
+}
+
+func (*fakeRuntime) ListContainerStats(context.Context, *runtimeapi.ListContainerStatsRequest) (*runtimeapi.ListContainerStatsResponse, error) {
+	return &runtimeapi.ListContainerStatsResponse{
+		Stats: []*runtimeapi.ContainerStats{
+			{
+				Attributes: &runtimeapi.ContainerAttributes{
+					Id:       "container-1",
+					Metadata: &runtimeapi.ContainerMetadata{Name: "nginx"},
+					Labels: map[string]string{
+						labelPodName:       "web",
+						labelPodNamespace:  "default",
+						labelPodUID:        "uid-1",
+						labelContainerName: "nginx",
+						"app":              "frontend",
+					},
+				},
+				Cpu: &runtimeapi.CpuUsage{
+					Timestamp:            1,
+					UsageCoreNanoSeconds: &runtimeapi.UInt64Value{Value: 1500000000},
+					UsageNanoCores:       &runtimeapi.UInt64Value{Value: 2500000},
+				},
+				Memory: &runtimeapi.MemoryUsage{
+					Timestamp:       1,
+					WorkingSetBytes: &runtimeapi.UInt64Value{Value: 6291456},
+					UsageBytes:      &runtimeapi.UInt64Value{Value: 8388608},
+					RssBytes:        &runtimeapi.UInt64Value{Value: 4194304},
+				},
+				WritableLayer: &runtimeapi.FilesystemUsage{
+					Timestamp:  1,
+					UsedBytes:  &runtimeapi.UInt64Value{Value: 16384},
+					InodesUsed: &runtimeapi.UInt64Value{Value: 42},
+				},
+			},
+			{
+				Attributes: &runtimeapi.ContainerAttributes{
+					Id:       "container-2",
+					Metadata: &runtimeapi.ContainerMetadata{Name: "coredns"},
+					Labels: map[string]string{
+						labelPodName:      "dns",
+						labelPodNamespace: "kube-system",
+						labelPodUID:       "uid-2",
+					},
+				},
+				Cpu: &runtimeapi.CpuUsage{
+					Timestamp:            1,
+					UsageCoreNanoSeconds: &runtimeapi.UInt64Value{Value: 300000000},
+				},
+				Memory: &runtimeapi.MemoryUsage{
+					Timestamp:       1,
+					WorkingSetBytes: &runtimeapi.UInt64Value{Value: 1048576},
+				},
+			},
+			{
+				// Containers without statistics must be skipped
+				Attributes: &runtimeapi.ContainerAttributes{
+					Id:       "container-3",
+					Metadata: &runtimeapi.ContainerMetadata{Name: "starting"},
+				},
+			},
+		},
+	}, nil
+}
+
+func (*fakeRuntime) ListPodSandboxStats(context.Context, *runtimeapi.ListPodSandboxStatsRequest) (*runtimeapi.ListPodSandboxStatsResponse, error) {
+	return &runtimeapi.ListPodSandboxStatsResponse{
+		Stats: []*runtimeapi.PodSandboxStats{
+			{
+				Attributes: &runtimeapi.PodSandboxAttributes{
+					Id: "sandbox-1",
+					Metadata: &runtimeapi.PodSandboxMetadata{
+						Name:      "web",
+						Uid:       "uid-1",
+						Namespace: "default",
+					},
+				},
+				Linux: &runtimeapi.LinuxPodSandboxStats{
+					Cpu: &runtimeapi.CpuUsage{
+						Timestamp:            1,
+						UsageCoreNanoSeconds: &runtimeapi.UInt64Value{Value: 1600000000},
+					},
+					Memory: &runtimeapi.MemoryUsage{
+						Timestamp:       1,
+						WorkingSetBytes: &runtimeapi.UInt64Value{Value: 7340032},
+					},
+					Process: &runtimeapi.ProcessUsage{
+						Timestamp:    1,
+						ProcessCount: &runtimeapi.UInt64Value{Value: 3},
+					},
+					Network: &runtimeapi.NetworkUsage{
+						Timestamp: 1,
+						DefaultInterface: &runtimeapi.NetworkInterfaceUsage{
+							Name:     "eth0",
+							RxBytes:  &runtimeapi.UInt64Value{Value: 123456},
+							RxErrors: &runtimeapi.UInt64Value{Value: 0},
+							TxBytes:  &runtimeapi.UInt64Value{Value: 654321},
+							TxErrors: &runtimeapi.UInt64Value{Value: 1},
+						},
+						Interfaces: []*runtimeapi.NetworkInterfaceUsage{
+							{
+								Name:    "eth1",
+								RxBytes: &runtimeapi.UInt64Value{Value: 100},
+								TxBytes: &runtimeapi.UInt64Value{Value: 200},
+							},
+						},
+					},
+				},
+			},
+			{
+				// Windows sandboxes are not supported
+				Attributes: &runtimeapi.PodSandboxAttributes{
+					Id: "sandbox-2",
+					Metadata: &runtimeapi.PodSandboxMetadata{
+						Name:      "win",
+						Namespace: "default",
+					},
+				},
+				Windows: &runtimeapi.WindowsPodSandboxStats{},
+			},
+		},
+	}, nil
+}
+
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "cri.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(server, &fakeRuntime{})
+	go server.Serve(listener) //nolint:errcheck // Ignore the error on shutdown
+	t.Cleanup(server.Stop)
+
+	return "unix://" + path
+}
+
+func withTag(tags map[string]string, key, value string) map[string]string {
+	result := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		result[k] = v
+	}
+	result[key] = value
+	return result
+}
@@ -45,12 +45,14 @@ type GNMI struct {
 	KeepaliveTime                 config.Duration               `toml:"keepalive_time"`
 	KeepaliveTimeout              config.Duration               `toml:"keepalive_timeout"`
 	EnforceFirstNamespaceAsOrigin bool                          `toml:"enforce_first_namespace_as_origin"`
+	Dialout                       *common_gnmi.DialoutConfig    `toml:"dialout"`
 	Log                           telegraf.Logger               `toml:"-"`
 	common_tls.ClientConfig
 	common_gnmi.HandlerConfig
 
 	// Internal state
 	handler *common_gnmi.Handler
+	dialout *common_gnmi.DialoutServer
 	cancel  context.CancelFunc
 	wg      sync.WaitGroup
 }
@@ -96,6 +98,9 @@ func (c *GNMI) Init() error {
 		if s.Path == "" {
 			return fmt.Errorf("empty 'path' found for subscription %d", i+1)
 		}
+		if s.SuppressUnchanged && !strings.EqualFold(s.SubscriptionMode, "on_change") {
+			return fmt.Errorf("'suppress_unchanged' is only supported for 'on_change' subscription %q", s.Name)
+		}
 
 		if err := s.Build(c.Origin, c.Prefix, c.Target); err != nil {
 			return err
@@ -105,6 +110,9 @@ func (c *GNMI) Init() error {
 		if err := c.handler.AddAliasFromSubscription(s); err != nil {
 			return fmt.Errorf("adding alias for subscription %q (%s) failed: %w", s.Name, s.Path, err)
 		}
+		if err := c.handler.AddChangeFilterFromSubscription(s); err != nil {
+			return fmt.Errorf("adding change filter for subscription %q (%s) failed: %w", s.Name, s.Path, err)
+		}
 	}
 
 	for i := range c.TagSubscriptions {
@@ -167,6 +175,15 @@ func (c *GNMI) Init() error {
 		return err
 	}
 
+	// Setup the server for devices connecting to Telegraf if requested
+	if c.Dialout != nil {
+		server, err := c.Dialout.Server(c.handler, c.Log)
+		if err != nil {
+			return fmt.Errorf("setting up dial-out server failed: %w", err)
+		}
+		c.dialout = server
+	}
+
 	return nil
 }
 
@@ -205,6 +222,15 @@ func (c *GNMI) Start(acc telegraf.Accumulator) error {
 		ctx = metadata.AppendToOutgoingContext(ctx, "username", username, "password", password)
 	}
 
+	// Start receiving data from devices connecting to Telegraf
+	if c.dialout != nil {
+		if err := c.dialout.Start(acc); err != nil {
+			c.cancel()
+			return err
+		}
+		c.Log.Infof("Listening for dial-out connections on %q", c.dialout.Addr())
+	}
+
 	// Create a goroutine for each device, dial and subscribe
 	c.wg.Add(len(c.Addresses))
 	for _, addr := range c.Addresses {
@@ -245,8 +271,13 @@ func (c *GNMI) Start(acc telegraf.Accumulator) error {
 }
 
 func (c *GNMI) Stop() {
-	c.cancel()
+	if c.cancel != nil {
+		c.cancel()
+	}
 	c.wg.Wait()
+	if c.dialout != nil {
+		c.dialout.Stop()
+	}
 }
 
 func (*GNMI) Gather(telegraf.Accumulator) error {
@@ -15,6 +15,7 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
@@ -24,6 +25,7 @@ import (
 	"github.com/influxdata/telegraf/metric"
 	common_gnmi "github.com/influxdata/telegraf/plugins/common/gnmi"
 	"github.com/influxdata/telegraf/plugins/common/gnmi/extensions/jnpr_gnmi_extention"
+	"github.com/influxdata/telegraf/plugins/common/gnmi/nokia"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
@@ -946,6 +948,92 @@ func TestRedial(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSuppressUnchangedInvalidMode(t *testing.T) {
+	plugin := &GNMI{
+		Log:    testutil.Logger{},
+		Redial: config.Duration(1 * time.Second),
+		Subscriptions: []common_gnmi.Subscription{
+			{
+				Name:              "alias",
+				Origin:            "type",
+				Path:              "/model",
+				SubscriptionMode:  "sample",
+				SuppressUnchanged: true,
+			},
+		},
+	}
+	require.ErrorContains(t, plugin.Init(), "'suppress_unchanged' is only supported for 'on_change' subscription")
+}
+
+func TestDialout(t *testing.T) {
+	plugin := &GNMI{
+		Log:     testutil.Logger{},
+		Redial:  config.Duration(1 * time.Second),
+		Dialout: &common_gnmi.DialoutConfig{Address: "127.0.0.1:0"},
+		Subscriptions: []common_gnmi.Subscription{
+			{
+				Name:             "alias",
+				Origin:           "type",
+				Path:             "/model",
+				SubscriptionMode: "sample",
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Connect to the plugin as device and publish the data
+	conn, err := grpc.NewClient(plugin.dialout.Addr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	defer cancel()
+	stream, err := nokia.NewDialoutTelemetryClient(conn).Publish(ctx)
+	require.NoError(t, err)
+	notification := mockGNMINotification()
+	require.NoError(t, stream.Send(&gnmi.SubscribeResponse{Response: &gnmi.SubscribeResponse_Update{Update: notification}}))
+	require.NoError(t, stream.CloseSend())
+
+	// The data should be handled in the same way as for dial-in subscriptions
+	expected := []telegraf.Metric{
+		metric.New(
+			"alias",
+			map[string]string{
+				"path":   "type:/model",
+				"source": "127.0.0.1",
+				"foo":    "bar",
+				"name":   "str",
+				"uint64": "1234",
+			},
+			map[string]interface{}{
+				"some/path": int64(5678),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"alias",
+			map[string]string{
+				"path":   "type:/model",
+				"source": "127.0.0.1",
+				"foo":    "bar",
+			},
+			map[string]interface{}{
+				"other/path": "foobar",
+				"other/this": "that",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= uint64(len(expected))
+	}, 5*time.Second, 100*time.Millisecond)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
 func TestCases(t *testing.T) {
 	// Get all testcase directories
 	folders, err := os.ReadDir("testcases")
@@ -29,9 +29,10 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	common_gnmi "github.com/influxdata/telegraf/plugins/common/gnmi"
+	"github.com/influxdata/telegraf/plugins/common/gnmi/nokia"
 	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
-	"github.com/influxdata/telegraf/plugins/inputs/gnmi_listener/nokia"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -39,11 +40,13 @@ import (
 func TestMutualTLSFail(t *testing.T) {
 	// Setup plugin
 	plugin := &GNMIListener{
-		Address: "127.0.0.1:0",
-		ServerConfig: common_tls.ServerConfig{
-			TLSCert:           "../../../testutil/pki/servercert.pem",
-			TLSKey:            "../../../testutil/pki/serverkey.pem",
-			TLSAllowedCACerts: []string{"../../../testutil/pki/cacert.pem"},
+		DialoutConfig: common_gnmi.DialoutConfig{
+			Address: "127.0.0.1:0",
+			ServerConfig: common_tls.ServerConfig{
+				TLSCert:           "../../../testutil/pki/servercert.pem",
+				TLSKey:            "../../../testutil/pki/serverkey.pem",
+				TLSAllowedCACerts: []string{"../../../testutil/pki/cacert.pem"},
+			},
 		},
 		Log: testutil.Logger{LogLevel: new(telegraf.Trace)},
 	}
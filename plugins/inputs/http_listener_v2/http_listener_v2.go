@@ -28,6 +28,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/choice"
 	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	common_tls_secret "github.com/influxdata/telegraf/plugins/common/tls/secret"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -63,6 +64,7 @@ type HTTPListenerV2 struct {
 	HTTPHeaderTags map[string]string `toml:"http_header_tags"`
 
 	common_tls.ServerConfig
+	common_tls_secret.KeyPair
 	tlsConf *tls.Config
 
 	timeFunc
@@ -86,6 +88,9 @@ func (*HTTPListenerV2) SampleConfig() string {
 }
 
 func (h *HTTPListenerV2) Init() error {
+	if err := h.KeyPair.Setup(&h.ServerConfig); err != nil {
+		return err
+	}
 	tlsConf, err := h.ServerConfig.TLSConfig()
 	if err != nil {
 		return err
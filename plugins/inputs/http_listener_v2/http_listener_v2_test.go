@@ -192,6 +192,26 @@ func TestWriteHTTPSWithClientAuth(t *testing.T) {
 	require.EqualValues(t, 204, resp.StatusCode)
 }
 
+func TestWriteHTTPSWithCertificateSecrets(t *testing.T) {
+	listener, err := newTestHTTPSListenerV2()
+	require.NoError(t, err)
+	listener.TLSCert = ""
+	listener.TLSKey = ""
+	listener.TLSCertSecret = config.NewSecret([]byte(pki.ReadServerCert()))
+	listener.TLSKeySecret = config.NewSecret([]byte(pki.ReadServerKey()))
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Init())
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	// post single message to listener
+	resp, err := getHTTPSClient().Post(createURL(listener, "https", "/write", "db=mydb"), "", bytes.NewBufferString(testMsg))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.EqualValues(t, 204, resp.StatusCode)
+}
+
 func TestWriteHTTPBasicAuth(t *testing.T) {
 	listener, err := newTestHTTPAuthListener()
 	require.NoError(t, err)
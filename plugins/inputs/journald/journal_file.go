@@ -0,0 +1,286 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Layout of the journal file format as described in
+// https://systemd.io/JOURNAL_FILE_FORMAT/
+const (
+	headerSignature = "LPKSHHRH"
+
+	// Minimum header size containing all fields used by the reader
+	minHeaderSize = 184
+
+	// Incompatible header flags
+	headerCompressedXZ   = 1 << 0
+	headerCompressedLZ4  = 1 << 1
+	headerKeyedHash      = 1 << 2
+	headerCompressedZSTD = 1 << 3
+	headerCompact        = 1 << 4
+	headerSupported      = headerCompressedXZ | headerCompressedLZ4 | headerKeyedHash | headerCompressedZSTD | headerCompact
+
+	// Object types and flags
+	objectHeaderSize     = 16
+	objectData           = 1
+	objectEntry          = 3
+	objectEntryArray     = 6
+	objectCompressedXZ   = 1 << 0
+	objectCompressedLZ4  = 1 << 1
+	objectCompressedZSTD = 1 << 2
+
+	// Size limit for a single object to protect against corrupted files
+	maxObjectSize = 64 * 1024 * 1024
+)
+
+var zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+
+// journalHeader contains the header information of a journal file required
+// for reading the entries
+type journalHeader struct {
+	fileID           [16]byte
+	seqnumID         [16]byte
+	compact          bool
+	nEntries         uint64
+	entryArrayOffset uint64
+}
+
+// journalEntry is a single log entry read from a journal file
+type journalEntry struct {
+	seqnumID  [16]byte
+	seqnum    uint64
+	realtime  uint64
+	monotonic uint64
+	bootID    [16]byte
+	xorHash   uint64
+	fields    map[string]string
+}
+
+// cursor returns the entry's position in the same format as used by systemd
+func (e *journalEntry) cursor() string {
+	return fmt.Sprintf("s=%s;i=%x;b=%s;m=%x;t=%x;x=%x",
+		hex.EncodeToString(e.seqnumID[:]), e.seqnum, hex.EncodeToString(e.bootID[:]), e.monotonic, e.realtime, e.xorHash,
+	)
+}
+
+// journalFile provides read access to the entries of a journal file
+type journalFile struct {
+	r      io.ReaderAt
+	header journalHeader
+}
+
+func openJournalFile(r io.ReaderAt) (*journalFile, error) {
+	buf := make([]byte, minHeaderSize)
+	if _, err := r.ReadAt(buf, 0); err != nil {
+		return nil, fmt.Errorf("reading header failed: %w", err)
+	}
+	if string(buf[0:8]) != headerSignature {
+		return nil, errors.New("invalid signature")
+	}
+
+	incompatible := binary.LittleEndian.Uint32(buf[12:16])
+	if incompatible&^headerSupported != 0 {
+		return nil, fmt.Errorf("unsupported incompatible flags 0x%x", incompatible)
+	}
+	if size := binary.LittleEndian.Uint64(buf[88:96]); size < minHeaderSize {
+		return nil, fmt.Errorf("header size %d too small", size)
+	}
+
+	f := &journalFile{
+		r: r,
+		header: journalHeader{
+			compact:          incompatible&headerCompact != 0,
+			nEntries:         binary.LittleEndian.Uint64(buf[152:160]),
+			entryArrayOffset: binary.LittleEndian.Uint64(buf[176:184]),
+		},
+	}
+	copy(f.header.fileID[:], buf[24:40])
+	copy(f.header.seqnumID[:], buf[72:88])
+
+	return f, nil
+}
+
+// entries returns the entries of the file in order, skipping the given
+// number of leading entries. The callback can stop the iteration by
+// returning false.
+func (f *journalFile) entries(skip uint64, fn func(*journalEntry) bool) error {
+	if skip >= f.header.nEntries {
+		return nil
+	}
+
+	var n uint64
+	offset := f.header.entryArrayOffset
+	for offset != 0 && n < f.header.nEntries {
+		buf, err := f.readObject(offset, objectEntryArray)
+		if err != nil {
+			return fmt.Errorf("reading entry array at %d failed: %w", offset, err)
+		}
+		if len(buf) < 24 {
+			return fmt.Errorf("entry array object at %d too small (%d bytes)", offset, len(buf))
+		}
+		next := binary.LittleEndian.Uint64(buf[16:24])
+		items := f.offsets(buf[24:])
+
+		// Skip the whole array if all entries were consumed previously
+		if n+uint64(len(items)) <= skip {
+			n += uint64(len(items))
+			offset = next
+			continue
+		}
+
+		for _, item := range items {
+			if item == 0 || n >= f.header.nEntries {
+				return nil
+			}
+			n++
+			if n <= skip {
+				continue
+			}
+
+			entry, err := f.readEntry(item)
+			if err != nil {
+				return fmt.Errorf("reading entry at %d failed: %w", item, err)
+			}
+			if !fn(entry) {
+				return nil
+			}
+		}
+		offset = next
+	}
+
+	return nil
+}
+
+func (f *journalFile) readEntry(offset uint64) (*journalEntry, error) {
+	buf, err := f.readObject(offset, objectEntry)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) < 64 {
+		return nil, fmt.Errorf("entry object too small (%d bytes)", len(buf))
+	}
+
+	entry := &journalEntry{
+		seqnumID:  f.header.seqnumID,
+		seqnum:    binary.LittleEndian.Uint64(buf[16:24]),
+		realtime:  binary.LittleEndian.Uint64(buf[24:32]),
+		monotonic: binary.LittleEndian.Uint64(buf[32:40]),
+		xorHash:   binary.LittleEndian.Uint64(buf[56:64]),
+	}
+	copy(entry.bootID[:], buf[40:56])
+
+	var items []uint64
+	if f.header.compact {
+		items = f.offsets(buf[64:])
+	} else {
+		// Regular items consist of the data offset followed by the data hash
+		items = make([]uint64, 0, (len(buf)-64)/16)
+		for i := 64; i+16 <= len(buf); i += 16 {
+			items = append(items, binary.LittleEndian.Uint64(buf[i:i+8]))
+		}
+	}
+
+	entry.fields = make(map[string]string, len(items))
+	for _, item := range items {
+		data, err := f.readData(item)
+		if err != nil {
+			return nil, fmt.Errorf("reading data at %d failed: %w", item, err)
+		}
+		key, value, found := bytes.Cut(data, []byte("="))
+		if !found {
+			continue
+		}
+		if _, exists := entry.fields[string(key)]; !exists {
+			entry.fields[string(key)] = string(value)
+		}
+	}
+
+	return entry, nil
+}
+
+func (f *journalFile) readData(offset uint64) ([]byte, error) {
+	buf, err := f.readObject(offset, objectData)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 64
+	if f.header.compact {
+		start = 72
+	}
+	if len(buf) < start {
+		return nil, fmt.Errorf("data object too small (%d bytes)", len(buf))
+	}
+	payload := buf[start:]
+
+	switch flags := buf[1]; {
+	case flags&objectCompressedZSTD != 0:
+		return zstdDecoder.DecodeAll(payload, nil)
+	case flags&objectCompressedLZ4 != 0:
+		// LZ4 payloads are prefixed by the uncompressed size
+		if len(payload) < 8 {
+			return nil, errors.New("invalid lz4 payload")
+		}
+		size := binary.LittleEndian.Uint64(payload[:8])
+		if size > maxObjectSize {
+			return nil, fmt.Errorf("lz4 payload size %d exceeds limit", size)
+		}
+		out := make([]byte, size)
+		n, err := lz4.UncompressBlock(payload[8:], out)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing lz4 failed: %w", err)
+		}
+		return out[:n], nil
+	case flags&objectCompressedXZ != 0:
+		return nil, errors.New("xz compression is not supported")
+	}
+
+	return payload, nil
+}
+
+// readObject reads the complete object at the given offset and checks its type
+func (f *journalFile) readObject(offset uint64, objectType uint8) ([]byte, error) {
+	var header [objectHeaderSize]byte
+	if _, err := f.r.ReadAt(header[:], int64(offset)); err != nil {
+		return nil, err
+	}
+	if header[0] != objectType {
+		return nil, fmt.Errorf("unexpected object type %d, expected %d", header[0], objectType)
+	}
+	size := binary.LittleEndian.Uint64(header[8:16])
+	if size < objectHeaderSize || size > maxObjectSize {
+		return nil, fmt.Errorf("invalid object size %d", size)
+	}
+
+	buf := make([]byte, size)
+	if _, err := f.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// offsets decodes a list of object offsets which are stored as 32-bit values
+// in compact files and 64-bit values otherwise
+func (f *journalFile) offsets(buf []byte) []uint64 {
+	if f.header.compact {
+		items := make([]uint64, 0, len(buf)/4)
+		for i := 0; i+4 <= len(buf); i += 4 {
+			items = append(items, uint64(binary.LittleEndian.Uint32(buf[i:i+4])))
+		}
+		return items
+	}
+
+	items := make([]uint64, 0, len(buf)/8)
+	for i := 0; i+8 <= len(buf); i += 8 {
+		items = append(items, binary.LittleEndian.Uint64(buf[i:i+8]))
+	}
+	return items
+}
@@ -0,0 +1,386 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package journald
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var priorities = map[string]int64{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// Journal fields converted to integers
+var numericFields = map[string]bool{
+	"priority":        true,
+	"syslog_facility": true,
+	"pid":             true,
+	"uid":             true,
+	"gid":             true,
+	"errno":           true,
+	"code_line":       true,
+}
+
+// Fields containing the unit an entry belongs to
+var unitFields = []string{"_SYSTEMD_UNIT", "UNIT", "OBJECT_SYSTEMD_UNIT"}
+
+type Journald struct {
+	Files         []string        `toml:"files"`
+	FromBeginning bool            `toml:"from_beginning"`
+	Units         []string        `toml:"units"`
+	Priority      string          `toml:"priority"`
+	Matches       []string        `toml:"matches"`
+	TagFields     []string        `toml:"tag_fields"`
+	MetricFields  []string        `toml:"metric_fields"`
+	Log           telegraf.Logger `toml:"-"`
+
+	globs        []*globpath.GlobPath
+	unitFilter   filter.Filter
+	maxPriority  int64
+	matches      map[string][]string
+	tagFilter    filter.Filter
+	metricFilter filter.Filter
+
+	// Number of entries consumed per journal file-ID
+	consumed map[[16]byte]uint64
+	started  bool
+
+	// Position of the last entry emitted
+	cursor     string
+	position   *cursorPosition
+	cursorLock sync.Mutex
+}
+
+type cursorPosition struct {
+	seqnumID [16]byte
+	seqnum   uint64
+	realtime uint64
+}
+
+func (*Journald) SampleConfig() string {
+	return sampleConfig
+}
+
+func (j *Journald) Init() error {
+	if len(j.Files) == 0 {
+		j.Files = []string{"/var/log/journal/*/*.journal", "/run/log/journal/*/*.journal"}
+	}
+	for _, pattern := range j.Files {
+		g, err := globpath.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+		}
+		j.globs = append(j.globs, g)
+	}
+
+	if len(j.Units) > 0 {
+		f, err := filter.Compile(j.Units)
+		if err != nil {
+			return fmt.Errorf("invalid units: %w", err)
+		}
+		j.unitFilter = f
+	}
+
+	j.maxPriority = -1
+	if j.Priority != "" {
+		level, found := priorities[strings.ToLower(j.Priority)]
+		if !found {
+			var err error
+			level, err = strconv.ParseInt(j.Priority, 10, 64)
+			if err != nil || level < 0 || level > 7 {
+				return fmt.Errorf("invalid priority %q", j.Priority)
+			}
+		}
+		j.maxPriority = level
+	}
+
+	j.matches = make(map[string][]string, len(j.Matches))
+	for _, m := range j.Matches {
+		key, value, found := strings.Cut(m, "=")
+		if !found || key == "" {
+			return fmt.Errorf("invalid match %q, expected 'FIELD=value'", m)
+		}
+		j.matches[key] = append(j.matches[key], value)
+	}
+
+	tagFilter, err := filter.Compile(j.TagFields)
+	if err != nil {
+		return fmt.Errorf("invalid tag fields: %w", err)
+	}
+	j.tagFilter = tagFilter
+
+	metricFilter, err := filter.Compile(j.MetricFields)
+	if err != nil {
+		return fmt.Errorf("invalid metric fields: %w", err)
+	}
+	j.metricFilter = metricFilter
+
+	j.consumed = make(map[[16]byte]uint64)
+
+	return nil
+}
+
+func (j *Journald) GetState() interface{} {
+	j.cursorLock.Lock()
+	defer j.cursorLock.Unlock()
+
+	return j.cursor
+}
+
+func (j *Journald) SetState(state interface{}) error {
+	cursor, ok := state.(string)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+	var position *cursorPosition
+	if cursor != "" {
+		p, err := parseCursor(cursor)
+		if err != nil {
+			return fmt.Errorf("invalid cursor %q: %w", cursor, err)
+		}
+		position = p
+	}
+
+	j.cursorLock.Lock()
+	j.cursor = cursor
+	j.position = position
+	j.cursorLock.Unlock()
+
+	return nil
+}
+
+func (j *Journald) Gather(acc telegraf.Accumulator) error {
+	j.cursorLock.Lock()
+	position := j.position
+	j.cursorLock.Unlock()
+
+	// Without a previous position skip the existing entries on the first
+	// gather unless requested otherwise
+	skip := position == nil && !j.FromBeginning && !j.started
+	j.started = true
+
+	// Collect the new entries of all files and merge them by time
+	var entries []*journalEntry
+	seen := make(map[[16]byte]bool)
+	for _, g := range j.globs {
+		for _, path := range g.Match() {
+			if err := j.readFile(path, position, skip, seen, &entries); err != nil {
+				acc.AddError(fmt.Errorf("reading %q failed: %w", path, err))
+			}
+		}
+	}
+
+	// Forget about files that vanished
+	for id := range j.consumed {
+		if !seen[id] {
+			delete(j.consumed, id)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].realtime != entries[b].realtime {
+			return entries[a].realtime < entries[b].realtime
+		}
+		return entries[a].seqnum < entries[b].seqnum
+	})
+
+	if !skip {
+		for _, e := range entries {
+			if j.filter(e) {
+				j.emit(acc, e)
+			}
+		}
+	}
+
+	last := entries[len(entries)-1]
+	j.cursorLock.Lock()
+	j.cursor = last.cursor()
+	j.position = &cursorPosition{seqnumID: last.seqnumID, seqnum: last.seqnum, realtime: last.realtime}
+	j.cursorLock.Unlock()
+
+	return nil
+}
+
+// readFile appends the entries not yet consumed to the given list. When
+// skipping the existing entries only the last entry is appended to determine
+// the position.
+func (j *Journald) readFile(path string, position *cursorPosition, skip bool, seen map[[16]byte]bool, entries *[]*journalEntry) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	f, err := openJournalFile(file)
+	if err != nil {
+		return err
+	}
+
+	// The same file might be matched by multiple patterns
+	id := f.header.fileID
+	if seen[id] {
+		return nil
+	}
+	seen[id] = true
+
+	if skip {
+		j.consumed[id] = f.header.nEntries
+		if f.header.nEntries == 0 {
+			return nil
+		}
+		return f.entries(f.header.nEntries-1, func(e *journalEntry) bool {
+			*entries = append(*entries, e)
+			return false
+		})
+	}
+
+	return f.entries(j.consumed[id], func(e *journalEntry) bool {
+		j.consumed[id]++
+		if !position.covers(e) {
+			*entries = append(*entries, e)
+		}
+		return true
+	})
+}
+
+func (j *Journald) filter(e *journalEntry) bool {
+	if j.maxPriority >= 0 {
+		level, err := strconv.ParseInt(e.fields["PRIORITY"], 10, 64)
+		if err != nil || level > j.maxPriority {
+			return false
+		}
+	}
+
+	if j.unitFilter != nil {
+		if !slices.ContainsFunc(unitFields, func(field string) bool {
+			unit, found := e.fields[field]
+			return found && j.unitFilter.Match(unit)
+		}) {
+			return false
+		}
+	}
+
+	for key, values := range j.matches {
+		value, found := e.fields[key]
+		if !found || !slices.Contains(values, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (j *Journald) emit(acc telegraf.Accumulator, e *journalEntry) {
+	tags := make(map[string]string)
+	fields := make(map[string]interface{})
+	for key, value := range e.fields {
+		name := strings.ToLower(strings.TrimLeft(key, "_"))
+		if j.tagFilter != nil && j.tagFilter.Match(key) {
+			if value != "" {
+				tags[name] = value
+			}
+			continue
+		}
+		if j.metricFilter == nil || !j.metricFilter.Match(key) {
+			continue
+		}
+		if numericFields[name] {
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				fields[name] = v
+				continue
+			}
+		}
+		fields[name] = value
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	acc.AddFields("journald", fields, tags, time.UnixMicro(int64(e.realtime)))
+}
+
+// covers checks if the given entry was already processed when reaching the
+// cursor position
+func (p *cursorPosition) covers(e *journalEntry) bool {
+	if p == nil {
+		return false
+	}
+	if p.seqnumID == e.seqnumID {
+		return e.seqnum <= p.seqnum
+	}
+	return e.realtime <= p.realtime
+}
+
+func parseCursor(cursor string) (*cursorPosition, error) {
+	var p cursorPosition
+	var hasID, hasSeqnum, hasRealtime bool
+	for _, part := range strings.Split(cursor, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid element %q", part)
+		}
+		switch key {
+		case "s":
+			id, err := hex.DecodeString(value)
+			if err != nil || len(id) != len(p.seqnumID) {
+				return nil, fmt.Errorf("invalid sequence number ID %q", value)
+			}
+			copy(p.seqnumID[:], id)
+			hasID = true
+		case "i":
+			v, err := strconv.ParseUint(value, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sequence number %q", value)
+			}
+			p.seqnum = v
+			hasSeqnum = true
+		case "t":
+			v, err := strconv.ParseUint(value, 16, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid realtime %q", value)
+			}
+			p.realtime = v
+			hasRealtime = true
+		}
+	}
+	if !hasID || !hasSeqnum || !hasRealtime {
+		return nil, errors.New("missing elements")
+	}
+	return &p, nil
+}
+
+func init() {
+	inputs.Add("journald", func() telegraf.Input {
+		return &Journald{
+			TagFields:    []string{"_HOSTNAME", "_SYSTEMD_UNIT", "SYSLOG_IDENTIFIER"},
+			MetricFields: []string{"MESSAGE", "PRIORITY", "_PID"},
+		}
+	})
+}
@@ -0,0 +1,323 @@
+package journald
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestReadFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		pids     [3]int64
+		realtime []int64
+		cursor   string
+	}{
+		{
+			name:     "regular",
+			file:     "testdata/regular.journal",
+			pids:     [3]int64{3805, 3807, 3861},
+			realtime: []int64{1792403428478778, 1792403428478945, 1792403430600519, 1792403430601064, 1792403430715938, 1792403430716797, 1792403430717192, 1792403431732142},
+			cursor: "s=5f8c3c62c5e043dc8bf4db2f67acb36f;i=8;b=8f1d0058f3364d47ba6bfc1c0cc9e2b3;" +
+				"m=1cafdc3ee;t=65e2e71d7b3ae;x=caf7bcbc9425cd8f",
+		},
+		{
+			name:     "compact",
+			file:     "testdata/compact.journal",
+			pids:     [3]int64{3277, 3279, 3333},
+			realtime: []int64{1792403411620837, 1792403411620920, 1792403413702720, 1792403413704491, 1792403413834679, 1792403413835265, 1792403413835505, 1792403414844244},
+			cursor: "s=87b31e5693a440b78d3e168f2361f4d2;i=8;b=8f1d0058f3364d47ba6bfc1c0cc9e2b3;" +
+				"m=1c9fc1394;t=65e2e70d60354;x=12d09d213b83a535",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Journald{
+				Files:         []string{tt.file},
+				FromBeginning: true,
+				TagFields:     []string{"_HOSTNAME", "_SYSTEMD_UNIT", "SYSLOG_IDENTIFIER"},
+				MetricFields:  []string{"MESSAGE", "PRIORITY", "_PID"},
+				Log:           testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Gather(&acc))
+			require.Empty(t, acc.Errors)
+
+			journaldPID, nginxPID, appPID := tt.pids[0], tt.pids[1], tt.pids[2]
+			runtime := "Runtime Journal (/run/log/journal/fed6b2924c424cf1b9a322f606b4de6d) is 512.0K, max 4.0G, 3.9G free."
+			expected := []telegraf.Metric{
+				newMetric("systemd-journald", "Journal started", 6, journaldPID, tt.realtime[0]),
+				newMetric("systemd-journald", runtime, 6, journaldPID, tt.realtime[1]),
+				newMetric("nginx", "Started nginx web server", 6, nginxPID, tt.realtime[2]),
+				newMetric("nginx", "upstream timed out", 3, nginxPID, tt.realtime[3]),
+				newMetric("app", "multi\nline message", 4, appPID, tt.realtime[4]),
+				newMetric("app", strings.Repeat("x", 1200), 5, appPID, tt.realtime[5]),
+				newMetric("app", "debugging details", 7, appPID, tt.realtime[6]),
+				newMetric("systemd-journald", "Journal stopped", 6, journaldPID, tt.realtime[7]),
+			}
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+			require.Equal(t, tt.cursor, plugin.GetState())
+
+			// Nothing new should be collected on the next gather
+			acc.ClearMetrics()
+			require.NoError(t, plugin.Gather(&acc))
+			require.Empty(t, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestMergeFiles(t *testing.T) {
+	plugin := &Journald{
+		Files:         []string{"testdata/*.journal"},
+		FromBeginning: true,
+		MetricFields:  []string{"MESSAGE"},
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 16)
+	for i := 1; i < len(metrics); i++ {
+		require.False(t, metrics[i].Time().Before(metrics[i-1].Time()))
+	}
+	require.Contains(t, plugin.GetState(), "s=5f8c3c62c5e043dc8bf4db2f67acb36f;i=8;")
+}
+
+func TestFromEnd(t *testing.T) {
+	plugin := &Journald{
+		Files:        []string{"testdata/compact.journal"},
+		MetricFields: []string{"MESSAGE"},
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.GetTelegrafMetrics())
+	require.Contains(t, plugin.GetState(), ";i=8;")
+	require.NotNil(t, plugin.position)
+	require.Equal(t, uint64(8), plugin.position.seqnum)
+
+	// All existing entries are consumed so no entry is emitted later on
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestResumeFromCursor(t *testing.T) {
+	filename := "testdata/compact.journal"
+	plugin := &Journald{
+		Files:        []string{filename},
+		MetricFields: []string{"MESSAGE"},
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	cursor := "s=87b31e5693a440b78d3e168f2361f4d2;i=5;b=8f1d0058f3364d47ba6bfc1c0cc9e2b3;" +
+		"m=1c9ecabf8;t=65e2e70c69bb7;x=a58fcd5590e7259c"
+	require.NoError(t, plugin.SetState(cursor))
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	var messages []string
+	for _, m := range acc.GetTelegrafMetrics() {
+		msg, _ := m.GetField("message")
+		messages = append(messages, msg.(string)[:min(len(msg.(string)), 10)])
+	}
+	require.Equal(t, []string{"xxxxxxxxxx", "debugging ", "Journal st"}, messages)
+
+	// A restarted instance must not duplicate any entries
+	restarted := &Journald{
+		Files:         []string{filename},
+		FromBeginning: true,
+		MetricFields:  []string{"MESSAGE"},
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, restarted.Init())
+	require.NoError(t, restarted.SetState(plugin.GetState()))
+	acc.ClearMetrics()
+	require.NoError(t, restarted.Gather(&acc))
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		units    []string
+		priority string
+		matches  []string
+		expected []string
+	}{
+		{
+			name:     "units",
+			units:    []string{"nginx*"},
+			expected: []string{"Started nginx web server", "upstream timed out"},
+		},
+		{
+			name:     "priority name",
+			priority: "warning",
+			expected: []string{"upstream timed out", "multi\nline message"},
+		},
+		{
+			name:     "priority number",
+			priority: "3",
+			expected: []string{"upstream timed out"},
+		},
+		{
+			name:     "matches same field",
+			matches:  []string{"SYSLOG_IDENTIFIER=app", "SYSLOG_IDENTIFIER=nginx"},
+			priority: "notice",
+			expected: []string{"upstream timed out", "multi\nline message", strings.Repeat("x", 1200)},
+		},
+		{
+			name:     "matches different fields",
+			matches:  []string{"SYSLOG_IDENTIFIER=app", "CODE_LINE=42"},
+			expected: []string{"multi\nline message"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Journald{
+				Files:         []string{"testdata/regular.journal"},
+				FromBeginning: true,
+				Units:         tt.units,
+				Priority:      tt.priority,
+				Matches:       tt.matches,
+				MetricFields:  []string{"MESSAGE"},
+				Log:           testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Gather(&acc))
+			require.Empty(t, acc.Errors)
+
+			messages := make([]string, 0, len(tt.expected))
+			for _, m := range acc.GetTelegrafMetrics() {
+				msg, _ := m.GetField("message")
+				messages = append(messages, msg.(string))
+			}
+			require.Equal(t, tt.expected, messages)
+		})
+	}
+}
+
+func TestFieldSelection(t *testing.T) {
+	plugin := &Journald{
+		Files:         []string{"testdata/regular.journal"},
+		FromBeginning: true,
+		Matches:       []string{"REQUEST_ID=abc123"},
+		TagFields:     []string{"UNIT"},
+		MetricFields:  []string{"REQUEST_ID", "SYSLOG_*"},
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"journald",
+			map[string]string{"unit": "nginx.service"},
+			map[string]interface{}{
+				"request_id":        "abc123",
+				"syslog_identifier": "nginx",
+				"syslog_facility":   int64(3),
+			},
+			time.UnixMicro(1792403430601064),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestInvalidSettings(t *testing.T) {
+	plugin := &Journald{Priority: "verbose"}
+	require.ErrorContains(t, plugin.Init(), "invalid priority")
+
+	plugin = &Journald{Priority: "8"}
+	require.ErrorContains(t, plugin.Init(), "invalid priority")
+
+	plugin = &Journald{Matches: []string{"MESSAGE"}}
+	require.ErrorContains(t, plugin.Init(), "invalid match")
+
+	plugin = &Journald{}
+	require.NoError(t, plugin.Init())
+	require.ErrorContains(t, plugin.SetState("s=abc;i=1"), "invalid cursor")
+	require.ErrorContains(t, plugin.SetState(42), "wrong type")
+}
+
+func TestInvalidFile(t *testing.T) {
+	plugin := &Journald{
+		Files:         []string{"testdata/../journald.go"},
+		FromBeginning: true,
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.Errors[0], "invalid signature")
+}
+
+func TestCorruptEntryArray(t *testing.T) {
+	buf, err := os.ReadFile("testdata/regular.journal")
+	require.NoError(t, err)
+
+	// Shrink the first entry array object to its bare object header
+	offset := binary.LittleEndian.Uint64(buf[176:184])
+	binary.LittleEndian.PutUint64(buf[offset+8:offset+16], 16)
+
+	filename := filepath.Join(t.TempDir(), "corrupt.journal")
+	require.NoError(t, os.WriteFile(filename, buf, 0600))
+
+	plugin := &Journald{
+		Files:         []string{filename},
+		FromBeginning: true,
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.Errors[0], "too small (16 bytes)")
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func newMetric(identifier, message string, priority, pid, realtime int64) telegraf.Metric {
+	return metric.New(
+		"journald",
+		map[string]string{
+			"hostname":          "vm",
+			"syslog_identifier": identifier,
+		},
+		map[string]interface{}{
+			"message":  message,
+			"priority": priority,
+			"pid":      pid,
+		},
+		time.UnixMicro(realtime),
+	)
+}
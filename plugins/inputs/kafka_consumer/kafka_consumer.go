@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 //go:embed sample.conf
@@ -41,6 +43,9 @@ type KafkaConsumer struct {
 	MaxUndeliveredMessages               int             `toml:"max_undelivered_messages"`
 	MaxProcessingTime                    config.Duration `toml:"max_processing_time"`
 	Offset                               string          `toml:"offset"`
+	CommitMode                           string          `toml:"commit_mode"`
+	StartPosition                        string          `toml:"start_position"`
+	StartTimestamp                       string          `toml:"start_timestamp"`
 	BalanceStrategy                      string          `toml:"balance_strategy"`
 	Topics                               []string        `toml:"topics"`
 	TopicRegexps                         []string        `toml:"topic_regexps"`
@@ -60,10 +65,16 @@ type KafkaConsumer struct {
 	consumer        consumerGroup
 	config          *sarama.Config
 
-	topicClient     sarama.Client
+	client          sarama.Client
 	regexps         []regexp.Regexp
 	allWantedTopics []string
 	fingerprint     string
+	startTime       time.Time
+
+	// Offsets of the next message to consume per topic and partition
+	offsets   map[string]map[int32]int64
+	seeked    map[string]map[int32]bool
+	stateLock sync.Mutex
 
 	parser    telegraf.Parser
 	topicLock sync.Mutex
@@ -78,6 +89,13 @@ type consumerGroupHandler struct {
 	msgHeadersToTags      map[string]bool
 	msgHeaderToMetricName string
 	timestampSource       string
+	consumerGroup         string
+	commitMode            string
+
+	// Callbacks for recording committed offsets and for determining the
+	// offset to start consuming a newly claimed partition
+	onCommit    func(topic string, partition int32, offset int64)
+	startOffset func(topic string, partition int32) (int64, bool)
 
 	acc    telegraf.TrackingAccumulator
 	sem    semaphore
@@ -87,6 +105,7 @@ type consumerGroupHandler struct {
 
 	mu          sync.Mutex
 	undelivered map[telegraf.TrackingID]message
+	partitions  map[string]map[int32]*partitionTracker
 
 	log telegraf.Logger
 }
@@ -97,6 +116,17 @@ type message struct {
 	session sarama.ConsumerGroupSession
 }
 
+// partitionTracker keeps the offsets of in-flight messages of a partition
+// in the order of consumption to allow committing them in order. If delivery
+// of a message fails, the tracker stalls and the offset of the partition is
+// not advanced anymore.
+type partitionTracker struct {
+	pending []int64
+	done    map[int64]bool
+	stalled bool
+	failed  chan empty
+}
+
 type (
 	empty     struct{}
 	semaphore chan empty
@@ -169,6 +199,37 @@ func (k *KafkaConsumer) Init() error {
 		return fmt.Errorf("invalid offset %q", k.Offset)
 	}
 
+	switch k.CommitMode {
+	case "":
+		k.CommitMode = "any"
+	case "any", "ordered":
+	default:
+		return fmt.Errorf("invalid commit mode %q", k.CommitMode)
+	}
+
+	switch k.StartPosition {
+	case "":
+		k.StartPosition = "group"
+	case "group":
+	case "state":
+		if k.CommitMode != "ordered" {
+			return errors.New("start position 'state' requires commit mode 'ordered'")
+		}
+	case "timestamp":
+		if k.StartTimestamp == "" {
+			return errors.New("'start_timestamp' required for start position 'timestamp'")
+		}
+		t, err := time.Parse(time.RFC3339, k.StartTimestamp)
+		if err != nil {
+			return fmt.Errorf("parsing start timestamp failed: %w", err)
+		}
+		k.startTime = t
+	default:
+		return fmt.Errorf("invalid start position %q", k.StartPosition)
+	}
+	k.offsets = make(map[string]map[int32]int64)
+	k.seeked = make(map[string]map[int32]bool)
+
 	switch strings.ToLower(k.BalanceStrategy) {
 	case "range", "":
 		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
@@ -210,22 +271,56 @@ func (k *KafkaConsumer) Init() error {
 
 	if len(k.TopicRegexps) == 0 {
 		k.allWantedTopics = k.Topics
-	} else {
-		if err := k.compileTopicRegexps(); err != nil {
-			return err
-		}
-		// We have regexps, so we're going to need a client to ask
-		// the broker for topics
+	} else if err := k.compileTopicRegexps(); err != nil {
+		return err
+	}
+
+	// We have regexps or need to lookup offsets for timestamps, so we're
+	// going to need a client to ask the broker
+	if len(k.TopicRegexps) > 0 || k.StartPosition == "timestamp" {
 		client, err := sarama.NewClient(k.Brokers, k.config)
 		if err != nil {
 			return err
 		}
-		k.topicClient = client
+		k.client = client
 	}
 
 	return nil
 }
 
+func (k *KafkaConsumer) GetState() interface{} {
+	k.stateLock.Lock()
+	defer k.stateLock.Unlock()
+
+	state := make(map[string]map[int32]int64, len(k.offsets))
+	for topic, partitions := range k.offsets {
+		state[topic] = make(map[int32]int64, len(partitions))
+		for partition, offset := range partitions {
+			state[topic][partition] = offset
+		}
+	}
+	return state
+}
+
+func (k *KafkaConsumer) SetState(state interface{}) error {
+	offsets, ok := state.(map[string]map[int32]int64)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	k.stateLock.Lock()
+	defer k.stateLock.Unlock()
+	for topic, partitions := range offsets {
+		if k.offsets[topic] == nil {
+			k.offsets[topic] = make(map[int32]int64, len(partitions))
+		}
+		for partition, offset := range partitions {
+			k.offsets[topic][partition] = offset
+		}
+	}
+	return nil
+}
+
 func (k *KafkaConsumer) SetParser(parser telegraf.Parser) {
 	k.parser = parser
 }
@@ -286,6 +381,14 @@ func (k *KafkaConsumer) Start(acc telegraf.Accumulator) error {
 			}
 			handler.msgHeadersToTags = msgHeadersMap
 			handler.timestampSource = k.TimestampSource
+			handler.consumerGroup = k.ConsumerGroup
+			handler.commitMode = k.CommitMode
+			if k.CommitMode == "ordered" {
+				handler.onCommit = k.recordOffset
+			}
+			if k.StartPosition != "group" {
+				handler.startOffset = k.initialOffset
+			}
 
 			// We need to copy allWantedTopics; the Consume() is
 			// long-running and we can easily deadlock if our
@@ -316,8 +419,8 @@ func (*KafkaConsumer) Gather(telegraf.Accumulator) error {
 func (k *KafkaConsumer) Stop() {
 	// Lock so that a topic refresh cannot start while we are stopping.
 	k.topicLock.Lock()
-	if k.topicClient != nil {
-		k.topicClient.Close()
+	if k.client != nil {
+		k.client.Close()
 	}
 	k.topicLock.Unlock()
 
@@ -352,7 +455,7 @@ func (k *KafkaConsumer) refreshTopics() error {
 		return nil
 	}
 
-	allDiscoveredTopics, err := k.topicClient.Topics()
+	allDiscoveredTopics, err := k.client.Topics()
 	if err != nil {
 		return err
 	}
@@ -414,6 +517,55 @@ func (k *KafkaConsumer) create() error {
 	return err
 }
 
+// recordOffset stores the offset of the next message to consume for the
+// given partition in the plugin state
+func (k *KafkaConsumer) recordOffset(topic string, partition int32, offset int64) {
+	k.stateLock.Lock()
+	defer k.stateLock.Unlock()
+
+	if k.offsets[topic] == nil {
+		k.offsets[topic] = make(map[int32]int64)
+	}
+	k.offsets[topic][partition] = offset
+}
+
+// initialOffset returns the offset to start consuming the given partition
+// at according to the configured start position. The offset is only returned
+// for the first claim of the partition to avoid rewinding on rebalance.
+func (k *KafkaConsumer) initialOffset(topic string, partition int32) (int64, bool) {
+	k.stateLock.Lock()
+	defer k.stateLock.Unlock()
+
+	if k.seeked[topic][partition] {
+		return 0, false
+	}
+	if k.seeked[topic] == nil {
+		k.seeked[topic] = make(map[int32]bool)
+	}
+	k.seeked[topic][partition] = true
+
+	switch k.StartPosition {
+	case "state":
+		offset, found := k.offsets[topic][partition]
+		return offset, found
+	case "timestamp":
+		offset, err := k.client.GetOffset(topic, partition, k.startTime.UnixMilli())
+		if err != nil {
+			k.Log.Errorf("Getting offset for timestamp of partition %d of topic %q failed: %v", partition, topic, err)
+			return 0, false
+		}
+		// No message at or after the timestamp, so start at the end
+		if offset < 0 {
+			if offset, err = k.client.GetOffset(topic, partition, sarama.OffsetNewest); err != nil {
+				k.Log.Errorf("Getting newest offset of partition %d of topic %q failed: %v", partition, topic, err)
+				return 0, false
+			}
+		}
+		return offset, true
+	}
+	return 0, false
+}
+
 func (k *KafkaConsumer) startErrorAdder(acc telegraf.Accumulator) {
 	k.wg.Add(1)
 	go func() {
@@ -429,6 +581,7 @@ func newConsumerGroupHandler(acc telegraf.Accumulator, maxUndelivered int, parse
 		acc:         acc.WithTracking(maxUndelivered),
 		sem:         make(chan empty, maxUndelivered),
 		undelivered: make(map[telegraf.TrackingID]message, maxUndelivered),
+		partitions:  make(map[string]map[int32]*partitionTracker),
 		parser:      parser,
 		log:         log,
 	}
@@ -436,8 +589,21 @@ func newConsumerGroupHandler(acc telegraf.Accumulator, maxUndelivered int, parse
 }
 
 // Setup is called once when a new session is opened. It setups up the handler and begins processing delivered messages.
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
 	h.undelivered = make(map[telegraf.TrackingID]message)
+	h.partitions = make(map[string]map[int32]*partitionTracker)
+
+	// Seek the claimed partitions to the configured start position
+	if session != nil && h.startOffset != nil {
+		for topic, partitions := range session.Claims() {
+			for _, partition := range partitions {
+				if offset, ok := h.startOffset(topic, partition); ok {
+					h.log.Debugf("Starting partition %d of topic %q at offset %d", partition, topic, offset)
+					session.ResetOffset(topic, partition, offset, "")
+				}
+			}
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	h.cancel = cancel
@@ -454,6 +620,26 @@ func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	ctx := session.Context()
 
+	// Report the consumer lag of the partition as internal metric for the
+	// lifetime of the claim
+	tags := map[string]string{
+		"consumer_group": h.consumerGroup,
+		"topic":          claim.Topic(),
+		"partition":      strconv.FormatInt(int64(claim.Partition()), 10),
+	}
+	lag := selfstat.Register("kafka_consumer", "lag", tags)
+	defer selfstat.Unregister("kafka_consumer", "lag", tags)
+
+	// In ordered commit mode, stop consuming the partition if delivery of a
+	// message failed. Returning ends the session, so the next session starts
+	// again at the committed offset and the failed message is redelivered.
+	var stalled <-chan empty
+	if h.commitMode == "ordered" {
+		h.mu.Lock()
+		stalled = h.tracker(claim.Topic(), claim.Partition()).failed
+		h.mu.Unlock()
+	}
+
 	for {
 		err := h.reserve(ctx)
 		if err != nil {
@@ -463,10 +649,14 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-stalled:
+			h.release()
+			return nil
 		case msg, ok := <-claim.Messages():
 			if !ok {
 				return nil
 			}
+			lag.Set(max(claim.HighWaterMarkOffset()-msg.Offset-1, 0))
 			err := h.handle(session, msg)
 			if err != nil {
 				h.acc.AddError(err)
@@ -476,9 +666,15 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 }
 
 // Cleanup stops the internal goroutine and is called after all ConsumeClaim functions have completed.
-func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
 	h.cancel()
 	h.wg.Wait()
+
+	// Flush the offsets of delivered messages before the partitions are
+	// handed over to another consumer
+	if h.commitMode == "ordered" && session != nil {
+		session.Commit()
+	}
 	return nil
 }
 
@@ -505,7 +701,9 @@ func (h *consumerGroupHandler) onDelivery(track telegraf.DeliveryInfo) {
 	}
 
 	if track.Delivered() {
-		msg.session.MarkMessage(msg.message, "")
+		h.markDone(msg.session, msg.message)
+	} else if h.commitMode == "ordered" {
+		h.stall(msg.message)
 	}
 
 	delete(h.undelivered, track.ID())
@@ -526,10 +724,94 @@ func (h *consumerGroupHandler) release() {
 	<-h.sem
 }
 
+// track registers the message as in-flight for in-order commits.
+func (h *consumerGroupHandler) track(msg *sarama.ConsumerMessage) {
+	if h.commitMode != "ordered" {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	tracker := h.tracker(msg.Topic, msg.Partition)
+	if !tracker.stalled {
+		tracker.pending = append(tracker.pending, msg.Offset)
+	}
+}
+
+// tracker returns the tracker of the given partition, creating it if
+// necessary. This function must be called with the lock held.
+func (h *consumerGroupHandler) tracker(topic string, partition int32) *partitionTracker {
+	if h.partitions[topic] == nil {
+		h.partitions[topic] = make(map[int32]*partitionTracker)
+	}
+	tracker, found := h.partitions[topic][partition]
+	if !found {
+		tracker = &partitionTracker{
+			done:   make(map[int64]bool),
+			failed: make(chan empty),
+		}
+		h.partitions[topic][partition] = tracker
+	}
+	return tracker
+}
+
+// stall stops advancing the offset of the partition of the given message
+// and signals the claim of the partition to stop consuming. This function
+// must be called with the lock held.
+func (h *consumerGroupHandler) stall(msg *sarama.ConsumerMessage) {
+	tracker, found := h.partitions[msg.Topic][msg.Partition]
+	if !found || tracker.stalled {
+		return
+	}
+	h.log.Errorf("Delivery of message at offset %d of partition %d of topic %q failed; consuming partition again from last committed offset",
+		msg.Offset, msg.Partition, msg.Topic)
+
+	tracker.stalled = true
+	tracker.pending = nil
+	tracker.done = nil
+	close(tracker.failed)
+}
+
+// markDone marks the message as processed. In ordered commit mode the offset
+// of a partition only advances if all previous messages of the partition
+// are processed. This function must be called with the lock held.
+func (h *consumerGroupHandler) markDone(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) {
+	if h.commitMode != "ordered" {
+		session.MarkMessage(msg, "")
+		return
+	}
+
+	tracker, found := h.partitions[msg.Topic][msg.Partition]
+	if !found || tracker.stalled {
+		return
+	}
+	tracker.done[msg.Offset] = true
+
+	next := int64(-1)
+	for len(tracker.pending) > 0 && tracker.done[tracker.pending[0]] {
+		delete(tracker.done, tracker.pending[0])
+		next = tracker.pending[0] + 1
+		tracker.pending = tracker.pending[1:]
+	}
+	if next < 0 {
+		return
+	}
+
+	session.MarkOffset(msg.Topic, msg.Partition, next, "")
+	if h.onCommit != nil {
+		h.onCommit(msg.Topic, msg.Partition, next)
+	}
+}
+
 // handle processes a message and if successful saves it to be acknowledged after delivery.
 func (h *consumerGroupHandler) handle(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) error {
+	h.track(msg)
+
 	if h.maxMessageLen != 0 && len(msg.Value) > h.maxMessageLen {
-		session.MarkMessage(msg, "")
+		h.mu.Lock()
+		h.markDone(session, msg)
+		h.mu.Unlock()
 		h.release()
 		return fmt.Errorf("message exceeds max_message_len (actual %d, max %d)",
 			len(msg.Value), h.maxMessageLen)
@@ -537,7 +819,9 @@ func (h *consumerGroupHandler) handle(session sarama.ConsumerGroupSession, msg *
 
 	metrics, err := h.parser.Parse(msg.Value)
 	if err != nil {
-		session.MarkMessage(msg, "")
+		h.mu.Lock()
+		h.markDone(session, msg)
+		h.mu.Unlock()
 		h.release()
 		return err
 	}
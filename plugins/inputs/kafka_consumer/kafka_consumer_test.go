@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	serializers_influx "github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -139,6 +141,30 @@ func TestInit(t *testing.T) {
 			},
 			initError: true,
 		},
+		{
+			name: "invalid commit mode",
+			plugin: &KafkaConsumer{
+				CommitMode: "random",
+				Log:        testutil.Logger{},
+			},
+			initError: true,
+		},
+		{
+			name: "invalid start position",
+			plugin: &KafkaConsumer{
+				StartPosition: "middle",
+				Log:           testutil.Logger{},
+			},
+			initError: true,
+		},
+		{
+			name: "start position timestamp without timestamp",
+			plugin: &KafkaConsumer{
+				StartPosition: "timestamp",
+				Log:           testutil.Logger{},
+			},
+			initError: true,
+		},
 		{
 			name: "default tls without tls config",
 			plugin: &KafkaConsumer{
@@ -258,11 +284,16 @@ func TestStartStop(t *testing.T) {
 }
 
 type FakeConsumerGroupSession struct {
-	ctx context.Context
+	ctx    context.Context
+	claims map[string][]int32
+
+	marked map[int32]int64
+	reset  map[int32]int64
+	sync.Mutex
 }
 
-func (*FakeConsumerGroupSession) Claims() map[string][]int32 {
-	panic("not implemented")
+func (s *FakeConsumerGroupSession) Claims() map[string][]int32 {
+	return s.claims
 }
 
 func (*FakeConsumerGroupSession) MemberID() string {
@@ -273,12 +304,22 @@ func (*FakeConsumerGroupSession) GenerationID() int32 {
 	panic("not implemented")
 }
 
-func (*FakeConsumerGroupSession) MarkOffset(string, int32, int64, string) {
-	panic("not implemented")
+func (s *FakeConsumerGroupSession) MarkOffset(_ string, partition int32, offset int64, _ string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.marked == nil {
+		s.marked = make(map[int32]int64)
+	}
+	s.marked[partition] = offset
 }
 
-func (*FakeConsumerGroupSession) ResetOffset(string, int32, int64, string) {
-	panic("not implemented")
+func (s *FakeConsumerGroupSession) ResetOffset(_ string, partition int32, offset int64, _ string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.reset == nil {
+		s.reset = make(map[int32]int64)
+	}
+	s.reset[partition] = offset
 }
 
 func (*FakeConsumerGroupSession) MarkMessage(*sarama.ConsumerMessage, string) {
@@ -292,23 +333,24 @@ func (*FakeConsumerGroupSession) Commit() {
 }
 
 type FakeConsumerGroupClaim struct {
-	messages chan *sarama.ConsumerMessage
+	messages      chan *sarama.ConsumerMessage
+	highWaterMark int64
 }
 
 func (*FakeConsumerGroupClaim) Topic() string {
-	panic("not implemented")
+	return "telegraf"
 }
 
 func (*FakeConsumerGroupClaim) Partition() int32 {
-	panic("not implemented")
+	return 0
 }
 
 func (*FakeConsumerGroupClaim) InitialOffset() int64 {
 	panic("not implemented")
 }
 
-func (*FakeConsumerGroupClaim) HighWaterMarkOffset() int64 {
-	panic("not implemented")
+func (c *FakeConsumerGroupClaim) HighWaterMarkOffset() int64 {
+	return c.highWaterMark
 }
 
 func (c *FakeConsumerGroupClaim) Messages() <-chan *sarama.ConsumerMessage {
@@ -498,6 +540,194 @@ func TestConsumerGroupHandlerHandle(t *testing.T) {
 	}
 }
 
+func TestConsumerGroupHandlerOrderedCommit(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	parser := value.Parser{
+		MetricName: "cpu",
+		DataType:   "int",
+	}
+	require.NoError(t, parser.Init())
+
+	var committed []int64
+	cg := newConsumerGroupHandler(acc, 3, &parser, testutil.Logger{})
+	cg.commitMode = "ordered"
+	cg.onCommit = func(_ string, _ int32, offset int64) {
+		committed = append(committed, offset)
+	}
+
+	session := &FakeConsumerGroupSession{ctx: t.Context()}
+	for i := range 3 {
+		require.NoError(t, cg.reserve(t.Context()))
+		msg := &sarama.ConsumerMessage{
+			Topic:  "telegraf",
+			Offset: int64(10 + i),
+			Value:  []byte("42"),
+		}
+		require.NoError(t, cg.handle(session, msg))
+	}
+	require.Len(t, cg.undelivered, 3)
+
+	// Deliver the messages out of order and make sure the offset is only
+	// advanced if all previous messages were delivered
+	ids := make(map[int64]telegraf.TrackingID, len(cg.undelivered))
+	for id, msg := range cg.undelivered {
+		ids[msg.message.Offset] = id
+	}
+	cg.onDelivery(&testDeliveryInfo{id: ids[12], delivered: true})
+	require.Empty(t, session.marked)
+	cg.onDelivery(&testDeliveryInfo{id: ids[11], delivered: true})
+	require.Empty(t, session.marked)
+	cg.onDelivery(&testDeliveryInfo{id: ids[10], delivered: true})
+	require.Equal(t, map[int32]int64{0: 13}, session.marked)
+	require.Equal(t, []int64{13}, committed)
+}
+
+func TestConsumerGroupHandlerOrderedCommitFailedDelivery(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	parser := value.Parser{
+		MetricName: "cpu",
+		DataType:   "int",
+	}
+	require.NoError(t, parser.Init())
+
+	cg := newConsumerGroupHandler(acc, 3, &parser, testutil.Logger{})
+	cg.commitMode = "ordered"
+
+	session := &FakeConsumerGroupSession{ctx: t.Context()}
+	for i := range 3 {
+		require.NoError(t, cg.reserve(t.Context()))
+		msg := &sarama.ConsumerMessage{
+			Topic:  "telegraf",
+			Offset: int64(10 + i),
+			Value:  []byte("42"),
+		}
+		require.NoError(t, cg.handle(session, msg))
+	}
+
+	ids := make(map[int64]telegraf.TrackingID, len(cg.undelivered))
+	for id, msg := range cg.undelivered {
+		ids[msg.message.Offset] = id
+	}
+
+	// A failed delivery must stall the partition and never advance the offset
+	// beyond the failed message, even if later messages are delivered
+	cg.onDelivery(&testDeliveryInfo{id: ids[10], delivered: true})
+	require.Equal(t, map[int32]int64{0: 11}, session.marked)
+	cg.onDelivery(&testDeliveryInfo{id: ids[11], delivered: false})
+	cg.onDelivery(&testDeliveryInfo{id: ids[12], delivered: true})
+	require.Equal(t, map[int32]int64{0: 11}, session.marked)
+	require.Empty(t, cg.undelivered)
+
+	// The claim of the partition must be signaled to stop consuming
+	select {
+	case <-cg.partitions["telegraf"][0].failed:
+	default:
+		require.Fail(t, "partition not stalled")
+	}
+}
+
+func TestConsumerGroupHandlerStartOffset(t *testing.T) {
+	plugin := &KafkaConsumer{
+		StartPosition: "state",
+		CommitMode:    "ordered",
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.SetState(map[string]map[int32]int64{"telegraf": {0: 42, 1: 23}}))
+
+	cg := newConsumerGroupHandler(&testutil.Accumulator{}, 1, nil, testutil.Logger{})
+	cg.commitMode = "ordered"
+	cg.startOffset = plugin.initialOffset
+	cg.onCommit = plugin.recordOffset
+
+	// Only seek the partitions with a stored offset
+	session := &FakeConsumerGroupSession{
+		ctx:    t.Context(),
+		claims: map[string][]int32{"telegraf": {0, 1, 2}},
+	}
+	require.NoError(t, cg.Setup(session))
+	require.NoError(t, cg.Cleanup(session))
+	require.Equal(t, map[int32]int64{0: 42, 1: 23}, session.reset)
+
+	// Committed offsets should be stored in the state
+	msg := &sarama.ConsumerMessage{Topic: "telegraf", Partition: 2, Offset: 7}
+	cg.track(msg)
+	cg.markDone(session, msg)
+	require.Equal(t, map[string]map[int32]int64{"telegraf": {0: 42, 1: 23, 2: 8}}, plugin.GetState())
+
+	// Partitions must not be rewound on rebalance
+	session = &FakeConsumerGroupSession{
+		ctx:    t.Context(),
+		claims: map[string][]int32{"telegraf": {0, 1, 2}},
+	}
+	require.NoError(t, cg.Setup(session))
+	require.NoError(t, cg.Cleanup(session))
+	require.Empty(t, session.reset)
+}
+
+func TestConsumerGroupHandlerLag(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	parser := value.Parser{
+		MetricName: "cpu",
+		DataType:   "int",
+	}
+	require.NoError(t, parser.Init())
+	cg := newConsumerGroupHandler(acc, 1, &parser, testutil.Logger{})
+	cg.consumerGroup = "telegraf_metrics_consumers"
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	session := &FakeConsumerGroupSession{ctx: ctx}
+	claim := &FakeConsumerGroupClaim{
+		messages:      make(chan *sarama.ConsumerMessage, 1),
+		highWaterMark: 100,
+	}
+	require.NoError(t, cg.Setup(session))
+
+	claim.messages <- &sarama.ConsumerMessage{
+		Topic:  "telegraf",
+		Offset: 41,
+		Value:  []byte("42"),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cg.ConsumeClaim(session, claim) //nolint:errcheck // context cancellation error is expected
+	}()
+	acc.Wait(1)
+
+	var lag interface{}
+	for _, m := range selfstat.Metrics() {
+		if m.Name() != "internal_kafka_consumer" {
+			continue
+		}
+		if tag, _ := m.GetTag("topic"); tag != "telegraf" {
+			continue
+		}
+		lag, _ = m.GetField("lag")
+	}
+	require.Equal(t, int64(58), lag)
+
+	cancel()
+	<-done
+	require.NoError(t, cg.Cleanup(session))
+}
+
+type testDeliveryInfo struct {
+	id        telegraf.TrackingID
+	delivered bool
+}
+
+func (d *testDeliveryInfo) ID() telegraf.TrackingID {
+	return d.id
+}
+
+func (d *testDeliveryInfo) Delivered() bool {
+	return d.delivered
+}
+
 func TestExponentialBackoff(t *testing.T) {
 	var err error
 
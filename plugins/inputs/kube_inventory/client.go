@@ -2,17 +2,23 @@ package kube_inventory
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/plugins/common/tls"
@@ -21,7 +27,15 @@ import (
 type client struct {
 	namespace string
 	timeout   time.Duration
-	*kubernetes.Clientset
+	kubernetes.Interface
+
+	// Shared informers serving the resources from a local cache kept
+	// up-to-date by watching the API server, nil if disabled. Pods and
+	// nodes use separate factories restricted to the configured node.
+	informers     informers.SharedInformerFactory
+	podInformers  informers.SharedInformerFactory
+	nodeInformers informers.SharedInformerFactory
+	stop          chan struct{}
 }
 
 func newClient(baseURL, namespace, bearerTokenFile string, timeout time.Duration, tlsConfig tls.ClientConfig) (*client, error) {
@@ -57,7 +71,7 @@ func newClient(baseURL, namespace, bearerTokenFile string, timeout time.Duration
 	}
 
 	return &client{
-		Clientset: c,
+		Interface: c,
 		timeout:   timeout,
 		namespace: namespace,
 	}, nil
@@ -79,31 +93,116 @@ func newHTTPClient(tlsConfig tls.ClientConfig, bearerTokenFile string, responseT
 	return rest.HTTPClientFor(clientConfig)
 }
 
+// startInformers enables serving the resources from the informer caches.
+// Informers are started lazily on the first request for a resource. If a
+// node name is given, only the pods scheduled on and the node itself are
+// watched instead of caching all pods and nodes of the cluster.
+func (c *client) startInformers(nodeName string) {
+	c.stop = make(chan struct{})
+	c.informers = informers.NewSharedInformerFactoryWithOptions(c.Interface, 0, informers.WithNamespace(c.namespace))
+	c.podInformers = c.informers
+	c.nodeInformers = c.informers
+	if nodeName != "" {
+		c.podInformers = informers.NewSharedInformerFactoryWithOptions(c.Interface, 0,
+			informers.WithNamespace(c.namespace),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = "spec.nodeName=" + nodeName
+			}),
+		)
+		c.nodeInformers = informers.NewSharedInformerFactoryWithOptions(c.Interface, 0,
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = "metadata.name=" + nodeName
+			}),
+		)
+	}
+}
+
+func (c *client) stopInformers() {
+	if c.informers == nil {
+		return
+	}
+	close(c.stop)
+	c.informers.Shutdown()
+	c.podInformers.Shutdown()
+	c.nodeInformers.Shutdown()
+	c.informers = nil
+	c.podInformers = nil
+	c.nodeInformers = nil
+}
+
+// fromCache returns the items of the given informer after making sure the
+// informer is running and its cache is populated
+func fromCache[T any](ctx context.Context, c *client, informer cache.SharedIndexInformer) ([]T, error) {
+	c.informers.Start(c.stop)
+	c.podInformers.Start(c.stop)
+	c.nodeInformers.Start(c.stop)
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return nil, errors.New("timeout waiting for informer cache to sync")
+	}
+
+	objs := informer.GetStore().List()
+	items := make([]T, 0, len(objs))
+	for _, obj := range objs {
+		if item, ok := obj.(*T); ok {
+			items = append(items, *item)
+		}
+	}
+	return items, nil
+}
+
 func (c *client) getDaemonSets(ctx context.Context) (*appsv1.DaemonSetList, error) {
+	if c.informers != nil {
+		items, err := fromCache[appsv1.DaemonSet](ctx, c, c.informers.Apps().V1().DaemonSets().Informer())
+		return &appsv1.DaemonSetList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.AppsV1().DaemonSets(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getDeployments(ctx context.Context) (*appsv1.DeploymentList, error) {
+	if c.informers != nil {
+		items, err := fromCache[appsv1.Deployment](ctx, c, c.informers.Apps().V1().Deployments().Informer())
+		return &appsv1.DeploymentList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.AppsV1().Deployments(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getEndpoints(ctx context.Context) (*discoveryv1.EndpointSliceList, error) {
+	if c.informers != nil {
+		items, err := fromCache[discoveryv1.EndpointSlice](ctx, c, c.informers.Discovery().V1().EndpointSlices().Informer())
+		return &discoveryv1.EndpointSliceList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.DiscoveryV1().EndpointSlices(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getIngress(ctx context.Context) (*netv1.IngressList, error) {
+	if c.informers != nil {
+		items, err := fromCache[netv1.Ingress](ctx, c, c.informers.Networking().V1().Ingresses().Informer())
+		return &netv1.IngressList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.NetworkingV1().Ingresses(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getNodes(ctx context.Context, name string) (*corev1.NodeList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.Node](ctx, c, c.nodeInformers.Core().V1().Nodes().Informer())
+		return &corev1.NodeList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	var fieldSelector string
@@ -114,18 +213,33 @@ func (c *client) getNodes(ctx context.Context, name string) (*corev1.NodeList, e
 }
 
 func (c *client) getPersistentVolumes(ctx context.Context) (*corev1.PersistentVolumeList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.PersistentVolume](ctx, c, c.informers.Core().V1().PersistentVolumes().Informer())
+		return &corev1.PersistentVolumeList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getPersistentVolumeClaims(ctx context.Context) (*corev1.PersistentVolumeClaimList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.PersistentVolumeClaim](ctx, c, c.informers.Core().V1().PersistentVolumeClaims().Informer())
+		return &corev1.PersistentVolumeClaimList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.CoreV1().PersistentVolumeClaims(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getPods(ctx context.Context, nodeName string) (*corev1.PodList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.Pod](ctx, c, c.podInformers.Core().V1().Pods().Informer())
+		return &corev1.PodList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
@@ -137,23 +251,40 @@ func (c *client) getPods(ctx context.Context, nodeName string) (*corev1.PodList,
 }
 
 func (c *client) getServices(ctx context.Context) (*corev1.ServiceList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.Service](ctx, c, c.informers.Core().V1().Services().Informer())
+		return &corev1.ServiceList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.CoreV1().Services(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getStatefulSets(ctx context.Context) (*appsv1.StatefulSetList, error) {
+	if c.informers != nil {
+		items, err := fromCache[appsv1.StatefulSet](ctx, c, c.informers.Apps().V1().StatefulSets().Informer())
+		return &appsv1.StatefulSetList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.AppsV1().StatefulSets(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
 func (c *client) getResourceQuotas(ctx context.Context) (*corev1.ResourceQuotaList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.ResourceQuota](ctx, c, c.informers.Core().V1().ResourceQuotas().Informer())
+		return &corev1.ResourceQuotaList{Items: items}, err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.CoreV1().ResourceQuotas(c.namespace).List(ctx, metav1.ListOptions{})
 }
 
+// getTLSSecrets always queries the API server to avoid keeping the content of
+// all secrets in memory
 func (c *client) getTLSSecrets(ctx context.Context) (*corev1.SecretList, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
@@ -162,3 +293,84 @@ func (c *client) getTLSSecrets(ctx context.Context) (*corev1.SecretList, error)
 		FieldSelector: labels.Set(labelSelector.MatchLabels).String(),
 	})
 }
+
+func (c *client) getJobs(ctx context.Context) (*batchv1.JobList, error) {
+	if c.informers != nil {
+		items, err := fromCache[batchv1.Job](ctx, c, c.informers.Batch().V1().Jobs().Informer())
+		return &batchv1.JobList{Items: items}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.BatchV1().Jobs(c.namespace).List(ctx, metav1.ListOptions{})
+}
+
+func (c *client) getCronJobs(ctx context.Context) (*batchv1.CronJobList, error) {
+	if c.informers != nil {
+		items, err := fromCache[batchv1.CronJob](ctx, c, c.informers.Batch().V1().CronJobs().Informer())
+		return &batchv1.CronJobList{Items: items}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.BatchV1().CronJobs(c.namespace).List(ctx, metav1.ListOptions{})
+}
+
+func (c *client) getHorizontalPodAutoscalers(ctx context.Context) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+	if c.informers != nil {
+		items, err := fromCache[autoscalingv2.HorizontalPodAutoscaler](ctx, c, c.informers.Autoscaling().V2().HorizontalPodAutoscalers().Informer())
+		return &autoscalingv2.HorizontalPodAutoscalerList{Items: items}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).List(ctx, metav1.ListOptions{})
+}
+
+func (c *client) getReplicaSets(ctx context.Context) (*appsv1.ReplicaSetList, error) {
+	if c.informers != nil {
+		items, err := fromCache[appsv1.ReplicaSet](ctx, c, c.informers.Apps().V1().ReplicaSets().Informer())
+		return &appsv1.ReplicaSetList{Items: items}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.AppsV1().ReplicaSets(c.namespace).List(ctx, metav1.ListOptions{})
+}
+
+func (c *client) getNamespaces(ctx context.Context) (*corev1.NamespaceList, error) {
+	var list *corev1.NamespaceList
+	if c.informers != nil {
+		items, err := fromCache[corev1.Namespace](ctx, c, c.informers.Core().V1().Namespaces().Informer())
+		if err != nil {
+			return nil, err
+		}
+		list = &corev1.NamespaceList{Items: items}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+		var err error
+		if list, err = c.CoreV1().Namespaces().List(ctx, metav1.ListOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Namespaces are cluster-scoped so restrict them to the configured one
+	if c.namespace != "" {
+		list.Items = slices.DeleteFunc(list.Items, func(n corev1.Namespace) bool {
+			return n.Name != c.namespace
+		})
+	}
+	return list, nil
+}
+
+func (c *client) getEvents(ctx context.Context) (*corev1.EventList, error) {
+	if c.informers != nil {
+		items, err := fromCache[corev1.Event](ctx, c, c.informers.Core().V1().Events().Informer())
+		return &corev1.EventList{Items: items}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.CoreV1().Events(c.namespace).List(ctx, metav1.ListOptions{})
+}
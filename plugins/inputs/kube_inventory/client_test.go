@@ -1,12 +1,23 @@
 package kube_inventory
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/testutil"
 )
 
 type mockHandler struct {
@@ -20,3 +31,184 @@ func TestNewClient(t *testing.T) {
 	_, err = newClient("https://127.0.0.1:443/", "default", "nonexistantFile", time.Second, tls.ClientConfig{})
 	require.Errorf(t, err, "Failed to read token file \"file\": open file: no such file or directory: %v", err)
 }
+
+func TestFakeClientset(t *testing.T) {
+	now := time.Now()
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{}},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "ns2", CreationTimestamp: metav1.Time{Time: now}},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+			Status:     batchv1.JobStatus{Succeeded: 1},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns2", CreationTimestamp: metav1.Time{Time: now}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"kubernetes_deployment",
+			map[string]string{"deployment_name": "web", "namespace": "ns1"},
+			map[string]interface{}{
+				"replicas_available":   int32(2),
+				"replicas_unavailable": int32(0),
+				"created":              now.UnixNano(),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"kubernetes_job",
+			map[string]string{"job_name": "migrate", "namespace": "ns1"},
+			map[string]interface{}{
+				"active":    int32(0),
+				"succeeded": int32(1),
+				"failed":    int32(0),
+				"created":   now.UnixNano(),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"kubernetes_namespace",
+			map[string]string{"namespace": "ns1", "phase": "Active"},
+			map[string]interface{}{"phase_type": 0, "created": now.UnixNano()},
+			time.Unix(0, 0),
+		),
+	}
+
+	for _, useInformers := range []bool{false, true} {
+		t.Run(fmt.Sprintf("informers %v", useInformers), func(t *testing.T) {
+			ki := &KubernetesInventory{
+				ResourceInclude: []string{"deployments", "jobs", "namespaces"},
+				Log:             testutil.Logger{},
+				client: &client{
+					namespace: "ns1",
+					timeout:   5 * time.Second,
+					Interface: fake.NewClientset(objects...),
+				},
+			}
+			if useInformers {
+				ki.client.startInformers("")
+			}
+			defer ki.Stop()
+
+			var acc testutil.Accumulator
+			require.NoError(t, ki.Gather(&acc))
+			require.NoError(t, acc.FirstError())
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+		})
+	}
+}
+
+func TestOptInCollectors(t *testing.T) {
+	now := time.Now()
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{}},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+		},
+		&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+		},
+	}
+
+	// Without resource_include only the default resources are collected
+	ki := &KubernetesInventory{
+		Log: testutil.Logger{},
+		client: &client{
+			namespace: "ns1",
+			timeout:   5 * time.Second,
+			Interface: fake.NewClientset(objects...),
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, ki.Gather(&acc))
+	require.NoError(t, acc.FirstError())
+	require.True(t, acc.HasMeasurement("kubernetes_deployment"))
+	require.False(t, acc.HasMeasurement("kubernetes_job"))
+	require.False(t, acc.HasMeasurement("kubernetes_namespace"))
+
+	// Globs explicitly include the optional resources
+	ki.ResourceInclude = []string{"*"}
+	acc.ClearMetrics()
+	require.NoError(t, ki.Gather(&acc))
+	require.NoError(t, acc.FirstError())
+	require.True(t, acc.HasMeasurement("kubernetes_deployment"))
+	require.True(t, acc.HasMeasurement("kubernetes_job"))
+	require.True(t, acc.HasMeasurement("kubernetes_namespace"))
+}
+
+func TestInformerUpdates(t *testing.T) {
+	clientset := fake.NewClientset()
+	ki := &KubernetesInventory{
+		ResourceInclude: []string{"replicasets"},
+		Log:             testutil.Logger{},
+		client: &client{
+			namespace: "ns1",
+			timeout:   5 * time.Second,
+			Interface: clientset,
+		},
+	}
+	ki.client.startInformers("")
+	defer ki.Stop()
+
+	var acc testutil.Accumulator
+	require.NoError(t, ki.Gather(&acc))
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// New resources should be picked up by watching the API server
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "ns1"}}
+	_, err := clientset.AppsV1().ReplicaSets("ns1").Create(t.Context(), rs, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		acc.ClearMetrics()
+		require.NoError(t, ki.Gather(&acc))
+		return acc.HasMeasurement("kubernetes_replicaset")
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestInformersRestrictedToNode(t *testing.T) {
+	clientset := fake.NewClientset()
+	c := &client{
+		namespace: "ns1",
+		timeout:   5 * time.Second,
+		Interface: clientset,
+	}
+	c.startInformers("node1")
+	defer c.stopInformers()
+
+	_, err := c.getPods(t.Context(), "node1")
+	require.NoError(t, err)
+	_, err = c.getNodes(t.Context(), "node1")
+	require.NoError(t, err)
+
+	// Pods and nodes must be listed and watched with a field selector for
+	// the node instead of caching the whole cluster
+	selectors := make(map[string]string)
+	for _, action := range clientset.Actions() {
+		if a, ok := action.(k8stesting.ListAction); ok {
+			selectors[a.GetResource().Resource] = a.GetListRestrictions().Fields.String()
+		}
+	}
+	require.Equal(t, map[string]string{
+		"pods":  "spec.nodeName=node1",
+		"nodes": "metadata.name=node1",
+	}, selectors)
+}
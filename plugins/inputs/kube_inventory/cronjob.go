@@ -0,0 +1,45 @@
+package kube_inventory
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectCronJobs(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getCronJobs(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for i := range list.Items {
+		gatherCronJob(&list.Items[i], acc)
+	}
+}
+
+func gatherCronJob(c *batchv1.CronJob, acc telegraf.Accumulator) {
+	suspended := 0
+	if c.Spec.Suspend != nil && *c.Spec.Suspend {
+		suspended = 1
+	}
+	fields := map[string]interface{}{
+		"active":    len(c.Status.Active),
+		"suspended": suspended,
+		"created":   c.GetCreationTimestamp().UnixNano(),
+	}
+	if c.Status.LastScheduleTime != nil {
+		fields["last_schedule_time"] = c.Status.LastScheduleTime.UnixNano()
+	}
+	if c.Status.LastSuccessfulTime != nil {
+		fields["last_successful_time"] = c.Status.LastSuccessfulTime.UnixNano()
+	}
+	tags := map[string]string{
+		"cronjob_name": c.Name,
+		"namespace":    c.Namespace,
+		"schedule":     c.Spec.Schedule,
+	}
+
+	acc.AddFields(cronJobMeasurement, fields, tags)
+}
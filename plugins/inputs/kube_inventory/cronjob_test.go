@@ -0,0 +1,61 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestCronJob(t *testing.T) {
+	now := time.Now()
+	scheduled := now.Add(-time.Hour)
+
+	cronjob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "backup",
+			Namespace:         "ns1",
+			CreationTimestamp: metav1.Time{Time: now},
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 * * * *",
+			Suspend:  new(true),
+		},
+		Status: batchv1.CronJobStatus{
+			Active:             []corev1.ObjectReference{{Name: "backup-1"}},
+			LastScheduleTime:   &metav1.Time{Time: scheduled},
+			LastSuccessfulTime: &metav1.Time{Time: scheduled},
+		},
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"kubernetes_cronjob",
+			map[string]string{
+				"cronjob_name": "backup",
+				"namespace":    "ns1",
+				"schedule":     "0 * * * *",
+			},
+			map[string]interface{}{
+				"active":               1,
+				"suspended":            1,
+				"last_schedule_time":   scheduled.UnixNano(),
+				"last_successful_time": scheduled.UnixNano(),
+				"created":              now.UnixNano(),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	acc := new(testutil.Accumulator)
+	gatherCronJob(cronjob, acc)
+	require.NoError(t, acc.FirstError())
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
@@ -0,0 +1,83 @@
+package kube_inventory
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectEvents(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getEvents(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	ki.gatherEvents(list.Items, acc)
+}
+
+// gatherEvents emits each new occurrence of an event exactly once. Events are
+// identified by their UID and updated in-place by Kubernetes when reoccurring,
+// so changes in the resource version indicate a new occurrence.
+func (ki *KubernetesInventory) gatherEvents(events []corev1.Event, acc telegraf.Accumulator) {
+	seen := make(map[types.UID]string, len(events))
+	for i := range events {
+		e := &events[i]
+		seen[e.UID] = e.ResourceVersion
+		if version, found := ki.events[e.UID]; found && version == e.ResourceVersion {
+			continue
+		}
+
+		// Skip events having occurred before Telegraf started
+		ts := eventTime(e)
+		if ts.Before(ki.eventsSince) {
+			continue
+		}
+		gatherEvent(e, ts, acc)
+	}
+	ki.events = seen
+}
+
+func gatherEvent(e *corev1.Event, ts time.Time, acc telegraf.Accumulator) {
+	count := e.Count
+	if e.Series != nil {
+		count = e.Series.Count
+	}
+	fields := map[string]interface{}{
+		"message": e.Message,
+		"count":   count,
+	}
+	tags := map[string]string{
+		"namespace":   e.Namespace,
+		"kind":        e.InvolvedObject.Kind,
+		"object_name": e.InvolvedObject.Name,
+		"reason":      e.Reason,
+		"type":        e.Type,
+	}
+	if e.Source.Component != "" {
+		tags["source_component"] = e.Source.Component
+	} else if e.ReportingController != "" {
+		tags["source_component"] = e.ReportingController
+	}
+	if e.Source.Host != "" {
+		tags["source_host"] = e.Source.Host
+	}
+
+	acc.AddFields(eventMeasurement, fields, tags, ts)
+}
+
+// eventTime returns the time of the latest occurrence of the event
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case e.Series != nil && !e.Series.LastObservedTime.IsZero():
+		return e.Series.LastObservedTime.Time
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
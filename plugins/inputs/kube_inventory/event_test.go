@@ -0,0 +1,109 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestEvents(t *testing.T) {
+	started := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	first := started.Add(time.Minute)
+	second := started.Add(2 * time.Minute)
+
+	old := corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "old", Namespace: "ns1", UID: "uid-0", ResourceVersion: "1"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Reason:         "Scheduled",
+		Type:           corev1.EventTypeNormal,
+		Count:          1,
+		LastTimestamp:  metav1.Time{Time: started.Add(-time.Minute)},
+	}
+	backoff := corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "backoff", Namespace: "ns1", UID: "uid-1", ResourceVersion: "10"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-1"},
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "kubelet", Host: "node1"},
+		Count:          1,
+		LastTimestamp:  metav1.Time{Time: first},
+	}
+
+	ki := &KubernetesInventory{eventsSince: started}
+	acc := new(testutil.Accumulator)
+
+	// Only events occurring after the start should be reported
+	ki.gatherEvents([]corev1.Event{old, backoff}, acc)
+	expected := []telegraf.Metric{
+		metric.New(
+			"kubernetes_event",
+			map[string]string{
+				"namespace":        "ns1",
+				"kind":             "Pod",
+				"object_name":      "web-1",
+				"reason":           "BackOff",
+				"type":             "Warning",
+				"source_component": "kubelet",
+				"source_host":      "node1",
+			},
+			map[string]interface{}{
+				"message": "Back-off restarting failed container",
+				"count":   int32(1),
+			},
+			first,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Unchanged events must not be reported again
+	acc.ClearMetrics()
+	ki.gatherEvents([]corev1.Event{old, backoff}, acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+
+	// Reoccurring events are updated in-place
+	backoff.ResourceVersion = "11"
+	backoff.Count = 2
+	backoff.LastTimestamp = metav1.Time{Time: second}
+	ki.gatherEvents([]corev1.Event{old, backoff}, acc)
+	expected = []telegraf.Metric{
+		metric.New(
+			"kubernetes_event",
+			map[string]string{
+				"namespace":        "ns1",
+				"kind":             "Pod",
+				"object_name":      "web-1",
+				"reason":           "BackOff",
+				"type":             "Warning",
+				"source_component": "kubelet",
+				"source_host":      "node1",
+			},
+			map[string]interface{}{
+				"message": "Back-off restarting failed container",
+				"count":   int32(2),
+			},
+			second,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestEventTime(t *testing.T) {
+	ts := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	e := &corev1.Event{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: ts}}}
+	require.Equal(t, ts, eventTime(e))
+
+	e.EventTime = metav1.MicroTime{Time: ts.Add(time.Second)}
+	require.Equal(t, ts.Add(time.Second), eventTime(e))
+
+	e.Series = &corev1.EventSeries{Count: 5, LastObservedTime: metav1.MicroTime{Time: ts.Add(time.Minute)}}
+	require.Equal(t, ts.Add(time.Minute), eventTime(e))
+}
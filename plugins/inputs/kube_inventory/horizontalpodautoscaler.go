@@ -0,0 +1,46 @@
+package kube_inventory
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectHorizontalPodAutoscalers(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getHorizontalPodAutoscalers(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for i := range list.Items {
+		gatherHorizontalPodAutoscaler(&list.Items[i], acc)
+	}
+}
+
+func gatherHorizontalPodAutoscaler(h *autoscalingv2.HorizontalPodAutoscaler, acc telegraf.Accumulator) {
+	// The minimum number of replicas defaults to one if unset
+	minReplicas := int32(1)
+	if h.Spec.MinReplicas != nil {
+		minReplicas = *h.Spec.MinReplicas
+	}
+	fields := map[string]interface{}{
+		"min_replicas":     minReplicas,
+		"max_replicas":     h.Spec.MaxReplicas,
+		"current_replicas": h.Status.CurrentReplicas,
+		"desired_replicas": h.Status.DesiredReplicas,
+		"created":          h.GetCreationTimestamp().UnixNano(),
+	}
+	if h.Status.LastScaleTime != nil {
+		fields["last_scale_time"] = h.Status.LastScaleTime.UnixNano()
+	}
+	tags := map[string]string{
+		"hpa_name":    h.Name,
+		"namespace":   h.Namespace,
+		"target_kind": h.Spec.ScaleTargetRef.Kind,
+		"target_name": h.Spec.ScaleTargetRef.Name,
+	}
+
+	acc.AddFields(horizontalPodAutoscalerMeasurement, fields, tags)
+}
@@ -0,0 +1,111 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestHorizontalPodAutoscaler(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		hpa    *autoscalingv2.HorizontalPodAutoscaler
+		output []telegraf.Metric
+	}{
+		{
+			name: "default min replicas",
+			hpa: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "web",
+					Namespace:         "ns1",
+					CreationTimestamp: metav1.Time{Time: now},
+				},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web"},
+					MaxReplicas:    10,
+				},
+				Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+					CurrentReplicas: 2,
+					DesiredReplicas: 3,
+					LastScaleTime:   &metav1.Time{Time: now},
+				},
+			},
+			output: []telegraf.Metric{
+				metric.New(
+					"kubernetes_hpa",
+					map[string]string{
+						"hpa_name":    "web",
+						"namespace":   "ns1",
+						"target_kind": "Deployment",
+						"target_name": "web",
+					},
+					map[string]interface{}{
+						"min_replicas":     int32(1),
+						"max_replicas":     int32(10),
+						"current_replicas": int32(2),
+						"desired_replicas": int32(3),
+						"last_scale_time":  now.UnixNano(),
+						"created":          now.UnixNano(),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "explicit min replicas",
+			hpa: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "db",
+					Namespace:         "ns1",
+					CreationTimestamp: metav1.Time{Time: now},
+				},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "StatefulSet", Name: "db"},
+					MinReplicas:    new(int32(3)),
+					MaxReplicas:    5,
+				},
+				Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+					CurrentReplicas: 3,
+					DesiredReplicas: 3,
+				},
+			},
+			output: []telegraf.Metric{
+				metric.New(
+					"kubernetes_hpa",
+					map[string]string{
+						"hpa_name":    "db",
+						"namespace":   "ns1",
+						"target_kind": "StatefulSet",
+						"target_name": "db",
+					},
+					map[string]interface{}{
+						"min_replicas":     int32(3),
+						"max_replicas":     int32(5),
+						"current_replicas": int32(3),
+						"desired_replicas": int32(3),
+						"created":          now.UnixNano(),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := new(testutil.Accumulator)
+			gatherHorizontalPodAutoscaler(tt.hpa, acc)
+			require.NoError(t, acc.FirstError())
+			testutil.RequireMetricsEqual(t, tt.output, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package kube_inventory
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectJobs(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getJobs(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for i := range list.Items {
+		ki.gatherJob(&list.Items[i], acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherJob(j *batchv1.Job, acc telegraf.Accumulator) {
+	fields := map[string]interface{}{
+		"active":    j.Status.Active,
+		"succeeded": j.Status.Succeeded,
+		"failed":    j.Status.Failed,
+		"created":   j.GetCreationTimestamp().UnixNano(),
+	}
+	if j.Spec.Completions != nil {
+		fields["spec_completions"] = *j.Spec.Completions
+	}
+	if j.Spec.Parallelism != nil {
+		fields["spec_parallelism"] = *j.Spec.Parallelism
+	}
+	if j.Status.StartTime != nil {
+		fields["start_time"] = j.Status.StartTime.UnixNano()
+	}
+	if j.Status.CompletionTime != nil {
+		fields["completion_time"] = j.Status.CompletionTime.UnixNano()
+	}
+	tags := map[string]string{
+		"job_name":  j.Name,
+		"namespace": j.Namespace,
+	}
+	for _, owner := range j.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			tags["cronjob_name"] = owner.Name
+		}
+	}
+	if j.Spec.Selector != nil {
+		for key, val := range j.Spec.Selector.MatchLabels {
+			if ki.selectorFilter.Match(key) {
+				tags["selector_"+key] = val
+			}
+		}
+	}
+
+	acc.AddFields(jobMeasurement, fields, tags)
+}
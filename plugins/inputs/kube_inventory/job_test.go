@@ -0,0 +1,117 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestJob(t *testing.T) {
+	now := time.Now()
+	started := now.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		job    *batchv1.Job
+		output []telegraf.Metric
+	}{
+		{
+			name: "running job",
+			job: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "job1",
+					Namespace:         "ns1",
+					CreationTimestamp: metav1.Time{Time: now},
+				},
+				Spec: batchv1.JobSpec{
+					Completions: new(int32(3)),
+					Parallelism: new(int32(2)),
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"select1": "s1"},
+					},
+				},
+				Status: batchv1.JobStatus{
+					Active:    2,
+					Succeeded: 1,
+					StartTime: &metav1.Time{Time: started},
+				},
+			},
+			output: []telegraf.Metric{
+				metric.New(
+					"kubernetes_job",
+					map[string]string{
+						"job_name":         "job1",
+						"namespace":        "ns1",
+						"selector_select1": "s1",
+					},
+					map[string]interface{}{
+						"active":           int32(2),
+						"succeeded":        int32(1),
+						"failed":           int32(0),
+						"spec_completions": int32(3),
+						"spec_parallelism": int32(2),
+						"start_time":       started.UnixNano(),
+						"created":          now.UnixNano(),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "completed job of cronjob",
+			job: &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "backup-28000000",
+					Namespace:         "ns1",
+					CreationTimestamp: metav1.Time{Time: now},
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "CronJob", Name: "backup"},
+					},
+				},
+				Status: batchv1.JobStatus{
+					Succeeded:      1,
+					StartTime:      &metav1.Time{Time: started},
+					CompletionTime: &metav1.Time{Time: now},
+				},
+			},
+			output: []telegraf.Metric{
+				metric.New(
+					"kubernetes_job",
+					map[string]string{
+						"job_name":     "backup-28000000",
+						"cronjob_name": "backup",
+						"namespace":    "ns1",
+					},
+					map[string]interface{}{
+						"active":          int32(0),
+						"succeeded":       int32(1),
+						"failed":          int32(0),
+						"start_time":      started.UnixNano(),
+						"completion_time": now.UnixNano(),
+						"created":         now.UnixNano(),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ki := &KubernetesInventory{}
+			require.NoError(t, ki.createSelectorFilters())
+
+			acc := new(testutil.Accumulator)
+			ki.gatherJob(tt.job, acc)
+			require.NoError(t, acc.FirstError())
+			testutil.RequireMetricsEqual(t, tt.output, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
@@ -24,33 +26,49 @@ import (
 var sampleConfig string
 
 var availableCollectors = map[string]func(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory){
-	"daemonsets":             collectDaemonSets,
-	"deployments":            collectDeployments,
-	"endpoints":              collectEndpoints,
-	"ingress":                collectIngress,
-	"nodes":                  collectNodes,
-	"pods":                   collectPods,
-	"services":               collectServices,
-	"statefulsets":           collectStatefulSets,
-	"persistentvolumes":      collectPersistentVolumes,
-	"persistentvolumeclaims": collectPersistentVolumeClaims,
-	"resourcequotas":         collectResourceQuotas,
-	"secrets":                collectSecrets,
+	"daemonsets":               collectDaemonSets,
+	"deployments":              collectDeployments,
+	"endpoints":                collectEndpoints,
+	"ingress":                  collectIngress,
+	"nodes":                    collectNodes,
+	"pods":                     collectPods,
+	"services":                 collectServices,
+	"statefulsets":             collectStatefulSets,
+	"persistentvolumes":        collectPersistentVolumes,
+	"persistentvolumeclaims":   collectPersistentVolumeClaims,
+	"resourcequotas":           collectResourceQuotas,
+	"secrets":                  collectSecrets,
+	"jobs":                     collectJobs,
+	"cronjobs":                 collectCronJobs,
+	"horizontalpodautoscalers": collectHorizontalPodAutoscalers,
+	"replicasets":              collectReplicaSets,
+	"namespaces":               collectNamespaces,
+	"events":                   collectEvents,
 }
 
+// Collectors only enabled if explicitly listed in resource_include to not
+// change the collected data and required permissions of existing setups
+var optInCollectors = []string{"cronjobs", "events", "horizontalpodautoscalers", "jobs", "namespaces", "replicasets"}
+
 const (
-	daemonSetMeasurement             = "kubernetes_daemonset"
-	deploymentMeasurement            = "kubernetes_deployment"
-	endpointMeasurement              = "kubernetes_endpoint"
-	ingressMeasurement               = "kubernetes_ingress"
-	nodeMeasurement                  = "kubernetes_node"
-	persistentVolumeMeasurement      = "kubernetes_persistentvolume"
-	persistentVolumeClaimMeasurement = "kubernetes_persistentvolumeclaim"
-	podContainerMeasurement          = "kubernetes_pod_container"
-	serviceMeasurement               = "kubernetes_service"
-	statefulSetMeasurement           = "kubernetes_statefulset"
-	resourcequotaMeasurement         = "kubernetes_resourcequota"
-	certificateMeasurement           = "kubernetes_certificate"
+	daemonSetMeasurement               = "kubernetes_daemonset"
+	deploymentMeasurement              = "kubernetes_deployment"
+	endpointMeasurement                = "kubernetes_endpoint"
+	ingressMeasurement                 = "kubernetes_ingress"
+	nodeMeasurement                    = "kubernetes_node"
+	persistentVolumeMeasurement        = "kubernetes_persistentvolume"
+	persistentVolumeClaimMeasurement   = "kubernetes_persistentvolumeclaim"
+	podContainerMeasurement            = "kubernetes_pod_container"
+	serviceMeasurement                 = "kubernetes_service"
+	statefulSetMeasurement             = "kubernetes_statefulset"
+	resourcequotaMeasurement           = "kubernetes_resourcequota"
+	certificateMeasurement             = "kubernetes_certificate"
+	jobMeasurement                     = "kubernetes_job"
+	cronJobMeasurement                 = "kubernetes_cronjob"
+	horizontalPodAutoscalerMeasurement = "kubernetes_hpa"
+	replicaSetMeasurement              = "kubernetes_replicaset"
+	namespaceMeasurement               = "kubernetes_namespace"
+	eventMeasurement                   = "kubernetes_event"
 
 	defaultServiceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 )
@@ -64,6 +82,7 @@ type KubernetesInventory struct {
 	ResourceExclude []string        `toml:"resource_exclude"`
 	ResourceInclude []string        `toml:"resource_include"`
 	MaxConfigMapAge config.Duration `toml:"max_config_map_age"`
+	UseInformers    bool            `toml:"use_informers"`
 
 	SelectorInclude []string `toml:"selector_include"`
 	SelectorExclude []string `toml:"selector_exclude"`
@@ -76,6 +95,10 @@ type KubernetesInventory struct {
 	httpClient *http.Client
 
 	selectorFilter filter.Filter
+
+	// Resource versions of the events seen in the last gather cycle
+	events      map[types.UID]string
+	eventsSince time.Time
 }
 
 func (*KubernetesInventory) SampleConfig() string {
@@ -97,6 +120,10 @@ func (ki *KubernetesInventory) Init() error {
 	if ki.ResponseTimeout < config.Duration(time.Second) {
 		ki.ResponseTimeout = config.Duration(time.Second * 5)
 	}
+	if ki.UseInformers {
+		ki.client.startInformers(ki.NodeName)
+	}
+	ki.eventsSince = time.Now()
 	// Only create an http client if we have a kubelet url
 	if ki.KubeletURL != "" {
 		ki.httpClient, err = newHTTPClient(ki.ClientConfig, ki.BearerToken, ki.ResponseTimeout)
@@ -108,6 +135,18 @@ func (ki *KubernetesInventory) Init() error {
 	return nil
 }
 
+// Start is a noop which is required for a *KubernetesInventory to implement
+// the telegraf.ServiceInput interface
+func (*KubernetesInventory) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (ki *KubernetesInventory) Stop() {
+	if ki.client != nil {
+		ki.client.stopInformers()
+	}
+}
+
 func (ki *KubernetesInventory) Gather(acc telegraf.Accumulator) (err error) {
 	resourceFilter, err := filter.NewIncludeExcludeFilter(ki.ResourceInclude, ki.ResourceExclude)
 	if err != nil {
@@ -123,6 +162,9 @@ func (ki *KubernetesInventory) Gather(acc telegraf.Accumulator) (err error) {
 	ctx := context.Background()
 
 	for collector, f := range availableCollectors {
+		if len(ki.ResourceInclude) == 0 && slices.Contains(optInCollectors, collector) {
+			continue
+		}
 		if resourceFilter.Match(collector) {
 			wg.Add(1)
 			go func(f func(ctx context.Context, acc telegraf.Accumulator, k *KubernetesInventory)) {
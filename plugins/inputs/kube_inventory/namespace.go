@@ -0,0 +1,40 @@
+package kube_inventory
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectNamespaces(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getNamespaces(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for i := range list.Items {
+		gatherNamespace(&list.Items[i], acc)
+	}
+}
+
+func gatherNamespace(n *corev1.Namespace, acc telegraf.Accumulator) {
+	phaseType := 2
+	switch n.Status.Phase {
+	case corev1.NamespaceActive:
+		phaseType = 0
+	case corev1.NamespaceTerminating:
+		phaseType = 1
+	}
+	fields := map[string]interface{}{
+		"phase_type": phaseType,
+		"created":    n.GetCreationTimestamp().UnixNano(),
+	}
+	tags := map[string]string{
+		"namespace": n.Name,
+		"phase":     string(n.Status.Phase),
+	}
+
+	acc.AddFields(namespaceMeasurement, fields, tags)
+}
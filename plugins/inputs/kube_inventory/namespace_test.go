@@ -0,0 +1,51 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestNamespace(t *testing.T) {
+	now := time.Now()
+
+	namespaces := []corev1.Namespace{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns1", CreationTimestamp: metav1.Time{Time: now}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ns2", CreationTimestamp: metav1.Time{Time: now}},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+		},
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"kubernetes_namespace",
+			map[string]string{"namespace": "ns1", "phase": "Active"},
+			map[string]interface{}{"phase_type": 0, "created": now.UnixNano()},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"kubernetes_namespace",
+			map[string]string{"namespace": "ns2", "phase": "Terminating"},
+			map[string]interface{}{"phase_type": 1, "created": now.UnixNano()},
+			time.Unix(0, 0),
+		),
+	}
+
+	acc := new(testutil.Accumulator)
+	for i := range namespaces {
+		gatherNamespace(&namespaces[i], acc)
+	}
+	require.NoError(t, acc.FirstError())
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
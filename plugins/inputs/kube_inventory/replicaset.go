@@ -0,0 +1,53 @@
+package kube_inventory
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/influxdata/telegraf"
+)
+
+func collectReplicaSets(ctx context.Context, acc telegraf.Accumulator, ki *KubernetesInventory) {
+	list, err := ki.client.getReplicaSets(ctx)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	for i := range list.Items {
+		ki.gatherReplicaSet(&list.Items[i], acc)
+	}
+}
+
+func (ki *KubernetesInventory) gatherReplicaSet(r *appsv1.ReplicaSet, acc telegraf.Accumulator) {
+	fields := map[string]interface{}{
+		"created":                r.GetCreationTimestamp().UnixNano(),
+		"generation":             r.Generation,
+		"replicas":               r.Status.Replicas,
+		"replicas_ready":         r.Status.ReadyReplicas,
+		"replicas_available":     r.Status.AvailableReplicas,
+		"replicas_fully_labeled": r.Status.FullyLabeledReplicas,
+		"observed_generation":    r.Status.ObservedGeneration,
+	}
+	if r.Spec.Replicas != nil {
+		fields["spec_replicas"] = *r.Spec.Replicas
+	}
+	tags := map[string]string{
+		"replicaset_name": r.Name,
+		"namespace":       r.Namespace,
+	}
+	for _, owner := range r.OwnerReferences {
+		if owner.Kind == "Deployment" {
+			tags["deployment_name"] = owner.Name
+		}
+	}
+	if r.Spec.Selector != nil {
+		for key, val := range r.Spec.Selector.MatchLabels {
+			if ki.selectorFilter.Match(key) {
+				tags["selector_"+key] = val
+			}
+		}
+	}
+
+	acc.AddFields(replicaSetMeasurement, fields, tags)
+}
@@ -0,0 +1,73 @@
+package kube_inventory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestReplicaSet(t *testing.T) {
+	now := time.Now()
+
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "web-5997f76f69",
+			Namespace:         "ns1",
+			Generation:        2,
+			CreationTimestamp: metav1.Time{Time: now},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "Deployment", Name: "web"},
+			},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: new(int32(3)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"select1": "s1"},
+			},
+		},
+		Status: appsv1.ReplicaSetStatus{
+			Replicas:             3,
+			ReadyReplicas:        2,
+			AvailableReplicas:    2,
+			FullyLabeledReplicas: 3,
+			ObservedGeneration:   2,
+		},
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"kubernetes_replicaset",
+			map[string]string{
+				"replicaset_name":  "web-5997f76f69",
+				"deployment_name":  "web",
+				"namespace":        "ns1",
+				"selector_select1": "s1",
+			},
+			map[string]interface{}{
+				"created":                now.UnixNano(),
+				"generation":             int64(2),
+				"replicas":               int32(3),
+				"replicas_ready":         int32(2),
+				"replicas_available":     int32(2),
+				"replicas_fully_labeled": int32(3),
+				"spec_replicas":          int32(3),
+				"observed_generation":    int64(2),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	ki := &KubernetesInventory{}
+	require.NoError(t, ki.createSelectorFilters())
+	acc := new(testutil.Accumulator)
+	ki.gatherReplicaSet(rs, acc)
+	require.NoError(t, acc.FirstError())
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
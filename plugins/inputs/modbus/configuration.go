@@ -48,17 +48,3 @@ func normalizeOutputDatatype(dataType string) (string, error) {
 	}
 	return "unknown", fmt.Errorf("unknown output type %q", dataType)
 }
-
-func normalizeByteOrder(byteOrder string) (string, error) {
-	switch byteOrder {
-	case "ABCD", "MSW-BE", "MSW": // Big endian (Motorola)
-		return "ABCD", nil
-	case "BADC", "MSW-LE": // Big endian with bytes swapped
-		return "BADC", nil
-	case "CDAB", "LSW-BE": // Little endian with bytes swapped
-		return "CDAB", nil
-	case "DCBA", "LSW-LE", "LSW": // Little endian (Intel)
-		return "DCBA", nil
-	}
-	return "unknown", fmt.Errorf("unknown byte-order %q", byteOrder)
-}
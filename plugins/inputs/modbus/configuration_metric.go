@@ -8,6 +8,7 @@ import (
 	"math"
 
 	"github.com/influxdata/telegraf"
+	common_modbus "github.com/influxdata/telegraf/plugins/common/modbus"
 )
 
 //go:embed sample_metric.conf
@@ -291,7 +292,7 @@ func (c *configurationPerMetric) newField(def metricFieldDefinition, mdef metric
 	// Handle type conversions for coil and discrete registers
 	if !typed {
 		var err error
-		f.converter, err = determineUntypedConverter(def.OutputType)
+		f.converter, err = common_modbus.DetermineUntypedConverter(def.OutputType)
 		if err != nil {
 			return field{}, err
 		}
@@ -334,12 +335,12 @@ func (c *configurationPerMetric) newField(def metricFieldDefinition, mdef metric
 	if err != nil {
 		return field{}, err
 	}
-	order, err := normalizeByteOrder(byteOrder)
+	order, err := common_modbus.NormalizeByteOrder(byteOrder)
 	if err != nil {
 		return field{}, err
 	}
 
-	f.converter, err = determineConverter(inType, order, outType, def.Scale, def.Bit, c.workarounds.StringRegisterLocation)
+	f.converter, err = common_modbus.DetermineConverter(inType, order, outType, def.Scale, def.Bit, c.workarounds.StringRegisterLocation)
 	if err != nil {
 		return field{}, err
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/influxdata/telegraf"
+	common_modbus "github.com/influxdata/telegraf/plugins/common/modbus"
 )
 
 //go:embed sample_register.conf
@@ -162,7 +163,7 @@ func (c *configurationOriginal) newFieldFromDefinition(def fieldDefinition, type
 	// Handle coil and discrete registers which do have a limited datatype set
 	if !typed {
 		var err error
-		f.converter, err = determineUntypedConverter(def.DataType)
+		f.converter, err = common_modbus.DetermineUntypedConverter(def.DataType)
 		if err != nil {
 			return field{}, err
 		}
@@ -183,7 +184,7 @@ func (c *configurationOriginal) newFieldFromDefinition(def fieldDefinition, type
 			return f, err
 		}
 
-		f.converter, err = determineConverter(inType, byteOrder, outType, def.Scale, def.Bit, c.workarounds.StringRegisterLocation)
+		f.converter, err = common_modbus.DetermineConverter(inType, byteOrder, outType, def.Scale, def.Bit, c.workarounds.StringRegisterLocation)
 		if err != nil {
 			return f, err
 		}
@@ -346,5 +347,5 @@ func (*configurationOriginal) normalizeByteOrder(byteOrder string) (string, erro
 	case "BA", "HGFEDCBA":
 		return "DCBA", nil
 	}
-	return normalizeByteOrder(byteOrder)
+	return common_modbus.NormalizeByteOrder(byteOrder)
 }
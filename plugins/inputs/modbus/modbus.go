@@ -17,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	common_modbus "github.com/influxdata/telegraf/plugins/common/modbus"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -86,8 +87,6 @@ type rs485Config struct {
 	RxDuringTx         bool            `toml:"rx_during_tx"`
 }
 
-type fieldConverterFunc func(bytes []byte) interface{}
-
 type requestSet struct {
 	coil     []request
 	discrete []request
@@ -109,7 +108,7 @@ type field struct {
 	address     uint16
 	length      uint16
 	omit        bool
-	converter   fieldConverterFunc
+	converter   common_modbus.FieldConverterFunc
 	value       interface{}
 	tags        map[string]string
 }
@@ -0,0 +1,315 @@
+package netflow
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// Keys available for aggregating flows
+var aggregationKeys = []string{
+	"src", "dst", "src_prefix", "dst_prefix", "src_port", "dst_port", "protocol",
+	"in_snmp", "out_snmp", "src_asn", "dst_asn", "src_country", "dst_country",
+}
+
+// Counter fields scaled by the sampling rate
+var sampledCounters = []string{"in_bytes", "in_packets", "out_bytes", "out_packets"}
+
+type aggregationConfig struct {
+	Window           config.Duration `toml:"window"`
+	Keys             []string        `toml:"keys"`
+	IPv4PrefixLength int             `toml:"ipv4_prefix_length"`
+	IPv6PrefixLength int             `toml:"ipv6_prefix_length"`
+	TopN             int             `toml:"top_n"`
+}
+
+type flowAggregator struct {
+	keys             []string
+	ipv4PrefixLength int
+	ipv6PrefixLength int
+	topN             int
+
+	entries map[string]*flowEntry
+	sync.Mutex
+}
+
+type flowEntry struct {
+	tags     map[string]string
+	counters map[string]uint64
+	flows    uint64
+}
+
+// volume returns the total number of bytes and packets of the entry
+func (e *flowEntry) volume() (bytes, packets uint64) {
+	return e.counters["in_bytes"] + e.counters["out_bytes"], e.counters["in_packets"] + e.counters["out_packets"]
+}
+
+func (e *flowEntry) merge(counters map[string]uint64, flows uint64) {
+	for k, v := range counters {
+		e.counters[k] += v
+	}
+	e.flows += flows
+}
+
+func newFlowAggregator(cfg *aggregationConfig) (*flowAggregator, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, fmt.Errorf("no aggregation keys specified, choose from %s", strings.Join(aggregationKeys, ", "))
+	}
+	for _, k := range cfg.Keys {
+		if !slices.Contains(aggregationKeys, k) {
+			return nil, fmt.Errorf("invalid aggregation key %q, choose from %s", k, strings.Join(aggregationKeys, ", "))
+		}
+	}
+	if cfg.IPv4PrefixLength < 0 || cfg.IPv4PrefixLength > 32 {
+		return nil, fmt.Errorf("invalid IPv4 prefix length %d", cfg.IPv4PrefixLength)
+	}
+	if cfg.IPv6PrefixLength < 0 || cfg.IPv6PrefixLength > 128 {
+		return nil, fmt.Errorf("invalid IPv6 prefix length %d", cfg.IPv6PrefixLength)
+	}
+	if cfg.TopN < 0 {
+		return nil, fmt.Errorf("invalid top-n setting %d", cfg.TopN)
+	}
+
+	return &flowAggregator{
+		keys:             cfg.Keys,
+		ipv4PrefixLength: cfg.IPv4PrefixLength,
+		ipv6PrefixLength: cfg.IPv6PrefixLength,
+		topN:             cfg.TopN,
+		entries:          make(map[string]*flowEntry),
+	}, nil
+}
+
+// add accumulates the flow into its aggregate and returns false if the metric
+// is not a flow record and thus cannot be aggregated.
+func (a *flowAggregator) add(m telegraf.Metric) bool {
+	counters := make(map[string]uint64, len(sampledCounters))
+	for _, k := range sampledCounters {
+		if raw, found := m.GetField(k); found {
+			counters[k], _ = internal.ToUint64(raw)
+		}
+	}
+	if len(counters) == 0 {
+		return false
+	}
+
+	tags := make(map[string]string, len(a.keys)+2)
+	for _, k := range []string{"source", "version"} {
+		if v, found := m.GetTag(k); found {
+			tags[k] = v
+		}
+	}
+	for _, k := range a.keys {
+		tags[k] = a.value(m, k)
+	}
+
+	id := groupID(tags)
+
+	a.Lock()
+	defer a.Unlock()
+
+	entry, found := a.entries[id]
+	if !found {
+		entry = &flowEntry{tags: tags, counters: make(map[string]uint64, len(counters))}
+		a.entries[id] = entry
+	}
+	entry.merge(counters, 1)
+
+	return true
+}
+
+// flush returns the aggregated flows and resets the aggregator
+func (a *flowAggregator) flush(t time.Time) []telegraf.Metric {
+	a.Lock()
+	entries := a.entries
+	a.entries = make(map[string]*flowEntry)
+	a.Unlock()
+
+	// Group the entries by exporter to apply the top-n truncation per exporter
+	groups := make(map[string][]*flowEntry)
+	for _, e := range entries {
+		groups[e.tags["source"]] = append(groups[e.tags["source"]], e)
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(entries))
+	for _, group := range groups {
+		if a.topN > 0 && len(group) > a.topN {
+			sort.SliceStable(group, func(i, j int) bool {
+				bytesI, packetsI := group[i].volume()
+				bytesJ, packetsJ := group[j].volume()
+				if bytesI == bytesJ {
+					return packetsI > packetsJ
+				}
+				return bytesI > bytesJ
+			})
+
+			// Sum up the remaining flows into an "other" entry
+			other := &flowEntry{
+				tags:     make(map[string]string, len(a.keys)+2),
+				counters: make(map[string]uint64, len(sampledCounters)),
+			}
+			for k, v := range group[0].tags {
+				other.tags[k] = v
+			}
+			for _, k := range a.keys {
+				other.tags[k] = "other"
+			}
+			for _, e := range group[a.topN:] {
+				other.merge(e.counters, e.flows)
+			}
+			group = append(group[:a.topN], other)
+		}
+
+		for _, e := range group {
+			fields := make(map[string]interface{}, len(e.counters)+1)
+			for k, v := range e.counters {
+				fields[k] = v
+			}
+			fields["flows"] = e.flows
+			metrics = append(metrics, metric.New("netflow", e.tags, fields, t))
+		}
+	}
+
+	return metrics
+}
+
+func (a *flowAggregator) value(m telegraf.Metric, key string) string {
+	var raw interface{}
+	switch key {
+	case "src_prefix", "dst_prefix":
+		v, found := m.GetField(strings.TrimSuffix(key, "_prefix"))
+		if !found {
+			return ""
+		}
+		s, ok := v.(string)
+		if !ok {
+			return ""
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return ""
+		}
+		bits := a.ipv6PrefixLength
+		if addr.Unmap().Is4() {
+			addr = addr.Unmap()
+			bits = a.ipv4PrefixLength
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return ""
+		}
+		return prefix.String()
+	default:
+		v, found := m.GetField(key)
+		if !found {
+			return ""
+		}
+		raw = v
+	}
+
+	s, err := internal.ToString(raw)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func groupID(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(tags[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// applySampling scales the byte and packet counters of the flow by the
+// sampling rate reported by the exporter or the given default rate.
+func applySampling(m telegraf.Metric, defaultRate uint64) {
+	rate := reportedSamplingRate(m)
+	if rate == 0 {
+		rate = defaultRate
+	}
+
+	// sFlow flow samples represent a single sampled packet
+	if version, _ := m.GetTag("version"); version == "sFlowV5" && !m.HasField("in_packets") {
+		if v, found := m.GetField("l2_bytes"); found {
+			m.AddField("in_packets", uint64(1))
+			length, _ := internal.ToUint64(v)
+			m.AddField("in_bytes", length)
+		}
+	}
+
+	if rate <= 1 {
+		return
+	}
+	for _, k := range sampledCounters {
+		v, found := m.GetField(k)
+		if !found {
+			continue
+		}
+		n, err := internal.ToUint64(v)
+		if err != nil {
+			continue
+		}
+		m.AddField(k, n*rate)
+	}
+}
+
+// reportedSamplingRate returns the sampling rate from the fields of the flow
+// or zero if the rate is unknown
+func reportedSamplingRate(m telegraf.Metric) uint64 {
+	if v, found := m.GetField("sampling_interval"); found {
+		rate, _ := internal.ToUint64(v)
+		// Netflow v5 encodes the sampling mode in the two most significant
+		// bits of the sampling interval
+		if version, _ := m.GetTag("version"); version == "NetFlowV5" {
+			rate &= 0x3fff
+		}
+		if rate > 0 {
+			return rate
+		}
+	}
+
+	// Random n-out-of-N sampling, i.e. samplerRandomInterval
+	if v, found := m.GetField("flow_sampler_interval"); found {
+		if rate, _ := internal.ToUint64(v); rate > 0 {
+			return rate
+		}
+	}
+
+	// Systematic count-based sampling selecting samplingPacketInterval
+	// packets followed by samplingPacketSpace unselected packets. Some
+	// exporters only send the interval denoting the "one out of N" rate.
+	if v, found := m.GetField("sampling_packet_interval"); found {
+		interval, _ := internal.ToUint64(v)
+		if interval == 0 {
+			return 0
+		}
+		s, found := m.GetField("sampling_packet_space")
+		if !found {
+			return interval
+		}
+		space, _ := internal.ToUint64(s)
+		return uint64(math.Round(float64(interval+space) / float64(interval)))
+	}
+
+	return 0
+}
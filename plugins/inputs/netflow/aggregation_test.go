@@ -0,0 +1,371 @@
+package netflow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestAggregationInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      aggregationConfig
+		expected string
+	}{
+		{
+			name: "valid",
+			cfg:  aggregationConfig{Keys: []string{"src_prefix", "dst_asn"}, IPv4PrefixLength: 24, IPv6PrefixLength: 64},
+		},
+		{
+			name:     "no keys",
+			cfg:      aggregationConfig{IPv4PrefixLength: 24, IPv6PrefixLength: 64},
+			expected: "no aggregation keys specified",
+		},
+		{
+			name:     "invalid key",
+			cfg:      aggregationConfig{Keys: []string{"foo"}, IPv4PrefixLength: 24, IPv6PrefixLength: 64},
+			expected: `invalid aggregation key "foo"`,
+		},
+		{
+			name:     "invalid prefix length",
+			cfg:      aggregationConfig{Keys: []string{"src"}, IPv4PrefixLength: 33, IPv6PrefixLength: 64},
+			expected: "invalid IPv4 prefix length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Window = config.Duration(time.Minute)
+			plugin := &NetFlow{
+				ServiceAddress: "udp://:2055",
+				Aggregation:    tt.cfg,
+				Log:            testutil.Logger{},
+			}
+			err := plugin.Init()
+			if tt.expected != "" {
+				require.ErrorContains(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAggregation(t *testing.T) {
+	aggregator, err := newFlowAggregator(&aggregationConfig{
+		Keys:             []string{"src_prefix", "dst_prefix", "protocol"},
+		IPv4PrefixLength: 24,
+		IPv6PrefixLength: 48,
+	})
+	require.NoError(t, err)
+
+	input := []telegraf.Metric{
+		flow("10.0.0.1", "192.168.1.1", "tcp", 100, 1),
+		flow("10.0.0.2", "192.168.1.2", "tcp", 200, 2),
+		flow("10.0.0.3", "192.168.1.3", "udp", 300, 3),
+		flow("10.0.1.1", "192.168.1.1", "tcp", 400, 4),
+		flow("2001:db8:1:1::1", "2001:db8:2:1::1", "tcp", 500, 5),
+		flow("2001:db8:1:2::1", "2001:db8:2:2::1", "tcp", 600, 6),
+	}
+	for _, m := range input {
+		require.True(t, aggregator.add(m))
+	}
+
+	// Options records must not be aggregated
+	options := metric.New(
+		"netflow",
+		map[string]string{"source": "127.0.0.1", "version": "IPFIX"},
+		map[string]interface{}{"sampling_interval": uint64(100)},
+		time.Unix(0, 0),
+	)
+	require.False(t, aggregator.add(options))
+
+	expected := []telegraf.Metric{
+		aggregated(map[string]string{"src_prefix": "10.0.0.0/24", "dst_prefix": "192.168.1.0/24", "protocol": "tcp"}, 300, 3, 2),
+		aggregated(map[string]string{"src_prefix": "10.0.0.0/24", "dst_prefix": "192.168.1.0/24", "protocol": "udp"}, 300, 3, 1),
+		aggregated(map[string]string{"src_prefix": "10.0.1.0/24", "dst_prefix": "192.168.1.0/24", "protocol": "tcp"}, 400, 4, 1),
+		aggregated(map[string]string{"src_prefix": "2001:db8:1::/48", "dst_prefix": "2001:db8:2::/48", "protocol": "tcp"}, 1100, 11, 2),
+	}
+	actual := aggregator.flush(time.Unix(0, 0))
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+
+	// The aggregator must be empty after flushing
+	require.Empty(t, aggregator.flush(time.Unix(0, 0)))
+}
+
+func TestAggregationTopN(t *testing.T) {
+	aggregator, err := newFlowAggregator(&aggregationConfig{
+		Keys:             []string{"src", "dst", "protocol"},
+		IPv4PrefixLength: 24,
+		IPv6PrefixLength: 64,
+		TopN:             2,
+	})
+	require.NoError(t, err)
+
+	input := []telegraf.Metric{
+		flow("10.0.0.1", "192.168.1.1", "tcp", 100, 1),
+		flow("10.0.0.2", "192.168.1.1", "tcp", 1000, 10),
+		flow("10.0.0.3", "192.168.1.1", "tcp", 200, 2),
+		flow("10.0.0.4", "192.168.1.1", "tcp", 3000, 30),
+		flow("10.0.0.2", "192.168.1.1", "tcp", 1000, 10),
+	}
+	for _, m := range input {
+		require.True(t, aggregator.add(m))
+	}
+
+	expected := []telegraf.Metric{
+		aggregated(map[string]string{"src": "10.0.0.4", "dst": "192.168.1.1", "protocol": "tcp"}, 3000, 30, 1),
+		aggregated(map[string]string{"src": "10.0.0.2", "dst": "192.168.1.1", "protocol": "tcp"}, 2000, 20, 2),
+		aggregated(map[string]string{"src": "other", "dst": "other", "protocol": "other"}, 300, 3, 2),
+	}
+	actual := aggregator.flush(time.Unix(0, 0))
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
+func TestAggregationOutgoingCounters(t *testing.T) {
+	aggregator, err := newFlowAggregator(&aggregationConfig{
+		Keys:             []string{"protocol"},
+		IPv4PrefixLength: 24,
+		IPv6PrefixLength: 64,
+	})
+	require.NoError(t, err)
+
+	tags := map[string]string{"source": "127.0.0.1", "version": "IPFIX"}
+	input := []telegraf.Metric{
+		metric.New("netflow", tags, map[string]interface{}{
+			"protocol":    "tcp",
+			"in_bytes":    uint64(100),
+			"in_packets":  uint64(1),
+			"out_bytes":   uint64(1000),
+			"out_packets": uint64(10),
+		}, time.Unix(0, 0)),
+		metric.New("netflow", tags, map[string]interface{}{
+			"protocol":    "tcp",
+			"out_bytes":   uint64(2000),
+			"out_packets": uint64(20),
+		}, time.Unix(0, 0)),
+	}
+	for _, m := range input {
+		require.True(t, aggregator.add(m))
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"netflow",
+			map[string]string{"source": "127.0.0.1", "version": "IPFIX", "protocol": "tcp"},
+			map[string]interface{}{
+				"in_bytes":    uint64(100),
+				"in_packets":  uint64(1),
+				"out_bytes":   uint64(3000),
+				"out_packets": uint64(30),
+				"flows":       uint64(2),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	actual := aggregator.flush(time.Unix(0, 0))
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestSampling(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		fields      map[string]interface{}
+		defaultRate uint64
+		expected    map[string]interface{}
+	}{
+		{
+			name:    "reported rate",
+			version: "IPFIX",
+			fields: map[string]interface{}{
+				"sampling_interval": uint64(100),
+				"in_bytes":          uint64(1500),
+				"in_packets":        uint64(1),
+			},
+			defaultRate: 10,
+			expected: map[string]interface{}{
+				"sampling_interval": uint64(100),
+				"in_bytes":          uint64(150000),
+				"in_packets":        uint64(100),
+			},
+		},
+		{
+			name:    "netflow v5 sampling mode",
+			version: "NetFlowV5",
+			fields: map[string]interface{}{
+				"sampling_interval": uint64(0x4000 | 512),
+				"in_bytes":          uint64(100),
+				"in_packets":        uint64(2),
+			},
+			expected: map[string]interface{}{
+				"sampling_interval": uint64(0x4000 | 512),
+				"in_bytes":          uint64(51200),
+				"in_packets":        uint64(1024),
+			},
+		},
+		{
+			name:    "ipfix random sampler interval",
+			version: "IPFIX",
+			fields: map[string]interface{}{
+				"flow_sampler_interval": uint64(1000),
+				"in_bytes":              uint64(100),
+				"in_packets":            uint64(1),
+			},
+			defaultRate: 10,
+			expected: map[string]interface{}{
+				"flow_sampler_interval": uint64(1000),
+				"in_bytes":              uint64(100000),
+				"in_packets":            uint64(1000),
+			},
+		},
+		{
+			name:    "ipfix packet interval",
+			version: "IPFIX",
+			fields: map[string]interface{}{
+				"sampling_packet_interval": uint64(512),
+				"in_bytes":                 uint64(100),
+			},
+			expected: map[string]interface{}{
+				"sampling_packet_interval": uint64(512),
+				"in_bytes":                 uint64(51200),
+			},
+		},
+		{
+			name:    "ipfix packet interval and space",
+			version: "IPFIX",
+			fields: map[string]interface{}{
+				"sampling_packet_interval": uint64(1),
+				"sampling_packet_space":    uint64(99),
+				"in_bytes":                 uint64(100),
+				"in_packets":               uint64(2),
+			},
+			expected: map[string]interface{}{
+				"sampling_packet_interval": uint64(1),
+				"sampling_packet_space":    uint64(99),
+				"in_bytes":                 uint64(10000),
+				"in_packets":               uint64(200),
+			},
+		},
+		{
+			name:    "default rate",
+			version: "NetFlowV9",
+			fields: map[string]interface{}{
+				"in_bytes":  uint64(100),
+				"out_bytes": uint64(200),
+			},
+			defaultRate: 10,
+			expected: map[string]interface{}{
+				"in_bytes":  uint64(1000),
+				"out_bytes": uint64(2000),
+			},
+		},
+		{
+			name:    "unknown rate",
+			version: "NetFlowV9",
+			fields: map[string]interface{}{
+				"in_bytes": uint64(100),
+			},
+			expected: map[string]interface{}{
+				"in_bytes": uint64(100),
+			},
+		},
+		{
+			name:    "sflow sample",
+			version: "sFlowV5",
+			fields: map[string]interface{}{
+				"sampling_interval": uint64(1024),
+				"l2_bytes":          uint64(64),
+			},
+			expected: map[string]interface{}{
+				"sampling_interval": uint64(1024),
+				"l2_bytes":          uint64(64),
+				"in_bytes":          uint64(65536),
+				"in_packets":        uint64(1024),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := map[string]string{"source": "127.0.0.1", "version": tt.version}
+			m := metric.New("netflow", tags, tt.fields, time.Unix(0, 0))
+			applySampling(m, tt.defaultRate)
+			require.Equal(t, tt.expected, m.Fields())
+		})
+	}
+}
+
+func TestEnrichment(t *testing.T) {
+	e, err := newEnricher([]string{"testcases/geoip.mmdb"})
+	require.NoError(t, err)
+	defer e.close()
+
+	m := metric.New(
+		"netflow",
+		map[string]string{"source": "127.0.0.1", "version": "IPFIX"},
+		map[string]interface{}{
+			"src": "140.82.121.3",
+			"dst": "2a0a:a540::1",
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, e.enrich(m))
+
+	expected := map[string]interface{}{
+		"src":         "140.82.121.3",
+		"src_asn":     uint64(36459),
+		"src_as_name": "GITHUB",
+		"src_country": "US",
+		"dst":         "2a0a:a540::1",
+		"dst_asn":     uint64(64500),
+		"dst_as_name": "EXAMPLE",
+		"dst_country": "DE",
+	}
+	require.Equal(t, expected, m.Fields())
+
+	// Unknown addresses should not be enriched
+	m = metric.New(
+		"netflow",
+		map[string]string{"source": "127.0.0.1", "version": "IPFIX"},
+		map[string]interface{}{"src": "192.168.1.1"},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, e.enrich(m))
+	require.Equal(t, map[string]interface{}{"src": "192.168.1.1"}, m.Fields())
+}
+
+func flow(src, dst, protocol string, bytes, packets uint64) telegraf.Metric {
+	return metric.New(
+		"netflow",
+		map[string]string{"source": "127.0.0.1", "version": "IPFIX"},
+		map[string]interface{}{
+			"src":        src,
+			"dst":        dst,
+			"protocol":   protocol,
+			"src_port":   uint64(12345),
+			"in_bytes":   bytes,
+			"in_packets": packets,
+		},
+		time.Unix(0, 0),
+	)
+}
+
+func aggregated(keys map[string]string, bytes, packets, flows uint64) telegraf.Metric {
+	tags := map[string]string{"source": "127.0.0.1", "version": "IPFIX"}
+	for k, v := range keys {
+		tags[k] = v
+	}
+	fields := map[string]interface{}{
+		"in_bytes":   bytes,
+		"in_packets": packets,
+		"flows":      flows,
+	}
+	return metric.New("netflow", tags, fields, time.Unix(0, 0))
+}
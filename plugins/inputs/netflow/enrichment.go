@@ -0,0 +1,111 @@
+package netflow
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang/v2"
+
+	"github.com/influxdata/telegraf"
+)
+
+// geoRecord contains the subset of the MaxMind GeoLite2/GeoIP2 ASN and
+// country database records used for enrichment
+type geoRecord struct {
+	ASN     uint64 `maxminddb:"autonomous_system_number"`
+	ASName  string `maxminddb:"autonomous_system_organization"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+type enricher struct {
+	readers []*maxminddb.Reader
+}
+
+func newEnricher(databases []string) (*enricher, error) {
+	e := &enricher{readers: make([]*maxminddb.Reader, 0, len(databases))}
+	for _, fn := range databases {
+		r, err := maxminddb.Open(fn)
+		if err != nil {
+			e.close()
+			return nil, fmt.Errorf("opening database %q failed: %w", fn, err)
+		}
+		e.readers = append(e.readers, r)
+	}
+	return e, nil
+}
+
+func (e *enricher) close() {
+	for _, r := range e.readers {
+		_ = r.Close()
+	}
+	e.readers = nil
+}
+
+// enrich adds the autonomous system and country information for the source
+// and destination addresses of the flow if available
+func (e *enricher) enrich(m telegraf.Metric) error {
+	var errs []error
+	for _, prefix := range []string{"src", "dst"} {
+		v, found := m.GetField(prefix)
+		if !found {
+			continue
+		}
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+
+		record, err := e.lookup(addr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("looking up %q failed: %w", s, err))
+			continue
+		}
+		if record.ASN > 0 {
+			m.AddField(prefix+"_asn", record.ASN)
+		}
+		if record.ASName != "" {
+			m.AddField(prefix+"_as_name", record.ASName)
+		}
+		if record.Country.ISOCode != "" {
+			m.AddField(prefix+"_country", record.Country.ISOCode)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookup merges the records of all databases with earlier databases taking
+// precedence over later ones
+func (e *enricher) lookup(addr netip.Addr) (*geoRecord, error) {
+	var record geoRecord
+	for _, r := range e.readers {
+		result := r.Lookup(addr)
+		if err := result.Err(); err != nil {
+			return nil, err
+		}
+		if !result.Found() {
+			continue
+		}
+		var current geoRecord
+		if err := result.Decode(&current); err != nil {
+			return nil, err
+		}
+		if record.ASN == 0 {
+			record.ASN = current.ASN
+		}
+		if record.ASName == "" {
+			record.ASName = current.ASName
+		}
+		if record.Country.ISOCode == "" {
+			record.Country.ISOCode = current.Country.ISOCode
+		}
+	}
+	return &record, nil
+}
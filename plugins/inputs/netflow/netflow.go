@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
@@ -20,16 +21,24 @@ import (
 var sampleConfig string
 
 type NetFlow struct {
-	ServiceAddress string          `toml:"service_address"`
-	ReadBufferSize config.Size     `toml:"read_buffer_size"`
-	Protocol       string          `toml:"protocol"`
-	DumpPackets    bool            `toml:"dump_packets" deprecated:"1.35.0;use 'log_level' 'trace' instead"`
-	PENFiles       []string        `toml:"private_enterprise_number_files"`
-	Log            telegraf.Logger `toml:"-"`
-
-	conn    *net.UDPConn
-	decoder protocolDecoder
-	wg      sync.WaitGroup
+	ServiceAddress string            `toml:"service_address"`
+	ReadBufferSize config.Size       `toml:"read_buffer_size"`
+	Protocol       string            `toml:"protocol"`
+	DumpPackets    bool              `toml:"dump_packets" deprecated:"1.35.0;use 'log_level' 'trace' instead"`
+	PENFiles       []string          `toml:"private_enterprise_number_files"`
+	ApplySampling  bool              `toml:"apply_sampling"`
+	SamplingRate   uint64            `toml:"default_sampling_rate"`
+	GeoIPDatabases []string          `toml:"geoip_databases"`
+	Aggregation    aggregationConfig `toml:"aggregation"`
+	Log            telegraf.Logger   `toml:"-"`
+
+	conn       *net.UDPConn
+	decoder    protocolDecoder
+	enricher   *enricher
+	aggregator *flowAggregator
+	done       chan struct{}
+	readDone   chan struct{}
+	wg         sync.WaitGroup
 }
 
 type protocolDecoder interface {
@@ -79,6 +88,14 @@ func (n *NetFlow) Init() error {
 		return fmt.Errorf("invalid protocol %q, only supports 'sflow', 'netflow v5', 'netflow v9' and 'ipfix'", n.Protocol)
 	}
 
+	if n.Aggregation.Window > 0 {
+		aggregator, err := newFlowAggregator(&n.Aggregation)
+		if err != nil {
+			return fmt.Errorf("creating aggregator failed: %w", err)
+		}
+		n.aggregator = aggregator
+	}
+
 	return n.decoder.init()
 }
 
@@ -96,21 +113,44 @@ func (n *NetFlow) Start(acc telegraf.Accumulator) error {
 	if err != nil {
 		return err
 	}
-	n.conn = conn
 
 	if n.ReadBufferSize > 0 {
 		if err := conn.SetReadBuffer(int(n.ReadBufferSize)); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	// Open the databases only after listening succeeded to not leak them
+	// on errors
+	if len(n.GeoIPDatabases) > 0 {
+		e, err := newEnricher(n.GeoIPDatabases)
+		if err != nil {
+			conn.Close()
 			return err
 		}
+		n.enricher = e
 	}
+	n.conn = conn
 	n.Log.Infof("Listening on %s://%s", n.conn.LocalAddr().Network(), n.conn.LocalAddr().String())
 
+	n.readDone = make(chan struct{})
 	n.wg.Add(1)
 	go func() {
 		defer n.wg.Done()
+		defer close(n.readDone)
 		n.read(acc)
 	}()
 
+	if n.aggregator != nil {
+		n.done = make(chan struct{})
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.flush(acc)
+		}()
+	}
+
 	return nil
 }
 
@@ -122,7 +162,14 @@ func (n *NetFlow) Stop() {
 	if n.conn != nil {
 		_ = n.conn.Close()
 	}
+	if n.done != nil {
+		close(n.done)
+	}
 	n.wg.Wait()
+
+	if n.enricher != nil {
+		n.enricher.close()
+	}
 }
 
 func (n *NetFlow) read(acc telegraf.Accumulator) {
@@ -149,14 +196,52 @@ func (n *NetFlow) read(acc telegraf.Accumulator) {
 			continue
 		}
 		for _, m := range metrics {
+			if n.ApplySampling {
+				applySampling(m, n.SamplingRate)
+			}
+			if n.enricher != nil {
+				if err := n.enricher.enrich(m); err != nil {
+					n.Log.Debugf("Enriching flow failed: %v", err)
+				}
+			}
+			if n.aggregator != nil && n.aggregator.add(m) {
+				continue
+			}
 			acc.AddMetric(m)
 		}
 	}
 }
 
+func (n *NetFlow) flush(acc telegraf.Accumulator) {
+	ticker := time.NewTicker(time.Duration(n.Aggregation.Window))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			// Make sure we do not lose the flows of the last window by
+			// waiting for the reader to add all received flows
+			<-n.readDone
+			for _, m := range n.aggregator.flush(time.Now()) {
+				acc.AddMetric(m)
+			}
+			return
+		case t := <-ticker.C:
+			for _, m := range n.aggregator.flush(t) {
+				acc.AddMetric(m)
+			}
+		}
+	}
+}
+
 // Register the plugin
 func init() {
 	inputs.Add("netflow", func() telegraf.Input {
-		return &NetFlow{}
+		return &NetFlow{
+			Aggregation: aggregationConfig{
+				IPv4PrefixLength: 24,
+				IPv6PrefixLength: 64,
+			},
+		}
 	})
 }
@@ -170,6 +170,24 @@ func TestWrongMapping(t *testing.T) {
 	require.ErrorContains(t, plugin.Init(), "does not match pattern")
 }
 
+func TestStartListenFailure(t *testing.T) {
+	// Occupy the port to make listening fail
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	plugin := &NetFlow{
+		ServiceAddress: "udp://" + conn.LocalAddr().String(),
+		GeoIPDatabases: []string{"testcases/geoip.mmdb"},
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.Error(t, plugin.Start(&acc))
+	require.Nil(t, plugin.enricher)
+}
+
 func TestCases(t *testing.T) {
 	// Get all directories in testdata
 	folders, err := os.ReadDir("testcases")
@@ -177,7 +195,12 @@ func TestCases(t *testing.T) {
 
 	// Register the plugin
 	inputs.Add("netflow", func() telegraf.Input {
-		return &NetFlow{}
+		return &NetFlow{
+			Aggregation: aggregationConfig{
+				IPv4PrefixLength: 24,
+				IPv6PrefixLength: 64,
+			},
+		}
 	})
 
 	// Prepare the influx parser for expectations
@@ -2,13 +2,14 @@ package opentelemetry
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/influxdata/influxdb-observability/common"
 	"github.com/influxdata/influxdb-observability/otel2influx"
 	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	"github.com/influxdata/telegraf"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
 )
 
 type traceService struct {
@@ -18,12 +19,8 @@ type traceService struct {
 
 var _ ptraceotlp.GRPCServer = (*traceService)(nil)
 
-func newTraceService(logger common.Logger, writer *writeToAccumulator, spanDimensions []string) (*traceService, error) {
-	expConfig := otel2influx.DefaultOtelTracesToLineProtocolConfig()
-	expConfig.Logger = logger
-	expConfig.Writer = writer
-	expConfig.SpanDimensions = spanDimensions
-	exp, err := otel2influx.NewOtelTracesToLineProtocol(expConfig)
+func newTraceService(logger telegraf.Logger, writer *common_otlp.Writer, spanDimensions []string) (*traceService, error) {
+	exp, err := common_otlp.NewTracesConverter(logger, writer, spanDimensions)
 	if err != nil {
 		return nil, err
 	}
@@ -45,22 +42,8 @@ type metricsService struct {
 
 var _ pmetricotlp.GRPCServer = (*metricsService)(nil)
 
-var metricsSchemata = map[string]common.MetricsSchema{
-	"prometheus-v1": common.MetricsSchemaTelegrafPrometheusV1,
-	"prometheus-v2": common.MetricsSchemaTelegrafPrometheusV2,
-}
-
-func newMetricsService(logger common.Logger, writer *writeToAccumulator, schema string) (*metricsService, error) {
-	ms, found := metricsSchemata[schema]
-	if !found {
-		return nil, fmt.Errorf("schema %q not recognized", schema)
-	}
-
-	expConfig := otel2influx.DefaultOtelMetricsToLineProtocolConfig()
-	expConfig.Logger = logger
-	expConfig.Writer = writer
-	expConfig.Schema = ms
-	exp, err := otel2influx.NewOtelMetricsToLineProtocol(expConfig)
+func newMetricsService(logger telegraf.Logger, writer *common_otlp.Writer, schema string) (*metricsService, error) {
+	exp, err := common_otlp.NewMetricsConverter(logger, writer, schema)
 	if err != nil {
 		return nil, err
 	}
@@ -82,12 +65,8 @@ type logsService struct {
 
 var _ plogotlp.GRPCServer = (*logsService)(nil)
 
-func newLogsService(logger common.Logger, writer *writeToAccumulator, logRecordDimensions []string) (*logsService, error) {
-	expConfig := otel2influx.DefaultOtelLogsToLineProtocolConfig()
-	expConfig.Logger = logger
-	expConfig.Writer = writer
-	expConfig.LogRecordDimensions = logRecordDimensions
-	exp, err := otel2influx.NewOtelLogsToLineProtocol(expConfig)
+func newLogsService(logger telegraf.Logger, writer *common_otlp.Writer, logRecordDimensions []string) (*logsService, error) {
+	exp, err := common_otlp.NewLogsConverter(logger, writer, logRecordDimensions)
 	if err != nil {
 		return nil, err
 	}
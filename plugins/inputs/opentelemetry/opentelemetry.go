@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -75,23 +76,22 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 		grpcOptions = append(grpcOptions, grpc.MaxRecvMsgSize(int(o.MaxMsgSize)))
 	}
 
-	logger := &otelLogger{o.Log}
-	influxWriter := &writeToAccumulator{acc}
+	influxWriter := common_otlp.NewWriter(acc.AddMetric)
 	o.grpcServer = grpc.NewServer(grpcOptions...)
 
-	traceSvc, err := newTraceService(logger, influxWriter, o.SpanDimensions)
+	traceSvc, err := newTraceService(o.Log, influxWriter, o.SpanDimensions)
 	if err != nil {
 		return err
 	}
 	ptraceotlp.RegisterGRPCServer(o.grpcServer, traceSvc)
 
-	metricsSvc, err := newMetricsService(logger, influxWriter, o.MetricsSchema)
+	metricsSvc, err := newMetricsService(o.Log, influxWriter, o.MetricsSchema)
 	if err != nil {
 		return err
 	}
 	pmetricotlp.RegisterGRPCServer(o.grpcServer, metricsSvc)
 
-	logsSvc, err := newLogsService(logger, influxWriter, o.LogRecordDimensions)
+	logsSvc, err := newLogsService(o.Log, influxWriter, o.LogRecordDimensions)
 	if err != nil {
 		return err
 	}
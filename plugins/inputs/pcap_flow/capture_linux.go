@@ -0,0 +1,39 @@
+//go:build linux
+
+package pcap_flow
+
+import (
+	"fmt"
+
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+)
+
+// liveCapture captures packets from an AF_PACKET socket
+type liveCapture struct {
+	*pcapgo.EthernetHandle
+}
+
+func (liveCapture) LinkType() layers.LinkType {
+	return layers.LinkTypeEthernet
+}
+
+func openInterface(name string, snaplen int, promiscuous bool) (packetSource, error) {
+	handle, err := pcapgo.NewEthernetHandle(name)
+	if err != nil {
+		return nil, err
+	}
+	if snaplen > 0 {
+		if err := handle.SetCaptureLength(snaplen); err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("setting snaplen failed: %w", err)
+		}
+	}
+	if promiscuous {
+		if err := handle.SetPromiscuous(true); err != nil {
+			handle.Close()
+			return nil, fmt.Errorf("enabling promiscuous mode failed: %w", err)
+		}
+	}
+	return liveCapture{handle}, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package pcap_flow
+
+import (
+	"errors"
+)
+
+func openInterface(string, int, bool) (packetSource, error) {
+	return nil, errors.New("capturing from interfaces is only supported on Linux")
+}
@@ -0,0 +1,179 @@
+package pcap_flow
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+)
+
+var errNoIP = errors.New("no IP layer")
+
+type packetDecoder struct {
+	eth   layers.Ethernet
+	sll   layers.LinuxSLL
+	dot1q layers.Dot1Q
+	ipv4  layers.IPv4
+	ipv6  layers.IPv6
+	tcp   layers.TCP
+	udp   layers.UDP
+	sctp  layers.SCTP
+
+	parser  *gopacket.DecodingLayerParser
+	decoded []gopacket.LayerType
+
+	// Parser for raw IPv6 packets as the parser's first layer is fixed
+	parserIPv6 *gopacket.DecodingLayerParser
+}
+
+func newPacketDecoder(linkType layers.LinkType) (*packetDecoder, error) {
+	d := &packetDecoder{decoded: make([]gopacket.LayerType, 0, 8)}
+
+	var first gopacket.LayerType
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		first = layers.LayerTypeEthernet
+	case layers.LinkTypeLinuxSLL:
+		first = layers.LayerTypeLinuxSLL
+	case layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+		// The first layer is determined per packet from the IP version
+		first = layers.LayerTypeIPv4
+		d.parserIPv6 = d.newParser(layers.LayerTypeIPv6)
+	default:
+		return nil, fmt.Errorf("unsupported link-type %s", linkType)
+	}
+	d.parser = d.newParser(first)
+
+	return d, nil
+}
+
+func (d *packetDecoder) newParser(first gopacket.LayerType) *gopacket.DecodingLayerParser {
+	parser := gopacket.NewDecodingLayerParser(
+		first,
+		&d.eth, &d.sll, &d.dot1q, &d.ipv4, &d.ipv6, &d.tcp, &d.udp, &d.sctp,
+	)
+	parser.IgnoreUnsupported = true
+	return parser
+}
+
+// decode extracts the flow information of the given packet data
+func (d *packetDecoder) decode(data []byte, ci gopacket.CaptureInfo) (*packetInfo, error) {
+	parser := d.parser
+	if d.parserIPv6 != nil && len(data) > 0 && data[0]>>4 == 6 {
+		parser = d.parserIPv6
+	}
+
+	// Decoding errors are not fatal as long as the IP layer is decoded, e.g.
+	// for truncated packets, so check the decoded layers instead
+	err := parser.DecodeLayers(data, &d.decoded)
+
+	p := &packetInfo{timestamp: ci.Timestamp}
+
+	// VLAN tags stripped by the kernel are passed as ancillary data
+	if len(ci.AncillaryData) > 0 {
+		if vlan, ok := ci.AncillaryData[0].(int); ok {
+			p.vlan = uint16(vlan & 0x0fff)
+			p.hasVlan = true
+		}
+	}
+
+	var ipPayloadLength uint32
+	var hasIP bool
+	for _, layer := range d.decoded {
+		switch layer {
+		case layers.LayerTypeEthernet:
+			p.srcMAC = slices.Clone(d.eth.SrcMAC)
+			p.dstMAC = slices.Clone(d.eth.DstMAC)
+		case layers.LayerTypeDot1Q:
+			if !p.hasVlan {
+				p.vlan = d.dot1q.VLANIdentifier
+				p.hasVlan = true
+			}
+		case layers.LayerTypeIPv4:
+			src, _ := netip.AddrFromSlice(d.ipv4.SrcIP.To4())
+			dst, _ := netip.AddrFromSlice(d.ipv4.DstIP.To4())
+			p.key.src, p.key.dst = src, dst
+			p.key.protocol = uint8(d.ipv4.Protocol)
+			p.ipVersion = 4
+			p.tos = d.ipv4.TOS
+			p.length = uint64(d.ipv4.Length)
+			ipPayloadLength = uint32(d.ipv4.Length) - 4*uint32(d.ipv4.IHL)
+			hasIP = true
+		case layers.LayerTypeIPv6:
+			src, _ := netip.AddrFromSlice(d.ipv6.SrcIP.To16())
+			dst, _ := netip.AddrFromSlice(d.ipv6.DstIP.To16())
+			p.key.src, p.key.dst = src, dst
+			p.key.protocol = uint8(d.ipv6.NextHeader)
+			p.ipVersion = 6
+			p.tos = d.ipv6.TrafficClass
+			p.length = 40 + uint64(d.ipv6.Length)
+			ipPayloadLength = uint32(d.ipv6.Length)
+			hasIP = true
+		case layers.LayerTypeTCP:
+			p.key.srcPort = uint16(d.tcp.SrcPort)
+			p.key.dstPort = uint16(d.tcp.DstPort)
+			p.tcpFlags = tcpFlags(&d.tcp)
+			p.seq = d.tcp.Seq
+			p.ack = d.tcp.Ack
+
+			// Use the length from the IP header as the payload might be
+			// truncated by the capture length. SYN and FIN consume a
+			// sequence number each.
+			if headerLength := 4 * uint32(d.tcp.DataOffset); ipPayloadLength > headerLength {
+				p.segLength = ipPayloadLength - headerLength
+			}
+			if d.tcp.SYN {
+				p.segLength++
+			}
+			if d.tcp.FIN {
+				p.segLength++
+			}
+		case layers.LayerTypeUDP:
+			p.key.srcPort = uint16(d.udp.SrcPort)
+			p.key.dstPort = uint16(d.udp.DstPort)
+		case layers.LayerTypeSCTP:
+			p.key.srcPort = uint16(d.sctp.SrcPort)
+			p.key.dstPort = uint16(d.sctp.DstPort)
+		}
+	}
+
+	if !hasIP {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errNoIP
+	}
+	return p, nil
+}
+
+func tcpFlags(tcp *layers.TCP) uint8 {
+	var flags uint8
+	if tcp.FIN {
+		flags |= tcpFIN
+	}
+	if tcp.SYN {
+		flags |= tcpSYN
+	}
+	if tcp.RST {
+		flags |= tcpRST
+	}
+	if tcp.PSH {
+		flags |= tcpPSH
+	}
+	if tcp.ACK {
+		flags |= tcpACK
+	}
+	if tcp.URG {
+		flags |= tcpURG
+	}
+	if tcp.ECE {
+		flags |= tcpECE
+	}
+	if tcp.CWR {
+		flags |= tcpCWR
+	}
+	return flags
+}
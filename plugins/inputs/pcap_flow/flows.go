@@ -0,0 +1,339 @@
+package pcap_flow
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// TCP control bits as used in the flags byte of the TCP header
+const (
+	tcpFIN uint8 = 1 << iota
+	tcpSYN
+	tcpRST
+	tcpPSH
+	tcpACK
+	tcpURG
+	tcpECE
+	tcpCWR
+)
+
+// Flow termination reasons using the naming of the netflow plugin, see
+// https://www.iana.org/assignments/ipfix/ipfix.xhtml#ipfix-flow-end-reason
+const (
+	reasonIdleTimeout   = "idle timeout"
+	reasonActiveTimeout = "active timeout"
+	reasonEndOfFlow     = "end of flow"
+	reasonForcedEnd     = "forced end"
+)
+
+// flowKey identifies a unidirectional flow by its 5-tuple
+type flowKey struct {
+	protocol uint8
+	src      netip.Addr
+	dst      netip.Addr
+	srcPort  uint16
+	dstPort  uint16
+}
+
+func (k flowKey) reverse() flowKey {
+	return flowKey{
+		protocol: k.protocol,
+		src:      k.dst,
+		dst:      k.src,
+		srcPort:  k.dstPort,
+		dstPort:  k.srcPort,
+	}
+}
+
+// packetInfo holds the information of a decoded packet relevant for flows
+type packetInfo struct {
+	key       flowKey
+	timestamp time.Time
+	length    uint64
+	ipVersion uint8
+	tos       uint8
+	vlan      uint16
+	hasVlan   bool
+	srcMAC    net.HardwareAddr
+	dstMAC    net.HardwareAddr
+
+	// TCP specific information
+	tcpFlags  uint8
+	seq       uint32
+	ack       uint32
+	segLength uint32
+}
+
+type flow struct {
+	key       flowKey
+	ipVersion uint8
+	tos       uint8
+	vlan      uint16
+	hasVlan   bool
+	srcMAC    net.HardwareAddr
+	dstMAC    net.HardwareAddr
+
+	start    time.Time
+	last     time.Time
+	bytes    uint64
+	packets  uint64
+	tcpFlags uint8
+	ended    bool
+	reason   string
+
+	// State for sampling the round-trip time of TCP flows. Only one segment
+	// is timed at once and retransmitted segments are not timed following
+	// Karn's algorithm.
+	rttPending bool
+	rttSeq     uint32
+	rttSent    time.Time
+	rttMin     time.Duration
+	rttMax     time.Duration
+	rttSum     time.Duration
+	rttSamples uint64
+}
+
+// track starts timing the given segment if no other segment is in flight
+func (f *flow) track(seq, length uint32, t time.Time) {
+	if length == 0 {
+		return
+	}
+	end := seq + length
+	if f.rttPending {
+		// Segments not advancing the sequence number are retransmissions so
+		// the acknowledgment becomes ambiguous
+		if int32(end-f.rttSeq) <= 0 {
+			f.rttPending = false
+		}
+		return
+	}
+	f.rttPending = true
+	f.rttSeq = end
+	f.rttSent = t
+}
+
+// acknowledge completes the timing of the tracked segment if the given
+// acknowledgment number covers the segment
+func (f *flow) acknowledge(ack uint32, t time.Time) {
+	if !f.rttPending || int32(ack-f.rttSeq) < 0 {
+		return
+	}
+	f.rttPending = false
+
+	rtt := t.Sub(f.rttSent)
+	if rtt < 0 {
+		return
+	}
+	if f.rttSamples == 0 || rtt < f.rttMin {
+		f.rttMin = rtt
+	}
+	if rtt > f.rttMax {
+		f.rttMax = rtt
+	}
+	f.rttSum += rtt
+	f.rttSamples++
+}
+
+func (f *flow) metric(source string) telegraf.Metric {
+	tags := map[string]string{
+		"source":  source,
+		"version": "PCAP",
+	}
+	fields := map[string]interface{}{
+		"src":             f.key.src.String(),
+		"dst":             f.key.dst.String(),
+		"src_port":        f.key.srcPort,
+		"dst_port":        f.key.dstPort,
+		"protocol":        mapL4Proto(f.key.protocol),
+		"ip_version":      "IPv" + strconv.Itoa(int(f.ipVersion)),
+		"src_tos":         fmt.Sprintf("0x%02x", f.tos),
+		"in_bytes":        f.bytes,
+		"in_packets":      f.packets,
+		"flow_start_ms":   uint64(f.start.UnixMilli()),
+		"flow_end_ms":     uint64(f.last.UnixMilli()),
+		"flow_end_reason": f.reason,
+	}
+	if f.key.protocol == protocolTCP {
+		fields["tcp_flags"] = mapTCPFlags(f.tcpFlags)
+	}
+	if f.hasVlan {
+		fields["vlan_src"] = f.vlan
+	}
+	if len(f.srcMAC) > 0 {
+		fields["in_src_mac"] = f.srcMAC.String()
+	}
+	if len(f.dstMAC) > 0 {
+		fields["in_dst_mac"] = f.dstMAC.String()
+	}
+	if f.rttSamples > 0 {
+		fields["rtt_min_us"] = uint64(f.rttMin.Microseconds())
+		fields["rtt_max_us"] = uint64(f.rttMax.Microseconds())
+		fields["rtt_avg_us"] = uint64((f.rttSum / time.Duration(f.rttSamples)).Microseconds())
+		fields["rtt_samples"] = f.rttSamples
+	}
+
+	return metric.New("netflow", tags, fields, f.last)
+}
+
+type flowTable struct {
+	activeTimeout   time.Duration
+	inactiveTimeout time.Duration
+	maxFlows        int
+
+	flows map[flowKey]*flow
+	sync.Mutex
+}
+
+func newFlowTable(activeTimeout, inactiveTimeout time.Duration, maxFlows int) *flowTable {
+	return &flowTable{
+		activeTimeout:   activeTimeout,
+		inactiveTimeout: inactiveTimeout,
+		maxFlows:        maxFlows,
+		flows:           make(map[flowKey]*flow),
+	}
+}
+
+// add accounts the packet to its flow and returns false if the packet
+// starts a new flow but the table is full
+func (t *flowTable) add(p *packetInfo) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	f, found := t.flows[p.key]
+	if !found {
+		if t.maxFlows > 0 && len(t.flows) >= t.maxFlows {
+			return false
+		}
+		f = &flow{
+			key:       p.key,
+			ipVersion: p.ipVersion,
+			tos:       p.tos,
+			vlan:      p.vlan,
+			hasVlan:   p.hasVlan,
+			srcMAC:    p.srcMAC,
+			dstMAC:    p.dstMAC,
+			start:     p.timestamp,
+		}
+		t.flows[p.key] = f
+	}
+	if p.timestamp.After(f.last) {
+		f.last = p.timestamp
+	}
+	f.bytes += p.length
+	f.packets++
+
+	if p.key.protocol == protocolTCP {
+		f.tcpFlags |= p.tcpFlags
+		if p.tcpFlags&(tcpFIN|tcpRST) != 0 {
+			f.ended = true
+		}
+
+		// Acknowledgments complete the timing of the reverse flow
+		if p.tcpFlags&tcpACK != 0 {
+			if r, found := t.flows[p.key.reverse()]; found {
+				r.acknowledge(p.ack, p.timestamp)
+			}
+		}
+		f.track(p.seq, p.segLength, p.timestamp)
+	}
+
+	return true
+}
+
+// expire removes and returns all flows terminated at the given time
+func (t *flowTable) expire(now time.Time) []*flow {
+	t.Lock()
+	defer t.Unlock()
+
+	var expired []*flow
+	for k, f := range t.flows {
+		switch {
+		case f.ended:
+			f.reason = reasonEndOfFlow
+		case now.Sub(f.last) >= t.inactiveTimeout:
+			f.reason = reasonIdleTimeout
+		case now.Sub(f.start) >= t.activeTimeout:
+			f.reason = reasonActiveTimeout
+		default:
+			continue
+		}
+		expired = append(expired, f)
+		delete(t.flows, k)
+	}
+
+	return expired
+}
+
+// flush removes and returns all flows
+func (t *flowTable) flush() []*flow {
+	t.Lock()
+	defer t.Unlock()
+
+	flows := make([]*flow, 0, len(t.flows))
+	for _, f := range t.flows {
+		f.reason = reasonForcedEnd
+		if f.ended {
+			f.reason = reasonEndOfFlow
+		}
+		flows = append(flows, f)
+	}
+	t.flows = make(map[flowKey]*flow)
+
+	return flows
+}
+
+// Layer 4 protocol numbers handled specifically
+const (
+	protocolICMP   uint8 = 1
+	protocolTCP    uint8 = 6
+	protocolUDP    uint8 = 17
+	protocolICMPv6 uint8 = 58
+	protocolSCTP   uint8 = 132
+)
+
+// mapL4Proto returns the IANA keyword of common protocols matching the naming
+// of the netflow plugin and the protocol number otherwise
+func mapL4Proto(id uint8) string {
+	switch id {
+	case protocolICMP:
+		return "icmp"
+	case protocolTCP:
+		return "tcp"
+	case protocolUDP:
+		return "udp"
+	case protocolICMPv6:
+		return "ipv6-icmp"
+	case protocolSCTP:
+		return "sctp"
+	case 47:
+		return "gre"
+	case 50:
+		return "esp"
+	case 51:
+		return "ah"
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// mapTCPFlags formats the flags in the same way as the netflow plugin
+func mapTCPFlags(flags uint8) string {
+	const names = "FSRPAUEC"
+
+	var b strings.Builder
+	for i := 7; i >= 0; i-- {
+		if (flags>>i)&0x01 != 0 {
+			b.WriteByte(names[i])
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
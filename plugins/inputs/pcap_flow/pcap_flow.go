@@ -0,0 +1,292 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package pcap_flow
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// Interval for checking the flows for timeouts
+const expiryInterval = time.Second
+
+// Bounds of the delay between retries after failing to read a packet
+const (
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+type PcapFlow struct {
+	Interface       string          `toml:"interface"`
+	File            string          `toml:"file"`
+	Promiscuous     bool            `toml:"promiscuous"`
+	Snaplen         int             `toml:"snaplen"`
+	ActiveTimeout   config.Duration `toml:"active_timeout"`
+	InactiveTimeout config.Duration `toml:"inactive_timeout"`
+	MaxFlows        int             `toml:"max_flows"`
+	Log             telegraf.Logger `toml:"-"`
+
+	source       string
+	handle       packetSource
+	flows        *flowTable
+	packetsTotal selfstat.Stat
+	packetsSkip  selfstat.Stat
+	flowsDropped selfstat.Stat
+	done         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// packetSource is the common interface of live captures and capture files
+type packetSource interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+	Close() error
+}
+
+func (*PcapFlow) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PcapFlow) Init() error {
+	switch {
+	case p.Interface == "" && p.File == "":
+		return errors.New("either 'interface' or 'file' must be specified")
+	case p.Interface != "" && p.File != "":
+		return errors.New("'interface' and 'file' are mutually exclusive")
+	}
+	if p.Snaplen < 0 {
+		return fmt.Errorf("invalid snaplen %d", p.Snaplen)
+	}
+	if p.ActiveTimeout <= 0 {
+		return errors.New("'active_timeout' must be positive")
+	}
+	if p.InactiveTimeout <= 0 {
+		return errors.New("'inactive_timeout' must be positive")
+	}
+	if p.MaxFlows < 0 {
+		return fmt.Errorf("invalid max_flows %d", p.MaxFlows)
+	}
+
+	p.source = p.Interface
+	if p.File != "" {
+		p.source = filepath.Base(p.File)
+	}
+	p.flows = newFlowTable(time.Duration(p.ActiveTimeout), time.Duration(p.InactiveTimeout), p.MaxFlows)
+
+	tags := map[string]string{"source": p.source}
+	p.packetsTotal = selfstat.Register("pcap_flow", "packets_received", tags)
+	p.packetsSkip = selfstat.Register("pcap_flow", "packets_skipped", tags)
+	p.flowsDropped = selfstat.Register("pcap_flow", "flows_dropped", tags)
+
+	return nil
+}
+
+func (p *PcapFlow) Start(acc telegraf.Accumulator) error {
+	var err error
+	if p.File != "" {
+		p.handle, err = openFile(p.File)
+	} else {
+		p.handle, err = openInterface(p.Interface, p.Snaplen, p.Promiscuous)
+	}
+	if err != nil {
+		return err
+	}
+
+	decoder, err := newPacketDecoder(p.handle.LinkType())
+	if err != nil {
+		p.handle.Close()
+		return err
+	}
+
+	p.done = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.read(acc, decoder)
+	}()
+
+	// Live captures need to expire flows even if no packets arrive while for
+	// files the packet timestamps drive the expiry
+	if p.Interface != "" {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.expire(acc)
+		}()
+	}
+
+	return nil
+}
+
+func (*PcapFlow) Gather(telegraf.Accumulator) error {
+	return nil
+}
+
+func (p *PcapFlow) Stop() {
+	if p.done != nil {
+		close(p.done)
+	}
+	if p.handle != nil {
+		p.handle.Close()
+	}
+	p.wg.Wait()
+}
+
+func (p *PcapFlow) read(acc telegraf.Accumulator, decoder *packetDecoder) {
+	var lastExpiry time.Time
+	var backoff time.Duration
+loop:
+	for {
+		data, ci, err := p.handle.ReadPacketData()
+		if err != nil {
+			select {
+			case <-p.done:
+				break loop
+			default:
+			}
+
+			// Stop at the end of capture files, a truncated last packet is
+			// common for captures that were interrupted
+			if p.File != "" && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+				p.Log.Infof("Finished reading %q", p.File)
+				break
+			}
+
+			// Keep reading on other errors but back off to avoid spinning on
+			// persistent errors
+			acc.AddError(fmt.Errorf("reading packet failed: %w", err))
+			backoff = min(max(2*backoff, minReadBackoff), maxReadBackoff)
+			select {
+			case <-p.done:
+				break loop
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+		p.packetsTotal.Incr(1)
+
+		info, err := decoder.decode(data, ci)
+		if err != nil {
+			p.Log.Tracef("Skipping packet: %v", err)
+			p.packetsSkip.Incr(1)
+			continue
+		}
+		if !p.flows.add(info) {
+			p.flowsDropped.Incr(1)
+		}
+
+		if ci.Timestamp.Sub(lastExpiry) >= expiryInterval {
+			p.emit(acc, p.flows.expire(ci.Timestamp))
+			lastExpiry = ci.Timestamp
+		}
+	}
+
+	// Export the remaining flows when reaching the end of the capture
+	p.emit(acc, p.flows.flush())
+}
+
+func (p *PcapFlow) expire(acc telegraf.Accumulator) {
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case t := <-ticker.C:
+			p.emit(acc, p.flows.expire(t))
+		}
+	}
+}
+
+func (p *PcapFlow) emit(acc telegraf.Accumulator, flows []*flow) {
+	for _, f := range flows {
+		acc.AddMetric(f.metric(p.source))
+	}
+}
+
+// captureFile closes the underlying file of pcap and pcapng readers
+type captureFile struct {
+	reader interface {
+		ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+		LinkType() layers.LinkType
+	}
+	file *os.File
+}
+
+func (c *captureFile) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	return c.reader.ReadPacketData()
+}
+
+func (c *captureFile) LinkType() layers.LinkType {
+	return c.reader.LinkType()
+}
+
+func (c *captureFile) Close() error {
+	return c.file.Close()
+}
+
+func openFile(path string) (packetSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening capture file failed: %w", err)
+	}
+
+	// Determine the file format from the magic number
+	r := bufio.NewReader(file)
+	magic, err := r.Peek(4)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("reading capture file header failed: %w", err)
+	}
+
+	c := &captureFile{file: file}
+	if string(magic) == "\x0a\x0d\x0d\x0a" {
+		reader, err := pcapgo.NewNgReader(r, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("reading pcapng file failed: %w", err)
+		}
+		c.reader = reader
+	} else {
+		reader, err := pcapgo.NewReader(r)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("reading pcap file failed: %w", err)
+		}
+		c.reader = reader
+	}
+
+	return c, nil
+}
+
+// Register the plugin
+func init() {
+	inputs.Add("pcap_flow", func() telegraf.Input {
+		return &PcapFlow{
+			Snaplen:         128,
+			ActiveTimeout:   config.Duration(time.Minute),
+			InactiveTimeout: config.Duration(15 * time.Second),
+			MaxFlows:        65536,
+		}
+	})
+}
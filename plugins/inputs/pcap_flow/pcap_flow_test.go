@@ -0,0 +1,510 @@
+package pcap_flow
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopacket/gopacket"
+	"github.com/gopacket/gopacket/layers"
+	"github.com/gopacket/gopacket/pcapgo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var (
+	clientMAC = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+	serverMAC = net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *PcapFlow
+		expected string
+	}{
+		{
+			name:     "no source",
+			plugin:   &PcapFlow{},
+			expected: "either 'interface' or 'file' must be specified",
+		},
+		{
+			name:     "both sources",
+			plugin:   &PcapFlow{Interface: "eth0", File: "foo.pcap"},
+			expected: "'interface' and 'file' are mutually exclusive",
+		},
+		{
+			name:     "invalid active timeout",
+			plugin:   &PcapFlow{Interface: "eth0", InactiveTimeout: config.Duration(time.Second)},
+			expected: "'active_timeout' must be positive",
+		},
+		{
+			name: "invalid max flows",
+			plugin: &PcapFlow{
+				Interface:       "eth0",
+				ActiveTimeout:   config.Duration(time.Minute),
+				InactiveTimeout: config.Duration(time.Second),
+				MaxFlows:        -1,
+			},
+			expected: "invalid max_flows -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestFileTCP(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	ms := time.Millisecond
+	packets := []capturedPacket{
+		{t0, tcpPacket(t, true, 100, 0, tcpSYN, 0)},
+		{t0.Add(5 * ms), udpPacket(t, "10.0.0.1", "10.0.0.3", 5353, 53, 30)},
+		{t0.Add(10 * ms), tcpPacket(t, false, 500, 101, tcpSYN|tcpACK, 0)},
+		{t0.Add(11 * ms), tcpPacket(t, true, 101, 501, tcpACK, 0)},
+		{t0.Add(12 * ms), tcpPacket(t, true, 101, 501, tcpPSH|tcpACK, 100)},
+		{t0.Add(22 * ms), tcpPacket(t, false, 501, 201, tcpACK, 0)},
+		{t0.Add(23 * ms), tcpPacket(t, true, 201, 501, tcpFIN|tcpACK, 0)},
+		// Expire the flows above
+		{t0.Add(20 * time.Second), udpPacket(t, "10.0.0.4", "10.0.0.1", 123, 123, 48)},
+	}
+	filename := writeCapture(t, layers.LinkTypeEthernet, packets)
+
+	plugin := &PcapFlow{
+		File:            filename,
+		ActiveTimeout:   config.Duration(time.Minute),
+		InactiveTimeout: config.Duration(15 * time.Second),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 4
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	tags := map[string]string{"source": filepath.Base(filename), "version": "PCAP"}
+	expected := []telegraf.Metric{
+		metric.New("netflow", tags,
+			map[string]interface{}{
+				"src":             "10.0.0.1",
+				"dst":             "10.0.0.2",
+				"src_port":        uint64(40000),
+				"dst_port":        uint64(80),
+				"protocol":        "tcp",
+				"ip_version":      "IPv4",
+				"src_tos":         "0x00",
+				"in_bytes":        uint64(260),
+				"in_packets":      uint64(4),
+				"flow_start_ms":   uint64(t0.UnixMilli()),
+				"flow_end_ms":     uint64(t0.Add(23 * ms).UnixMilli()),
+				"flow_end_reason": "end of flow",
+				"tcp_flags":       "...AP.SF",
+				"in_src_mac":      clientMAC.String(),
+				"in_dst_mac":      serverMAC.String(),
+				"rtt_min_us":      uint64(10000),
+				"rtt_max_us":      uint64(10000),
+				"rtt_avg_us":      uint64(10000),
+				"rtt_samples":     uint64(2),
+			},
+			t0.Add(23*ms),
+		),
+		metric.New("netflow", tags,
+			map[string]interface{}{
+				"src":             "10.0.0.2",
+				"dst":             "10.0.0.1",
+				"src_port":        uint64(80),
+				"dst_port":        uint64(40000),
+				"protocol":        "tcp",
+				"ip_version":      "IPv4",
+				"src_tos":         "0x00",
+				"in_bytes":        uint64(80),
+				"in_packets":      uint64(2),
+				"flow_start_ms":   uint64(t0.Add(10 * ms).UnixMilli()),
+				"flow_end_ms":     uint64(t0.Add(22 * ms).UnixMilli()),
+				"flow_end_reason": "idle timeout",
+				"tcp_flags":       "...A..S.",
+				"in_src_mac":      serverMAC.String(),
+				"in_dst_mac":      clientMAC.String(),
+				"rtt_min_us":      uint64(1000),
+				"rtt_max_us":      uint64(1000),
+				"rtt_avg_us":      uint64(1000),
+				"rtt_samples":     uint64(1),
+			},
+			t0.Add(22*ms),
+		),
+		metric.New("netflow", tags,
+			map[string]interface{}{
+				"src":             "10.0.0.1",
+				"dst":             "10.0.0.3",
+				"src_port":        uint64(5353),
+				"dst_port":        uint64(53),
+				"protocol":        "udp",
+				"ip_version":      "IPv4",
+				"src_tos":         "0x00",
+				"in_bytes":        uint64(58),
+				"in_packets":      uint64(1),
+				"flow_start_ms":   uint64(t0.Add(5 * ms).UnixMilli()),
+				"flow_end_ms":     uint64(t0.Add(5 * ms).UnixMilli()),
+				"flow_end_reason": "idle timeout",
+				"in_src_mac":      clientMAC.String(),
+				"in_dst_mac":      serverMAC.String(),
+			},
+			t0.Add(5*ms),
+		),
+		metric.New("netflow", tags,
+			map[string]interface{}{
+				"src":             "10.0.0.4",
+				"dst":             "10.0.0.1",
+				"src_port":        uint64(123),
+				"dst_port":        uint64(123),
+				"protocol":        "udp",
+				"ip_version":      "IPv4",
+				"src_tos":         "0x00",
+				"in_bytes":        uint64(76),
+				"in_packets":      uint64(1),
+				"flow_start_ms":   uint64(t0.Add(20 * time.Second).UnixMilli()),
+				"flow_end_ms":     uint64(t0.Add(20 * time.Second).UnixMilli()),
+				"flow_end_reason": "forced end",
+				"in_src_mac":      clientMAC.String(),
+				"in_dst_mac":      serverMAC.String(),
+			},
+			t0.Add(20*time.Second),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestFileActiveTimeout(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	packets := make([]capturedPacket, 0, 14)
+	for i := range 14 {
+		ts := t0.Add(time.Duration(i) * 10 * time.Second)
+		packets = append(packets, capturedPacket{ts, udpPacket(t, "10.0.0.1", "10.0.0.2", 1000, 2000, 10)})
+	}
+	filename := writeCapture(t, layers.LinkTypeEthernet, packets)
+
+	plugin := &PcapFlow{
+		File:            filename,
+		ActiveTimeout:   config.Duration(time.Minute),
+		InactiveTimeout: config.Duration(15 * time.Second),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 2)
+	for i, m := range metrics {
+		start := t0.Add(time.Duration(i) * 70 * time.Second)
+		require.Equal(t, "active timeout", m.Fields()["flow_end_reason"])
+		require.Equal(t, uint64(7), m.Fields()["in_packets"])
+		require.Equal(t, uint64(start.UnixMilli()), m.Fields()["flow_start_ms"])
+		require.True(t, start.Add(time.Minute).Equal(m.Time()))
+	}
+}
+
+func TestLiveReadErrors(t *testing.T) {
+	source := &mockSource{
+		packets: [][]byte{
+			nil,
+			nil,
+			udpPacket(t, "10.0.0.1", "10.0.0.2", 1000, 2000, 10),
+		},
+		closed: make(chan struct{}),
+	}
+
+	plugin := &PcapFlow{
+		Interface:       "eth0",
+		ActiveTimeout:   config.Duration(time.Minute),
+		InactiveTimeout: config.Duration(15 * time.Second),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	decoder, err := newPacketDecoder(layers.LinkTypeEthernet)
+	require.NoError(t, err)
+	plugin.handle = source
+	plugin.done = make(chan struct{})
+
+	// Reading must continue after errors of live captures
+	var acc testutil.Accumulator
+	plugin.wg.Add(1)
+	go func() {
+		defer plugin.wg.Done()
+		plugin.read(&acc, decoder)
+	}()
+	require.Eventually(t, func() bool {
+		return plugin.packetsTotal.Get() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	require.Len(t, acc.Errors, 2)
+	for _, err := range acc.Errors {
+		require.ErrorContains(t, err, "reading packet failed: temporary failure")
+	}
+
+	// The remaining flow is exported on shutdown
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+}
+
+func TestFileMaxFlows(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+	packets := []capturedPacket{
+		{t0, udpPacket(t, "10.0.0.1", "10.0.0.2", 1000, 2000, 10)},
+		{t0, udpPacket(t, "10.0.0.1", "10.0.0.3", 1000, 2000, 10)},
+		{t0, udpPacket(t, "10.0.0.1", "10.0.0.2", 1000, 2000, 10)},
+	}
+	filename := writeCapture(t, layers.LinkTypeEthernet, packets)
+
+	plugin := &PcapFlow{
+		File:            filename,
+		ActiveTimeout:   config.Duration(time.Minute),
+		InactiveTimeout: config.Duration(15 * time.Second),
+		MaxFlows:        1,
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	dropped := selfstat.Register("pcap_flow", "flows_dropped", map[string]string{"source": filepath.Base(filename)})
+	before := dropped.Get()
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "10.0.0.2", metrics[0].Fields()["dst"])
+	require.Equal(t, uint64(2), metrics[0].Fields()["in_packets"])
+	require.Equal(t, int64(1), dropped.Get()-before)
+}
+
+func TestFilePcapngRawIPv6(t *testing.T) {
+	ip := &layers.IPv6{
+		Version:      6,
+		TrafficClass: 0xb8,
+		HopLimit:     64,
+		NextHeader:   layers.IPProtocolUDP,
+		SrcIP:        net.ParseIP("2001:db8::1"),
+		DstIP:        net.ParseIP("2001:db8::2"),
+	}
+	udp := &layers.UDP{SrcPort: 4000, DstPort: 5000}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+	data := serialize(t, ip, udp, gopacket.Payload(make([]byte, 12)))
+
+	filename := filepath.Join(t.TempDir(), "capture.pcapng")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	w, err := pcapgo.NewNgWriter(f, layers.LinkTypeRaw)
+	require.NoError(t, err)
+	ts := time.Unix(1700000000, 0)
+	require.NoError(t, w.WritePacket(gopacket.CaptureInfo{Timestamp: ts, CaptureLength: len(data), Length: len(data)}, data))
+	require.NoError(t, w.Flush())
+	require.NoError(t, f.Close())
+
+	plugin := &PcapFlow{
+		File:            filename,
+		ActiveTimeout:   config.Duration(time.Minute),
+		InactiveTimeout: config.Duration(15 * time.Second),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= 1
+	}, 5*time.Second, 10*time.Millisecond)
+	plugin.Stop()
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"netflow",
+			map[string]string{"source": "capture.pcapng", "version": "PCAP"},
+			map[string]interface{}{
+				"src":             "2001:db8::1",
+				"dst":             "2001:db8::2",
+				"src_port":        uint64(4000),
+				"dst_port":        uint64(5000),
+				"protocol":        "udp",
+				"ip_version":      "IPv6",
+				"src_tos":         "0xb8",
+				"in_bytes":        uint64(60),
+				"in_packets":      uint64(1),
+				"flow_start_ms":   uint64(ts.UnixMilli()),
+				"flow_end_ms":     uint64(ts.UnixMilli()),
+				"flow_end_reason": "forced end",
+			},
+			ts,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestRetransmissionNotTimed(t *testing.T) {
+	table := newFlowTable(time.Minute, 15*time.Second, 0)
+	client := flowKey{
+		protocol: protocolTCP,
+		src:      netipMustParse(t, "10.0.0.1"),
+		dst:      netipMustParse(t, "10.0.0.2"),
+		srcPort:  40000,
+		dstPort:  80,
+	}
+	t0 := time.Unix(1700000000, 0)
+
+	// Data segment, its retransmission and the acknowledgment
+	require.True(t, table.add(&packetInfo{key: client, timestamp: t0, tcpFlags: tcpACK, seq: 1, segLength: 100}))
+	require.True(t, table.add(&packetInfo{key: client, timestamp: t0.Add(time.Second), tcpFlags: tcpACK, seq: 1, segLength: 100}))
+	require.True(t, table.add(&packetInfo{key: client.reverse(), timestamp: t0.Add(time.Second + time.Millisecond), tcpFlags: tcpACK, ack: 101}))
+
+	// A new segment is timed again
+	require.True(t, table.add(&packetInfo{key: client, timestamp: t0.Add(2 * time.Second), tcpFlags: tcpACK, seq: 101, segLength: 100}))
+	require.True(t, table.add(&packetInfo{key: client.reverse(), timestamp: t0.Add(2*time.Second + 3*time.Millisecond), tcpFlags: tcpACK, ack: 201}))
+
+	f := table.flows[client]
+	require.Equal(t, uint64(1), f.rttSamples)
+	require.Equal(t, 3*time.Millisecond, f.rttMin)
+}
+
+// mockSource returns the packets in order where nil entries cause an error
+// and blocks after the last packet until closed
+type mockSource struct {
+	packets [][]byte
+	closed  chan struct{}
+}
+
+func (m *mockSource) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	if len(m.packets) == 0 {
+		<-m.closed
+		return nil, gopacket.CaptureInfo{}, errors.New("closed")
+	}
+	data := m.packets[0]
+	m.packets = m.packets[1:]
+	if data == nil {
+		return nil, gopacket.CaptureInfo{}, errors.New("temporary failure")
+	}
+	ci := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(data), Length: len(data)}
+	return data, ci, nil
+}
+
+func (*mockSource) LinkType() layers.LinkType {
+	return layers.LinkTypeEthernet
+}
+
+func (m *mockSource) Close() error {
+	close(m.closed)
+	return nil
+}
+
+type capturedPacket struct {
+	timestamp time.Time
+	data      []byte
+}
+
+func writeCapture(t *testing.T, linkType layers.LinkType, packets []capturedPacket) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "capture.pcap")
+	f, err := os.Create(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	w := pcapgo.NewWriter(f)
+	require.NoError(t, w.WriteFileHeader(65536, linkType))
+	for _, p := range packets {
+		ci := gopacket.CaptureInfo{Timestamp: p.timestamp, CaptureLength: len(p.data), Length: len(p.data)}
+		require.NoError(t, w.WritePacket(ci, p.data))
+	}
+	return filename
+}
+
+// tcpPacket creates a packet of the connection between the client
+// 10.0.0.1:40000 and the server 10.0.0.2:80
+func tcpPacket(t *testing.T, fromClient bool, seq, ack uint32, flags uint8, payload int) []byte {
+	t.Helper()
+
+	eth := &layers.Ethernet{SrcMAC: clientMAC, DstMAC: serverMAC, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    net.IPv4(10, 0, 0, 1),
+		DstIP:    net.IPv4(10, 0, 0, 2),
+	}
+	tcp := &layers.TCP{
+		SrcPort: 40000,
+		DstPort: 80,
+		Seq:     seq,
+		Ack:     ack,
+		Window:  65535,
+		FIN:     flags&tcpFIN != 0,
+		SYN:     flags&tcpSYN != 0,
+		RST:     flags&tcpRST != 0,
+		PSH:     flags&tcpPSH != 0,
+		ACK:     flags&tcpACK != 0,
+	}
+	if !fromClient {
+		eth.SrcMAC, eth.DstMAC = eth.DstMAC, eth.SrcMAC
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+		tcp.SrcPort, tcp.DstPort = tcp.DstPort, tcp.SrcPort
+	}
+	require.NoError(t, tcp.SetNetworkLayerForChecksum(ip))
+
+	return serialize(t, eth, ip, tcp, gopacket.Payload(make([]byte, payload)))
+}
+
+func udpPacket(t *testing.T, src, dst string, srcPort, dstPort uint16, payload int) []byte {
+	t.Helper()
+
+	eth := &layers.Ethernet{SrcMAC: clientMAC, DstMAC: serverMAC, EthernetType: layers.EthernetTypeIPv4}
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		Protocol: layers.IPProtocolUDP,
+		SrcIP:    net.ParseIP(src),
+		DstIP:    net.ParseIP(dst),
+	}
+	udp := &layers.UDP{SrcPort: layers.UDPPort(srcPort), DstPort: layers.UDPPort(dstPort)}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+
+	return serialize(t, eth, ip, udp, gopacket.Payload(make([]byte, payload)))
+}
+
+func serialize(t *testing.T, l ...gopacket.SerializableLayer) []byte {
+	t.Helper()
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, l...))
+	return buf.Bytes()
+}
+
+func netipMustParse(t *testing.T, s string) netip.Addr {
+	t.Helper()
+
+	addr, err := netip.ParseAddr(s)
+	require.NoError(t, err)
+	return addr
+}
@@ -0,0 +1,207 @@
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+func (f *file) parse(acc telegraf.Accumulator, path string, data []byte, now time.Time) error {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if f.SkipLines >= len(lines) {
+		return nil
+	}
+	lines = lines[f.SkipLines:]
+
+	switch f.Layout {
+	case "key_value":
+		return f.parseKeyValue(acc, path, lines, now)
+	case "table":
+		return f.parseTable(acc, path, lines, now)
+	}
+	return fmt.Errorf("layout %q not supported for files", f.Layout)
+}
+
+// parseKeyValue handles files with a "KEY VALUE [UNIT]" entry per line and
+// creates a single metric
+func (f *file) parseKeyValue(acc telegraf.Accumulator, path string, lines []string, now time.Time) error {
+	fields := make(map[string]interface{}, len(lines))
+	for i, line := range lines {
+		var key, rest string
+		if f.Separator != "" {
+			var found bool
+			key, rest, found = strings.Cut(line, f.Separator)
+			if !found {
+				if strings.TrimSpace(line) == "" {
+					continue
+				}
+				return fmt.Errorf("line %d: missing separator", i+f.SkipLines+1)
+			}
+		} else {
+			tokens := strings.SplitN(strings.TrimSpace(line), " ", 2)
+			key = tokens[0]
+			if len(tokens) > 1 {
+				rest = tokens[1]
+			}
+		}
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		var value, unit string
+		tokens := strings.Fields(rest)
+		switch len(tokens) {
+		case 0:
+			return fmt.Errorf("line %d: missing value for %q", i+f.SkipLines+1, key)
+		case 1:
+			value = tokens[0]
+		case 2:
+			value, unit = tokens[0], tokens[1]
+		default:
+			value = strings.Join(tokens, " ")
+		}
+
+		v, err := f.convert(key, value, unit)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+f.SkipLines+1, err)
+		}
+		fields[key] = v
+	}
+
+	if len(fields) > 0 {
+		acc.AddFields(f.Measurement, fields, f.tags(path), now)
+	}
+	return nil
+}
+
+// parseTable handles whitespace separated tables and creates a metric per row
+func (f *file) parseTable(acc telegraf.Accumulator, path string, lines []string, now time.Time) error {
+	columns := f.Columns
+	offset := f.SkipLines + 1
+	if len(columns) == 0 {
+		// Use the first line as header
+		for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+			lines = lines[1:]
+			offset++
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		columns = strings.Fields(lines[0])
+		lines = lines[1:]
+		offset++
+	}
+
+	for i, line := range lines {
+		values := strings.Fields(line)
+		if len(values) == 0 {
+			continue
+		}
+		// Trailing columns, e.g. descriptions, might contain whitespace
+		if f.JoinLastColumn && len(values) > len(columns) {
+			values = append(values[:len(columns)-1], strings.Join(values[len(columns)-1:], " "))
+		}
+		if len(values) != len(columns) {
+			f.log.Debugf("Skipping line %d of %q: expected %d columns but got %d", i+offset, path, len(columns), len(values))
+			continue
+		}
+
+		tags := f.tags(path)
+		fields := make(map[string]interface{}, len(columns))
+		for j, column := range columns {
+			// Skip unnamed columns, e.g. separators
+			if column == "" || column == "_" {
+				continue
+			}
+			if slices.Contains(f.TagColumns, column) {
+				tags[column] = values[j]
+				continue
+			}
+			v, err := f.convert(column, values[j], "")
+			if err != nil {
+				return fmt.Errorf("line %d: %w", i+offset, err)
+			}
+			fields[column] = v
+		}
+		if len(fields) > 0 {
+			acc.AddFields(f.Measurement, fields, tags, now)
+		}
+	}
+	return nil
+}
+
+// convert converts the value of the given field to the configured type
+// applying the multiplier of the given unit
+func (f *file) convert(name, value, unit string) (interface{}, error) {
+	typ := "auto"
+	for _, rule := range f.rules {
+		if rule.filter.Match(name) {
+			typ = rule.typ
+			break
+		}
+	}
+
+	multiplier := int64(1)
+	if unit != "" {
+		if m, found := f.UnitMultipliers[unit]; found {
+			multiplier = m
+		}
+	}
+
+	switch typ {
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("converting %q of %q to int failed: %w", value, name, err)
+		}
+		return v * multiplier, nil
+	case "uint":
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("converting %q of %q to uint failed: %w", value, name, err)
+		}
+		return v * uint64(multiplier), nil
+	case "float":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("converting %q of %q to float failed: %w", value, name, err)
+		}
+		return v * float64(multiplier), nil
+	case "bool":
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("converting %q of %q to bool failed: %w", value, name, err)
+		}
+		return v, nil
+	case "string":
+		return value, nil
+	}
+
+	// Automatically determine the type preferring integers
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v * multiplier, nil
+	}
+	if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return v * uint64(multiplier), nil
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil && !math.IsNaN(v) && !math.IsInf(v, 0) {
+		return v * float64(multiplier), nil
+	}
+	return value, nil
+}
@@ -0,0 +1,215 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package procfs
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var layouts = []string{"key_value", "table", "single_value"}
+
+var types = []string{"auto", "int", "uint", "float", "bool", "string"}
+
+type Procfs struct {
+	Files []*file         `toml:"file"`
+	Log   telegraf.Logger `toml:"-"`
+}
+
+type file struct {
+	Measurement     string            `toml:"measurement"`
+	Path            string            `toml:"path"`
+	Layout          string            `toml:"layout"`
+	Separator       string            `toml:"separator"`
+	SkipLines       int               `toml:"skip_lines"`
+	Columns         []string          `toml:"columns"`
+	JoinLastColumn  bool              `toml:"join_last_column"`
+	TagColumns      []string          `toml:"tag_columns"`
+	PathTags        string            `toml:"path_tags"`
+	Types           map[string]string `toml:"types"`
+	UnitMultipliers map[string]int64  `toml:"unit_multipliers"`
+
+	glob     *globpath.GlobPath
+	pathTags *regexp.Regexp
+	rules    []typeRule
+	log      telegraf.Logger
+}
+
+// typeRule assigns a type to all fields matching the filter
+type typeRule struct {
+	filter filter.Filter
+	typ    string
+}
+
+func (*Procfs) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *Procfs) Init() error {
+	if len(p.Files) == 0 {
+		return errors.New("no files configured")
+	}
+
+	for i, f := range p.Files {
+		if f.Path == "" {
+			return fmt.Errorf("file %d: path required", i+1)
+		}
+		if f.Measurement == "" {
+			f.Measurement = "procfs"
+		}
+		if f.Layout == "" {
+			f.Layout = "key_value"
+		}
+		if !slices.Contains(layouts, f.Layout) {
+			return fmt.Errorf("file %q: invalid layout %q", f.Path, f.Layout)
+		}
+		if f.SkipLines < 0 {
+			return fmt.Errorf("file %q: invalid number of lines to skip", f.Path)
+		}
+		f.log = p.Log
+
+		g, err := globpath.Compile(f.Path)
+		if err != nil {
+			return fmt.Errorf("file %q: invalid path: %w", f.Path, err)
+		}
+		f.glob = g
+
+		if f.PathTags != "" {
+			re, err := regexp.Compile(f.PathTags)
+			if err != nil {
+				return fmt.Errorf("file %q: invalid path tags: %w", f.Path, err)
+			}
+			f.pathTags = re
+		}
+
+		// Exact field names take precedence over patterns which are applied
+		// in lexical order to get a deterministic result
+		names := make([]string, 0, len(f.Types))
+		for name, typ := range f.Types {
+			if !slices.Contains(types, typ) {
+				return fmt.Errorf("file %q: invalid type %q for %q", f.Path, typ, name)
+			}
+			names = append(names, name)
+		}
+		sort.SliceStable(names, func(a, b int) bool {
+			exactA, exactB := !strings.ContainsAny(names[a], "*?["), !strings.ContainsAny(names[b], "*?[")
+			if exactA != exactB {
+				return exactA
+			}
+			return names[a] < names[b]
+		})
+		for _, name := range names {
+			flt, err := filter.Compile([]string{name})
+			if err != nil {
+				return fmt.Errorf("file %q: invalid type pattern %q: %w", f.Path, name, err)
+			}
+			f.rules = append(f.rules, typeRule{filter: flt, typ: f.Types[name]})
+		}
+	}
+
+	return nil
+}
+
+func (p *Procfs) Gather(acc telegraf.Accumulator) error {
+	now := time.Now()
+	for _, f := range p.Files {
+		paths := f.glob.Match()
+		if len(paths) == 0 {
+			p.Log.Debugf("No files matching %q", f.Path)
+			continue
+		}
+
+		if f.Layout == "single_value" {
+			if err := f.gatherDirectories(acc, paths, now); err != nil {
+				acc.AddError(err)
+			}
+			continue
+		}
+
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				acc.AddError(fmt.Errorf("reading %q failed: %w", path, err))
+				continue
+			}
+			if err := f.parse(acc, path, data, now); err != nil {
+				acc.AddError(fmt.Errorf("parsing %q failed: %w", path, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+// gatherDirectories reads single-value files and combines the files in the
+// same directory into one metric with the file names as field names
+func (f *file) gatherDirectories(acc telegraf.Accumulator, paths []string, now time.Time) error {
+	var dirs []string
+	fields := make(map[string]map[string]interface{})
+	for _, path := range paths {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			// Some sysfs attributes cannot be read, e.g. write-only triggers
+			continue
+		}
+
+		dir, name := filepath.Split(path)
+		value, err := f.convert(name, strings.TrimSpace(string(data)), "")
+		if err != nil {
+			return fmt.Errorf("parsing %q failed: %w", path, err)
+		}
+		if _, found := fields[dir]; !found {
+			dirs = append(dirs, dir)
+			fields[dir] = make(map[string]interface{})
+		}
+		fields[dir][name] = value
+	}
+
+	for _, dir := range dirs {
+		acc.AddFields(f.Measurement, fields[dir], f.tags(dir), now)
+	}
+	return nil
+}
+
+// tags extracts the tags from the path using the named capture groups of the
+// path tags expression
+func (f *file) tags(path string) map[string]string {
+	tags := make(map[string]string)
+	if f.pathTags == nil {
+		return tags
+	}
+	matches := f.pathTags.FindStringSubmatch(path)
+	if matches == nil {
+		return tags
+	}
+	for i, name := range f.pathTags.SubexpNames() {
+		if name != "" && matches[i] != "" {
+			tags[name] = matches[i]
+		}
+	}
+	return tags
+}
+
+func init() {
+	inputs.Add("procfs", func() telegraf.Input {
+		return &Procfs{}
+	})
+}
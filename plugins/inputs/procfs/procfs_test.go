@@ -0,0 +1,359 @@
+package procfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestKeyValue(t *testing.T) {
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Measurement:     "meminfo",
+				Path:            "../hugepages/testdata/valid/meminfo",
+				Separator:       ":",
+				UnitMultipliers: map[string]int64{"kB": 1024},
+				Types:           map[string]string{"HugePages_*": "uint"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "meminfo", metrics[0].Name())
+	require.Len(t, metrics[0].FieldList(), 51)
+
+	fields := metrics[0].Fields()
+	require.Equal(t, int64(264026876*1024), fields["MemTotal"])
+	require.Equal(t, int64(34359738367*1024), fields["VmallocTotal"])
+	require.Equal(t, int64(2048*1024), fields["Hugepagesize"])
+	require.Equal(t, uint64(2048), fields["HugePages_Total"])
+	require.Equal(t, uint64(883), fields["HugePages_Free"])
+}
+
+func TestKeyValueWhitespace(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "vmstat")
+	content := "nr_free_pages 2593\nnr_zone_inactive_anon 12\n\npgpgin 36447\nworkingset_ratio 0.25\n"
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+
+	plugin := &Procfs{
+		Files: []*file{{Measurement: "vmstat", Path: filename}},
+		Log:   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"vmstat",
+			map[string]string{},
+			map[string]interface{}{
+				"nr_free_pages":         int64(2593),
+				"nr_zone_inactive_anon": int64(12),
+				"pgpgin":                int64(36447),
+				"workingset_ratio":      float64(0.25),
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestTableWithColumns(t *testing.T) {
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Measurement: "slab",
+				Path:        "../slab/testdata/slabinfo",
+				Layout:      "table",
+				SkipLines:   2,
+				Columns: []string{
+					"name", "active_objs", "num_objs", "objsize", "objperslab", "pagesperslab",
+					"_", "_", "limit", "batchcount", "sharedfactor",
+					"_", "_", "active_slabs", "num_slabs", "sharedavail",
+				},
+				TagColumns: []string{"name"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 21)
+
+	expected := metric.New(
+		"slab",
+		map[string]string{"name": "ext4_inode_cache"},
+		map[string]interface{}{
+			"active_objs":  int64(480),
+			"num_objs":     int64(480),
+			"objsize":      int64(1024),
+			"objperslab":   int64(32),
+			"pagesperslab": int64(8),
+			"limit":        int64(0),
+			"batchcount":   int64(0),
+			"sharedfactor": int64(0),
+			"active_slabs": int64(15),
+			"num_slabs":    int64(15),
+			"sharedavail":  int64(0),
+		},
+		time.Unix(0, 0),
+	)
+	testutil.RequireMetricEqual(t, expected, metrics[0], testutil.IgnoreTime())
+}
+
+func TestTableWithHeader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "table")
+	content := "device reads writes ratio state\nsda 100 200 0.5 1\nsdb 0755 0 1 0\n"
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Measurement: "disk",
+				Path:        filename,
+				Layout:      "table",
+				TagColumns:  []string{"device"},
+				Types: map[string]string{
+					"*":     "int",
+					"ratio": "float",
+					"state": "bool",
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"disk",
+			map[string]string{"device": "sda"},
+			map[string]interface{}{
+				"reads":  int64(100),
+				"writes": int64(200),
+				"ratio":  float64(0.5),
+				"state":  true,
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"disk",
+			map[string]string{"device": "sdb"},
+			map[string]interface{}{
+				"reads":  int64(755),
+				"writes": int64(0),
+				"ratio":  float64(1),
+				"state":  false,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestSingleValueDirectories(t *testing.T) {
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Measurement: "hugepages",
+				Path:        "../hugepages/testdata/valid/node/node*/hugepages/hugepages-*/*",
+				Layout:      "single_value",
+				PathTags:    `node(?P<node>\d+)/hugepages/hugepages-(?P<size_kb>\d+)kB`,
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 4)
+	for _, m := range metrics {
+		require.Equal(t, "hugepages", m.Name())
+		require.Contains(t, []string{"0", "1"}, m.Tags()["node"])
+		require.Contains(t, []string{"2048", "1048576"}, m.Tags()["size_kb"])
+		require.ElementsMatch(t, []string{"free_hugepages", "nr_hugepages", "surplus_hugepages"}, fieldKeys(m))
+	}
+
+	expected := metric.New(
+		"hugepages",
+		map[string]string{"node": "0", "size_kb": "2048"},
+		map[string]interface{}{
+			"free_hugepages":    int64(434),
+			"nr_hugepages":      int64(1024),
+			"surplus_hugepages": int64(0),
+		},
+		time.Unix(0, 0),
+	)
+	testutil.RequireMetricsSubset(t, []telegraf.Metric{expected}, metrics, testutil.IgnoreTime())
+}
+
+func TestConversionError(t *testing.T) {
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Path:      "../hugepages/testdata/valid/meminfo",
+				Separator: ":",
+				Types:     map[string]string{"MemTotal": "bool"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.Errors[0], `converting "264026876" of "MemTotal" to bool failed`)
+}
+
+func TestTableColumnMismatch(t *testing.T) {
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Path:      "../slab/testdata/slabinfo",
+				Layout:    "table",
+				SkipLines: 2,
+				Columns:   []string{"name", "active_objs"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	// Rows not matching the columns are skipped
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestTableJoinLastColumn(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "interrupts")
+	content := `           CPU0       CPU1
+  0:         22          0   IO-APIC   2-edge      timer
+  8:          0          1   IO-APIC   8-edge      rtc0
+NMI:          3          4   Non-maskable interrupts
+ERR:          0
+`
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0600))
+
+	plugin := &Procfs{
+		Files: []*file{
+			{
+				Measurement:    "interrupts",
+				Path:           filename,
+				Layout:         "table",
+				SkipLines:      1,
+				Columns:        []string{"irq", "cpu0", "cpu1", "description"},
+				JoinLastColumn: true,
+				TagColumns:     []string{"irq"},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"interrupts",
+			map[string]string{"irq": "0:"},
+			map[string]interface{}{"cpu0": int64(22), "cpu1": int64(0), "description": "IO-APIC 2-edge timer"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"interrupts",
+			map[string]string{"irq": "8:"},
+			map[string]interface{}{"cpu0": int64(0), "cpu1": int64(1), "description": "IO-APIC 8-edge rtc0"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"interrupts",
+			map[string]string{"irq": "NMI:"},
+			map[string]interface{}{"cpu0": int64(3), "cpu1": int64(4), "description": "Non-maskable interrupts"},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestInvalidSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     *file
+		expected string
+	}{
+		{
+			name:     "missing path",
+			file:     &file{},
+			expected: "path required",
+		},
+		{
+			name:     "invalid layout",
+			file:     &file{Path: "/proc/meminfo", Layout: "json"},
+			expected: "invalid layout",
+		},
+		{
+			name:     "invalid type",
+			file:     &file{Path: "/proc/meminfo", Types: map[string]string{"*": "double"}},
+			expected: "invalid type",
+		},
+		{
+			name:     "invalid path tags",
+			file:     &file{Path: "/proc/meminfo", PathTags: "(?P<x"},
+			expected: "invalid path tags",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Procfs{Files: []*file{tt.file}}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+
+	plugin := &Procfs{}
+	require.ErrorContains(t, plugin.Init(), "no files configured")
+}
+
+func fieldKeys(m telegraf.Metric) []string {
+	keys := make([]string, 0, len(m.FieldList()))
+	for _, f := range m.FieldList() {
+		keys = append(keys, f.Key)
+	}
+	return keys
+}
@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
@@ -137,6 +138,87 @@ func collectTotalReadWrite(proc process) (r, w uint64, err error) {
 	return stat.RChar, stat.WChar, nil
 }
 
+func collectNetwork(proc process, prefix string, fields map[string]any) {
+	fs, err := procfs.NewFS(internal.GetProcPath())
+	if err != nil {
+		return
+	}
+	p, err := fs.Proc(int(proc.pid()))
+	if err != nil {
+		return
+	}
+
+	// The counters are the ones of the network namespace the process is in
+	netdev, err := p.NetDev()
+	if err != nil {
+		return
+	}
+	delete(netdev, "lo")
+	total := netdev.Total()
+	fields[prefix+"net_rx_bytes"] = total.RxBytes
+	fields[prefix+"net_rx_packets"] = total.RxPackets
+	fields[prefix+"net_rx_errors"] = total.RxErrors
+	fields[prefix+"net_rx_dropped"] = total.RxDropped
+	fields[prefix+"net_tx_bytes"] = total.TxBytes
+	fields[prefix+"net_tx_packets"] = total.TxPackets
+	fields[prefix+"net_tx_errors"] = total.TxErrors
+	fields[prefix+"net_tx_dropped"] = total.TxDropped
+
+	if namespaces, err := p.Namespaces(); err == nil {
+		if ns, found := namespaces["net"]; found {
+			fields[prefix+"net_namespace"] = ns.Inode
+		}
+	}
+}
+
+func listThreads(proc process) ([]threadInfo, error) {
+	threads, err := procfs.AllThreads(int(proc.pid()))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]threadInfo, 0, len(threads))
+	for _, t := range threads {
+		//nolint:errcheck // The thread might have exited in the meantime
+		name, _ := t.Comm()
+		infos = append(infos, threadInfo{id: int32(t.PID), name: name})
+	}
+	return infos, nil
+}
+
+// systemdUnitOf determines the systemd unit, i.e. the service or scope, the
+// process belongs to from its cgroup path
+func systemdUnitOf(id pid) string {
+	fs, err := procfs.NewFS(internal.GetProcPath())
+	if err != nil {
+		return ""
+	}
+	p, err := fs.Proc(int(id))
+	if err != nil {
+		return ""
+	}
+	cgroups, err := p.Cgroups()
+	if err != nil {
+		return ""
+	}
+
+	for _, cg := range cgroups {
+		// Use the unified hierarchy or the systemd hierarchy for cgroup v1
+		if cg.HierarchyID != 0 && !slices.Contains(cg.Controllers, "name=systemd") {
+			continue
+		}
+
+		// Use the innermost unit as systemd units might be nested
+		elements := strings.Split(cg.Path, "/")
+		for i := len(elements) - 1; i >= 0; i-- {
+			if strings.HasSuffix(elements[i], ".service") || strings.HasSuffix(elements[i], ".scope") {
+				return elements[i]
+			}
+		}
+	}
+	return ""
+}
+
 /* Socket statistics functions */
 func socketStateName(s uint8) string {
 	switch s {
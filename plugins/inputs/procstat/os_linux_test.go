@@ -0,0 +1,36 @@
+package procstat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectNetwork(t *testing.T) {
+	t.Setenv("HOST_PROC", "testdata/proc")
+
+	proc, err := newTestProc(42)
+	require.NoError(t, err)
+
+	fields := make(map[string]interface{})
+	collectNetwork(proc, "", fields)
+	require.Equal(t, map[string]interface{}{
+		"net_rx_bytes":   uint64(5100000),
+		"net_rx_packets": uint64(4200),
+		"net_rx_errors":  uint64(1),
+		"net_rx_dropped": uint64(3),
+		"net_tx_bytes":   uint64(2050000),
+		"net_tx_packets": uint64(3100),
+		"net_tx_errors":  uint64(3),
+		"net_tx_dropped": uint64(4),
+		"net_namespace":  uint32(4026531840),
+	}, fields)
+}
+
+func TestSystemdUnitOf(t *testing.T) {
+	t.Setenv("HOST_PROC", "testdata/proc")
+
+	require.Equal(t, "nginx.service", systemdUnitOf(42))
+	require.Equal(t, "session-2.scope", systemdUnitOf(44))
+	require.Empty(t, systemdUnitOf(1))
+}
@@ -66,6 +66,20 @@ func queryPidWithWinServiceName(winServiceName string) (uint32, error) {
 
 func collectMemmap(process, string, map[string]any) {}
 
+func newDelayReader() (delayReader, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func collectNetwork(process, string, map[string]any) {}
+
+func listThreads(process) ([]threadInfo, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func systemdUnitOf(pid) string {
+	return ""
+}
+
 func findBySystemdUnits([]string) ([]processGroup, error) {
 	return nil, nil
 }
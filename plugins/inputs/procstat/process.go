@@ -32,6 +32,37 @@ type pidFinder interface {
 	children(pid pid) ([]pid, error)
 }
 
+// taskDelays contains the delay accounting information of a task in
+// nanoseconds together with the number of delays
+type taskDelays struct {
+	cpuCount    uint64
+	cpuDelay    uint64
+	blkioCount  uint64
+	blkioDelay  uint64
+	swapinCount uint64
+	swapinDelay uint64
+}
+
+func (d *taskDelays) addFields(prefix string, fields map[string]interface{}) {
+	fields[prefix+"cpu_delay_total"] = d.cpuDelay
+	fields[prefix+"cpu_delay_count"] = d.cpuCount
+	fields[prefix+"blkio_delay_total"] = d.blkioDelay
+	fields[prefix+"blkio_delay_count"] = d.blkioCount
+	fields[prefix+"swapin_delay_total"] = d.swapinDelay
+	fields[prefix+"swapin_delay_count"] = d.swapinCount
+}
+
+type threadInfo struct {
+	id   int32
+	name string
+}
+
+type delayReader interface {
+	process(id int32) (*taskDelays, error)
+	thread(id int32) (*taskDelays, error)
+	close() error
+}
+
 type proc struct {
 	hasCPUTimes bool
 	tags        map[string]string
@@ -204,6 +235,20 @@ func (p *proc) metrics(prefix string, cfg *collectionConfig, t time.Time) ([]tel
 		}
 	}
 
+	var delayErr error
+	if cfg.features["delays"] && cfg.delays != nil {
+		delays, err := cfg.delays.process(p.Pid)
+		if err == nil {
+			delays.addFields(prefix, fields)
+		} else {
+			delayErr = fmt.Errorf("cannot get delays of PID %d: %w", p.Pid, err)
+		}
+	}
+
+	if cfg.features["network"] {
+		collectNetwork(p, prefix, fields)
+	}
+
 	// Add the tags as requested by the user
 	cmdline, err := p.Cmdline()
 	if err == nil {
@@ -255,6 +300,29 @@ func (p *proc) metrics(prefix string, cfg *collectionConfig, t time.Time) ([]tel
 
 	metrics := []telegraf.Metric{metric.New("procstat", p.tags, fields, t)}
 
+	// Collect the delays of the individual threads if requested
+	if cfg.features["thread_delays"] && cfg.delays != nil {
+		threads, err := listThreads(p)
+		if err != nil {
+			return metrics, fmt.Errorf("cannot list threads of PID %d: %w", p.Pid, err)
+		}
+		for _, thread := range threads {
+			delays, err := cfg.delays.thread(thread.id)
+			if err != nil {
+				// The thread might have exited in the meantime
+				continue
+			}
+			tags := make(map[string]string, len(p.tags)+1)
+			for k, v := range p.tags {
+				tags[k] = v
+			}
+			tags["tid"] = strconv.Itoa(int(thread.id))
+			tfields := map[string]interface{}{prefix + "thread_name": thread.name}
+			delays.addFields(prefix, tfields)
+			metrics = append(metrics, metric.New("procstat_thread", tags, tfields, t))
+		}
+	}
+
 	// Collect the socket statistics if requested
 	if cfg.features["sockets"] {
 		for _, protocol := range cfg.socketProtos {
@@ -387,5 +455,5 @@ func (p *proc) metrics(prefix string, cfg *collectionConfig, t time.Time) ([]tel
 		}
 	}
 
-	return metrics, nil
+	return metrics, delayErr
 }
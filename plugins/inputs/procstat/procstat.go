@@ -49,6 +49,7 @@ type Procstat struct {
 	Mode                   string          `toml:"mode"`
 	Properties             []string        `toml:"properties"`
 	SocketProtocols        []string        `toml:"socket_protocols"`
+	SystemdUnitRollup      bool            `toml:"systemd_unit_rollup"`
 	TagWith                []string        `toml:"tag_with"`
 	Filter                 []filter        `toml:"filter"`
 	Log                    telegraf.Logger `toml:"-"`
@@ -66,6 +67,7 @@ type collectionConfig struct {
 	tagging      map[string]bool
 	features     map[string]bool
 	socketProtos []string
+	delays       delayReader
 }
 
 type pidsTags struct {
@@ -114,7 +116,11 @@ func (p *Procstat) Init() error {
 	p.cfg.features = make(map[string]bool, len(p.Properties))
 	for _, prop := range p.Properties {
 		switch prop {
-		case "cpu", "limits", "memory", "mmap":
+		case "cpu", "limits", "memory", "mmap", "network":
+		case "delays", "thread_delays":
+			if runtime.GOOS != "linux" {
+				return fmt.Errorf("property %q is only supported on Linux", prop)
+			}
 		case "sockets":
 			if len(p.SocketProtocols) == 0 {
 				p.SocketProtocols = []string{"all"}
@@ -210,14 +216,33 @@ func (p *Procstat) Init() error {
 }
 
 func (p *Procstat) Gather(acc telegraf.Accumulator) error {
+	if p.cfg.features["delays"] || p.cfg.features["thread_delays"] {
+		reader, err := newDelayReader()
+		if err != nil {
+			acc.AddError(fmt.Errorf("opening taskstats interface failed: %w", err))
+		} else {
+			p.cfg.delays = reader
+			defer func() {
+				reader.close()
+				p.cfg.delays = nil
+			}()
+		}
+	}
+
+	var rollup *unitRollup
+	if p.SystemdUnitRollup {
+		rollup = newUnitRollup(p.Prefix)
+		defer rollup.emit(acc)
+	}
+
 	if p.oldMode {
-		return p.gatherOld(acc)
+		return p.gatherOld(acc, rollup)
 	}
 
-	return p.gatherNew(acc)
+	return p.gatherNew(acc, rollup)
 }
 
-func (p *Procstat) gatherOld(acc telegraf.Accumulator) error {
+func (p *Procstat) gatherOld(acc telegraf.Accumulator, rollup *unitRollup) error {
 	now := time.Now()
 	results, err := p.findPids()
 	if err != nil {
@@ -297,6 +322,7 @@ func (p *Procstat) gatherOld(acc telegraf.Accumulator) error {
 			for _, m := range metrics {
 				acc.AddMetric(m)
 			}
+			rollup.add(pid, metrics)
 		}
 	}
 
@@ -330,7 +356,7 @@ func (p *Procstat) gatherOld(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (p *Procstat) gatherNew(acc telegraf.Accumulator) error {
+func (p *Procstat) gatherNew(acc telegraf.Accumulator, rollup *unitRollup) error {
 	now := time.Now()
 	for _, f := range p.Filter {
 		groups, err := f.applyFilter()
@@ -413,6 +439,7 @@ func (p *Procstat) gatherNew(acc telegraf.Accumulator) error {
 				for _, m := range metrics {
 					acc.AddMetric(m)
 				}
+				rollup.add(pid, metrics)
 			}
 			if p.cfg.tagging["level"] {
 				// Add lookup statistics-metric
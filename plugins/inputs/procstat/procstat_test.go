@@ -784,3 +784,87 @@ func TestGather_MultipleFiltersProcessCacheIsolation(t *testing.T) {
 	require.GreaterOrEqual(t, filterCounts["first"], 1, "first filter should produce metrics on second gather")
 	require.GreaterOrEqual(t, filterCounts["second"], 1, "second filter should produce metrics on second gather")
 }
+
+func TestInitDelayProperties(t *testing.T) {
+	p := Procstat{
+		Exe:           exe,
+		PidFinder:     "test",
+		Properties:    []string{"cpu", "delays", "thread_delays", "network"},
+		Log:           testutil.Logger{},
+		finder:        newTestFinder([]pid{processID}),
+		createProcess: newTestProc,
+	}
+	if runtime.GOOS == "linux" {
+		require.NoError(t, p.Init())
+	} else {
+		require.ErrorContains(t, p.Init(), "only supported on Linux")
+	}
+}
+
+func TestGather_SystemdUnitRollup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping test on non-linux platform")
+	}
+	t.Setenv("HOST_PROC", "testdata/proc")
+
+	p := Procstat{
+		Exe:               exe,
+		PidFinder:         "test",
+		Properties:        []string{"cpu", "memory"},
+		SystemdUnitRollup: true,
+		Log:               testutil.Logger{},
+		finder:            newTestFinder([]pid{42, 43, 44, 45}),
+		createProcess:     newTestProc,
+	}
+	require.NoError(t, p.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"procstat_systemd_unit",
+			map[string]string{"systemd_unit": "nginx.service"},
+			map[string]interface{}{
+				"num_threads":     int64(0),
+				"num_fds":         int64(0),
+				"cpu_time_user":   float64(0),
+				"cpu_time_system": float64(0),
+				"cpu_usage":       float64(0),
+				"memory_rss":      int64(0),
+				"memory_vms":      int64(0),
+				"memory_usage":    float64(0),
+				"read_bytes":      int64(0),
+				"write_bytes":     int64(0),
+				"process_count":   int64(2),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"procstat_systemd_unit",
+			map[string]string{"systemd_unit": "session-2.scope"},
+			map[string]interface{}{
+				"num_threads":     int64(0),
+				"num_fds":         int64(0),
+				"cpu_time_user":   float64(0),
+				"cpu_time_system": float64(0),
+				"cpu_usage":       float64(0),
+				"memory_rss":      int64(0),
+				"memory_vms":      int64(0),
+				"memory_usage":    float64(0),
+				"read_bytes":      int64(0),
+				"write_bytes":     int64(0),
+				"process_count":   int64(1),
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	var actual []telegraf.Metric
+	for _, m := range acc.GetTelegrafMetrics() {
+		if m.Name() == "procstat_systemd_unit" {
+			actual = append(actual, m)
+		}
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime(), testutil.SortMetrics())
+}
@@ -0,0 +1,110 @@
+package procstat
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Fields summed up per systemd unit
+var rollupFields = []string{
+	"num_threads",
+	"num_fds",
+	"cpu_time_user",
+	"cpu_time_system",
+	"cpu_usage",
+	"memory_rss",
+	"memory_vms",
+	"memory_usage",
+	"read_bytes",
+	"write_bytes",
+	"cpu_delay_total",
+	"cpu_delay_count",
+	"blkio_delay_total",
+	"blkio_delay_count",
+	"swapin_delay_total",
+	"swapin_delay_count",
+}
+
+// unitRollup aggregates the process metrics of a gather cycle by the systemd
+// unit the processes belong to according to their cgroup
+type unitRollup struct {
+	prefix string
+	seen   map[pid]bool
+	units  map[string]map[string]interface{}
+	counts map[string]int64
+	ts     time.Time
+}
+
+func newUnitRollup(prefix string) *unitRollup {
+	if prefix != "" {
+		prefix += "_"
+	}
+	return &unitRollup{
+		prefix: prefix,
+		seen:   make(map[pid]bool),
+		units:  make(map[string]map[string]interface{}),
+		counts: make(map[string]int64),
+	}
+}
+
+func (r *unitRollup) add(id pid, metrics []telegraf.Metric) {
+	// Processes might be matched by multiple filters so only count them once
+	if r == nil || r.seen[id] {
+		return
+	}
+	r.seen[id] = true
+
+	unit := systemdUnitOf(id)
+	if unit == "" {
+		return
+	}
+
+	sums, found := r.units[unit]
+	if !found {
+		sums = make(map[string]interface{}, len(rollupFields))
+		r.units[unit] = sums
+	}
+	r.counts[unit]++
+
+	for _, m := range metrics {
+		if m.Name() != "procstat" {
+			continue
+		}
+		r.ts = m.Time()
+		for _, name := range rollupFields {
+			v, ok := m.GetField(r.prefix + name)
+			if !ok {
+				continue
+			}
+			switch v := v.(type) {
+			case float64:
+				sum, _ := sums[r.prefix+name].(float64)
+				sums[r.prefix+name] = sum + v
+			case float32:
+				sum, _ := sums[r.prefix+name].(float64)
+				sums[r.prefix+name] = sum + float64(v)
+			case int32:
+				sum, _ := sums[r.prefix+name].(int64)
+				sums[r.prefix+name] = sum + int64(v)
+			case int64:
+				sum, _ := sums[r.prefix+name].(int64)
+				sums[r.prefix+name] = sum + v
+			case uint64:
+				sum, _ := sums[r.prefix+name].(int64)
+				sums[r.prefix+name] = sum + int64(v)
+			}
+		}
+	}
+}
+
+func (r *unitRollup) emit(acc telegraf.Accumulator) {
+	for unit, sums := range r.units {
+		fields := make(map[string]interface{}, len(sums)+1)
+		for k, v := range sums {
+			fields[k] = v
+		}
+		fields[r.prefix+"process_count"] = r.counts[unit]
+		acc.AddFields("procstat_systemd_unit", fields, map[string]string{"systemd_unit": unit}, r.ts)
+	}
+}
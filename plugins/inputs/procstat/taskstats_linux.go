@@ -0,0 +1,137 @@
+//go:build linux
+
+package procstat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// Minimum size of the taskstats structure containing the delay accounting
+// fields, available since version 1 of the structure
+const taskstatsMinSize = 64
+
+// dialTaskstats is so tests can mock out the netlink connection
+var dialTaskstats = func() (*genetlink.Conn, error) {
+	return genetlink.Dial(nil)
+}
+
+// taskstatsReader queries the kernel's delay accounting via the taskstats
+// generic netlink interface, see
+// https://docs.kernel.org/accounting/taskstats.html
+type taskstatsReader struct {
+	conn   *genetlink.Conn
+	family genetlink.Family
+}
+
+func newDelayReader() (delayReader, error) {
+	conn, err := dialTaskstats()
+	if err != nil {
+		return nil, fmt.Errorf("connecting to generic netlink failed: %w", err)
+	}
+
+	family, err := conn.GetFamily(unix.TASKSTATS_GENL_NAME)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("getting taskstats family failed: %w", err)
+	}
+
+	return &taskstatsReader{conn: conn, family: family}, nil
+}
+
+func (r *taskstatsReader) process(id int32) (*taskDelays, error) {
+	return r.query(unix.TASKSTATS_CMD_ATTR_TGID, unix.TASKSTATS_TYPE_AGGR_TGID, id)
+}
+
+func (r *taskstatsReader) thread(id int32) (*taskDelays, error) {
+	return r.query(unix.TASKSTATS_CMD_ATTR_PID, unix.TASKSTATS_TYPE_AGGR_PID, id)
+}
+
+func (r *taskstatsReader) close() error {
+	return r.conn.Close()
+}
+
+func (r *taskstatsReader) query(attr, aggregate uint16, id int32) (*taskDelays, error) {
+	ae := netlink.NewAttributeEncoder()
+	ae.Uint32(attr, uint32(id))
+	data, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	req := genetlink.Message{
+		Header: genetlink.Header{
+			Command: unix.TASKSTATS_CMD_GET,
+			Version: r.family.Version,
+		},
+		Data: data,
+	}
+	msgs, err := r.conn.Execute(req, r.family.ID, netlink.Request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range msgs {
+		delays, err := parseTaskstatsMessage(msg.Data, aggregate)
+		if err != nil {
+			return nil, err
+		}
+		if delays != nil {
+			return delays, nil
+		}
+	}
+	return nil, errors.New("no statistics in response")
+}
+
+func parseTaskstatsMessage(data []byte, aggregate uint16) (*taskDelays, error) {
+	ad, err := netlink.NewAttributeDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var delays *taskDelays
+	for ad.Next() {
+		if ad.Type() != aggregate {
+			continue
+		}
+		ad.Nested(func(nad *netlink.AttributeDecoder) error {
+			for nad.Next() {
+				if nad.Type() != unix.TASKSTATS_TYPE_STATS {
+					continue
+				}
+				d, err := parseTaskstats(nad.Bytes())
+				if err != nil {
+					return err
+				}
+				delays = d
+			}
+			return nil
+		})
+	}
+	if err := ad.Err(); err != nil {
+		return nil, err
+	}
+	return delays, nil
+}
+
+// parseTaskstats decodes the delay accounting fields of the kernel's
+// 'struct taskstats' which is encoded in native byte order
+func parseTaskstats(buf []byte) (*taskDelays, error) {
+	if len(buf) < taskstatsMinSize {
+		return nil, fmt.Errorf("taskstats too short (%d bytes)", len(buf))
+	}
+
+	return &taskDelays{
+		cpuCount:    binary.NativeEndian.Uint64(buf[16:24]),
+		cpuDelay:    binary.NativeEndian.Uint64(buf[24:32]),
+		blkioCount:  binary.NativeEndian.Uint64(buf[32:40]),
+		blkioDelay:  binary.NativeEndian.Uint64(buf[40:48]),
+		swapinCount: binary.NativeEndian.Uint64(buf[48:56]),
+		swapinDelay: binary.NativeEndian.Uint64(buf[56:64]),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package procstat
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/genetlink/genltest"
+	"github.com/mdlayher/netlink"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestTaskstatsReader(t *testing.T) {
+	family := genetlink.Family{
+		ID:      26,
+		Name:    unix.TASKSTATS_GENL_NAME,
+		Version: unix.TASKSTATS_GENL_VERSION,
+	}
+
+	fn := func(greq genetlink.Message, _ netlink.Message) ([]genetlink.Message, error) {
+		ad, err := netlink.NewAttributeDecoder(greq.Data)
+		if err != nil {
+			return nil, err
+		}
+		require.True(t, ad.Next())
+		id := ad.Uint32()
+
+		var aggregate uint16
+		switch ad.Type() {
+		case unix.TASKSTATS_CMD_ATTR_TGID:
+			aggregate = unix.TASKSTATS_TYPE_AGGR_TGID
+		case unix.TASKSTATS_CMD_ATTR_PID:
+			aggregate = unix.TASKSTATS_TYPE_AGGR_PID
+		}
+		if id == 404 {
+			return nil, genltest.Error(int(unix.ESRCH))
+		}
+
+		// Encode a version 8 taskstats structure with the delays derived from
+		// the requested ID
+		buf := make([]byte, 328)
+		binary.NativeEndian.PutUint16(buf[0:2], 8)
+		for i := range 6 {
+			binary.NativeEndian.PutUint64(buf[16+8*i:24+8*i], uint64(id)*10+uint64(i))
+		}
+
+		ae := netlink.NewAttributeEncoder()
+		ae.Nested(aggregate, func(nae *netlink.AttributeEncoder) error {
+			nae.Uint32(unix.TASKSTATS_TYPE_PID, id)
+			nae.Bytes(unix.TASKSTATS_TYPE_STATS, buf)
+			return nil
+		})
+		data, err := ae.Encode()
+		if err != nil {
+			return nil, err
+		}
+		return []genetlink.Message{{Data: data}}, nil
+	}
+
+	dialTaskstats = func() (*genetlink.Conn, error) {
+		return genltest.Dial(genltest.ServeFamily(family, fn)), nil
+	}
+	defer func() {
+		dialTaskstats = func() (*genetlink.Conn, error) {
+			return genetlink.Dial(nil)
+		}
+	}()
+
+	reader, err := newDelayReader()
+	require.NoError(t, err)
+	defer reader.close()
+
+	delays, err := reader.process(42)
+	require.NoError(t, err)
+	require.Equal(t, &taskDelays{
+		cpuCount:    420,
+		cpuDelay:    421,
+		blkioCount:  422,
+		blkioDelay:  423,
+		swapinCount: 424,
+		swapinDelay: 425,
+	}, delays)
+
+	delays, err = reader.thread(7)
+	require.NoError(t, err)
+	require.Equal(t, uint64(70), delays.cpuCount)
+	require.Equal(t, uint64(75), delays.swapinDelay)
+
+	_, err = reader.process(404)
+	require.Error(t, err)
+}
+
+func TestParseTaskstatsTooShort(t *testing.T) {
+	_, err := parseTaskstats(make([]byte, 32))
+	require.ErrorContains(t, err, "too short")
+}
@@ -0,0 +1,439 @@
+package snmp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/snmp"
+)
+
+const (
+	oidSysDescr    = ".1.3.6.1.2.1.1.1.0"
+	oidSysObjectID = ".1.3.6.1.2.1.1.2.0"
+
+	// Maximum number of addresses to sweep per network to prevent accidental
+	// sweeps of huge networks
+	maxNetworkSize = 1 << 16
+)
+
+type discoveryConfig struct {
+	Networks      []string        `toml:"networks"`
+	InventoryFile string          `toml:"inventory_file"`
+	Port          uint16          `toml:"port"`
+	Interval      config.Duration `toml:"interval"`
+	Timeout       config.Duration `toml:"timeout"`
+	Concurrency   int             `toml:"concurrency"`
+	Credentials   []credential    `toml:"credential"`
+}
+
+// credential is a set of parameters to probe devices with
+type credential struct {
+	Version      uint8         `toml:"version"`
+	Community    string        `toml:"community"`
+	ContextName  string        `toml:"context_name"`
+	SecLevel     string        `toml:"sec_level"`
+	SecName      string        `toml:"sec_name"`
+	AuthProtocol string        `toml:"auth_protocol"`
+	AuthPassword config.Secret `toml:"auth_password"`
+	PrivProtocol string        `toml:"priv_protocol"`
+	PrivPassword config.Secret `toml:"priv_password"`
+}
+
+// profile defines what to collect from devices matching the given
+// sysObjectID prefixes
+type profile struct {
+	Name         string       `toml:"name"`
+	SysObjectIDs []string     `toml:"sys_object_ids"`
+	Fields       []snmp.Field `toml:"field"`
+	Tables       []snmp.Table `toml:"table"`
+}
+
+// target is a discovered device
+type target struct {
+	agent       string
+	credential  int
+	sysObjectID string
+	sysDescr    string
+	profile     *profile
+	cfg         snmp.ClientConfig
+
+	// mu guards the connection and is held while gathering the target
+	mu   sync.Mutex
+	conn snmp.Connection
+}
+
+// connectFunc creates a connection to the given agent
+type connectFunc func(agent string, cfg snmp.ClientConfig) (snmp.Connection, error)
+
+func connect(agent string, cfg snmp.ClientConfig) (snmp.Connection, error) {
+	gs, err := snmp.NewWrapper(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := gs.SetAgent(agent); err != nil {
+		return nil, err
+	}
+	if err := gs.Connect(); err != nil {
+		return nil, fmt.Errorf("setting up connection: %w", err)
+	}
+	return gs, nil
+}
+
+func (d *discoveryConfig) init() error {
+	if len(d.Networks) == 0 && d.InventoryFile == "" {
+		return errors.New("discovery requires 'networks' or 'inventory_file'")
+	}
+	for _, n := range d.Networks {
+		prefix, err := netip.ParsePrefix(n)
+		if err != nil {
+			return fmt.Errorf("invalid network %q: %w", n, err)
+		}
+		if hostBits := prefix.Addr().BitLen() - prefix.Bits(); hostBits > 16 {
+			return fmt.Errorf("network %q exceeds the maximum of %d addresses", n, maxNetworkSize)
+		}
+	}
+	for i, c := range d.Credentials {
+		switch c.Version {
+		case 1, 2, 3:
+		default:
+			return fmt.Errorf("invalid version %d for credential %d", c.Version, i+1)
+		}
+	}
+
+	if d.Port == 0 {
+		d.Port = 161
+	}
+	if d.Interval <= 0 {
+		d.Interval = config.Duration(time.Hour)
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = config.Duration(2 * time.Second)
+	}
+	if d.Concurrency <= 0 {
+		d.Concurrency = 64
+	}
+
+	return nil
+}
+
+// candidates returns the list of agents to probe
+func (d *discoveryConfig) candidates() ([]string, error) {
+	var agents []string
+	seen := make(map[string]bool)
+	add := func(agent string) {
+		if !seen[agent] {
+			seen[agent] = true
+			agents = append(agents, agent)
+		}
+	}
+
+	port := strconv.FormatUint(uint64(d.Port), 10)
+	for _, n := range d.Networks {
+		prefix, err := netip.ParsePrefix(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", n, err)
+		}
+		prefix = prefix.Masked()
+		for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+			// Skip the network and broadcast addresses of IPv4 networks
+			if addr.Is4() && prefix.Bits() < 31 && (addr == prefix.Addr() || !prefix.Contains(addr.Next())) {
+				continue
+			}
+			add("udp://" + net.JoinHostPort(addr.String(), port))
+		}
+	}
+
+	if d.InventoryFile != "" {
+		f, err := os.Open(d.InventoryFile)
+		if err != nil {
+			return nil, fmt.Errorf("opening inventory failed: %w", err)
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if !strings.Contains(line, "://") {
+				line = "udp://" + line
+			}
+			if _, _, err := net.SplitHostPort(strings.SplitN(line, "://", 2)[1]); err != nil {
+				line += ":" + port
+			}
+			add(line)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading inventory failed: %w", err)
+		}
+	}
+
+	return agents, nil
+}
+
+// clientConfig returns the configuration for the given credential based
+// on the plugin-level configuration
+func (s *Snmp) clientConfig(idx int) snmp.ClientConfig {
+	cfg := s.ClientConfig
+	if idx < 0 || idx >= len(s.Discovery.Credentials) {
+		return cfg
+	}
+
+	c := s.Discovery.Credentials[idx]
+	cfg.Version = c.Version
+	cfg.Community = c.Community
+	cfg.ContextName = c.ContextName
+	cfg.SecLevel = c.SecLevel
+	cfg.SecName = c.SecName
+	cfg.AuthProtocol = c.AuthProtocol
+	cfg.AuthPassword = c.AuthPassword
+	cfg.PrivProtocol = c.PrivProtocol
+	cfg.PrivPassword = c.PrivPassword
+	return cfg
+}
+
+// probe tries the configured credentials in order and returns the first
+// target successfully answering the system information request
+func (s *Snmp) probe(agent string) (*target, error) {
+	// Use the plugin-level credentials if none are given
+	indices := []int{-1}
+	if len(s.Discovery.Credentials) > 0 {
+		indices = make([]int, 0, len(s.Discovery.Credentials))
+		for i := range s.Discovery.Credentials {
+			indices = append(indices, i)
+		}
+	}
+
+	var errs []error
+	for _, idx := range indices {
+		cfg := s.clientConfig(idx)
+		cfg.Timeout = s.Discovery.Timeout
+		cfg.Retries = 0
+		cfg.GosnmpDebugLogger = nil
+
+		conn, err := s.connect(agent, cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		packet, err := conn.Get([]string{oidSysObjectID, oidSysDescr})
+		closeConnection(conn)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		t := &target{agent: agent, credential: idx}
+		for _, v := range packet.Variables {
+			switch v.Type {
+			case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+				continue
+			}
+			switch v.Name {
+			case oidSysObjectID:
+				if oid, ok := v.Value.(string); ok {
+					t.sysObjectID = "." + strings.TrimPrefix(oid, ".")
+				}
+			case oidSysDescr:
+				switch value := v.Value.(type) {
+				case []byte:
+					t.sysDescr = string(value)
+				case string:
+					t.sysDescr = value
+				}
+			}
+		}
+		if t.sysObjectID == "" {
+			errs = append(errs, errors.New("no sysObjectID in response"))
+			continue
+		}
+		return t, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// matchProfile returns the profile with the longest sysObjectID prefix
+// matching the given OID
+func (s *Snmp) matchProfile(oid string) *profile {
+	var match *profile
+	var length int
+	for i := range s.Profiles {
+		p := &s.Profiles[i]
+		for _, prefix := range p.SysObjectIDs {
+			prefix = "." + strings.Trim(prefix, ".")
+			if oid != prefix && !strings.HasPrefix(oid, prefix+".") {
+				continue
+			}
+			if len(prefix) > length {
+				match = p
+				length = len(prefix)
+			}
+		}
+	}
+	return match
+}
+
+// discover probes all candidates and returns the list of devices that
+// respond and either match a profile or can be polled using the plugin-level
+// fields and tables
+func (s *Snmp) discover(ctx context.Context) ([]*target, error) {
+	candidates, err := s.Discovery.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	// Statically configured agents are already polled
+	static := make(map[string]bool, len(s.Agents))
+	for _, agent := range s.Agents {
+		static[normalizeAgent(agent)] = true
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	found := make([]*target, 0)
+	sem := make(chan struct{}, s.Discovery.Concurrency)
+	for _, agent := range candidates {
+		if static[normalizeAgent(agent)] {
+			s.Log.Tracef("Skipping %q as it is configured in 'agents'", agent)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(agent string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			t, err := s.probe(agent)
+			if err != nil {
+				s.Log.Tracef("Probing %q failed: %v", agent, err)
+				return
+			}
+
+			t.profile = s.matchProfile(t.sysObjectID)
+			if t.profile == nil && len(s.Fields) == 0 && len(s.Tables) == 0 {
+				s.Log.Debugf("No profile matches %q with sysObjectID %q", agent, t.sysObjectID)
+				return
+			}
+			t.cfg = s.clientConfig(t.credential)
+			if t.profile != nil {
+				s.Log.Debugf("Discovered %q (%s) using profile %q", agent, t.sysDescr, t.profile.Name)
+			} else {
+				s.Log.Debugf("Discovered %q (%s) using default fields and tables", agent, t.sysDescr)
+			}
+
+			mu.Lock()
+			found = append(found, t)
+			mu.Unlock()
+		}(agent)
+	}
+	wg.Wait()
+
+	sort.Slice(found, func(i, j int) bool { return found[i].agent < found[j].agent })
+
+	return found, nil
+}
+
+// normalizeAgent returns the agent address including the transport and port
+// with the same defaults as used for connecting to allow comparing addresses
+func normalizeAgent(agent string) string {
+	if !strings.Contains(agent, "://") {
+		agent = "udp://" + agent
+	}
+	u, err := url.Parse(agent)
+	if err != nil {
+		return agent
+	}
+
+	transport := strings.TrimRight(u.Scheme, "46")
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		host = addr.String()
+	}
+	port := u.Port()
+	if port == "" {
+		port = "161"
+	}
+	return transport + "://" + net.JoinHostPort(host, port)
+}
+
+// updateTargets replaces the list of discovered targets while keeping the
+// connections of unchanged targets and closing all others
+func (s *Snmp) updateTargets(targets []*target) {
+	s.targetsMu.Lock()
+	defer s.targetsMu.Unlock()
+
+	existing := make(map[string]*target, len(s.targets))
+	for _, t := range s.targets {
+		existing[t.agent] = t
+	}
+	for _, t := range targets {
+		if prev, found := existing[t.agent]; found && prev.credential == t.credential {
+			prev.mu.Lock()
+			t.conn = prev.conn
+			prev.conn = nil
+			prev.mu.Unlock()
+		}
+	}
+	for _, prev := range s.targets {
+		prev.mu.Lock()
+		closeConnection(prev.conn)
+		prev.conn = nil
+		prev.mu.Unlock()
+	}
+	s.targets = targets
+}
+
+// closeConnection closes the given connection if supported
+func closeConnection(conn snmp.Connection) {
+	if c, ok := conn.(interface{ Close() error }); ok {
+		_ = c.Close()
+	}
+}
+
+func (s *Snmp) runDiscovery(ctx context.Context, acc telegraf.Accumulator) {
+	ticker := time.NewTicker(time.Duration(s.Discovery.Interval))
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		targets, err := s.discover(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return
+		case err != nil:
+			acc.AddError(fmt.Errorf("discovery failed: %w", err))
+		default:
+			s.updateTargets(targets)
+			s.Log.Debugf("Discovered %d devices in %s", len(targets), time.Since(start))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/snmp"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDiscoveryInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      discoveryConfig
+		expected string
+	}{
+		{
+			name: "network",
+			cfg:  discoveryConfig{Networks: []string{"10.0.0.0/24"}},
+		},
+		{
+			name:     "no sources",
+			expected: "requires 'networks' or 'inventory_file'",
+		},
+		{
+			name:     "invalid network",
+			cfg:      discoveryConfig{Networks: []string{"10.0.0.0/33"}},
+			expected: "invalid network",
+		},
+		{
+			name:     "network too large",
+			cfg:      discoveryConfig{Networks: []string{"10.0.0.0/8"}},
+			expected: "exceeds the maximum",
+		},
+		{
+			name: "invalid credential",
+			cfg: discoveryConfig{
+				Networks:    []string{"10.0.0.0/24"},
+				Credentials: []credential{{Version: 4}},
+			},
+			expected: "invalid version 4 for credential 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.init()
+			if tt.expected != "" {
+				require.ErrorContains(t, err, tt.expected)
+				return
+			}
+			require.NoError(t, err)
+			require.EqualValues(t, 161, tt.cfg.Port)
+			require.Equal(t, 64, tt.cfg.Concurrency)
+		})
+	}
+}
+
+func TestDiscoveryCandidates(t *testing.T) {
+	inventory := filepath.Join(t.TempDir(), "inventory.txt")
+	content := "# switches\n10.0.1.1\n\ntcp://10.0.1.2:1161\n10.0.0.2\n"
+	require.NoError(t, os.WriteFile(inventory, []byte(content), 0600))
+
+	d := &discoveryConfig{
+		Networks:      []string{"10.0.0.0/30", "2001:db8::/127"},
+		InventoryFile: inventory,
+	}
+	require.NoError(t, d.init())
+
+	candidates, err := d.candidates()
+	require.NoError(t, err)
+
+	expected := []string{
+		"udp://10.0.0.1:161",
+		"udp://10.0.0.2:161",
+		"udp://[2001:db8::]:161",
+		"udp://[2001:db8::1]:161",
+		"udp://10.0.1.1:161",
+		"tcp://10.0.1.2:1161",
+	}
+	require.Equal(t, expected, candidates)
+}
+
+func TestDiscoveryMatchProfile(t *testing.T) {
+	s := &Snmp{
+		Profiles: []profile{
+			{Name: "cisco", SysObjectIDs: []string{".1.3.6.1.4.1.9"}},
+			{Name: "cisco-catalyst", SysObjectIDs: []string{"1.3.6.1.4.1.9.1.1208", ".1.3.6.1.4.1.9.1.2"}},
+			{Name: "juniper", SysObjectIDs: []string{".1.3.6.1.4.1.2636"}},
+		},
+	}
+
+	tests := []struct {
+		oid      string
+		expected string
+	}{
+		{oid: ".1.3.6.1.4.1.9.1.516", expected: "cisco"},
+		{oid: ".1.3.6.1.4.1.9.1.1208", expected: "cisco-catalyst"},
+		{oid: ".1.3.6.1.4.1.9.1.2", expected: "cisco-catalyst"},
+		{oid: ".1.3.6.1.4.1.9.1.20", expected: "cisco"},
+		{oid: ".1.3.6.1.4.1.2636.1.1.1.2.29", expected: "juniper"},
+		{oid: ".1.3.6.1.4.1.26366", expected: ""},
+		{oid: ".1.3.6.1.4.1.99", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.oid, func(t *testing.T) {
+			p := s.matchProfile(tt.oid)
+			if tt.expected == "" {
+				require.Nil(t, p)
+				return
+			}
+			require.NotNil(t, p)
+			require.Equal(t, tt.expected, p.Name)
+		})
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	devices := map[string]*testSNMPConnection{
+		"udp://10.0.0.1:161": {
+			host: "10.0.0.1",
+			values: map[string]interface{}{
+				oidSysObjectID: ".1.3.6.1.4.1.9.1.1208",
+				oidSysDescr:    []byte("Cisco IOS Software"),
+				".1.0.0.1.1":   "switch-1",
+			},
+		},
+		"udp://10.0.0.2:161": {
+			host: "10.0.0.2",
+			values: map[string]interface{}{
+				oidSysObjectID: ".1.3.6.1.4.1.2636.1.1.1.2.29",
+				oidSysDescr:    []byte("Juniper Networks"),
+				".1.0.0.1.2":   234,
+			},
+		},
+		"udp://10.0.0.3:161": {
+			host: "10.0.0.3",
+			values: map[string]interface{}{
+				oidSysObjectID: ".1.3.6.1.4.1.99.1",
+				oidSysDescr:    []byte("Unknown device"),
+			},
+		},
+	}
+
+	// Device 10.0.0.2 only responds to the SNMPv3 credential
+	connect := func(agent string, cfg snmp.ClientConfig) (snmp.Connection, error) {
+		dev, found := devices[agent]
+		if !found {
+			return nil, errors.New("timeout")
+		}
+		if dev.host == "10.0.0.2" && cfg.Version != 3 {
+			return &failingConnection{host: dev.host}, nil
+		}
+		return dev, nil
+	}
+
+	s := &Snmp{
+		Name:         "snmp",
+		AgentHostTag: "source",
+		Discovery: &discoveryConfig{
+			Networks: []string{"10.0.0.0/29"},
+			Credentials: []credential{
+				{Version: 2, Community: "public"},
+				{Version: 3, SecName: "myuser"},
+			},
+		},
+		Profiles: []profile{
+			{
+				Name:         "cisco",
+				SysObjectIDs: []string{".1.3.6.1.4.1.9"},
+				Fields:       []snmp.Field{{Name: "name", Oid: ".1.0.0.1.1"}},
+			},
+			{
+				Name:         "juniper",
+				SysObjectIDs: []string{".1.3.6.1.4.1.2636"},
+				Fields:       []snmp.Field{{Name: "value", Oid: ".1.0.0.1.2"}},
+			},
+		},
+		Log:     testutil.Logger{},
+		connect: connect,
+	}
+	require.NoError(t, s.Discovery.init())
+
+	targets, err := s.discover(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 2)
+	require.Equal(t, "udp://10.0.0.1:161", targets[0].agent)
+	require.Equal(t, "cisco", targets[0].profile.Name)
+	require.Equal(t, 0, targets[0].credential)
+	require.Equal(t, "udp://10.0.0.2:161", targets[1].agent)
+	require.Equal(t, "juniper", targets[1].profile.Name)
+	require.Equal(t, 1, targets[1].credential)
+	require.EqualValues(t, 3, targets[1].cfg.Version)
+	s.updateTargets(targets)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"snmp",
+			map[string]string{"source": "10.0.0.1"},
+			map[string]interface{}{"name": "switch-1"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"snmp",
+			map[string]string{"source": "10.0.0.2"},
+			map[string]interface{}{"value": 234},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// Devices without profile are polled with the plugin-level fields
+	s.Fields = []snmp.Field{{Name: "descr", Oid: oidSysDescr}}
+	targets, err = s.discover(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 3)
+	require.Nil(t, targets[2].profile)
+}
+
+func TestDiscoverySkipsStaticAgents(t *testing.T) {
+	devices := map[string]*testSNMPConnection{
+		"udp://10.0.0.1:161": {
+			host:   "10.0.0.1",
+			values: map[string]interface{}{oidSysObjectID: ".1.3.6.1.4.1.9.1.1208"},
+		},
+		"udp://10.0.0.2:161": {
+			host:   "10.0.0.2",
+			values: map[string]interface{}{oidSysObjectID: ".1.3.6.1.4.1.9.1.1208"},
+		},
+	}
+
+	var probed []string
+	s := &Snmp{
+		Agents:    []string{"10.0.0.1"},
+		Discovery: &discoveryConfig{Networks: []string{"10.0.0.0/29"}},
+		Fields:    []snmp.Field{{Name: "descr", Oid: oidSysDescr}},
+		Log:       testutil.Logger{},
+		connect: func(agent string, _ snmp.ClientConfig) (snmp.Connection, error) {
+			dev, found := devices[agent]
+			if !found {
+				return nil, errors.New("timeout")
+			}
+			probed = append(probed, agent)
+			return dev, nil
+		},
+	}
+	require.NoError(t, s.Discovery.init())
+	s.Discovery.Concurrency = 1
+
+	targets, err := s.discover(context.Background())
+	require.NoError(t, err)
+	require.Len(t, targets, 1)
+	require.Equal(t, "udp://10.0.0.2:161", targets[0].agent)
+	require.Equal(t, []string{"udp://10.0.0.2:161"}, probed)
+}
+
+func TestNormalizeAgent(t *testing.T) {
+	tests := []struct {
+		agent    string
+		expected string
+	}{
+		{agent: "10.0.0.1", expected: "udp://10.0.0.1:161"},
+		{agent: "10.0.0.1:1161", expected: "udp://10.0.0.1:1161"},
+		{agent: "udp4://10.0.0.1", expected: "udp://10.0.0.1:161"},
+		{agent: "tcp://10.0.0.1:161", expected: "tcp://10.0.0.1:161"},
+		{agent: "udp6://[2001:db8:0::1]", expected: "udp://[2001:db8::1]:161"},
+		{agent: "switch.example.com", expected: "udp://switch.example.com:161"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agent, func(t *testing.T) {
+			require.Equal(t, tt.expected, normalizeAgent(tt.agent))
+		})
+	}
+}
+
+func TestDiscoveryUpdateKeepsConnections(t *testing.T) {
+	s := &Snmp{}
+	conn := &closingConnection{}
+	s.updateTargets([]*target{{agent: "udp://10.0.0.1:161", conn: conn}})
+
+	s.updateTargets([]*target{{agent: "udp://10.0.0.1:161"}, {agent: "udp://10.0.0.2:161"}})
+	require.Len(t, s.targets, 2)
+	require.Same(t, conn, s.targets[0].conn)
+	require.Nil(t, s.targets[1].conn)
+	require.False(t, conn.closed)
+
+	// Connections must not be reused if the credential changed
+	s.updateTargets([]*target{{agent: "udp://10.0.0.1:161", credential: 1}})
+	require.Len(t, s.targets, 1)
+	require.Nil(t, s.targets[0].conn)
+	require.True(t, conn.closed)
+}
+
+func TestDiscoveryUpdateClosesVanishedTargets(t *testing.T) {
+	s := &Snmp{}
+	conn := &closingConnection{}
+	s.updateTargets([]*target{{agent: "udp://10.0.0.1:161", conn: conn}})
+
+	s.updateTargets([]*target{{agent: "udp://10.0.0.2:161"}})
+	require.Len(t, s.targets, 1)
+	require.True(t, conn.closed)
+}
+
+type closingConnection struct {
+	testSNMPConnection
+	closed bool
+}
+
+func (c *closingConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+type failingConnection struct {
+	host string
+}
+
+func (c *failingConnection) Host() string {
+	return c.host
+}
+
+func (*failingConnection) Walk(string, gosnmp.WalkFunc) error {
+	return errors.New("authentication failure")
+}
+
+func (*failingConnection) Get([]string) (*gosnmp.SnmpPacket, error) {
+	return nil, errors.New("authentication failure")
+}
+
+func (*failingConnection) Reconnect() error {
+	return nil
+}
@@ -2,6 +2,7 @@
 package snmp
 
 import (
+	"context"
 	_ "embed"
 	"errors"
 	"fmt"
@@ -38,11 +39,21 @@ type Snmp struct {
 	Name   string       `toml:"name"`
 	Fields []snmp.Field `toml:"field"`
 
+	// Discovery of agents and the profiles to apply to discovered agents
+	Discovery *discoveryConfig `toml:"discovery"`
+	Profiles  []profile        `toml:"profile"`
+
 	Log telegraf.Logger `toml:"-"`
 
 	connectionCache []snmp.Connection
 
 	translator snmp.Translator
+
+	connect   connectFunc
+	targets   []*target
+	targetsMu sync.Mutex
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
 }
 
 func (*Snmp) SampleConfig() string {
@@ -81,6 +92,38 @@ func (s *Snmp) Init() error {
 		}
 	}
 
+	for i := range s.Profiles {
+		p := &s.Profiles[i]
+		if p.Name == "" {
+			return fmt.Errorf("profile %d has no name", i+1)
+		}
+		if len(p.SysObjectIDs) == 0 {
+			return fmt.Errorf("profile %s has no sysObjectIDs", p.Name)
+		}
+		for j := range p.Tables {
+			if err := p.Tables[j].Init(s.translator); err != nil {
+				return fmt.Errorf("initializing table %s of profile %s: %w", p.Tables[j].Name, p.Name, err)
+			}
+		}
+		for j := range p.Fields {
+			if err := p.Fields[j].Init(s.translator); err != nil {
+				return fmt.Errorf("initializing field %s of profile %s: %w", p.Fields[j].Name, p.Name, err)
+			}
+		}
+	}
+
+	if s.Discovery != nil {
+		if err := s.Discovery.init(); err != nil {
+			return err
+		}
+		if len(s.Profiles) == 0 && len(s.Fields) == 0 && len(s.Tables) == 0 {
+			return errors.New("discovery requires profiles or fields and tables to collect")
+		}
+	}
+	if s.connect == nil {
+		s.connect = connect
+	}
+
 	if len(s.AgentHostTag) == 0 {
 		s.AgentHostTag = "agent_host"
 	}
@@ -96,6 +139,39 @@ func (s *Snmp) Init() error {
 	return nil
 }
 
+func (s *Snmp) Start(acc telegraf.Accumulator) error {
+	if s.Discovery == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runDiscovery(ctx, acc)
+	}()
+
+	return nil
+}
+
+func (s *Snmp) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+
+	s.targetsMu.Lock()
+	defer s.targetsMu.Unlock()
+	for _, t := range s.targets {
+		t.mu.Lock()
+		closeConnection(t.conn)
+		t.conn = nil
+		t.mu.Unlock()
+	}
+}
+
 func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 	for i, agent := range s.Agents {
@@ -107,36 +183,61 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
 				return
 			}
+			s.gatherAgent(acc, gs, agent, s.Fields, s.Tables)
+		}(i, agent)
+	}
 
-			// First is the top-level fields. We treat the fields as table prefixes with an empty index.
-			t := snmp.Table{
-				Name:   s.Name,
-				Fields: s.Fields,
+	s.targetsMu.Lock()
+	targets := s.targets
+	s.targetsMu.Unlock()
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t *target) {
+			defer wg.Done()
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			gs, err := s.getTargetConnection(t)
+			if err != nil {
+				acc.AddError(fmt.Errorf("agent %s: %w", t.agent, err))
+				return
 			}
-			topTags := make(map[string]string)
-			if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
-				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
-				if s.StopOnError {
-					return
-				}
+			if t.profile == nil {
+				s.gatherAgent(acc, gs, t.agent, s.Fields, s.Tables)
+			} else {
+				s.gatherAgent(acc, gs, t.agent, t.profile.Fields, t.profile.Tables)
 			}
-
-			// Now is the real tables.
-			for _, t := range s.Tables {
-				if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
-					acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
-					if s.StopOnError {
-						return
-					}
-				}
-			}
-		}(i, agent)
+		}(t)
 	}
 	wg.Wait()
 
 	return nil
 }
 
+func (s *Snmp) gatherAgent(acc telegraf.Accumulator, gs snmp.Connection, agent string, fields []snmp.Field, tables []snmp.Table) {
+	// First is the top-level fields. We treat the fields as table prefixes with an empty index.
+	t := snmp.Table{
+		Name:   s.Name,
+		Fields: fields,
+	}
+	topTags := make(map[string]string)
+	if err := s.gatherTable(acc, gs, t, topTags, false); err != nil {
+		acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
+		if s.StopOnError {
+			return
+		}
+	}
+
+	// Now is the real tables.
+	for _, t := range tables {
+		if err := s.gatherTable(acc, gs, t, topTags, true); err != nil {
+			acc.AddError(fmt.Errorf("agent %s: gathering table %s: %w", agent, t.Name, err))
+			if s.StopOnError {
+				return
+			}
+		}
+	}
+}
+
 func (s *Snmp) gatherTable(acc telegraf.Accumulator, gs snmp.Connection, t snmp.Table, topTags map[string]string, walk bool) error {
 	rt, err := t.Build(gs, walk)
 	if err != nil {
@@ -200,6 +301,28 @@ func (s *Snmp) getConnection(idx int) (snmp.Connection, error) {
 	return gs, nil
 }
 
+// getTargetConnection returns the connection of a discovered target creating
+// it if necessary. The lock of the target must be held while calling this
+// function and while using the connection.
+func (s *Snmp) getTargetConnection(t *target) (snmp.Connection, error) {
+	if t.conn != nil {
+		if err := t.conn.Reconnect(); err != nil {
+			return t.conn, fmt.Errorf("reconnecting: %w", err)
+		}
+		return t.conn, nil
+	}
+
+	cfg := t.cfg
+	cfg.GosnmpDebugLogger = s.Log
+	conn, err := s.connect(t.agent, cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.conn = conn
+
+	return conn, nil
+}
+
 func init() {
 	inputs.Add("snmp", func() telegraf.Input {
 		return &Snmp{
@@ -0,0 +1,492 @@
+package snmp_trap
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+const (
+	oidUsmStatsNotInTimeWindows = ".1.3.6.1.6.3.15.1.1.2.0"
+	oidUsmStatsUnknownEngineIDs = ".1.3.6.1.6.3.15.1.1.4.0"
+
+	// Maximum difference of the engine time in seconds accepted for
+	// authenticated confirmed-class messages according to RFC 3414
+	timeWindow = 150
+
+	// Bounds of the delay between retries after failing to read a message
+	minReadBackoff = 10 * time.Millisecond
+	maxReadBackoff = time.Second
+)
+
+// userParams contains the parameters to decode messages of a single USM user
+type userParams struct {
+	engineID string
+	params   *gosnmp.GoSNMP
+}
+
+// engine describes the local SNMP engine that is authoritative for
+// confirmed-class messages such as INFORM requests
+type engine struct {
+	id    string
+	boots uint32
+	start time.Time
+}
+
+func (e *engine) time() uint32 {
+	return uint32(time.Since(e.start).Seconds())
+}
+
+// trapListener receives traps and informs and implements the USM engine
+// discovery, time synchronization and user selection procedures required
+// for SNMPv3 INFORM requests and multiple users.
+type trapListener struct {
+	// Params contains the parameters for decoding v1, v2c and single-user
+	// v3 messages
+	Params *gosnmp.GoSNMP
+
+	handler gosnmp.TrapHandlerFunc
+	log     telegraf.Logger
+	engine  *engine
+	secName string
+	users   map[string][]*userParams
+	conn    *net.UDPConn
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	unknownUsers     selfstat.Stat
+	authFailures     selfstat.Stat
+	unknownEngineIDs selfstat.Stat
+	notInTimeWindows selfstat.Stat
+}
+
+func (l *trapListener) listen(address string) error {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return err
+	}
+	l.conn = conn
+	l.done = make(chan struct{})
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		l.read()
+	}()
+
+	return nil
+}
+
+func (l *trapListener) close() {
+	if l.done != nil {
+		close(l.done)
+	}
+	if l.conn != nil {
+		_ = l.conn.Close()
+	}
+	l.wg.Wait()
+}
+
+func (l *trapListener) read() {
+	buf := make([]byte, 64*1024)
+	var backoff time.Duration
+	for {
+		n, addr, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			select {
+			case <-l.done:
+				return
+			default:
+			}
+
+			// Back off to avoid spinning on persistent errors
+			l.log.Errorf("Reading failed: %v", err)
+			backoff = min(max(2*backoff, minReadBackoff), maxReadBackoff)
+			select {
+			case <-l.done:
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+		msg := make([]byte, n)
+		copy(msg, buf[:n])
+
+		if err := l.process(msg, addr); err != nil {
+			l.log.Debugf("Processing message from %s failed: %v", addr.IP, err)
+		}
+	}
+}
+
+func (l *trapListener) process(msg []byte, addr *net.UDPAddr) error {
+	// Check the USM header of SNMPv3 messages before decoding the message
+	// to be able to handle engine discovery and to select the user
+	var header *usmHeader
+	if version, err := messageVersion(msg); err == nil && version == 3 {
+		h, err := parseUSMHeader(msg)
+		if err != nil {
+			return fmt.Errorf("parsing header failed: %w", err)
+		}
+		header = h
+	}
+
+	// Discovery requests use an empty user without authentication and
+	// request the authoritative engine ID, boots and time in a report
+	if header != nil && header.isDiscovery() {
+		l.unknownEngineIDs.Incr(1)
+		return l.sendDiscoveryReport(header, addr)
+	}
+
+	var packet *gosnmp.SnmpPacket
+	var err error
+	if header != nil && len(l.users) > 0 {
+		packet, err = l.unmarshalUser(msg, header)
+	} else {
+		packet, err = l.Params.UnmarshalTrap(msg, false)
+		if err != nil && header != nil {
+			if header.user != l.secName {
+				l.unknownUsers.Incr(1)
+			} else {
+				l.authFailures.Incr(1)
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	switch packet.PDUType {
+	case gosnmp.Trap, gosnmp.SNMPv2Trap:
+		l.handler(packet, addr)
+		return nil
+	case gosnmp.InformRequest:
+	default:
+		return fmt.Errorf("unsupported PDU type %v", packet.PDUType)
+	}
+
+	// We are the authoritative engine for INFORM requests so check the
+	// engine ID and timeliness of the message
+	if packet.Version == gosnmp.Version3 {
+		sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		if !ok {
+			return errors.New("invalid security parameters")
+		}
+		if sp.AuthoritativeEngineID != l.engine.id {
+			l.unknownEngineIDs.Incr(1)
+			return l.sendDiscoveryReport(header, addr)
+		}
+		if packet.MsgFlags&gosnmp.AuthNoPriv != 0 && !l.inTimeWindow(sp) {
+			l.notInTimeWindows.Incr(1)
+			return l.sendTimeWindowReport(packet, addr)
+		}
+	}
+
+	l.handler(packet, addr)
+
+	// Respond to the INFORM request reusing the packet as the response has
+	// to contain the same variables
+	packet.PDUType = gosnmp.GetResponse
+	packet.Error = gosnmp.NoError
+	packet.ErrorIndex = 0
+	packet.MsgFlags &^= gosnmp.Reportable
+	if sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+		sp.AuthoritativeEngineBoots = l.engine.boots
+		sp.AuthoritativeEngineTime = l.engine.time()
+	}
+	return l.send(packet, addr)
+}
+
+// unmarshalUser decodes the message using the credentials of the users
+// matching the user name and authoritative engine ID of the message
+func (l *trapListener) unmarshalUser(msg []byte, header *usmHeader) (*gosnmp.SnmpPacket, error) {
+	candidates := make([]*userParams, 0, len(l.users[header.user]))
+	for _, u := range l.users[header.user] {
+		if u.engineID != "" && u.engineID == header.engineID {
+			candidates = append(candidates, u)
+		}
+	}
+	for _, u := range l.users[header.user] {
+		if u.engineID == "" {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		l.unknownUsers.Incr(1)
+		return nil, fmt.Errorf("unknown user %q for engine %s", header.user, hex.EncodeToString([]byte(header.engineID)))
+	}
+
+	var errs []error
+	for _, u := range candidates {
+		packet, err := u.params.UnmarshalTrap(msg, false)
+		if err == nil {
+			return packet, nil
+		}
+		errs = append(errs, err)
+	}
+	l.authFailures.Incr(1)
+	return nil, fmt.Errorf("authenticating user %q failed: %w", header.user, errors.Join(errs...))
+}
+
+func (l *trapListener) inTimeWindow(sp *gosnmp.UsmSecurityParameters) bool {
+	if sp.AuthoritativeEngineBoots != l.engine.boots || l.engine.boots == 0x7fffffff {
+		return false
+	}
+	now := int64(l.engine.time())
+	diff := int64(sp.AuthoritativeEngineTime) - now
+	return diff >= -timeWindow && diff <= timeWindow
+}
+
+// sendDiscoveryReport sends an unauthenticated usmStatsUnknownEngineIDs
+// report containing the local engine ID, boots and time
+func (l *trapListener) sendDiscoveryReport(header *usmHeader, addr *net.UDPAddr) error {
+	if header == nil || header.flags&gosnmp.Reportable == 0 {
+		return nil
+	}
+	report := &gosnmp.SnmpPacket{
+		Version:       gosnmp.Version3,
+		MsgFlags:      gosnmp.NoAuthNoPriv,
+		SecurityModel: gosnmp.UserSecurityModel,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			AuthoritativeEngineID:    l.engine.id,
+			AuthoritativeEngineBoots: l.engine.boots,
+			AuthoritativeEngineTime:  l.engine.time(),
+			UserName:                 header.user,
+			Logger:                   l.Params.Logger,
+		},
+		ContextEngineID: l.engine.id,
+		PDUType:         gosnmp.Report,
+		MsgID:           header.msgID,
+		Logger:          l.Params.Logger,
+		Variables: []gosnmp.SnmpPDU{
+			{
+				Name:  oidUsmStatsUnknownEngineIDs,
+				Type:  gosnmp.Counter32,
+				Value: uint32(l.unknownEngineIDs.Get()),
+			},
+		},
+	}
+	return l.send(report, addr)
+}
+
+// sendTimeWindowReport sends an authenticated usmStatsNotInTimeWindows
+// report allowing the sender to synchronize the engine boots and time
+func (l *trapListener) sendTimeWindowReport(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) error {
+	sp, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if !ok {
+		return errors.New("invalid security parameters")
+	}
+	sp.AuthoritativeEngineBoots = l.engine.boots
+	sp.AuthoritativeEngineTime = l.engine.time()
+
+	packet.PDUType = gosnmp.Report
+	packet.MsgFlags = gosnmp.AuthNoPriv
+	packet.Error = gosnmp.NoError
+	packet.ErrorIndex = 0
+	packet.Variables = []gosnmp.SnmpPDU{
+		{
+			Name:  oidUsmStatsNotInTimeWindows,
+			Type:  gosnmp.Counter32,
+			Value: uint32(l.notInTimeWindows.Get()),
+		},
+	}
+	return l.send(packet, addr)
+}
+
+func (l *trapListener) send(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) error {
+	buf, err := packet.MarshalMsg()
+	if err != nil {
+		return fmt.Errorf("marshaling response failed: %w", err)
+	}
+	if _, err := l.conn.WriteToUDP(buf, addr); err != nil {
+		return fmt.Errorf("sending response failed: %w", err)
+	}
+	return nil
+}
+
+// usmHeader contains the unauthenticated information of a SNMPv3 message
+// with user-based security model
+type usmHeader struct {
+	msgID    uint32
+	flags    gosnmp.SnmpV3MsgFlags
+	engineID string
+	user     string
+}
+
+func (h *usmHeader) isDiscovery() bool {
+	return h.user == "" && h.engineID == "" && h.flags&gosnmp.AuthNoPriv == 0
+}
+
+// messageVersion returns the SNMP version of the given message
+func messageVersion(msg []byte) (int, error) {
+	tag, content, _, err := readTLV(msg)
+	if err != nil {
+		return 0, err
+	}
+	if tag != byte(gosnmp.Sequence) {
+		return 0, errors.New("message is not a sequence")
+	}
+	tag, version, _, err := readTLV(content)
+	if err != nil {
+		return 0, err
+	}
+	if tag != byte(gosnmp.Integer) {
+		return 0, errors.New("version is not an integer")
+	}
+	v, err := decodeUint(version)
+	if err != nil {
+		return 0, err
+	}
+	// SNMP versions are encoded as 0 (v1), 1 (v2c) and 3 (v3)
+	if v == 0 {
+		return 1, nil
+	}
+	return int(v), nil
+}
+
+// parseUSMHeader extracts the header and user-based security parameters of
+// a SNMPv3 message without authenticating or decrypting the message
+func parseUSMHeader(msg []byte) (*usmHeader, error) {
+	_, content, _, err := readTLV(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip the version
+	_, _, rest, err := readTLV(content)
+	if err != nil {
+		return nil, err
+	}
+
+	// Global header data
+	tag, global, rest, err := readTLV(rest)
+	if err != nil {
+		return nil, err
+	}
+	if tag != byte(gosnmp.Sequence) {
+		return nil, errors.New("header is not a sequence")
+	}
+	var h usmHeader
+	values, err := readTLVs(global, 4)
+	if err != nil {
+		return nil, fmt.Errorf("parsing header data failed: %w", err)
+	}
+	id, err := decodeUint(values[0])
+	if err != nil {
+		return nil, fmt.Errorf("parsing message ID failed: %w", err)
+	}
+	h.msgID = uint32(id)
+	if len(values[2]) != 1 {
+		return nil, errors.New("invalid message flags")
+	}
+	h.flags = gosnmp.SnmpV3MsgFlags(values[2][0])
+	model, err := decodeUint(values[3])
+	if err != nil {
+		return nil, fmt.Errorf("parsing security model failed: %w", err)
+	}
+	if gosnmp.SnmpV3SecurityModel(model) != gosnmp.UserSecurityModel {
+		return nil, fmt.Errorf("unsupported security model %d", model)
+	}
+
+	// Security parameters are an octet-string wrapping a sequence
+	tag, secparams, _, err := readTLV(rest)
+	if err != nil {
+		return nil, err
+	}
+	if tag != byte(gosnmp.OctetString) {
+		return nil, errors.New("security parameters are not an octet-string")
+	}
+	_, usm, _, err := readTLV(secparams)
+	if err != nil {
+		return nil, err
+	}
+	values, err = readTLVs(usm, 4)
+	if err != nil {
+		return nil, fmt.Errorf("parsing security parameters failed: %w", err)
+	}
+	h.engineID = string(values[0])
+	h.user = string(values[3])
+
+	return &h, nil
+}
+
+// readTLV reads a single BER encoded element with definite length
+func readTLV(buf []byte) (tag byte, value, rest []byte, err error) {
+	if len(buf) < 2 {
+		return 0, nil, nil, errors.New("truncated element")
+	}
+	tag = buf[0]
+	length := int(buf[1])
+	offset := 2
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 4 || len(buf) < 2+n {
+			return 0, nil, nil, errors.New("invalid length")
+		}
+		length = 0
+		for _, b := range buf[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		offset += n
+	}
+	if length < 0 || len(buf) < offset+length {
+		return 0, nil, nil, errors.New("truncated element")
+	}
+	return tag, buf[offset : offset+length], buf[offset+length:], nil
+}
+
+// readTLVs reads the values of the given number of consecutive elements
+func readTLVs(buf []byte, n int) ([][]byte, error) {
+	values := make([][]byte, 0, n)
+	for range n {
+		_, value, rest, err := readTLV(buf)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		buf = rest
+	}
+	return values, nil
+}
+
+func decodeUint(buf []byte) (uint64, error) {
+	if len(buf) == 0 || len(buf) > 9 {
+		return 0, fmt.Errorf("invalid integer length %d", len(buf))
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// parseEngineID decodes a hex encoded engine ID as used by net-snmp and
+// in RFC 3411 with optional "0x" prefix and colon separators
+func parseEngineID(s string) (string, error) {
+	s = strings.TrimPrefix(strings.ToLower(s), "0x")
+	s = strings.ReplaceAll(s, ":", "")
+	id, err := hex.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	if len(id) < 5 || len(id) > 32 {
+		return "", fmt.Errorf("engine ID has to be between 5 and 32 bytes but is %d", len(id))
+	}
+	return string(id), nil
+}
@@ -2,6 +2,7 @@
 package snmp_trap
 
 import (
+	"crypto/rand"
 	_ "embed"
 	"encoding/hex"
 	"errors"
@@ -19,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/plugins/common/snmp"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 var defaultTimeout = config.Duration(time.Second * 5)
@@ -40,15 +42,38 @@ type SnmpTrap struct {
 	PrivProtocol string        `toml:"priv_protocol"`
 	PrivPassword config.Secret `toml:"priv_password"`
 
+	// Settings for multiple version 3 users and the local engine
+	Users    []usmUser `toml:"user"`
+	EngineID string    `toml:"engine_id"`
+
 	Translator string          `toml:"-"`
 	Log        telegraf.Logger `toml:"-"`
 
 	acc      telegraf.Accumulator
-	listener *gosnmp.TrapListener
+	listener *trapListener
+	state    engineState
 
 	transl translator
 }
 
+// usmUser contains the credentials of a SNMPv3 user, optionally restricted
+// to messages of the given authoritative engine
+type usmUser struct {
+	EngineID     string        `toml:"engine_id"`
+	SecLevel     string        `toml:"sec_level"`
+	SecName      config.Secret `toml:"sec_name"`
+	AuthProtocol string        `toml:"auth_protocol"`
+	AuthPassword config.Secret `toml:"auth_password"`
+	PrivProtocol string        `toml:"priv_protocol"`
+	PrivPassword config.Secret `toml:"priv_password"`
+}
+
+// engineState is the persisted state of the local SNMP engine
+type engineState struct {
+	EngineID    string `json:"engine_id"`
+	EngineBoots uint32 `json:"engine_boots"`
+}
+
 type translator interface {
 	lookup(oid string) (snmp.MibEntry, error)
 }
@@ -89,16 +114,21 @@ func (s *SnmpTrap) Init() error {
 	}
 
 	// Setup the SNMP parameters
-	params := gosnmp.GoSNMP{
-		Port:               gosnmp.Default.Port,
-		Transport:          gosnmp.Default.Transport,
-		Community:          gosnmp.Default.Community,
-		Timeout:            gosnmp.Default.Timeout,
-		Retries:            gosnmp.Default.Retries,
-		ExponentialTimeout: gosnmp.Default.ExponentialTimeout,
-		MaxOids:            gosnmp.Default.MaxOids,
-		Logger:             gosnmp.NewLogger(&snmp.Logger{Logger: s.Log}),
+	var secName string
+	var users map[string][]*userParams
+	newParams := func() *gosnmp.GoSNMP {
+		return &gosnmp.GoSNMP{
+			Port:               gosnmp.Default.Port,
+			Transport:          gosnmp.Default.Transport,
+			Community:          gosnmp.Default.Community,
+			Timeout:            gosnmp.Default.Timeout,
+			Retries:            gosnmp.Default.Retries,
+			ExponentialTimeout: gosnmp.Default.ExponentialTimeout,
+			MaxOids:            gosnmp.Default.MaxOids,
+			Logger:             gosnmp.NewLogger(&snmp.Logger{Logger: s.Log}),
+		}
 	}
+	params := newParams()
 
 	switch s.Version {
 	case "1":
@@ -110,93 +140,93 @@ func (s *SnmpTrap) Init() error {
 
 		// Setup the security for v3
 		params.SecurityModel = gosnmp.UserSecurityModel
-
-		// Set security mechanisms
-		switch strings.ToLower(s.SecLevel) {
-		case "noauthnopriv", "":
-			params.MsgFlags = gosnmp.NoAuthNoPriv
-		case "authnopriv":
-			params.MsgFlags = gosnmp.AuthNoPriv
-		case "authpriv":
-			params.MsgFlags = gosnmp.AuthPriv
-		default:
-			return fmt.Errorf("unknown security level %q", s.SecLevel)
+		if len(s.Users) == 0 {
+			flags, security, err := securityParameters(s.SecLevel, s.AuthProtocol, s.PrivProtocol, &s.SecName, &s.AuthPassword, &s.PrivPassword)
+			if err != nil {
+				return err
+			}
+			params.MsgFlags = flags
+			params.SecurityParameters = security
+			secName = security.UserName
+		} else {
+			users = make(map[string][]*userParams, len(s.Users))
+			for i := range s.Users {
+				u := &s.Users[i]
+				flags, security, err := securityParameters(u.SecLevel, u.AuthProtocol, u.PrivProtocol, &u.SecName, &u.AuthPassword, &u.PrivPassword)
+				if err != nil {
+					return fmt.Errorf("user %d: %w", i+1, err)
+				}
+				var engineID string
+				if u.EngineID != "" {
+					engineID, err = parseEngineID(u.EngineID)
+					if err != nil {
+						return fmt.Errorf("user %d: invalid engine ID %q: %w", i+1, u.EngineID, err)
+					}
+				}
+				p := newParams()
+				p.Version = gosnmp.Version3
+				p.SecurityModel = gosnmp.UserSecurityModel
+				p.MsgFlags = flags
+				p.SecurityParameters = security
+				users[security.UserName] = append(users[security.UserName], &userParams{
+					engineID: engineID,
+					params:   p,
+				})
+			}
 		}
+	default:
+		return fmt.Errorf("unknown version %q", s.Version)
+	}
 
-		// Set authentication
-		var security gosnmp.UsmSecurityParameters
-		switch strings.ToLower(s.AuthProtocol) {
-		case "":
-			security.AuthenticationProtocol = gosnmp.NoAuth
-		case "md5":
-			security.AuthenticationProtocol = gosnmp.MD5
-		case "sha":
-			security.AuthenticationProtocol = gosnmp.SHA
-		case "sha224":
-			security.AuthenticationProtocol = gosnmp.SHA224
-		case "sha256":
-			security.AuthenticationProtocol = gosnmp.SHA256
-		case "sha384":
-			security.AuthenticationProtocol = gosnmp.SHA384
-		case "sha512":
-			security.AuthenticationProtocol = gosnmp.SHA512
-		default:
-			return fmt.Errorf("unknown authentication protocol %q", s.AuthProtocol)
+	// Setup the local engine that is authoritative for INFORM requests
+	if s.EngineID != "" {
+		id, err := parseEngineID(s.EngineID)
+		if err != nil {
+			return fmt.Errorf("invalid engine ID %q: %w", s.EngineID, err)
 		}
-
-		// Set privacy
-		switch strings.ToLower(s.PrivProtocol) {
-		case "":
-			security.PrivacyProtocol = gosnmp.NoPriv
-		case "aes":
-			security.PrivacyProtocol = gosnmp.AES
-		case "des":
-			security.PrivacyProtocol = gosnmp.DES
-		case "aes192":
-			security.PrivacyProtocol = gosnmp.AES192
-		case "aes192c":
-			security.PrivacyProtocol = gosnmp.AES192C
-		case "aes256":
-			security.PrivacyProtocol = gosnmp.AES256
-		case "aes256c":
-			security.PrivacyProtocol = gosnmp.AES256C
-		default:
-			return fmt.Errorf("unknown privacy protocol %q", s.PrivProtocol)
+		s.state.EngineID = hex.EncodeToString([]byte(id))
+	} else {
+		// Generate a random engine ID in the format of RFC 3411 using the
+		// "octets" format
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("generating engine ID failed: %w", err)
 		}
+		s.state.EngineID = hex.EncodeToString(append([]byte{0x80, 0x00, 0x00, 0x00, 0x05}, id...))
+	}
 
-		// Set credentials
-		secnameSecret, err := s.SecName.Get()
-		if err != nil {
-			return fmt.Errorf("getting secname failed: %w", err)
-		}
-		security.UserName = secnameSecret.String()
-		secnameSecret.Destroy()
+	// Initialize the listener
+	tags := map[string]string{"address": s.ServiceAddress}
+	s.listener = &trapListener{
+		Params:           params,
+		handler:          s.handler,
+		log:              s.Log,
+		secName:          secName,
+		users:            users,
+		unknownUsers:     selfstat.Register("snmp_trap", "unknown_users", tags),
+		authFailures:     selfstat.Register("snmp_trap", "auth_failures", tags),
+		unknownEngineIDs: selfstat.Register("snmp_trap", "unknown_engine_ids", tags),
+		notInTimeWindows: selfstat.Register("snmp_trap", "not_in_time_windows", tags),
+	}
 
-		authPasswdSecret, err := s.AuthPassword.Get()
-		if err != nil {
-			return fmt.Errorf("getting auth-password failed: %w", err)
-		}
-		security.AuthenticationPassphrase = authPasswdSecret.String()
-		authPasswdSecret.Destroy()
+	return nil
+}
 
-		privPasswdSecret, err := s.PrivPassword.Get()
-		if err != nil {
-			return fmt.Errorf("getting priv-password failed: %w", err)
-		}
-		security.PrivacyPassphrase = privPasswdSecret.String()
-		privPasswdSecret.Destroy()
+func (s *SnmpTrap) GetState() interface{} {
+	return s.state
+}
 
-		// Enable security settings
-		params.SecurityParameters = &security
-	default:
-		return fmt.Errorf("unknown version %q", s.Version)
+func (s *SnmpTrap) SetState(state interface{}) error {
+	st, ok := state.(engineState)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
 	}
 
-	// Initialize the listener
-	s.listener = gosnmp.NewTrapListener()
-	s.listener.OnNewTrap = s.handler
-	s.listener.Params = &params
-
+	// Only keep the persisted engine ID if none is configured explicitly
+	if s.EngineID == "" && st.EngineID != "" {
+		s.state.EngineID = st.EngineID
+	}
+	s.state.EngineBoots = st.EngineBoots
 	return nil
 }
 
@@ -213,18 +243,24 @@ func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
 		return fmt.Errorf("unknown protocol for service address %q", s.ServiceAddress)
 	}
 
-	// If the listener immediately returns an error we need to return it
-	errCh := make(chan error, 1)
-	go func() {
-		errCh <- s.listener.Listen(u.Host)
-	}()
+	// Each start of the engine increments the boots counter, see RFC 3414
+	engineID, err := hex.DecodeString(s.state.EngineID)
+	if err != nil {
+		return fmt.Errorf("decoding engine ID failed: %w", err)
+	}
+	if s.state.EngineBoots < 0x7fffffff {
+		s.state.EngineBoots++
+	}
+	s.listener.engine = &engine{
+		id:    string(engineID),
+		boots: s.state.EngineBoots,
+		start: time.Now(),
+	}
 
-	select {
-	case <-s.listener.Listening():
-		s.Log.Infof("Listening on %s", s.ServiceAddress)
-	case err := <-errCh:
+	if err := s.listener.listen(u.Host); err != nil {
 		return fmt.Errorf("listening failed: %w", err)
 	}
+	s.Log.Infof("Listening on %s", s.ServiceAddress)
 
 	return nil
 }
@@ -234,7 +270,87 @@ func (*SnmpTrap) Gather(telegraf.Accumulator) error {
 }
 
 func (s *SnmpTrap) Stop() {
-	s.listener.Close()
+	s.listener.close()
+}
+
+func securityParameters(level, authProtocol, privProtocol string, name, authPassword, privPassword *config.Secret) (gosnmp.SnmpV3MsgFlags, *gosnmp.UsmSecurityParameters, error) {
+	// Set security mechanisms
+	var flags gosnmp.SnmpV3MsgFlags
+	switch strings.ToLower(level) {
+	case "noauthnopriv", "":
+		flags = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		flags = gosnmp.AuthNoPriv
+	case "authpriv":
+		flags = gosnmp.AuthPriv
+	default:
+		return 0, nil, fmt.Errorf("unknown security level %q", level)
+	}
+
+	// Set authentication
+	var security gosnmp.UsmSecurityParameters
+	switch strings.ToLower(authProtocol) {
+	case "":
+		security.AuthenticationProtocol = gosnmp.NoAuth
+	case "md5":
+		security.AuthenticationProtocol = gosnmp.MD5
+	case "sha":
+		security.AuthenticationProtocol = gosnmp.SHA
+	case "sha224":
+		security.AuthenticationProtocol = gosnmp.SHA224
+	case "sha256":
+		security.AuthenticationProtocol = gosnmp.SHA256
+	case "sha384":
+		security.AuthenticationProtocol = gosnmp.SHA384
+	case "sha512":
+		security.AuthenticationProtocol = gosnmp.SHA512
+	default:
+		return 0, nil, fmt.Errorf("unknown authentication protocol %q", authProtocol)
+	}
+
+	// Set privacy
+	switch strings.ToLower(privProtocol) {
+	case "":
+		security.PrivacyProtocol = gosnmp.NoPriv
+	case "aes":
+		security.PrivacyProtocol = gosnmp.AES
+	case "des":
+		security.PrivacyProtocol = gosnmp.DES
+	case "aes192":
+		security.PrivacyProtocol = gosnmp.AES192
+	case "aes192c":
+		security.PrivacyProtocol = gosnmp.AES192C
+	case "aes256":
+		security.PrivacyProtocol = gosnmp.AES256
+	case "aes256c":
+		security.PrivacyProtocol = gosnmp.AES256C
+	default:
+		return 0, nil, fmt.Errorf("unknown privacy protocol %q", privProtocol)
+	}
+
+	// Set credentials
+	secnameSecret, err := name.Get()
+	if err != nil {
+		return 0, nil, fmt.Errorf("getting secname failed: %w", err)
+	}
+	security.UserName = secnameSecret.String()
+	secnameSecret.Destroy()
+
+	authPasswdSecret, err := authPassword.Get()
+	if err != nil {
+		return 0, nil, fmt.Errorf("getting auth-password failed: %w", err)
+	}
+	security.AuthenticationPassphrase = authPasswdSecret.String()
+	authPasswdSecret.Destroy()
+
+	privPasswdSecret, err := privPassword.Get()
+	if err != nil {
+		return 0, nil, fmt.Errorf("getting priv-password failed: %w", err)
+	}
+	security.PrivacyPassphrase = privPasswdSecret.String()
+	privPasswdSecret.Destroy()
+
+	return flags, &security, nil
 }
 
 func setTrapOid(tags map[string]string, oid string, e snmp.MibEntry) {
@@ -3,6 +3,7 @@ package snmp_trap
 import (
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"testing"
@@ -1644,3 +1645,335 @@ func (l *testLogger) Printf(format string, v ...interface{}) {
 		l.out <- true
 	}
 }
+
+func TestReceiveTrapV3MultipleUsers(t *testing.T) {
+	translator := &testTranslator{entries: []entry{
+		{
+			oid: ".1.3.6.1.6.3.1.1.4.1.0",
+			e:   snmp.MibEntry{MibName: "SNMPv2-MIB", OidText: "snmpTrapOID.0"},
+		},
+		{
+			oid: ".1.3.6.1.6.3.1.1.5.1",
+			e:   snmp.MibEntry{MibName: "SNMPv2-MIB", OidText: "coldStart"},
+		},
+		{
+			oid: ".1.3.6.1.2.1.1.3.0",
+			e:   snmp.MibEntry{MibName: "UNUSED_MIB_NAME", OidText: "sysUpTimeInstance"},
+		},
+	}}
+
+	plugin := &SnmpTrap{
+		ServiceAddress: "udp://127.0.0.1:0",
+		Version:        "3",
+		Users: []usmUser{
+			{
+				SecName:      config.NewSecret([]byte("cisco")),
+				SecLevel:     "authPriv",
+				AuthProtocol: "sha256",
+				AuthPassword: config.NewSecret([]byte("cisco-auth-pass")),
+				PrivProtocol: "aes",
+				PrivPassword: config.NewSecret([]byte("cisco-priv-pass")),
+			},
+			{
+				SecName:      config.NewSecret([]byte("juniper")),
+				SecLevel:     "authNoPriv",
+				AuthProtocol: "sha",
+				AuthPassword: config.NewSecret([]byte("juniper-auth-pass")),
+			},
+			{
+				// Same user with different credentials per site
+				EngineID:     "0x6465616462656566", // deadbeef
+				SecName:      config.NewSecret([]byte("site")),
+				SecLevel:     "authNoPriv",
+				AuthProtocol: "md5",
+				AuthPassword: config.NewSecret([]byte("site-a-password")),
+			},
+			{
+				EngineID:     "0x6361666562616265", // cafebabe
+				SecName:      config.NewSecret([]byte("site")),
+				SecLevel:     "authNoPriv",
+				AuthProtocol: "md5",
+				AuthPassword: config.NewSecret([]byte("site-b-password")),
+			},
+		},
+		Log:    testutil.Logger{},
+		transl: translator,
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	port := uint16(plugin.listener.conn.LocalAddr().(*net.UDPAddr).Port)
+	unknownUsers := plugin.listener.unknownUsers.Get()
+	authFailures := plugin.listener.authFailures.Get()
+
+	trap := gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{
+				Name:  ".1.3.6.1.2.1.1.3.0",
+				Type:  gosnmp.TimeTicks,
+				Value: uint32(123123123),
+			},
+			{
+				Name:  ".1.3.6.1.6.3.1.1.4.1.0", // SNMPv2-MIB::snmpTrapOID.0
+				Type:  gosnmp.ObjectIdentifier,
+				Value: ".1.3.6.1.6.3.1.1.5.1", // coldStart
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		flags    gosnmp.SnmpV3MsgFlags
+		security *gosnmp.UsmSecurityParameters
+		accepted bool
+	}{
+		{
+			name:     "first user",
+			flags:    gosnmp.AuthPriv,
+			security: createSecurityParameters("sha256", "aes", "cisco", "cisco-priv-pass", "cisco-auth-pass"),
+			accepted: true,
+		},
+		{
+			name:     "second user",
+			flags:    gosnmp.AuthNoPriv,
+			security: createSecurityParameters("sha", "", "juniper", "", "juniper-auth-pass"),
+			accepted: true,
+		},
+		{
+			name:     "engine specific user",
+			flags:    gosnmp.AuthNoPriv,
+			security: createSecurityParameters("md5", "", "site", "", "site-a-password"),
+			accepted: true,
+		},
+		{
+			name:  "engine specific user with other engine",
+			flags: gosnmp.AuthNoPriv,
+			security: func() *gosnmp.UsmSecurityParameters {
+				sp := createSecurityParameters("md5", "", "site", "", "site-b-password")
+				sp.AuthoritativeEngineID = "cafebabe"
+				return sp
+			}(),
+			accepted: true,
+		},
+		{
+			name:     "engine specific user with wrong password",
+			flags:    gosnmp.AuthNoPriv,
+			security: createSecurityParameters("md5", "", "site", "", "site-b-password"),
+		},
+		{
+			name:     "unknown user",
+			flags:    gosnmp.AuthNoPriv,
+			security: createSecurityParameters("sha", "", "franz", "", "juniper-auth-pass"),
+		},
+		{
+			name:     "wrong password",
+			flags:    gosnmp.AuthPriv,
+			security: createSecurityParameters("sha256", "aes", "cisco", "cisco-priv-pass", "wrong-pass"),
+		},
+	}
+
+	var expectedMetrics int
+	for _, tt := range tests {
+		client := &gosnmp.GoSNMP{
+			Port:               port,
+			Version:            gosnmp.Version3,
+			Timeout:            2 * time.Second,
+			Retries:            1,
+			MaxOids:            gosnmp.MaxOids,
+			Target:             "127.0.0.1",
+			SecurityParameters: tt.security,
+			SecurityModel:      gosnmp.UserSecurityModel,
+			MsgFlags:           tt.flags,
+		}
+		require.NoError(t, client.Connect(), "connecting failed")
+		_, err := client.SendTrap(trap)
+		require.NoErrorf(t, err, "sending %q failed", tt.name)
+		require.NoError(t, client.Conn.Close(), "closing failed")
+		if tt.accepted {
+			expectedMetrics++
+		}
+	}
+
+	// Wait for the traps and errors to be processed
+	require.Eventually(t, func() bool {
+		return acc.NMetrics() >= uint64(expectedMetrics) &&
+			plugin.listener.unknownUsers.Get()-unknownUsers >= 1 &&
+			plugin.listener.authFailures.Get()-authFailures >= 2
+	}, 3*time.Second, 100*time.Millisecond, "timed out waiting for traps")
+
+	require.Len(t, acc.GetTelegrafMetrics(), expectedMetrics)
+	require.Equal(t, int64(1), plugin.listener.unknownUsers.Get()-unknownUsers)
+	require.Equal(t, int64(2), plugin.listener.authFailures.Get()-authFailures)
+}
+
+func TestReceiveInformV3(t *testing.T) {
+	translator := &testTranslator{entries: []entry{
+		{
+			oid: ".1.3.6.1.6.3.1.1.4.1.0",
+			e:   snmp.MibEntry{MibName: "SNMPv2-MIB", OidText: "snmpTrapOID.0"},
+		},
+		{
+			oid: ".1.3.6.1.6.3.1.1.5.1",
+			e:   snmp.MibEntry{MibName: "SNMPv2-MIB", OidText: "coldStart"},
+		},
+		{
+			oid: ".1.3.6.1.2.1.1.3.0",
+			e:   snmp.MibEntry{MibName: "UNUSED_MIB_NAME", OidText: "sysUpTimeInstance"},
+		},
+	}}
+
+	for _, multiuser := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiuser=%v", multiuser), func(t *testing.T) {
+			plugin := &SnmpTrap{
+				ServiceAddress: "udp://127.0.0.1:0",
+				Version:        "3",
+				EngineID:       "80:00:1f:88:04:74:65:6c:65:67:72:61:66",
+				Log:            testutil.Logger{},
+				transl:         translator,
+			}
+			user := usmUser{
+				SecName:      config.NewSecret([]byte("franz")),
+				SecLevel:     "authPriv",
+				AuthProtocol: "sha",
+				AuthPassword: config.NewSecret([]byte("what a nice day")),
+				PrivProtocol: "aes",
+				PrivPassword: config.NewSecret([]byte("for my privacy")),
+			}
+			if multiuser {
+				plugin.Users = []usmUser{user}
+			} else {
+				plugin.SecName = user.SecName
+				plugin.SecLevel = user.SecLevel
+				plugin.AuthProtocol = user.AuthProtocol
+				plugin.AuthPassword = user.AuthPassword
+				plugin.PrivProtocol = user.PrivProtocol
+				plugin.PrivPassword = user.PrivPassword
+			}
+			require.NoError(t, plugin.Init())
+			require.NoError(t, plugin.SetState(engineState{EngineBoots: 41}))
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Start(&acc))
+			defer plugin.Stop()
+			require.Equal(t, engineState{EngineID: "80001f880474656c6567726166", EngineBoots: 42}, plugin.GetState())
+
+			port := uint16(plugin.listener.conn.LocalAddr().(*net.UDPAddr).Port)
+			unknownEngineIDs := plugin.listener.unknownEngineIDs.Get()
+			notInTimeWindows := plugin.listener.notInTimeWindows.Get()
+
+			// The client has to discover the engine ID, boots and time of
+			// the receiver before sending the inform
+			security := createSecurityParameters("sha", "aes", "franz", "for my privacy", "what a nice day")
+			security.AuthoritativeEngineID = ""
+			security.AuthoritativeEngineBoots = 0
+			security.AuthoritativeEngineTime = 0
+			client := &gosnmp.GoSNMP{
+				Port:               port,
+				Version:            gosnmp.Version3,
+				Timeout:            2 * time.Second,
+				Retries:            1,
+				MaxOids:            gosnmp.MaxOids,
+				Target:             "127.0.0.1",
+				SecurityParameters: security,
+				SecurityModel:      gosnmp.UserSecurityModel,
+				MsgFlags:           gosnmp.AuthPriv,
+			}
+			require.NoError(t, client.Connect(), "connecting failed")
+			defer client.Conn.Close()
+
+			trap := gosnmp.SnmpTrap{
+				Variables: []gosnmp.SnmpPDU{
+					{
+						Name:  ".1.3.6.1.2.1.1.3.0",
+						Type:  gosnmp.TimeTicks,
+						Value: uint32(123123123),
+					},
+					{
+						Name:  ".1.3.6.1.6.3.1.1.4.1.0", // SNMPv2-MIB::snmpTrapOID.0
+						Type:  gosnmp.ObjectIdentifier,
+						Value: ".1.3.6.1.6.3.1.1.5.1", // coldStart
+					},
+				},
+				IsInform: true,
+			}
+			response, err := client.SendTrap(trap)
+			require.NoError(t, err)
+			require.Equal(t, gosnmp.GetResponse, response.PDUType)
+			require.Equal(t, int64(1), plugin.listener.unknownEngineIDs.Get()-unknownEngineIDs)
+
+			// The client must have learned the engine parameters
+			sp := client.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+			require.Equal(t, "\x80\x00\x1f\x88\x04telegraf", sp.AuthoritativeEngineID)
+			require.Equal(t, uint32(42), sp.AuthoritativeEngineBoots)
+
+			// Send another inform with outdated engine boots to trigger the time
+			// synchronization
+			sp.AuthoritativeEngineBoots = 1
+			response, err = client.SendTrap(trap)
+			require.NoError(t, err)
+			require.Equal(t, gosnmp.GetResponse, response.PDUType)
+			require.Equal(t, int64(1), plugin.listener.notInTimeWindows.Get()-notInTimeWindows)
+
+			expected := []telegraf.Metric{
+				metric.New(
+					"snmp_trap",
+					map[string]string{
+						"oid":       ".1.3.6.1.6.3.1.1.5.1",
+						"name":      "coldStart",
+						"mib":       "SNMPv2-MIB",
+						"version":   "3",
+						"source":    "127.0.0.1",
+						"engine_id": "80001f880474656c6567726166",
+					},
+					map[string]interface{}{
+						"sysUpTimeInstance": uint32(123123123),
+					},
+					time.Unix(0, 0),
+				),
+			}
+			expected = append(expected, expected[0].Copy())
+			require.Eventually(t, func() bool {
+				return acc.NMetrics() >= uint64(len(expected))
+			}, 3*time.Second, 100*time.Millisecond)
+			testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseEngineID(t *testing.T) {
+	id, err := parseEngineID("0x80001F8804")
+	require.NoError(t, err)
+	require.Equal(t, "\x80\x00\x1f\x88\x04", id)
+
+	id, err = parseEngineID("80:00:1f:88:04:01")
+	require.NoError(t, err)
+	require.Equal(t, "\x80\x00\x1f\x88\x04\x01", id)
+
+	_, err = parseEngineID("8000")
+	require.ErrorContains(t, err, "between 5 and 32 bytes")
+
+	_, err = parseEngineID("foo")
+	require.Error(t, err)
+}
+
+func TestListenerClosedConnection(t *testing.T) {
+	listener := &trapListener{log: testutil.Logger{}}
+	require.NoError(t, listener.listen("127.0.0.1:0"))
+	defer listener.close()
+
+	// The reader must terminate instead of spinning on the closed connection
+	require.NoError(t, listener.conn.Close())
+	stopped := make(chan struct{})
+	go func() {
+		listener.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "reader did not terminate")
+	}
+}
@@ -85,6 +85,7 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 	fields["priority"] = priorityNormal
 	ts := now
 	if len(message) < 2 {
+		s.addTenant(tags, defaultHostname)
 		s.acc.AddFields(name, fields, tags, ts)
 		return nil
 	}
@@ -122,6 +123,11 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 			tags["aggregation_key"] = rawMetadataFields[i][2:]
 		case "s:":
 			fields["source_type_name"] = rawMetadataFields[i][2:]
+		case "c:":
+			// This is optional container ID field of DogStatsD v1.2
+			if s.DataDogKeepContainerTag {
+				tags["container"] = rawMetadataFields[i][2:]
+			}
 		default:
 			if rawMetadataFields[i][0] != '#' {
 				return fmt.Errorf("unknown metadata type: %q", rawMetadataFields[i])
@@ -135,6 +141,7 @@ func (s *Statsd) parseEventMessage(now time.Time, message, defaultHostname strin
 		delete(tags, "host")
 		tags["source"] = host
 	}
+	s.addTenant(tags, defaultHostname)
 	s.acc.AddFields(name, fields, tags, ts)
 	return nil
 }
@@ -255,6 +262,11 @@ func (s *Statsd) parseServiceCheckMessage(now time.Time, message, defaultHostnam
 		case strings.HasPrefix(part, "m:"):
 			// Message
 			fields["message"] = uncommenter.Replace(part[2:])
+		case strings.HasPrefix(part, "c:"):
+			// Container ID
+			if s.DataDogKeepContainerTag {
+				tags["container"] = part[2:]
+			}
 		case strings.HasPrefix(part, "#"):
 			// Tags
 			parseDataDogTags(tags, part[1:])
@@ -267,6 +279,7 @@ func (s *Statsd) parseServiceCheckMessage(now time.Time, message, defaultHostnam
 		delete(tags, "host")
 		tags["source"] = host
 	}
+	s.addTenant(tags, defaultHostname)
 
 	s.acc.AddFields("statsd_service_check", fields, tags, ts)
 	return nil
@@ -787,3 +787,45 @@ func TestEventError(t *testing.T) {
 	err = s.parseEventMessage(now, "_e{5,4}:title|text|x:1234", "default-hostname")
 	require.Error(t, err)
 }
+
+func TestContainerID(t *testing.T) {
+	now := time.Now()
+
+	var acc testutil.Accumulator
+	s := newTestStatsd()
+	s.DataDogKeepContainerTag = true
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	require.NoError(t, s.parseServiceCheckMessage(now, "_sc|my.check|0|#env:prod|c:83c0a99c0a54", ""))
+	require.NoError(t, s.parseEventMessage(now, "_e{5,4}:title|text|c:83c0a99c0a54", ""))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"statsd_service_check",
+			map[string]string{
+				"check_name": "my.check",
+				"container":  "83c0a99c0a54",
+				"env":        "prod",
+			},
+			map[string]interface{}{
+				"status":      int64(0),
+				"status_text": "ok",
+			},
+			now,
+		),
+		metric.New(
+			"title",
+			map[string]string{
+				"container": "83c0a99c0a54",
+			},
+			map[string]interface{}{
+				"alert_type": "info",
+				"priority":   "normal",
+				"text":       "text",
+			},
+			now,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
 	"regexp"
 	"sort"
 	"strconv"
@@ -40,12 +41,15 @@ const (
 )
 
 type Statsd struct {
-	// Protocol used on listener - udp or tcp
+	// Protocol used on listener - udp, tcp or unixgram
 	Protocol string `toml:"protocol"`
 
-	// Address & Port to serve from
+	// Address & Port to serve from or path of the unix socket
 	ServiceAddress string `toml:"service_address"`
 
+	// Permissions of the unix socket
+	SocketMode string `toml:"socket_mode"`
+
 	// Number of messages allowed to queue up in between calls to Gather. If this
 	// fills up, packets will get dropped until the next Gather interval is ran.
 	AllowedPendingMessages int `toml:"allowed_pending_messages"`
@@ -93,7 +97,14 @@ type Statsd struct {
 
 	// Max duration for each metric to stay cached without being updated.
 	MaxTTL config.Duration `toml:"max_ttl"`
-	Log    telegraf.Logger `toml:"-"`
+
+	// Multi-tenant settings to isolate clients from each other
+	TenantSource    string `toml:"tenant_source"`
+	TenantSourceTag string `toml:"tenant_source_tag"`
+	TenantTag       string `toml:"tenant_tag"`
+	TenantMaxSeries int    `toml:"tenant_max_series"`
+
+	Log telegraf.Logger `toml:"-"`
 
 	sync.Mutex
 	// Lock for preventing a data race during resource cleanup
@@ -119,10 +130,18 @@ type Statsd struct {
 	sets          map[string]cachedset
 	timings       map[string]cachedtimings
 	distributions []cacheddistributions
+	timestamped   []cachedtimestamped
+
+	// Number of cached series per tenant, the timestamped series of the
+	// current interval and the statistics of dropped series per tenant
+	tenantSeries      map[string]int
+	timestampedSeries map[string]bool
+	tenantDropped     map[string]selfstat.Stat
 
 	// Protocol listeners
-	UDPlistener *net.UDPConn
-	TCPlistener *net.TCPListener
+	UDPlistener      *net.UDPConn
+	TCPlistener      *net.TCPListener
+	UnixgramListener *net.UnixConn
 
 	// track current connections so we can close them in Stop()
 	conns   map[string]*net.TCPConn
@@ -185,6 +204,8 @@ type rawMetric struct {
 	additive   bool
 	samplerate float64
 	tags       map[string]string
+	tenant     string
+	timestamp  time.Time
 }
 
 type cachedset struct {
@@ -221,10 +242,41 @@ type cacheddistributions struct {
 	tags  map[string]string
 }
 
+// cachedtimestamped is a gauge or counter sent with an explicit timestamp,
+// those are not aggregated but published as is
+type cachedtimestamped struct {
+	name      string
+	mtype     string
+	field     string
+	value     interface{}
+	tags      map[string]string
+	timestamp time.Time
+}
+
 func (*Statsd) SampleConfig() string {
 	return sampleConfig
 }
 
+func (s *Statsd) Init() error {
+	switch s.TenantSource {
+	case "", "address":
+	case "tag":
+		if s.TenantSourceTag == "" {
+			return errors.New("'tenant_source_tag' required for tenant source 'tag'")
+		}
+	default:
+		return fmt.Errorf("invalid tenant source %q", s.TenantSource)
+	}
+	if s.TenantTag == "" {
+		s.TenantTag = "tenant"
+	}
+	if s.TenantMaxSeries < 0 {
+		return errors.New("'tenant_max_series' cannot be negative")
+	}
+
+	return nil
+}
+
 func (s *Statsd) Start(ac telegraf.Accumulator) error {
 	s.acc = ac
 
@@ -235,6 +287,10 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 	s.sets = make(map[string]cachedset)
 	s.timings = make(map[string]cachedtimings)
 	s.distributions = make([]cacheddistributions, 0)
+	s.timestamped = make([]cachedtimestamped, 0)
+	s.tenantSeries = make(map[string]int)
+	s.timestampedSeries = make(map[string]bool)
+	s.tenantDropped = make(map[string]selfstat.Stat)
 
 	s.Lock()
 	defer s.Unlock()
@@ -274,7 +330,8 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 		s.MetricSeparator = defaultSeparator
 	}
 
-	if s.isUDP() {
+	switch {
+	case s.isUDP():
 		address, err := net.ResolveUDPAddr(s.Protocol, s.ServiceAddress)
 		if err != nil {
 			return err
@@ -295,7 +352,37 @@ func (s *Statsd) Start(ac telegraf.Accumulator) error {
 				ac.AddError(err)
 			}
 		}()
-	} else {
+	case s.Protocol == "unixgram":
+		if err := os.Remove(s.ServiceAddress); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing socket failed: %w", err)
+		}
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: s.ServiceAddress, Net: "unixgram"})
+		if err != nil {
+			return err
+		}
+		if s.SocketMode != "" {
+			mode, err := strconv.ParseUint(s.SocketMode, 8, 32)
+			if err != nil {
+				conn.Close()
+				return fmt.Errorf("converting socket mode failed: %w", err)
+			}
+			if err := os.Chmod(s.ServiceAddress, os.FileMode(uint32(mode))); err != nil {
+				conn.Close()
+				return fmt.Errorf("changing socket permissions failed: %w", err)
+			}
+		}
+
+		s.Log.Infof("Unixgram listening on %q", s.ServiceAddress)
+		s.UnixgramListener = conn
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := s.udpListen(conn); err != nil {
+				ac.AddError(err)
+			}
+		}()
+	default:
 		address, err := net.ResolveTCPAddr("tcp", s.ServiceAddress)
 		if err != nil {
 			return err
@@ -347,6 +434,17 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	}
 	s.distributions = make([]cacheddistributions, 0)
 
+	for _, m := range s.timestamped {
+		fields := map[string]interface{}{m.field: m.value}
+		if m.mtype == "g" {
+			acc.AddGauge(m.name, fields, m.tags, m.timestamp)
+		} else {
+			acc.AddCounter(m.name, fields, m.tags, m.timestamp)
+		}
+	}
+	s.timestamped = make([]cachedtimestamped, 0)
+	s.timestampedSeries = make(map[string]bool)
+
 	for _, m := range s.timings {
 		// Defining a template to parse field names for timers allows us to split
 		// out multiple fields per timer. In this case we prefix each stat with the
@@ -430,6 +528,7 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	}
 
 	s.expireCachedMetrics()
+	s.countTenantSeries()
 
 	s.lastGatherTime = now
 	return nil
@@ -439,11 +538,19 @@ func (s *Statsd) Stop() {
 	s.Lock()
 	s.Log.Infof("Stopping the statsd service")
 	close(s.done)
-	if s.isUDP() {
+	switch {
+	case s.isUDP():
 		if s.UDPlistener != nil {
 			s.UDPlistener.Close()
 		}
-	} else {
+	case s.Protocol == "unixgram":
+		if s.UnixgramListener != nil {
+			s.UnixgramListener.Close()
+			if err := os.Remove(s.ServiceAddress); err != nil && !errors.Is(err, os.ErrNotExist) {
+				s.Log.Errorf("Removing socket failed: %v", err)
+			}
+		}
+	default:
 		if s.TCPlistener != nil {
 			s.TCPlistener.Close()
 		}
@@ -517,10 +624,18 @@ func (s *Statsd) tcpListen(listener *net.TCPListener) error {
 	}
 }
 
-// udpListen starts listening for UDP packets on the configured port.
-func (s *Statsd) udpListen(conn *net.UDPConn) error {
+// datagramConn is a packet connection with configurable read buffer such as
+// UDP and unix datagram sockets
+type datagramConn interface {
+	net.PacketConn
+	SetReadBuffer(bytes int) error
+}
+
+// udpListen starts listening for UDP or unix datagram packets on the
+// configured address.
+func (s *Statsd) udpListen(conn datagramConn) error {
 	if s.ReadBufferSize > 0 {
-		if err := s.UDPlistener.SetReadBuffer(s.ReadBufferSize); err != nil {
+		if err := conn.SetReadBuffer(s.ReadBufferSize); err != nil {
 			return err
 		}
 	}
@@ -531,7 +646,7 @@ func (s *Statsd) udpListen(conn *net.UDPConn) error {
 		case <-s.done:
 			return nil
 		default:
-			n, addr, err := conn.ReadFromUDP(buf)
+			n, addr, err := conn.ReadFrom(buf)
 			if err != nil {
 				if !strings.Contains(err.Error(), "closed network") {
 					s.Log.Errorf("Error reading: %s", err.Error())
@@ -547,11 +662,17 @@ func (s *Statsd) udpListen(conn *net.UDPConn) error {
 			}
 			b.Reset()
 			b.Write(buf[:n])
+
+			// Unix datagram sockets have no meaningful source address
+			var source string
+			if udpAddr, ok := addr.(*net.UDPAddr); ok {
+				source = udpAddr.IP.String()
+			}
 			select {
 			case s.in <- input{
 				Buffer: b,
 				Time:   time.Now(),
-				Addr:   addr.IP.String()}:
+				Addr:   source}:
 				s.Stats.PendingMessages.Set(int64(len(s.in)))
 			default:
 				s.Stats.UDPPacketsDrop.Incr(1)
@@ -601,7 +722,7 @@ func (s *Statsd) parser() error {
 						s.Log.Debugf("  line was: %s", line)
 					}
 				default:
-					if err := s.parseStatsdLine(p, line); err != nil {
+					if err := s.parseStatsdLineFrom(p, line, in.Addr); err != nil {
 						if !errors.Is(err, errParsing) {
 							// Ignore parsing errors but error out on
 							// everything else...
@@ -619,7 +740,14 @@ func (s *Statsd) parser() error {
 // parseStatsdLine will parse the given statsd line, validating it as it goes.
 // If the line is valid, it will be cached for the next call to Gather()
 func (s *Statsd) parseStatsdLine(p *graphite.Parser, line string) error {
+	return s.parseStatsdLineFrom(p, line, "")
+}
+
+// parseStatsdLineFrom parses the given statsd line received from the given
+// source address
+func (s *Statsd) parseStatsdLineFrom(p *graphite.Parser, line, addr string) error {
 	lineTags := make(map[string]string)
+	var timestamp time.Time
 	if s.DataDogExtensions {
 		recombinedSegments := make([]string, 0)
 		// datadog tags look like this:
@@ -628,8 +756,16 @@ func (s *Statsd) parseStatsdLine(p *graphite.Parser, line string) error {
 		// we will split on the pipe and remove any elements that are datadog
 		// tags, parse them, and rebuild the line sans the datadog tags
 		pipesplit := strings.Split(line, "|")
-		for _, segment := range pipesplit {
-			if len(segment) > 0 && segment[0] == '#' {
+		for i, segment := range pipesplit {
+			if i > 0 && len(segment) > 1 && segment[0] == 'T' {
+				// This is the optional timestamp field of DogStatsD v1.3
+				ts, err := strconv.ParseInt(segment[1:], 10, 64)
+				if err != nil {
+					s.Log.Errorf("Parsing timestamp %q failed: %v", segment[1:], err)
+					return errParsing
+				}
+				timestamp = time.Unix(ts, 0)
+			} else if len(segment) > 0 && segment[0] == '#' {
 				// we have ourselves a tag; they are comma separated
 				parseDataDogTags(lineTags, segment[1:])
 			} else if len(segment) > 0 && strings.HasPrefix(segment, "c:") {
@@ -656,7 +792,7 @@ func (s *Statsd) parseStatsdLine(p *graphite.Parser, line string) error {
 
 	// Add a metric for each bit available
 	for _, bit := range bits {
-		m := rawMetric{}
+		m := rawMetric{timestamp: timestamp}
 
 		m.bucket = bucketName
 
@@ -757,6 +893,7 @@ func (s *Statsd) parseStatsdLine(p *graphite.Parser, line string) error {
 				m.tags[k] = v
 			}
 		}
+		m.tenant = s.addTenant(m.tags, addr)
 
 		// Make a unique key for the measurement name/tags
 		tg := make([]string, 0, len(m.tags)+1)
@@ -846,6 +983,44 @@ func (s *Statsd) aggregate(m rawMetric) {
 	s.Lock()
 	defer s.Unlock()
 
+	// Drop new series exceeding the limit of the tenant
+	if s.TenantSource != "" && s.TenantMaxSeries > 0 && !s.isCached(m) {
+		if s.tenantSeries == nil {
+			s.tenantSeries = make(map[string]int)
+		}
+		if s.tenantSeries[m.tenant] >= s.TenantMaxSeries {
+			s.tenantDroppedStat(m.tenant).Incr(1)
+			return
+		}
+		s.tenantSeries[m.tenant]++
+	}
+
+	// Metrics with timestamps are not aggregated, see
+	// https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/?tab=metrics#dogstatsd-protocol-v13
+	if !m.timestamp.IsZero() && (m.mtype == "g" || m.mtype == "c") {
+		if s.timestampedSeries == nil {
+			s.timestampedSeries = make(map[string]bool)
+		}
+		s.timestampedSeries[m.mtype+m.hash] = true
+
+		var value interface{} = m.floatvalue
+		if m.mtype == "c" {
+			value = m.intvalue
+			if s.FloatCounters {
+				value = float64(m.intvalue)
+			}
+		}
+		s.timestamped = append(s.timestamped, cachedtimestamped{
+			name:      m.name,
+			mtype:     m.mtype,
+			field:     m.field,
+			value:     value,
+			tags:      m.tags,
+			timestamp: m.timestamp,
+		})
+		return
+	}
+
 	switch m.mtype {
 	case "d":
 		if s.DataDogExtensions && s.DataDogDistributions {
@@ -1024,6 +1199,85 @@ func (s *Statsd) remember(id string, conn *net.TCPConn) {
 	s.conns[id] = conn
 }
 
+// isCached returns true if the series of the metric is already cached
+func (s *Statsd) isCached(m rawMetric) bool {
+	var found bool
+	switch m.mtype {
+	case "d":
+		// Distributions are not cached so never count them as series
+		return true
+	case "ms", "h":
+		_, found = s.timings[m.hash]
+	case "c":
+		_, found = s.counters[m.hash]
+	case "g":
+		_, found = s.gauges[m.hash]
+	case "s":
+		_, found = s.sets[m.hash]
+	}
+	return found || s.timestampedSeries[m.mtype+m.hash]
+}
+
+// tenantDroppedStat returns the statistic of dropped series for the tenant
+// registering it on first use
+func (s *Statsd) tenantDroppedStat(tenant string) selfstat.Stat {
+	if stat, found := s.tenantDropped[tenant]; found {
+		return stat
+	}
+	if s.tenantDropped == nil {
+		s.tenantDropped = make(map[string]selfstat.Stat)
+	}
+	stat := selfstat.Register("statsd", "tenant_series_dropped", map[string]string{
+		"address": s.ServiceAddress,
+		"tenant":  tenant,
+	})
+	s.tenantDropped[tenant] = stat
+	return stat
+}
+
+// addTenant determines the tenant of a metric based on the source address or
+// the configured tag and adds the tenant tag. The tenant is returned.
+func (s *Statsd) addTenant(tags map[string]string, addr string) string {
+	var tenant string
+	switch s.TenantSource {
+	case "":
+		return ""
+	case "address":
+		tenant = addr
+	case "tag":
+		tenant = tags[s.TenantSourceTag]
+		delete(tags, s.TenantSourceTag)
+	}
+	if tenant == "" {
+		tenant = "unknown"
+	}
+	tags[s.TenantTag] = tenant
+	return tenant
+}
+
+// countTenantSeries updates the number of cached series per tenant after
+// series were removed from the caches
+func (s *Statsd) countTenantSeries() {
+	if s.TenantSource == "" || s.TenantMaxSeries == 0 {
+		return
+	}
+
+	counts := make(map[string]int, len(s.tenantSeries))
+	for _, m := range s.gauges {
+		counts[m.tags[s.TenantTag]]++
+	}
+	for _, m := range s.counters {
+		counts[m.tags[s.TenantTag]]++
+	}
+	for _, m := range s.sets {
+		counts[m.tags[s.TenantTag]]++
+	}
+	for _, m := range s.timings {
+		counts[m.tags[s.TenantTag]]++
+	}
+	s.tenantSeries = counts
+}
+
 // IsUDP returns true if the protocol is UDP, false otherwise.
 func (s *Statsd) isUDP() bool {
 	return strings.HasPrefix(s.Protocol, "udp")
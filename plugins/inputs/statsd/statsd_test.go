@@ -3,6 +3,9 @@ package statsd
 import (
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -12,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -2560,3 +2564,269 @@ func TestParse_DeltaCounter(t *testing.T) {
 
 	require.NoError(t, conn.Close())
 }
+
+func TestParse_DataDogTimestamp(t *testing.T) {
+	s := newTestStatsd()
+	s.DataDogExtensions = true
+	s.DataDogKeepContainerTag = true
+
+	parser, err := s.newGraphiteParser()
+	require.NoError(t, err)
+
+	lines := []string{
+		"page.views:15|c|#env:prod|c:83c0a99c0a54|T1656581400",
+		"page.views:3|c|#env:prod|T1656581410",
+		"fuel.level:0.5|g|T1656581400",
+		"fuel.level:0.25|g",
+	}
+	for _, line := range lines {
+		require.NoErrorf(t, s.parseStatsdLine(parser, line), "Parsing line %s should not have resulted in an error", line)
+	}
+	require.ErrorIs(t, s.parseStatsdLine(parser, "fuel.level:0.5|g|Tfoo"), errParsing)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"page_views",
+			map[string]string{"metric_type": "counter", "env": "prod", "container": "83c0a99c0a54"},
+			map[string]interface{}{"value": int64(15)},
+			time.Unix(1656581400, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"page_views",
+			map[string]string{"metric_type": "counter", "env": "prod"},
+			map[string]interface{}{"value": int64(3)},
+			time.Unix(1656581410, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"fuel_level",
+			map[string]string{"metric_type": "gauge"},
+			map[string]interface{}{"value": 0.5},
+			time.Unix(1656581400, 0),
+			telegraf.Gauge,
+		),
+		metric.New(
+			"fuel_level",
+			map[string]string{"metric_type": "gauge"},
+			map[string]interface{}{"value": 0.25},
+			time.Unix(0, 0),
+			telegraf.Gauge,
+		),
+	}
+
+	// The aggregated gauge is published at gather time
+	actual := acc.GetTelegrafMetrics()
+	require.Len(t, actual, len(expected))
+	require.WithinDuration(t, time.Now(), actual[3].Time(), time.Minute)
+	actual[3].SetTime(time.Unix(0, 0))
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestUnixgram(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on Windows")
+	}
+
+	sock := filepath.Join(t.TempDir(), "statsd.sock")
+	plugin := &Statsd{
+		Log:                    testutil.Logger{},
+		Protocol:               "unixgram",
+		ServiceAddress:         sock,
+		SocketMode:             "0666",
+		AllowedPendingMessages: 100,
+		NumberWorkerThreads:    1,
+		DataDogExtensions:      true,
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+
+	info, err := os.Stat(sock)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0666), info.Mode().Perm())
+
+	conn, err := net.Dial("unixgram", sock)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("cpu.time_idle:42|c|#host:foo\n_sc|my.check|1\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, plugin.Gather(&acc))
+		return acc.NMetrics() >= 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"statsd_service_check",
+			map[string]string{"check_name": "my.check"},
+			map[string]interface{}{"status": int64(1), "status_text": "warning"},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"cpu_time_idle",
+			map[string]string{"metric_type": "counter", "host": "foo"},
+			map[string]interface{}{"value": int64(42)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// The socket is removed on stop
+	plugin.Stop()
+	_, err = os.Stat(sock)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTenantInitFail(t *testing.T) {
+	plugin := &Statsd{TenantSource: "header"}
+	require.ErrorContains(t, plugin.Init(), `invalid tenant source "header"`)
+
+	plugin = &Statsd{TenantSource: "tag"}
+	require.ErrorContains(t, plugin.Init(), "'tenant_source_tag' required")
+
+	plugin = &Statsd{TenantSource: "address", TenantMaxSeries: -1}
+	require.ErrorContains(t, plugin.Init(), "cannot be negative")
+}
+
+func TestTenantAddress(t *testing.T) {
+	s := newTestStatsd()
+	s.TenantSource = "address"
+	require.NoError(t, s.Init())
+
+	parser, err := s.newGraphiteParser()
+	require.NoError(t, err)
+	require.NoError(t, s.parseStatsdLineFrom(parser, "requests:1|c", "10.0.0.1"))
+	require.NoError(t, s.parseStatsdLineFrom(parser, "requests:2|c", "10.0.0.2"))
+	require.NoError(t, s.parseStatsdLineFrom(parser, "requests:3|c", "10.0.0.1"))
+	require.NoError(t, s.parseStatsdLine(parser, "requests:4|c"))
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"requests",
+			map[string]string{"metric_type": "counter", "tenant": "10.0.0.1"},
+			map[string]interface{}{"value": int64(4)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"requests",
+			map[string]string{"metric_type": "counter", "tenant": "10.0.0.2"},
+			map[string]interface{}{"value": int64(2)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+		metric.New(
+			"requests",
+			map[string]string{"metric_type": "counter", "tenant": "unknown"},
+			map[string]interface{}{"value": int64(4)},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+}
+
+func TestTenantMaxSeries(t *testing.T) {
+	s := newTestStatsd()
+	s.ServiceAddress = "tenant-max-series-test"
+	s.DataDogExtensions = true
+	s.TenantSource = "tag"
+	s.TenantSourceTag = "service"
+	s.TenantTag = "team"
+	s.TenantMaxSeries = 2
+	s.DeleteTimings = true
+	require.NoError(t, s.Init())
+
+	dropped := selfstat.Register("statsd", "tenant_series_dropped", map[string]string{
+		"address": s.ServiceAddress,
+		"tenant":  "noisy",
+	})
+	start := dropped.Get()
+
+	parser, err := s.newGraphiteParser()
+	require.NoError(t, err)
+
+	// The noisy tenant creates more series than allowed
+	for i := range 5 {
+		line := fmt.Sprintf("latency:%d|ms|#service:noisy,endpoint:e%d", i, i)
+		require.NoError(t, s.parseStatsdLine(parser, line))
+	}
+	// Existing series can still be updated
+	require.NoError(t, s.parseStatsdLine(parser, "latency:10|ms|#service:noisy,endpoint:e0"))
+	// Other tenants are not affected
+	require.NoError(t, s.parseStatsdLine(parser, "latency:1|ms|#service:quiet,endpoint:e0"))
+	require.NoError(t, s.parseStatsdLine(parser, "latency:1|ms|#service:quiet,endpoint:e1"))
+
+	require.Equal(t, int64(3), dropped.Get()-start)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	counts := make(map[string]int)
+	for _, m := range acc.GetTelegrafMetrics() {
+		team, found := m.GetTag("team")
+		require.True(t, found)
+		require.False(t, m.HasTag("service"))
+		counts[team]++
+	}
+	require.Equal(t, map[string]int{"noisy": 2, "quiet": 2}, counts)
+
+	// After the series were deleted, the tenant can create new series again
+	require.NoError(t, s.parseStatsdLine(parser, "latency:1|ms|#service:noisy,endpoint:e4"))
+	require.Equal(t, int64(3), dropped.Get()-start)
+}
+
+func TestTenantMaxSeriesTimestamped(t *testing.T) {
+	s := newTestStatsd()
+	s.ServiceAddress = "tenant-max-series-timestamped-test"
+	s.DataDogExtensions = true
+	s.TenantSource = "tag"
+	s.TenantSourceTag = "service"
+	s.TenantMaxSeries = 2
+	require.NoError(t, s.Init())
+
+	dropped := selfstat.Register("statsd", "tenant_series_dropped", map[string]string{
+		"address": s.ServiceAddress,
+		"tenant":  "noisy",
+	})
+	start := dropped.Get()
+
+	parser, err := s.newGraphiteParser()
+	require.NoError(t, err)
+
+	// Timestamps must not allow to bypass the series limit
+	for i := range 5 {
+		line := fmt.Sprintf("temperature:%d|g|#service:noisy,sensor:s%d|T1700000000", i, i)
+		require.NoError(t, s.parseStatsdLine(parser, line))
+	}
+	// Existing series can still be updated
+	require.NoError(t, s.parseStatsdLine(parser, "temperature:10|g|#service:noisy,sensor:s0|T1700000060"))
+	require.NoError(t, s.parseStatsdLine(parser, "temperature:1|g|#service:quiet,sensor:s0|T1700000000"))
+
+	require.Equal(t, int64(3), dropped.Get()-start)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Gather(&acc))
+
+	counts := make(map[string]int)
+	for _, m := range acc.GetTelegrafMetrics() {
+		tenant, found := m.GetTag("tenant")
+		require.True(t, found)
+		counts[tenant]++
+	}
+	require.Equal(t, map[string]int{"noisy": 3, "quiet": 1}, counts)
+
+	// The timestamped series are released after being emitted
+	require.NoError(t, s.parseStatsdLine(parser, "temperature:1|g|#service:noisy,sensor:s4|T1700000120"))
+	require.Equal(t, int64(3), dropped.Get()-start)
+}
@@ -0,0 +1,5 @@
+//go:build !custom || outputs || outputs.arrow_ipc
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/arrow_ipc" // register plugin
@@ -0,0 +1,5 @@
+//go:build !custom || outputs || outputs.modbus
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/outputs/modbus" // register plugin
@@ -0,0 +1,189 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package arrow_flight
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/flight"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	common_arrow "github.com/influxdata/telegraf/plugins/common/arrow"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type ArrowFlight struct {
+	Address            string            `toml:"address"`
+	Path               []string          `toml:"path"`
+	TimestampFieldName string            `toml:"timestamp_field_name"`
+	Token              config.Secret     `toml:"token"`
+	Headers            map[string]string `toml:"headers"`
+	Timeout            config.Duration   `toml:"timeout"`
+	Log                telegraf.Logger   `toml:"-"`
+	common_tls.ClientConfig
+
+	converter *common_arrow.Converter
+	client    flight.Client
+}
+
+func (*ArrowFlight) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *ArrowFlight) Init() error {
+	if f.Address == "" {
+		return errors.New("'address' must be specified")
+	}
+
+	f.converter = &common_arrow.Converter{
+		TimestampColumn: f.TimestampFieldName,
+		Log:             f.Log,
+	}
+
+	return nil
+}
+
+func (f *ArrowFlight) Connect() error {
+	tlsCfg, err := f.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("creating TLS configuration failed: %w", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsCfg != nil {
+		creds = credentials.NewTLS(tlsCfg)
+	}
+
+	client, err := flight.NewClientWithMiddleware(f.Address, nil, nil, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("creating flight client for %q failed: %w", f.Address, err)
+	}
+	f.client = client
+
+	return nil
+}
+
+func (f *ArrowFlight) Close() error {
+	if f.client == nil {
+		return nil
+	}
+	err := f.client.Close()
+	f.client = nil
+	return err
+}
+
+func (f *ArrowFlight) Write(metrics []telegraf.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(f.Timeout))
+	defer cancel()
+
+	// Add the authentication and custom headers
+	md := metadata.New(f.Headers)
+	if !f.Token.Empty() {
+		token, err := f.Token.Get()
+		if err != nil {
+			return fmt.Errorf("getting token failed: %w", err)
+		}
+		md.Set("authorization", "Bearer "+token.String())
+		token.Destroy()
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	// Group the metrics by measurement keeping track of the metric indices
+	groupedMetrics := make(map[string][]telegraf.Metric)
+	groupedIndices := make(map[string][]int)
+	for i, metric := range metrics {
+		groupedMetrics[metric.Name()] = append(groupedMetrics[metric.Name()], metric)
+		groupedIndices[metric.Name()] = append(groupedIndices[metric.Name()], i)
+	}
+	names := make([]string, 0, len(groupedMetrics))
+	for name := range groupedMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Upload one record batch per measurement and only retry the metrics of
+	// the failed and remaining measurements to avoid duplicates
+	accepted := make([]int, 0, len(metrics))
+	for _, name := range names {
+		if err := f.put(ctx, name, groupedMetrics[name]); err != nil {
+			return &internal.PartialWriteError{
+				Err:           fmt.Errorf("uploading %q failed: %w", name, err),
+				MetricsAccept: accepted,
+			}
+		}
+		accepted = append(accepted, groupedIndices[name]...)
+	}
+
+	return nil
+}
+
+func (f *ArrowFlight) put(ctx context.Context, name string, metrics []telegraf.Metric) error {
+	schema, err := f.converter.Schema(metrics)
+	if err != nil {
+		return fmt.Errorf("creating schema failed: %w", err)
+	}
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+	record := f.converter.RecordBatch(builder, metrics)
+	defer record.Release()
+
+	stream, err := f.client.DoPut(ctx)
+	if err != nil {
+		return fmt.Errorf("starting upload failed: %w", err)
+	}
+
+	// Send the data using a descriptor identifying the measurement
+	writer := flight.NewRecordWriter(stream, ipc.WithSchema(schema))
+	writer.SetFlightDescriptor(&flight.FlightDescriptor{
+		Type: flight.DescriptorPATH,
+		Path: append(slices.Clone(f.Path), name),
+	})
+	if err := writer.Write(record); err != nil {
+		writer.Close()
+		return fmt.Errorf("writing record failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("closing writer failed: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("closing stream failed: %w", err)
+	}
+
+	// Wait for the server to finish processing the data
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func init() {
+	outputs.Add("arrow_flight", func() telegraf.Output {
+		return &ArrowFlight{
+			TimestampFieldName: "timestamp",
+			Timeout:            config.Duration(5 * time.Second),
+		}
+	})
+}
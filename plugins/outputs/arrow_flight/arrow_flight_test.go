@@ -0,0 +1,168 @@
+package arrow_flight
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow/flight"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDoPut(t *testing.T) {
+	// Setup a local flight server
+	srv := &testServer{received: make(map[string]upload)}
+	server := startServer(t, srv)
+
+	plugin := &ArrowFlight{
+		Address:            server.Addr().String(),
+		Path:               []string{"telegraf"},
+		TimestampFieldName: "time",
+		Headers:            map[string]string{"x-database": "metrics"},
+		Token:              config.NewSecret([]byte("secret")),
+		Timeout:            config.Duration(5 * time.Second),
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(42)}, time.Unix(3, 0)),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	srv.Lock()
+	defer srv.Unlock()
+	require.Equal(t, map[string]upload{
+		"telegraf/cpu": {rows: 2, columns: "host,value,time", database: "metrics", authorization: "Bearer secret"},
+		"telegraf/mem": {rows: 1, columns: "host,used,time", database: "metrics", authorization: "Bearer secret"},
+	}, srv.received)
+}
+
+func TestDoPutError(t *testing.T) {
+	srv := &testServer{received: make(map[string]upload), fail: []string{"cpu"}}
+	server := startServer(t, srv)
+
+	plugin := &ArrowFlight{
+		Address: server.Addr().String(),
+		Timeout: config.Duration(5 * time.Second),
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0))
+	require.ErrorContains(t, plugin.Write([]telegraf.Metric{m}), "storage full")
+}
+
+func TestDoPutPartialError(t *testing.T) {
+	srv := &testServer{received: make(map[string]upload), fail: []string{"mem"}}
+	server := startServer(t, srv)
+
+	plugin := &ArrowFlight{
+		Address: server.Addr().String(),
+		Timeout: config.Duration(5 * time.Second),
+		Log:     testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"used": int64(42)}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		metric.New("swap", map[string]string{}, map[string]interface{}{"used": int64(0)}, time.Unix(1, 0)),
+	}
+
+	// Only the uploaded measurements must be accepted, the failed and
+	// remaining measurements are kept for retry
+	err := plugin.Write(metrics)
+	require.ErrorContains(t, err, "storage full")
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+	require.Empty(t, werr.MetricsReject)
+
+	srv.Lock()
+	defer srv.Unlock()
+	require.Contains(t, srv.received, "cpu")
+	require.NotContains(t, srv.received, "swap")
+}
+
+func startServer(t *testing.T, srv *testServer) flight.Server {
+	t.Helper()
+
+	server := flight.NewServerWithMiddleware(nil)
+	require.NoError(t, server.Init("127.0.0.1:0"))
+	server.RegisterFlightService(srv)
+	go server.Serve() //nolint:errcheck // Ignore the returned error as we cannot do anything about it anyway
+	t.Cleanup(server.Shutdown)
+
+	return server
+}
+
+type upload struct {
+	rows          int64
+	columns       string
+	database      string
+	authorization string
+}
+
+type testServer struct {
+	flight.BaseFlightServer
+	received map[string]upload
+	fail     []string
+	sync.Mutex
+}
+
+func (s *testServer) DoPut(stream flight.FlightService_DoPutServer) error {
+	reader, err := flight.NewRecordReader(stream)
+	if err != nil {
+		return err
+	}
+	defer reader.Release()
+
+	if slices.Contains(s.fail, strings.Join(reader.LatestFlightDescriptor().GetPath(), "/")) {
+		return status.Error(codes.ResourceExhausted, "storage full")
+	}
+
+	var u upload
+	names := make([]string, 0, reader.Schema().NumFields())
+	for _, field := range reader.Schema().Fields() {
+		names = append(names, field.Name)
+	}
+	u.columns = strings.Join(names, ",")
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		u.database = strings.Join(md.Get("x-database"), ",")
+		u.authorization = strings.Join(md.Get("authorization"), ",")
+	}
+	path := strings.Join(reader.LatestFlightDescriptor().GetPath(), "/")
+	for reader.Next() {
+		u.rows += reader.RecordBatch().NumRows()
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.received[path] = u
+	s.Unlock()
+
+	return stream.Send(&flight.PutResult{})
+}
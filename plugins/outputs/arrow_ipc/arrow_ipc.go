@@ -0,0 +1,241 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package arrow_ipc
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_arrow "github.com/influxdata/telegraf/plugins/common/arrow"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var defaultTimestampFieldName = "timestamp"
+
+// recordWriter is the common interface of the IPC file and stream writers
+type recordWriter interface {
+	Write(arrow.RecordBatch) error
+	Close() error
+}
+
+type metricGroup struct {
+	filename string
+	file     *os.File
+	builder  *array.RecordBuilder
+	schema   *arrow.Schema
+	writer   recordWriter
+	opened   time.Time
+}
+
+type ArrowIPC struct {
+	Directory          string          `toml:"directory"`
+	Format             string          `toml:"format"`
+	RotationInterval   config.Duration `toml:"rotation_interval"`
+	TimestampFieldName string          `toml:"timestamp_field_name"`
+	Compression        string          `toml:"compression"`
+	Log                telegraf.Logger `toml:"-"`
+
+	converter    *common_arrow.Converter
+	options      []ipc.Option
+	extension    string
+	metricGroups map[string]*metricGroup
+}
+
+func (*ArrowIPC) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *ArrowIPC) Init() error {
+	if a.Directory == "" {
+		a.Directory = "."
+	}
+
+	stat, err := os.Stat(a.Directory)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(a.Directory, 0750); err != nil {
+			return fmt.Errorf("failed to create directory %q: %w", a.Directory, err)
+		}
+	} else if !stat.IsDir() {
+		return fmt.Errorf("provided directory %q is not a directory", a.Directory)
+	}
+
+	switch a.Format {
+	case "", "file":
+		a.Format = "file"
+		a.extension = ".arrow"
+	case "stream":
+		a.extension = ".arrows"
+	default:
+		return fmt.Errorf("invalid format %q", a.Format)
+	}
+
+	switch a.Compression {
+	case "", "none":
+	case "lz4":
+		a.options = append(a.options, ipc.WithLZ4())
+	case "zstd":
+		a.options = append(a.options, ipc.WithZstd())
+	default:
+		return fmt.Errorf("invalid compression %q", a.Compression)
+	}
+
+	a.converter = &common_arrow.Converter{
+		TimestampColumn: a.TimestampFieldName,
+		Log:             a.Log,
+	}
+	a.metricGroups = make(map[string]*metricGroup)
+
+	return nil
+}
+
+func (*ArrowIPC) Connect() error {
+	return nil
+}
+
+func (a *ArrowIPC) Close() error {
+	var errorOccurred bool
+
+	for name, group := range a.metricGroups {
+		if err := group.close(); err != nil {
+			a.Log.Errorf("failed to close file %q: %v", group.filename, err)
+			errorOccurred = true
+		}
+		delete(a.metricGroups, name)
+	}
+
+	if errorOccurred {
+		return errors.New("failed closing one or more arrow files")
+	}
+
+	return nil
+}
+
+func (a *ArrowIPC) Write(metrics []telegraf.Metric) error {
+	now := time.Now()
+
+	// Close all files exceeding the rotation interval
+	rotation := &common_arrow.Rotation{Interval: time.Duration(a.RotationInterval)}
+	for name, group := range a.metricGroups {
+		if !rotation.Due(group.opened, 0, now) {
+			continue
+		}
+		if err := group.close(); err != nil {
+			a.Log.Errorf("failed to close file for rotation %q: %v", group.filename, err)
+		}
+		delete(a.metricGroups, name)
+	}
+
+	groupedMetrics := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		groupedMetrics[metric.Name()] = append(groupedMetrics[metric.Name()], metric)
+	}
+
+	// Write one record batch per measurement
+	for name, metrics := range groupedMetrics {
+		group, err := a.getGroup(name, metrics, now)
+		if err != nil {
+			return err
+		}
+
+		record := a.converter.RecordBatch(group.builder, metrics)
+		err = group.writer.Write(record)
+		record.Release()
+		if err != nil {
+			return fmt.Errorf("failed to write to file %q: %w", group.filename, err)
+		}
+	}
+
+	return nil
+}
+
+// getGroup returns the group for the given measurement, creating a new file
+// if the group does not exist yet or if the metrics contain columns not in the
+// schema of the current file
+func (a *ArrowIPC) getGroup(name string, metrics []telegraf.Metric, now time.Time) (*metricGroup, error) {
+	group, found := a.metricGroups[name]
+
+	var schema *arrow.Schema
+	if found {
+		merged, changed, err := a.converter.MergeSchema(group.schema, metrics)
+		if err != nil {
+			a.Log.Warnf("Ignoring new columns: %v", err)
+		}
+		if !changed {
+			return group, nil
+		}
+
+		// The schema of an IPC stream is fixed, so roll over to a new file
+		a.Log.Debugf("Schema of %q changed, rolling over to a new file", group.filename)
+		if err := group.close(); err != nil {
+			return nil, fmt.Errorf("failed to close file %q: %w", group.filename, err)
+		}
+		delete(a.metricGroups, name)
+		schema = merged
+	} else {
+		var err error
+		schema, err = a.converter.Schema(metrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema for file %q: %w", name, err)
+		}
+	}
+
+	filename := common_arrow.NewFilename(a.Directory, name, a.extension, now)
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %q: %w", filename, err)
+	}
+
+	options := append([]ipc.Option{ipc.WithSchema(schema)}, a.options...)
+	var writer recordWriter
+	if a.Format == "stream" {
+		writer = ipc.NewWriter(file, options...)
+	} else {
+		writer, err = ipc.NewFileWriter(file, options...)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create writer for file %q: %w", filename, err)
+		}
+	}
+
+	group = &metricGroup{
+		filename: filename,
+		file:     file,
+		builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		schema:   schema,
+		writer:   writer,
+		opened:   now,
+	}
+	a.metricGroups[name] = group
+
+	return group, nil
+}
+
+// close finalizes the file by writing the footer or end-of-stream marker
+func (g *metricGroup) close() error {
+	g.builder.Release()
+	if err := g.writer.Close(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
+}
+
+func init() {
+	outputs.Add("arrow_ipc", func() telegraf.Output {
+		return &ArrowIPC{
+			TimestampFieldName: defaultTimestampFieldName,
+		}
+	})
+}
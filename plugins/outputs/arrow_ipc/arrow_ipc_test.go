@@ -0,0 +1,151 @@
+package arrow_ipc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestFormats(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(2, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(42)}, time.Unix(3, 0)),
+	}
+
+	for _, format := range []string{"file", "stream"} {
+		for _, compression := range []string{"none", "lz4", "zstd"} {
+			t.Run(format+"_"+compression, func(t *testing.T) {
+				testDir := t.TempDir()
+				plugin := &ArrowIPC{
+					Directory:          testDir,
+					Format:             format,
+					Compression:        compression,
+					TimestampFieldName: defaultTimestampFieldName,
+					Log:                testutil.Logger{},
+				}
+				require.NoError(t, plugin.Init())
+				require.NoError(t, plugin.Connect())
+				require.NoError(t, plugin.Write(metrics))
+				require.NoError(t, plugin.Write(metrics))
+				require.NoError(t, plugin.Close())
+
+				files, err := os.ReadDir(testDir)
+				require.NoError(t, err)
+				require.Len(t, files, 2)
+
+				// Read the files back and check the content
+				rows := make(map[string]int64)
+				for _, f := range files {
+					table := readFile(t, filepath.Join(testDir, f.Name()), format)
+					var name string
+					if table.Schema().HasField("used") {
+						name = "mem"
+						require.Equal(t, "host, used, timestamp", columnNames(table))
+					} else {
+						name = "cpu"
+						require.Equal(t, "host, value, timestamp", columnNames(table))
+					}
+					rows[name] = table.NumRows()
+					table.Release()
+				}
+				require.Equal(t, map[string]int64{"cpu": 4, "mem": 2}, rows)
+			})
+		}
+	}
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	testDir := t.TempDir()
+	plugin := &ArrowIPC{
+		Directory:          testDir,
+		Format:             "stream",
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	first := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Now())
+	require.NoError(t, plugin.Write([]telegraf.Metric{first}))
+	second := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 2.0}, time.Now())
+	require.NoError(t, plugin.Write([]telegraf.Metric{second}))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	columns := make([]string, 0, len(files))
+	for _, f := range files {
+		table := readFile(t, filepath.Join(testDir, f.Name()), "stream")
+		columns = append(columns, columnNames(table))
+		table.Release()
+	}
+	require.ElementsMatch(t, []string{"value, timestamp", "value, timestamp, host"}, columns)
+}
+
+func TestInvalidSettings(t *testing.T) {
+	plugin := &ArrowIPC{Directory: t.TempDir(), Format: "parquet"}
+	require.ErrorContains(t, plugin.Init(), "invalid format")
+
+	plugin = &ArrowIPC{Directory: t.TempDir(), Compression: "gzip"}
+	require.ErrorContains(t, plugin.Init(), "invalid compression")
+}
+
+func readFile(t *testing.T, filename, format string) arrow.Table {
+	t.Helper()
+
+	f, err := os.Open(filename)
+	require.NoError(t, err)
+	defer f.Close()
+
+	if format == "file" {
+		reader, err := ipc.NewFileReader(f)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		records := make([]arrow.RecordBatch, 0, reader.NumRecords())
+		for i := range reader.NumRecords() {
+			record, err := reader.RecordBatch(i)
+			require.NoError(t, err)
+			record.Retain()
+			records = append(records, record)
+		}
+		return array.NewTableFromRecords(reader.Schema(), records)
+	}
+
+	reader, err := ipc.NewReader(f)
+	require.NoError(t, err)
+	defer reader.Release()
+
+	var records []arrow.RecordBatch
+	for reader.Next() {
+		record := reader.RecordBatch()
+		record.Retain()
+		records = append(records, record)
+	}
+	require.NoError(t, reader.Err())
+	return array.NewTableFromRecords(reader.Schema(), records)
+}
+
+func columnNames(table arrow.Table) string {
+	var names string
+	for i, field := range table.Schema().Fields() {
+		if i > 0 {
+			names += ", "
+		}
+		names += field.Name
+	}
+	return names
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"math"
 	"net/http"
 	"net/url"
@@ -21,6 +22,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -40,6 +42,7 @@ type Elasticsearch struct {
 	HealthCheckInterval config.Duration        `toml:"health_check_interval"`
 	HealthCheckTimeout  config.Duration        `toml:"health_check_timeout"`
 	IndexName           string                 `toml:"index_name"`
+	IndexMode           string                 `toml:"index_mode"`
 	IndexTemplate       map[string]interface{} `toml:"template_index_settings"`
 	ManageTemplate      bool                   `toml:"manage_template"`
 	OverwriteTemplate   bool                   `toml:"overwrite_template"`
@@ -126,6 +129,68 @@ const telegrafTemplate = `
 	}
 }`
 
+const telegrafDataStreamTemplate = `
+{
+	"index_patterns" : [ "{{.TemplatePattern}}" ],
+	"data_stream": {},
+	"priority": 200,
+	"template": {
+		"settings": {
+			"index": {{.IndexTemplate}}
+		},
+		"mappings" : {
+			"properties" : {
+				"@timestamp" : { "type" : "date" },
+				"measurement_name" : {
+					"type" : "keyword"{{ if .TimeSeries }},
+					"time_series_dimension": true{{ end }}
+				}
+			},
+			"dynamic_templates": [
+				{
+					"tags": {
+						"match_mapping_type": "string",
+						"path_match": "tag.*",
+						"mapping": {
+							"ignore_above": 512,
+							"type": "keyword"{{ if .TimeSeries }},
+							"time_series_dimension": true{{ end }}
+						}
+					}
+				},
+				{
+					"metrics_long": {
+						"match_mapping_type": "long",
+						"mapping": {
+							"type": "float",
+							"index": false{{ if .TimeSeries }},
+							"time_series_metric": "gauge"{{ end }}
+						}
+					}
+				},
+				{
+					"metrics_double": {
+						"match_mapping_type": "double",
+						"mapping": {
+							"type": "float",
+							"index": false{{ if .TimeSeries }},
+							"time_series_metric": "gauge"{{ end }}
+						}
+					}
+				},
+				{
+					"text_fields": {
+						"match": "*",
+						"mapping": {
+							"norms": false
+						}
+					}
+				}
+			]
+		}
+	}
+}`
+
 const defaultTemplateIndexSettings = `
 {
 	"refresh_interval": "10s",
@@ -138,6 +203,7 @@ type templatePart struct {
 	TemplatePattern string
 	Version         int
 	IndexTemplate   string
+	TimeSeries      bool
 }
 
 func (*Elasticsearch) SampleConfig() string {
@@ -158,6 +224,21 @@ func (a *Elasticsearch) Connect() error {
 		return fmt.Errorf("invalid float_handling type %q", a.FloatHandling)
 	}
 
+	// Check the index mode
+	switch a.IndexMode {
+	case "":
+		a.IndexMode = "index"
+	case "index", "data_stream":
+	case "time_series":
+		// Document IDs of time series data streams are generated from the
+		// dimensions and the timestamp
+		if a.ForceDocumentID {
+			return errors.New("'force_document_id' is not supported for index mode 'time_series'")
+		}
+	default:
+		return fmt.Errorf("invalid index_mode %q", a.IndexMode)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(a.Timeout))
 	defer cancel()
 
@@ -230,6 +311,11 @@ func (a *Elasticsearch) Connect() error {
 
 	a.Log.Infof("Elasticsearch version: %q", esVersion)
 
+	// Data streams require composable index templates
+	if a.IndexMode != "index" && majorReleaseNumber < 7 {
+		return fmt.Errorf("index mode %q requires Elasticsearch 7.9 or later", a.IndexMode)
+	}
+
 	a.Client = client
 	a.majorReleaseNumber = majorReleaseNumber
 
@@ -336,7 +422,8 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 
 		br := elastic.NewBulkIndexRequest().Index(indexName).Doc(m)
 
-		if a.UseOpTypeCreate {
+		// Data streams only accept the create operation
+		if a.UseOpTypeCreate || a.IndexMode != "index" {
 			br.OpType("create")
 		}
 
@@ -367,22 +454,53 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 		return fmt.Errorf("error sending bulk request to Elasticsearch: %w", err)
 	}
 
-	if res.Errors {
-		for id, err := range res.Failed() {
-			a.Log.Errorf(
-				"Elasticsearch indexing failure, id: %d, status: %d, error: %s, caused by: %s, %s",
-				id,
-				err.Status,
-				err.Error.Reason,
-				err.Error.CausedBy["reason"],
-				err.Error.CausedBy["type"],
-			)
-			break
+	if !res.Errors {
+		return nil
+	}
+	return a.handleBulkResponse(res.Items)
+}
+
+// handleBulkResponse checks the status of each item of the bulk response. Metrics
+// failing with a retryable status are kept for the next write while metrics
+// failing for other reasons, e.g. due to mapping errors, are rejected.
+func (a *Elasticsearch) handleBulkResponse(items []map[string]*elastic.BulkResponseItem) error {
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(items)),
+	}
+	var retry int
+	for i, item := range items {
+		for _, res := range item {
+			switch {
+			case res.Status >= 200 && res.Status < 300:
+				werr.MetricsAccept = append(werr.MetricsAccept, i)
+			case res.Status == http.StatusConflict && (a.UseOpTypeCreate || a.IndexMode != "index"):
+				// The document already exists e.g. because it was written
+				// as part of a previous, partially failed request
+				werr.MetricsAccept = append(werr.MetricsAccept, i)
+			case res.Status == http.StatusTooManyRequests || res.Status == http.StatusServiceUnavailable:
+				retry++
+			default:
+				var reason string
+				if res.Error != nil {
+					reason = res.Error.Reason
+					if cause, ok := res.Error.CausedBy["reason"]; ok && cause != nil && cause != "" {
+						reason += fmt.Sprintf(", caused by: %v, %v", cause, res.Error.CausedBy["type"])
+					}
+				}
+				if len(werr.MetricsReject) == 0 {
+					a.Log.Errorf("Elasticsearch indexing failure, id: %d, status: %d, error: %s", i, res.Status, reason)
+				}
+				werr.MetricsReject = append(werr.MetricsReject, i)
+				werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, fmt.Errorf("status %d: %s", res.Status, reason))
+			}
 		}
-		return fmt.Errorf("elasticsearch failed to index %d metrics", len(res.Failed()))
 	}
 
-	return nil
+	if retry == 0 && len(werr.MetricsReject) == 0 {
+		return nil
+	}
+	werr.Err = fmt.Errorf("elasticsearch failed to index %d metrics, retrying %d metrics", len(werr.MetricsReject)+retry, retry)
+	return werr
 }
 
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
@@ -390,12 +508,6 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 		return errors.New("elasticsearch template_name configuration not defined")
 	}
 
-	templateExists, errExists := a.Client.IndexTemplateExists(a.TemplateName).Do(ctx)
-
-	if errExists != nil {
-		return fmt.Errorf("elasticsearch template check failed, template name: %s, error: %w", a.TemplateName, errExists)
-	}
-
 	templatePattern := a.IndexName
 
 	if strings.Contains(templatePattern, "%") {
@@ -410,6 +522,16 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 		return errors.New("template cannot be created for dynamic index names without an index prefix")
 	}
 
+	if a.IndexMode != "index" {
+		return a.manageDataStreamTemplate(ctx, templatePattern)
+	}
+
+	templateExists, errExists := a.Client.IndexTemplateExists(a.TemplateName).Do(ctx)
+
+	if errExists != nil {
+		return fmt.Errorf("elasticsearch template check failed, template name: %s, error: %w", a.TemplateName, errExists)
+	}
+
 	if (a.OverwriteTemplate) || (!templateExists) || (templatePattern != "") {
 		data, err := a.createNewTemplate(templatePattern)
 		if err != nil {
@@ -428,6 +550,77 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 	return nil
 }
 
+// manageDataStreamTemplate creates a composable index template enabling data
+// streams for indices matching the pattern
+func (a *Elasticsearch) manageDataStreamTemplate(ctx context.Context, templatePattern string) error {
+	path := "/_index_template/" + url.PathEscape(a.TemplateName)
+	if !a.OverwriteTemplate {
+		res, err := a.Client.PerformRequest(ctx, elastic.PerformRequestOptions{
+			Method:       http.MethodHead,
+			Path:         path,
+			IgnoreErrors: []int{http.StatusNotFound},
+		})
+		if err != nil {
+			return fmt.Errorf("elasticsearch template check failed, template name: %s, error: %w", a.TemplateName, err)
+		}
+		if res.StatusCode == http.StatusOK {
+			a.Log.Debug("Found existing Elasticsearch template. Skipping template management")
+			return nil
+		}
+	}
+
+	data, err := a.createNewDataStreamTemplate(templatePattern)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.Client.PerformRequest(ctx, elastic.PerformRequestOptions{
+		Method: http.MethodPut,
+		Path:   path,
+		Body:   data.String(),
+	}); err != nil {
+		return fmt.Errorf("elasticsearch failed to create index template %s: %w", a.TemplateName, err)
+	}
+	a.Log.Debugf("Template %s created or updated", a.TemplateName)
+
+	return nil
+}
+
+func (a *Elasticsearch) createNewDataStreamTemplate(templatePattern string) (*bytes.Buffer, error) {
+	settings := a.IndexTemplate
+	if settings == nil {
+		if err := json.Unmarshal([]byte(defaultTemplateIndexSettings), &settings); err != nil {
+			return nil, err
+		}
+	}
+
+	// Time series data streams route documents by their dimensions
+	if a.IndexMode == "time_series" {
+		settings = maps.Clone(settings)
+		settings["mode"] = "time_series"
+		settings["routing_path"] = []string{"measurement_name", "tag.*"}
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch failed to create index settings for template %s: %w", a.TemplateName, err)
+	}
+
+	tp := templatePart{
+		TemplatePattern: templatePattern + "*",
+		Version:         a.majorReleaseNumber,
+		IndexTemplate:   string(data),
+		TimeSeries:      a.IndexMode == "time_series",
+	}
+
+	t := template.Must(template.New("template").Parse(telegrafDataStreamTemplate))
+	var tmpl bytes.Buffer
+	if err := t.Execute(&tmpl, tp); err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
 func (a *Elasticsearch) createNewTemplate(templatePattern string) (*bytes.Buffer, error) {
 	var indexTemplate string
 	if a.IndexTemplate != nil {
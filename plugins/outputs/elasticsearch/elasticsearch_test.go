@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -972,6 +974,141 @@ func TestProcessHeaders(t *testing.T) {
 	}
 }
 
+func TestBulkResponseItems(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_bulk":
+			response := `{
+				"errors": true,
+				"items": [
+					{"create": {"status": 201}},
+					{"create": {"status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "queue full"}}},
+					{"create": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}},
+					{"create": {"status": 409, "error": {"type": "version_conflict_engine_exception", "reason": "exists"}}},
+					{"create": {"status": 503, "error": {"type": "unavailable_shards_exception", "reason": "unavailable"}}},
+					{"create": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse",
+						"caused_by": {"type": "illegal_argument_exception", "reason": "invalid value"}}}}
+				]
+			}`
+			if _, err := w.Write([]byte(response)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		default:
+			if _, err := w.Write([]byte(`{"version": {"number": "8.15.0"}}`)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		URLs:      []string{"http://" + ts.Listener.Addr().String()},
+		IndexName: "metrics-telegraf",
+		IndexMode: "data_stream",
+		Timeout:   config.Duration(time.Second * 5),
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, e.Connect())
+
+	metrics := make([]telegraf.Metric, 0, 6)
+	for i := range 6 {
+		metrics = append(metrics, testutil.TestMetric(i))
+	}
+	err := e.Write(metrics)
+
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{2, 5}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 2)
+	require.EqualError(t, werr.MetricsRejectErrors[0], "status 400: failed to parse")
+	require.EqualError(t, werr.MetricsRejectErrors[1],
+		"status 400: failed to parse, caused by: invalid value, illegal_argument_exception")
+}
+
+func TestDataStreamOpType(t *testing.T) {
+	var body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_bulk":
+			buf, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+				return
+			}
+			body = string(buf)
+			if _, err := w.Write([]byte(`{"errors": false, "items": [{"create": {"status": 201}}]}`)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		default:
+			if _, err := w.Write([]byte(`{"version": {"number": "8.15.0"}}`)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	e := &Elasticsearch{
+		URLs:      []string{"http://" + ts.Listener.Addr().String()},
+		IndexName: "metrics-telegraf",
+		IndexMode: "time_series",
+		Timeout:   config.Duration(time.Second * 5),
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, e.Connect())
+	require.NoError(t, e.Write([]telegraf.Metric{testutil.TestMetric(1)}))
+	require.Contains(t, body, `{"create":{"_index":"metrics-telegraf"}}`)
+}
+
+func TestIndexModeInvalid(t *testing.T) {
+	e := &Elasticsearch{
+		URLs:      []string{"http://localhost:9200"},
+		IndexName: "metrics-telegraf",
+		IndexMode: "rollup",
+		Log:       testutil.Logger{},
+	}
+	require.ErrorContains(t, e.Connect(), "invalid index_mode")
+
+	e = &Elasticsearch{
+		URLs:            []string{"http://localhost:9200"},
+		IndexName:       "metrics-telegraf",
+		IndexMode:       "time_series",
+		ForceDocumentID: true,
+		Log:             testutil.Logger{},
+	}
+	require.ErrorContains(t, e.Connect(), "'force_document_id' is not supported")
+}
+
+func TestTimeSeriesTemplate(t *testing.T) {
+	e := &Elasticsearch{
+		TemplateName: "test",
+		IndexName:    "metrics-telegraf",
+		IndexMode:    "time_series",
+		Log:          testutil.Logger{},
+	}
+	buf, err := e.createNewDataStreamTemplate("metrics-telegraf")
+	require.NoError(t, err)
+
+	var jsonData struct {
+		IndexPatterns []string               `json:"index_patterns"`
+		DataStream    map[string]interface{} `json:"data_stream"`
+		Template      esTemplate             `json:"template"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &jsonData))
+	require.Equal(t, []string{"metrics-telegraf*"}, jsonData.IndexPatterns)
+	require.NotNil(t, jsonData.DataStream)
+	index := jsonData.Template.Settings.Index
+	require.Equal(t, "time_series", index["mode"])
+	require.Equal(t, []interface{}{"measurement_name", "tag.*"}, index["routing_path"])
+	require.Equal(t, "10s", index["refresh_interval"])
+	require.Contains(t, buf.String(), `"time_series_dimension": true`)
+}
+
 type esTemplate struct {
 	Settings esSettings `json:"settings"`
 }
@@ -26,6 +26,7 @@ import (
 	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
 	common_gcp "github.com/influxdata/telegraf/plugins/common/gcp"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
+	common_tls_secret "github.com/influxdata/telegraf/plugins/common/tls/secret"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -54,6 +55,7 @@ type HTTP struct {
 	AwsService              string                    `toml:"aws_service"`
 	NonRetryableStatusCodes []int                     `toml:"non_retryable_statuscodes"`
 	common_http.HTTPClientConfig
+	common_tls_secret.KeyPair
 	Log telegraf.Logger `toml:"-"`
 
 	client     *http.Client
@@ -75,6 +77,10 @@ func (h *HTTP) SetSerializer(serializer telegraf.Serializer) {
 	h.serializer = serializer
 }
 
+func (h *HTTP) Init() error {
+	return h.KeyPair.Setup(&h.HTTPClientConfig.ClientConfig)
+}
+
 func (h *HTTP) Connect() error {
 	if h.AwsService != "" {
 		cfg, err := h.CredentialConfig.Credentials()
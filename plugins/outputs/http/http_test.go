@@ -20,6 +20,8 @@ import (
 	"github.com/influxdata/telegraf/metric"
 	common_aws "github.com/influxdata/telegraf/plugins/common/aws"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
+	common_tls "github.com/influxdata/telegraf/plugins/common/tls"
+	common_tls_secret "github.com/influxdata/telegraf/plugins/common/tls/secret"
 	"github.com/influxdata/telegraf/plugins/common/oauth"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/plugins/serializers/json"
@@ -821,3 +823,63 @@ func TestAwsCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestCertificateSecrets(t *testing.T) {
+	pki := testutil.NewPKI("../../../testutil/pki")
+
+	// Server requiring a client certificate
+	serverConfig := &common_tls.ServerConfig{
+		TLSCert:           pki.ServerCertPath(),
+		TLSKey:            pki.ServerKeyPath(),
+		TLSAllowedCACerts: []string{pki.CACertPath()},
+	}
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	ts.TLS = serverTLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	plugin := &HTTP{
+		URL:    ts.URL,
+		Method: defaultMethod,
+		HTTPClientConfig: common_http.HTTPClientConfig{
+			TransportConfig: common_http.TransportConfig{
+				ClientConfig: common_tls.ClientConfig{
+					TLSCA: pki.CACertPath(),
+				},
+			},
+		},
+		KeyPair: common_tls_secret.KeyPair{
+			TLSCertSecret: config.NewSecret([]byte(pki.ReadClientCert())),
+			TLSKeySecret:  config.NewSecret([]byte(pki.ReadClientKey())),
+		},
+		Log: testutil.Logger{},
+	}
+
+	serializer := &influx.Serializer{}
+	require.NoError(t, serializer.Init())
+	plugin.SetSerializer(serializer)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+	require.NoError(t, plugin.Write([]telegraf.Metric{getMetric()}))
+}
+
+func TestCertificateSecretsIncomplete(t *testing.T) {
+	plugin := &HTTP{
+		URL: defaultURL,
+		KeyPair: common_tls_secret.KeyPair{
+			TLSCertSecret: config.NewSecret([]byte("cert")),
+		},
+		Log: testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "both 'tls_cert_secret' and 'tls_key_secret' must be set")
+}
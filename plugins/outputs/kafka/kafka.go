@@ -15,6 +15,7 @@ import (
 	"github.com/gofrs/uuid/v5"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/common/proxy"
@@ -27,17 +28,19 @@ var sampleConfig string
 var zeroTime = time.Unix(0, 0)
 
 type Kafka struct {
-	Brokers           []string          `toml:"brokers"`
-	Topic             string            `toml:"topic"`
-	TopicTag          string            `toml:"topic_tag"`
-	ExcludeTopicTag   bool              `toml:"exclude_topic_tag"`
-	TopicSuffix       TopicSuffix       `toml:"topic_suffix"`
-	RoutingTag        string            `toml:"routing_tag"`
-	RoutingKey        string            `toml:"routing_key"`
-	ProducerTimestamp string            `toml:"producer_timestamp"`
-	MetricNameHeader  string            `toml:"metric_name_header" deprecated:"1.39.0;1.45.0;please use 'headers' instead"`
-	Headers           map[string]string `toml:"headers"`
-	Log               telegraf.Logger   `toml:"-"`
+	Brokers            []string          `toml:"brokers"`
+	Topic              string            `toml:"topic"`
+	TopicTag           string            `toml:"topic_tag"`
+	ExcludeTopicTag    bool              `toml:"exclude_topic_tag"`
+	TopicSuffix        TopicSuffix       `toml:"topic_suffix"`
+	RoutingTag         string            `toml:"routing_tag"`
+	RoutingKey         string            `toml:"routing_key"`
+	ProducerTimestamp  string            `toml:"producer_timestamp"`
+	MetricNameHeader   string            `toml:"metric_name_header" deprecated:"1.39.0;1.45.0;please use 'headers' instead"`
+	Headers            map[string]string `toml:"headers"`
+	TransactionalID    string            `toml:"transactional_id"`
+	TransactionTimeout config.Duration   `toml:"transaction_timeout"`
+	Log                telegraf.Logger   `toml:"-"`
 	proxy.Socks5ProxyConfig
 	kafka.WriteConfig
 
@@ -82,22 +85,36 @@ func (k *Kafka) Init() error {
 		k.Headers[k.MetricNameHeader] = "{{ .Name }}"
 	}
 
+	// Transactions require idempotent writes acknowledged by all replicas
+	if k.TransactionalID != "" {
+		if k.RequiredAcks != -1 {
+			return errors.New("'transactional_id' requires 'required_acks' to be -1")
+		}
+		k.IdempotentWrites = true
+	}
+
 	// Create new configuration
-	config := sarama.NewConfig()
-	if err := k.SetConfig(config, k.Log); err != nil {
+	cfg := sarama.NewConfig()
+	if err := k.SetConfig(cfg, k.Log); err != nil {
 		return err
 	}
+	if k.TransactionalID != "" {
+		cfg.Producer.Transaction.ID = k.TransactionalID
+		if k.TransactionTimeout > 0 {
+			cfg.Producer.Transaction.Timeout = time.Duration(k.TransactionTimeout)
+		}
+	}
 
 	if k.Socks5ProxyEnabled {
-		config.Net.Proxy.Enable = true
+		cfg.Net.Proxy.Enable = true
 
 		dialer, err := k.Socks5ProxyConfig.GetDialer()
 		if err != nil {
 			return fmt.Errorf("connecting to proxy server failed: %w", err)
 		}
-		config.Net.Proxy.Dialer = dialer
+		cfg.Net.Proxy.Dialer = dialer
 	}
-	k.saramaConfig = config
+	k.saramaConfig = cfg
 
 	switch k.ProducerTimestamp {
 	case "":
@@ -138,12 +155,18 @@ func (k *Kafka) Close() error {
 
 func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	msgs := make([]*sarama.ProducerMessage, 0, len(metrics))
-	for _, metric := range metrics {
+	werr := &internal.PartialWriteError{
+		MetricsAccept: make([]int, 0, len(metrics)),
+	}
+	for i, metric := range metrics {
 		metric, topic := k.getTopicName(metric)
 
 		buf, err := k.serializer.Serialize(metric)
 		if err != nil {
 			k.Log.Debugf("Could not serialize metric: %v", err)
+			werr.Err = internal.ErrSerialization
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, err)
 			continue
 		}
 
@@ -182,31 +205,137 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 		}
 
 		msgs = append(msgs, m)
+		werr.MetricsAccept = append(werr.MetricsAccept, i)
+	}
+
+	if k.TransactionalID == "" {
+		return k.handleSendError(k.producer.SendMessages(msgs))
+	}
+
+	// Send the batch within a transaction to make it visible to consumers
+	// atomically. Failing batches are aborted and retried without creating
+	// duplicates for consumers reading committed messages.
+	if err := k.sendTransaction(msgs); err != nil {
+		return k.handleTransactionError(err, msgs, werr)
+	}
+
+	// Reject metrics that failed to serialize to avoid retrying them
+	if werr.Err != nil {
+		return werr
+	}
+	return nil
+}
+
+func (k *Kafka) sendTransaction(msgs []*sarama.ProducerMessage) error {
+	// A producer in fatal state cannot be used for transactions anymore so
+	// create a new one fencing off the old instance
+	if k.producer.TxnStatus()&sarama.ProducerTxnFlagFatalError != 0 {
+		k.Log.Warn("Producer in fatal transaction state, recreating producer")
+		if err := k.producer.Close(); err != nil {
+			k.Log.Debugf("Closing producer failed: %v", err)
+		}
+		producer, err := k.producerFunc(k.Brokers, k.saramaConfig)
+		if err != nil {
+			return fmt.Errorf("recreating producer failed: %w", err)
+		}
+		k.producer = producer
+	}
+
+	if err := k.producer.BeginTxn(); err != nil {
+		return fmt.Errorf("beginning transaction failed: %w", err)
 	}
 
 	if err := k.producer.SendMessages(msgs); err != nil {
-		// We could have many errors, return only the first encountered.
-		var errs sarama.ProducerErrors
-		if errors.As(err, &errs) && len(errs) > 0 {
-			// Just return the first error encountered
-			firstErr := errs[0]
-			if errors.Is(firstErr.Err, sarama.ErrMessageSizeTooLarge) {
-				k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping batch")
-				return nil
+		k.abortTransaction()
+		return err
+	}
+
+	if err := k.producer.CommitTxn(); err != nil {
+		k.abortTransaction()
+		return fmt.Errorf("committing transaction failed: %w", err)
+	}
+
+	return nil
+}
+
+func (k *Kafka) abortTransaction() {
+	status := k.producer.TxnStatus()
+	if status&(sarama.ProducerTxnFlagInTransaction|sarama.ProducerTxnFlagAbortableError) == 0 {
+		return
+	}
+	if err := k.producer.AbortTxn(); err != nil {
+		k.Log.Errorf("Aborting transaction failed: %v", err)
+	}
+}
+
+// handleTransactionError handles the error of an aborted transaction. None of
+// the messages were delivered, so metrics that can never be delivered, e.g.
+// due to their size, are rejected while all other metrics are kept for retry.
+func (k *Kafka) handleTransactionError(err error, msgs []*sarama.ProducerMessage, werr *internal.PartialWriteError) error {
+	// The accepted indices refer to the metrics of the messages in order
+	index := make(map[*sarama.ProducerMessage]int, len(msgs))
+	for j, m := range msgs {
+		index[m] = werr.MetricsAccept[j]
+	}
+	werr.MetricsAccept = nil
+
+	var errs sarama.ProducerErrors
+	if errors.As(err, &errs) {
+		for _, e := range errs {
+			i, found := index[e.Msg]
+			if !found {
+				continue
 			}
-			if errors.Is(firstErr.Err, sarama.ErrInvalidTimestamp) {
-				k.Log.Error(
-					"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
-						"dropping batch",
+			switch {
+			case errors.Is(e.Err, sarama.ErrMessageSizeTooLarge):
+				k.Log.Errorf("Message too large, consider increasing `max_message_bytes`; dropping metric %d", i)
+			case errors.Is(e.Err, sarama.ErrInvalidTimestamp):
+				k.Log.Errorf(
+					"The timestamp of the message is out of acceptable range, consider increasing broker "+
+						"`message.timestamp.difference.max.ms`; dropping metric %d", i,
 				)
-				return nil
+			default:
+				continue
 			}
-			return firstErr
+			werr.MetricsReject = append(werr.MetricsReject, i)
+			werr.MetricsRejectErrors = append(werr.MetricsRejectErrors, e.Err)
+		}
+		if len(errs) > 0 {
+			err = errs[0]
 		}
+	}
+
+	if len(werr.MetricsReject) == 0 {
 		return err
 	}
+	werr.Err = err
+	return werr
+}
 
-	return nil
+func (k *Kafka) handleSendError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// We could have many errors, return only the first encountered.
+	var errs sarama.ProducerErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		// Just return the first error encountered
+		firstErr := errs[0]
+		if errors.Is(firstErr.Err, sarama.ErrMessageSizeTooLarge) {
+			k.Log.Error("Message too large, consider increasing `max_message_bytes`; dropping batch")
+			return nil
+		}
+		if errors.Is(firstErr.Err, sarama.ErrInvalidTimestamp) {
+			k.Log.Error(
+				"The timestamp of the message is out of acceptable range, consider increasing broker `message.timestamp.difference.max.ms`; " +
+					"dropping batch",
+			)
+			return nil
+		}
+		return firstErr
+	}
+	return err
 }
 
 func (k *Kafka) getTopicName(metric telegraf.Metric) (telegraf.Metric, string) {
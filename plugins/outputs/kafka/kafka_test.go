@@ -1,6 +1,8 @@
 package kafka
 
 import (
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -10,7 +12,9 @@ import (
 	kafkacontainer "github.com/testcontainers/testcontainers-go/modules/kafka"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/kafka"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -367,8 +371,122 @@ func TestHeaders(t *testing.T) {
 	}
 }
 
+func TestTransactionalInvalidAcks(t *testing.T) {
+	plugin := &Kafka{
+		Brokers:         []string{"127.0.0.1"},
+		Topic:           "telegraf",
+		TransactionalID: "telegraf-1",
+		WriteConfig:     kafka.WriteConfig{MaxRetry: 3, RequiredAcks: 1},
+		Log:             testutil.Logger{},
+		producerFunc:    newMockProducer,
+	}
+	require.ErrorContains(t, plugin.Init(), "requires 'required_acks' to be -1")
+}
+
+func TestTransactional(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"used": 23.0}, time.Unix(0, 0)),
+	}
+
+	// Setup the serializer
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	// Setup the plugin under test
+	plugin := &Kafka{
+		Brokers:         []string{"127.0.0.1"},
+		Topic:           "telegraf",
+		TransactionalID: "telegraf-1",
+		WriteConfig:     kafka.WriteConfig{MaxRetry: 3, RequiredAcks: -1},
+		Log:             testutil.Logger{},
+		producerFunc:    newMockProducer,
+	}
+	plugin.SetSerializer(s)
+	require.NoError(t, plugin.Init())
+	require.True(t, plugin.saramaConfig.Producer.Idempotent)
+	require.Equal(t, "telegraf-1", plugin.saramaConfig.Producer.Transaction.ID)
+	require.NoError(t, plugin.Connect())
+
+	producer, ok := plugin.producer.(*mockProducer)
+	require.True(t, ok, "invalid producer type")
+
+	// A failing send must abort the transaction and keep the whole batch
+	producer.fail = errors.New("broker unavailable")
+	require.ErrorContains(t, plugin.Write(input), "broker unavailable")
+	require.Equal(t, 1, producer.aborted)
+	require.Zero(t, producer.committed)
+	require.Empty(t, producer.sent)
+
+	// A successful send must commit the transaction and reject the metric
+	// failing to serialize
+	producer.fail = nil
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorIs(t, err, internal.ErrSerialization)
+	require.Equal(t, []int{0, 2}, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Equal(t, 1, producer.aborted)
+	require.Equal(t, 1, producer.committed)
+	require.Len(t, producer.sent, 2)
+}
+
+func TestTransactionalMessageTooLarge(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": strings.Repeat("x", 1024)}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"used": 23.0}, time.Unix(0, 0)),
+	}
+
+	// Setup the serializer
+	s := &influx.Serializer{}
+	require.NoError(t, s.Init())
+
+	// Setup the plugin under test
+	plugin := &Kafka{
+		Brokers:         []string{"127.0.0.1"},
+		Topic:           "telegraf",
+		TransactionalID: "telegraf-1",
+		WriteConfig:     kafka.WriteConfig{MaxRetry: 3, RequiredAcks: -1},
+		Log:             testutil.Logger{},
+		producerFunc:    newMockProducer,
+	}
+	plugin.SetSerializer(s)
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	producer, ok := plugin.producer.(*mockProducer)
+	require.True(t, ok, "invalid producer type")
+	producer.maxSize = 512
+
+	// The oversized metric must be rejected while keeping the other metrics
+	// of the aborted transaction for retry
+	err := plugin.Write(input)
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.ErrorIs(t, err, sarama.ErrMessageSizeTooLarge)
+	require.Empty(t, werr.MetricsAccept)
+	require.Equal(t, []int{1}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+	require.Equal(t, 1, producer.aborted)
+	require.Zero(t, producer.committed)
+	require.Empty(t, producer.sent)
+
+	// Retrying the remaining metrics must succeed
+	require.NoError(t, plugin.Write([]telegraf.Metric{input[0], input[2]}))
+	require.Equal(t, 1, producer.committed)
+	require.Len(t, producer.sent, 2)
+}
+
 type mockProducer struct {
-	sent []*sarama.ProducerMessage
+	sent      []*sarama.ProducerMessage
+	fail      error
+	maxSize   int
+	txnStatus sarama.ProducerTxnStatusFlag
+	committed int
+	aborted   int
 	sarama.SyncProducer
 	sync.Mutex
 }
@@ -387,10 +505,47 @@ func (p *mockProducer) SendMessage(msg *sarama.ProducerMessage) (partition int32
 func (p *mockProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
 	p.Lock()
 	defer p.Unlock()
+	if p.fail != nil {
+		p.txnStatus |= sarama.ProducerTxnFlagAbortableError
+		return p.fail
+	}
+	if p.maxSize > 0 {
+		var errs sarama.ProducerErrors
+		for _, msg := range msgs {
+			if msg.Value.Length() > p.maxSize {
+				errs = append(errs, &sarama.ProducerError{Msg: msg, Err: sarama.ErrMessageSizeTooLarge})
+			}
+		}
+		if len(errs) > 0 {
+			p.txnStatus |= sarama.ProducerTxnFlagAbortableError
+			return errs
+		}
+	}
 	p.sent = append(p.sent, msgs...)
 	return nil
 }
 
+func (p *mockProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	return p.txnStatus
+}
+
+func (p *mockProducer) BeginTxn() error {
+	p.txnStatus = sarama.ProducerTxnFlagInTransaction
+	return nil
+}
+
+func (p *mockProducer) CommitTxn() error {
+	p.txnStatus = sarama.ProducerTxnFlagReady
+	p.committed++
+	return nil
+}
+
+func (p *mockProducer) AbortTxn() error {
+	p.txnStatus = sarama.ProducerTxnFlagReady
+	p.aborted++
+	return nil
+}
+
 func (*mockProducer) Close() error {
 	return nil
 }
@@ -0,0 +1,521 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package modbus
+
+import (
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	mb "github.com/grid-x/modbus"
+	"github.com/tbrandon/mbserver"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_modbus "github.com/influxdata/telegraf/plugins/common/modbus"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// Maximum number of registers in a "write multiple registers" request
+const maxQuantityWriteRegisters = 123
+
+type Modbus struct {
+	Mode                   string              `toml:"mode"`
+	Controller             string              `toml:"controller"`
+	ServiceAddress         string              `toml:"service_address"`
+	TransmissionMode       string              `toml:"transmission_mode"`
+	BaudRate               int                 `toml:"baud_rate"`
+	DataBits               int                 `toml:"data_bits"`
+	Parity                 string              `toml:"parity"`
+	StopBits               int                 `toml:"stop_bits"`
+	Timeout                config.Duration     `toml:"timeout"`
+	StringRegisterLocation string              `toml:"string_register_location"`
+	Requests               []requestDefinition `toml:"request"`
+	Log                    telegraf.Logger     `toml:"-"`
+
+	requests []request
+
+	// Client mode
+	handler     mb.ClientHandler
+	client      mb.Client
+	isConnected bool
+
+	// Server mode
+	server *mbserver.Server
+	sync.Mutex
+}
+
+type requestDefinition struct {
+	SlaveID      byte              `toml:"slave_id"`
+	ByteOrder    string            `toml:"byte_order"`
+	RegisterType string            `toml:"register"`
+	Measurement  string            `toml:"measurement"`
+	Fields       []fieldDefinition `toml:"fields"`
+	Tags         map[string]string `toml:"tags"`
+}
+
+type fieldDefinition struct {
+	Address   uint16  `toml:"address"`
+	Name      string  `toml:"name"`
+	InputType string  `toml:"type"`
+	Length    uint16  `toml:"length"`
+	Scale     float64 `toml:"scale"`
+	Bit       uint8   `toml:"bit"`
+}
+
+type request struct {
+	slaveID      byte
+	registerType string
+	measurement  string
+	tags         map[string]string
+	fields       []field
+}
+
+type field struct {
+	name     string
+	address  uint16
+	length   uint16
+	bitmask  uint16
+	bytemask uint16
+	encoder  common_modbus.FieldEncoderFunc
+}
+
+func (*Modbus) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Modbus) Init() error {
+	switch m.Mode {
+	case "":
+		m.Mode = "client"
+	case "client", "server":
+	default:
+		return fmt.Errorf("invalid mode %q", m.Mode)
+	}
+
+	switch m.StringRegisterLocation {
+	case "", "both", "lower", "upper":
+	default:
+		return fmt.Errorf("invalid 'string_register_location' %q", m.StringRegisterLocation)
+	}
+
+	if len(m.Requests) == 0 {
+		return errors.New("no requests defined")
+	}
+
+	m.requests = make([]request, 0, len(m.Requests))
+	for i, def := range m.Requests {
+		r, err := m.processRequest(def)
+		if err != nil {
+			return fmt.Errorf("request %d: %w", i+1, err)
+		}
+		m.requests = append(m.requests, r)
+	}
+
+	if m.Mode == "server" {
+		// The server provides a single register space for all unit IDs
+		for _, r := range m.requests[1:] {
+			if r.slaveID != m.requests[0].slaveID {
+				return errors.New("server mode does not support multiple slave IDs")
+			}
+		}
+		if m.ServiceAddress == "" {
+			m.ServiceAddress = "tcp://:502"
+		}
+		return nil
+	}
+
+	if err := m.initClient(); err != nil {
+		return fmt.Errorf("initializing client failed for controller %q: %w", m.Controller, err)
+	}
+	return nil
+}
+
+func (m *Modbus) processRequest(def requestDefinition) (request, error) {
+	byteOrder := def.ByteOrder
+	if byteOrder == "" {
+		byteOrder = "ABCD"
+	}
+	byteOrder, err := common_modbus.NormalizeByteOrder(byteOrder)
+	if err != nil {
+		return request{}, err
+	}
+
+	// Only coils and holding registers are writable on real devices, all
+	// register types can be served in server mode.
+	switch def.RegisterType {
+	case "":
+		def.RegisterType = "holding"
+	case "coil", "holding":
+	case "discrete", "input":
+		if m.Mode != "server" {
+			return request{}, fmt.Errorf("register-type %q is only supported in server mode", def.RegisterType)
+		}
+	default:
+		return request{}, fmt.Errorf("unknown register-type %q", def.RegisterType)
+	}
+
+	if len(def.Fields) == 0 {
+		return request{}, errors.New("found request section without fields")
+	}
+
+	r := request{
+		slaveID:      def.SlaveID,
+		registerType: def.RegisterType,
+		measurement:  def.Measurement,
+		tags:         def.Tags,
+		fields:       make([]field, 0, len(def.Fields)),
+	}
+	for _, fdef := range def.Fields {
+		if fdef.Name == "" {
+			return request{}, fmt.Errorf("empty field name for address %d", fdef.Address)
+		}
+
+		f := field{
+			name:    fdef.Name,
+			address: fdef.Address,
+			length:  1,
+		}
+		switch def.RegisterType {
+		case "coil", "discrete":
+			f.encoder = common_modbus.DetermineUntypedEncoder()
+		default:
+			switch fdef.InputType {
+			case "BIT":
+				f.bitmask, err = common_modbus.BitMask(byteOrder, fdef.Bit)
+			case "STRING":
+				if fdef.Length == 0 {
+					return request{}, fmt.Errorf("missing length for string field %q", fdef.Name)
+				}
+				f.length = fdef.Length
+			case "INT8L", "INT8H", "UINT8L", "UINT8H":
+				f.bytemask, err = common_modbus.ByteMask(fdef.InputType, byteOrder)
+			case "INT16", "UINT16", "FLOAT16":
+			case "INT32", "UINT32", "FLOAT32":
+				f.length = 2
+			case "INT64", "UINT64", "FLOAT64":
+				f.length = 4
+			default:
+				return request{}, fmt.Errorf("unknown type %q for field %q", fdef.InputType, fdef.Name)
+			}
+			if err != nil {
+				return request{}, fmt.Errorf("field %q: %w", fdef.Name, err)
+			}
+			if f.bitmask == 0 {
+				f.encoder, err = common_modbus.DetermineEncoder(fdef.InputType, byteOrder, fdef.Scale, f.length, m.StringRegisterLocation)
+				if err != nil {
+					return request{}, fmt.Errorf("field %q: %w", fdef.Name, err)
+				}
+			}
+		}
+		if f.length > maxQuantityWriteRegisters {
+			return request{}, fmt.Errorf("field %q exceeds %d registers", fdef.Name, maxQuantityWriteRegisters)
+		}
+		if uint32(f.address)+uint32(f.length) > 65536 {
+			return request{}, fmt.Errorf("field %q: address overflow", fdef.Name)
+		}
+		r.fields = append(r.fields, f)
+	}
+
+	return r, nil
+}
+
+func (m *Modbus) initClient() error {
+	u, err := url.Parse(m.Controller)
+	if err != nil {
+		return err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		host, port, err := net.SplitHostPort(u.Host)
+		if err != nil {
+			return err
+		}
+		switch m.TransmissionMode {
+		case "", "auto", "TCP":
+			handler := mb.NewTCPClientHandler(host + ":" + port)
+			handler.Timeout = time.Duration(m.Timeout)
+			m.handler = handler
+		case "RTUoverTCP":
+			handler := mb.NewRTUOverTCPClientHandler(host + ":" + port)
+			handler.Timeout = time.Duration(m.Timeout)
+			m.handler = handler
+		case "ASCIIoverTCP":
+			handler := mb.NewASCIIOverTCPClientHandler(host + ":" + port)
+			handler.Timeout = time.Duration(m.Timeout)
+			m.handler = handler
+		default:
+			return fmt.Errorf("invalid transmission mode %q for %q", m.TransmissionMode, u.Scheme)
+		}
+	case "", "file":
+		path := filepath.Join(u.Host, u.Path)
+		if path == "" {
+			return fmt.Errorf("invalid path for controller %q", m.Controller)
+		}
+		switch m.TransmissionMode {
+		case "", "auto", "RTU":
+			handler := mb.NewRTUClientHandler(path)
+			handler.Timeout = time.Duration(m.Timeout)
+			handler.BaudRate = m.BaudRate
+			handler.DataBits = m.DataBits
+			handler.Parity = m.Parity
+			handler.StopBits = m.StopBits
+			m.handler = handler
+		case "ASCII":
+			handler := mb.NewASCIIClientHandler(path)
+			handler.Timeout = time.Duration(m.Timeout)
+			handler.BaudRate = m.BaudRate
+			handler.DataBits = m.DataBits
+			handler.Parity = m.Parity
+			handler.StopBits = m.StopBits
+			m.handler = handler
+		default:
+			return fmt.Errorf("invalid transmission mode %q for %q", m.TransmissionMode, u.Scheme)
+		}
+	default:
+		return fmt.Errorf("invalid controller %q", m.Controller)
+	}
+
+	m.client = mb.NewClient(m.handler)
+	return nil
+}
+
+func (m *Modbus) Connect() error {
+	if m.Mode == "server" {
+		return m.startServer()
+	}
+
+	if err := m.handler.Connect(); err != nil {
+		return fmt.Errorf("connecting to %q failed: %w", m.Controller, err)
+	}
+	m.isConnected = true
+	return nil
+}
+
+func (m *Modbus) Close() error {
+	if m.server != nil {
+		m.server.Close()
+		m.server = nil
+		return nil
+	}
+	if m.handler != nil && m.isConnected {
+		m.isConnected = false
+		return m.handler.Close()
+	}
+	return nil
+}
+
+func (m *Modbus) Write(metrics []telegraf.Metric) error {
+	if m.Mode == "client" && !m.isConnected {
+		if err := m.handler.Connect(); err != nil {
+			return fmt.Errorf("connecting to %q failed: %w", m.Controller, err)
+		}
+		m.isConnected = true
+	}
+
+	for _, metric := range metrics {
+		for _, r := range m.requests {
+			if !r.matches(metric) {
+				continue
+			}
+			for _, f := range r.fields {
+				v, found := metric.GetField(f.name)
+				if !found {
+					continue
+				}
+				if err := m.writeField(r, f, v); err != nil {
+					var encErr *encodingError
+					if errors.As(err, &encErr) {
+						// Retrying will not help, so drop the value
+						m.Log.Errorf("Writing field %q of metric %q failed: %v", f.name, metric.Name(), err)
+						continue
+					}
+					// Force a reconnect on the next write
+					if m.Mode == "client" {
+						m.isConnected = false
+						_ = m.handler.Close()
+					}
+					return fmt.Errorf("writing field %q failed: %w", f.name, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// matches checks if the metric has the configured name and tags of the request
+func (r *request) matches(metric telegraf.Metric) bool {
+	if r.measurement != "" && metric.Name() != r.measurement {
+		return false
+	}
+	for k, v := range r.tags {
+		if tv, found := metric.GetTag(k); !found || tv != v {
+			return false
+		}
+	}
+	return true
+}
+
+type encodingError struct {
+	err error
+}
+
+func (e *encodingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *encodingError) Unwrap() error {
+	return e.err
+}
+
+func (m *Modbus) writeField(r request, f field, v interface{}) error {
+	// Handle single bits of a register
+	if f.bitmask != 0 {
+		set, err := common_modbus.DetermineUntypedEncoder()(v)
+		if err != nil {
+			return &encodingError{err}
+		}
+		var value uint16
+		if set[0] != 0 {
+			value = f.bitmask
+		}
+		return m.maskWrite(r, f.address, f.bitmask, value)
+	}
+
+	b, err := f.encoder(v)
+	if err != nil {
+		return &encodingError{err}
+	}
+
+	// Handle single bytes of a register, keeping the other byte intact
+	if f.bytemask != 0 {
+		return m.maskWrite(r, f.address, f.bytemask, binary.BigEndian.Uint16(b)&f.bytemask)
+	}
+
+	if m.server != nil {
+		m.Lock()
+		defer m.Unlock()
+		switch r.registerType {
+		case "coil":
+			m.server.Coils[f.address] = b[0]
+		case "discrete":
+			m.server.DiscreteInputs[f.address] = b[0]
+		case "holding":
+			for i := range f.length {
+				m.server.HoldingRegisters[f.address+i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+		case "input":
+			for i := range f.length {
+				m.server.InputRegisters[f.address+i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+		}
+		return nil
+	}
+
+	m.handler.SetSlave(r.slaveID)
+	switch r.registerType {
+	case "coil":
+		var value uint16
+		if b[0] != 0 {
+			value = 0xff00
+		}
+		_, err = m.client.WriteSingleCoil(f.address, value)
+	case "holding":
+		if f.length == 1 {
+			_, err = m.client.WriteSingleRegister(f.address, binary.BigEndian.Uint16(b))
+		} else {
+			_, err = m.client.WriteMultipleRegisters(f.address, f.length, b)
+		}
+	}
+	return err
+}
+
+// maskWrite only modifies the bits of the register selected by the mask
+func (m *Modbus) maskWrite(r request, address, mask, value uint16) error {
+	if m.server != nil {
+		m.Lock()
+		defer m.Unlock()
+		registers := m.server.HoldingRegisters
+		if r.registerType == "input" {
+			registers = m.server.InputRegisters
+		}
+		registers[address] = registers[address]&^mask | value
+		return nil
+	}
+	m.handler.SetSlave(r.slaveID)
+	_, err := m.client.MaskWriteRegister(address, ^mask, value)
+	return err
+}
+
+// startServer starts a Modbus server serving the written values to clients
+func (m *Modbus) startServer() error {
+	u, err := url.Parse(m.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("invalid service address %q: %w", m.ServiceAddress, err)
+	}
+	if u.Scheme != "tcp" {
+		return fmt.Errorf("invalid scheme %q for service address, only 'tcp' is supported", u.Scheme)
+	}
+
+	server := mbserver.NewServer()
+
+	// Protect the register memory from concurrent access
+	handlers := map[uint8]func(*mbserver.Server, mbserver.Framer) ([]byte, *mbserver.Exception){
+		1:  mbserver.ReadCoils,
+		2:  mbserver.ReadDiscreteInputs,
+		3:  mbserver.ReadHoldingRegisters,
+		4:  mbserver.ReadInputRegisters,
+		5:  mbserver.WriteSingleCoil,
+		6:  mbserver.WriteHoldingRegister,
+		15: mbserver.WriteMultipleCoils,
+		16: mbserver.WriteHoldingRegisters,
+		22: maskWriteRegister,
+	}
+	for code, fn := range handlers {
+		server.RegisterFunctionHandler(code, func(s *mbserver.Server, frame mbserver.Framer) ([]byte, *mbserver.Exception) {
+			m.Lock()
+			defer m.Unlock()
+			return fn(s, frame)
+		})
+	}
+
+	if err := server.ListenTCP(u.Host); err != nil {
+		return fmt.Errorf("listening on %q failed: %w", m.ServiceAddress, err)
+	}
+	m.server = server
+	m.Log.Infof("Serving registers on %q", m.ServiceAddress)
+
+	return nil
+}
+
+// maskWriteRegister implements function 22 not provided by the server library
+func maskWriteRegister(s *mbserver.Server, frame mbserver.Framer) ([]byte, *mbserver.Exception) {
+	data := frame.GetData()
+	if len(data) < 6 {
+		return []byte{}, &mbserver.IllegalDataValue
+	}
+	address := binary.BigEndian.Uint16(data[0:2])
+	andMask := binary.BigEndian.Uint16(data[2:4])
+	orMask := binary.BigEndian.Uint16(data[4:6])
+	s.HoldingRegisters[address] = s.HoldingRegisters[address]&andMask | orMask&^andMask
+	return data[0:6], &mbserver.Success
+}
+
+// Add this plugin to telegraf
+func init() {
+	outputs.Add("modbus", func() telegraf.Output {
+		return &Modbus{
+			Timeout: config.Duration(time.Second),
+		}
+	})
+}
@@ -0,0 +1,410 @@
+package modbus
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	mb "github.com/grid-x/modbus"
+	"github.com/stretchr/testify/require"
+	"github.com/tbrandon/mbserver"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	common_modbus "github.com/influxdata/telegraf/plugins/common/modbus"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Modbus
+		expected string
+	}{
+		{
+			name:     "invalid mode",
+			plugin:   &Modbus{Mode: "foo"},
+			expected: `invalid mode "foo"`,
+		},
+		{
+			name:     "no requests",
+			plugin:   &Modbus{Controller: "tcp://localhost:502"},
+			expected: "no requests defined",
+		},
+		{
+			name: "no fields",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests:   []requestDefinition{{SlaveID: 1}},
+			},
+			expected: "request 1: found request section without fields",
+		},
+		{
+			name: "invalid byte-order",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						ByteOrder: "AABB",
+						Fields:    []fieldDefinition{{Name: "foo", InputType: "INT16"}},
+					},
+				},
+			},
+			expected: `unknown byte-order "AABB"`,
+		},
+		{
+			name: "input register in client mode",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						RegisterType: "input",
+						Fields:       []fieldDefinition{{Name: "foo", InputType: "INT16"}},
+					},
+				},
+			},
+			expected: `register-type "input" is only supported in server mode`,
+		},
+		{
+			name: "invalid type",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						Fields: []fieldDefinition{{Name: "foo", InputType: "INT128"}},
+					},
+				},
+			},
+			expected: `unknown type "INT128" for field "foo"`,
+		},
+		{
+			name: "string without length",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						Fields: []fieldDefinition{{Name: "foo", InputType: "STRING"}},
+					},
+				},
+			},
+			expected: `missing length for string field "foo"`,
+		},
+		{
+			name: "address overflow",
+			plugin: &Modbus{
+				Controller: "tcp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						Fields: []fieldDefinition{{Name: "foo", Address: 65534, InputType: "FLOAT64"}},
+					},
+				},
+			},
+			expected: `field "foo": address overflow`,
+		},
+		{
+			name: "multiple slave IDs in server mode",
+			plugin: &Modbus{
+				Mode: "server",
+				Requests: []requestDefinition{
+					{SlaveID: 1, Fields: []fieldDefinition{{Name: "foo", InputType: "INT16"}}},
+					{SlaveID: 2, Fields: []fieldDefinition{{Name: "bar", InputType: "INT16"}}},
+				},
+			},
+			expected: "server mode does not support multiple slave IDs",
+		},
+		{
+			name: "invalid controller",
+			plugin: &Modbus{
+				Controller: "udp://localhost:502",
+				Requests: []requestDefinition{
+					{
+						Fields: []fieldDefinition{{Name: "foo", InputType: "INT16"}},
+					},
+				},
+			},
+			expected: `invalid controller "udp://localhost:502"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestWriteClient(t *testing.T) {
+	addr := freeAddress(t)
+	server := mbserver.NewServer()
+	require.NoError(t, server.ListenTCP(addr))
+	defer server.Close()
+	server.RegisterFunctionHandler(22, maskWriteRegister)
+
+	// Bit three is set by the device and must be kept when writing bits
+	server.HoldingRegisters[10] = 0x0008
+
+	plugin := &Modbus{
+		Controller: "tcp://" + addr,
+		Timeout:    config.Duration(time.Second),
+		Requests: []requestDefinition{
+			{
+				SlaveID:      1,
+				ByteOrder:    "ABCD",
+				RegisterType: "holding",
+				Measurement:  "setpoints",
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "temperature", InputType: "INT16", Scale: 0.1},
+					{Address: 1, Name: "flow", InputType: "FLOAT32"},
+					{Address: 3, Name: "recipe", InputType: "STRING", Length: 2},
+					{Address: 10, Name: "pump", InputType: "BIT", Bit: 0},
+				},
+				Tags: map[string]string{"line": "A"},
+			},
+			{
+				SlaveID:      1,
+				RegisterType: "coil",
+				Fields: []fieldDefinition{
+					{Address: 5, Name: "motor"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"setpoints",
+			map[string]string{"line": "A"},
+			map[string]interface{}{
+				"temperature": 21.5,
+				"flow":        float64(2.5),
+				"recipe":      "abc",
+				"pump":        true,
+				"motor":       int64(1),
+			},
+			time.Unix(0, 0),
+		),
+		// Not matching the tags of the holding registers
+		metric.New(
+			"setpoints",
+			map[string]string{"line": "B"},
+			map[string]interface{}{"temperature": 99.0},
+			time.Unix(0, 0),
+		),
+	}
+	require.NoError(t, plugin.Write(metrics))
+
+	require.Equal(t, uint16(215), server.HoldingRegisters[0])
+	require.Equal(t, []uint16{0x4020, 0x0000}, server.HoldingRegisters[1:3])
+	require.Equal(t, []uint16{0x6162, 0x6300}, server.HoldingRegisters[3:5])
+	require.Equal(t, uint16(0x0009), server.HoldingRegisters[10])
+	require.Equal(t, byte(1), server.Coils[5])
+
+	// Clear the bit again
+	m := metric.New("setpoints", map[string]string{"line": "A"}, map[string]interface{}{"pump": false}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Equal(t, uint16(0x0008), server.HoldingRegisters[10])
+}
+
+func TestWriteBytesOfSameRegister(t *testing.T) {
+	addr := freeAddress(t)
+	server := mbserver.NewServer()
+	require.NoError(t, server.ListenTCP(addr))
+	defer server.Close()
+	server.RegisterFunctionHandler(22, maskWriteRegister)
+
+	// The high byte of the second register is set by the device
+	server.HoldingRegisters[1] = 0xab00
+
+	plugin := &Modbus{
+		Controller: "tcp://" + addr,
+		Timeout:    config.Duration(time.Second),
+		Requests: []requestDefinition{
+			{
+				SlaveID: 1,
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "low", InputType: "UINT8L"},
+					{Address: 0, Name: "high", InputType: "INT8H"},
+					{Address: 1, Name: "other", InputType: "UINT8L"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"low": 0x12, "high": -2, "other": 0x34}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Equal(t, uint16(0xfe12), server.HoldingRegisters[0])
+	require.Equal(t, uint16(0xab34), server.HoldingRegisters[1])
+
+	// Writing only one of the bytes keeps the other byte
+	m = metric.New("test", map[string]string{}, map[string]interface{}{"low": 0x56}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Equal(t, uint16(0xfe56), server.HoldingRegisters[0])
+}
+
+func TestServerBytesOfSameRegister(t *testing.T) {
+	plugin := &Modbus{
+		Mode:           "server",
+		ServiceAddress: "tcp://" + freeAddress(t),
+		Requests: []requestDefinition{
+			{
+				ByteOrder:    "DCBA",
+				RegisterType: "input",
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "low", InputType: "UINT8L"},
+					{Address: 0, Name: "high", InputType: "UINT8H"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"low": 0x12, "high": 0x34}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+
+	// Little endian stores the low byte first on the wire
+	require.Equal(t, uint16(0x1234), plugin.server.InputRegisters[0])
+	require.Equal(t, uint16(0), plugin.server.HoldingRegisters[0])
+}
+
+func TestWriteInvalidValue(t *testing.T) {
+	addr := freeAddress(t)
+	server := mbserver.NewServer()
+	require.NoError(t, server.ListenTCP(addr))
+	defer server.Close()
+
+	plugin := &Modbus{
+		Controller: "tcp://" + addr,
+		Timeout:    config.Duration(time.Second),
+		Requests: []requestDefinition{
+			{
+				SlaveID: 1,
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "small", InputType: "UINT8L"},
+					{Address: 1, Name: "valid", InputType: "UINT16"},
+				},
+			},
+		},
+	}
+	logger := &testutil.CaptureLogger{}
+	plugin.Log = logger
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New("test", map[string]string{}, map[string]interface{}{"small": 300, "valid": 42}, time.Unix(0, 0))
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+	require.Equal(t, uint16(0), server.HoldingRegisters[0])
+	require.Equal(t, uint16(42), server.HoldingRegisters[1])
+	require.Len(t, logger.Errors(), 1)
+	require.Contains(t, logger.Errors()[0], `Writing field "small" of metric "test" failed`)
+}
+
+func TestServerRoundtrip(t *testing.T) {
+	addr := freeAddress(t)
+	plugin := &Modbus{
+		Mode:           "server",
+		ServiceAddress: "tcp://" + addr,
+		Requests: []requestDefinition{
+			{
+				ByteOrder:    "DCBA",
+				RegisterType: "input",
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "voltage", InputType: "INT16", Scale: 0.1},
+					{Address: 1, Name: "energy", InputType: "UINT64", Scale: 0.001},
+				},
+			},
+			{
+				ByteOrder:    "CDAB",
+				RegisterType: "holding",
+				Fields: []fieldDefinition{
+					{Address: 0, Name: "power", InputType: "FLOAT32"},
+					{Address: 2, Name: "alarm", InputType: "BIT", Bit: 9},
+				},
+			},
+			{
+				RegisterType: "discrete",
+				Fields: []fieldDefinition{
+					{Address: 3, Name: "door_open"},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	defer plugin.Close()
+
+	m := metric.New(
+		"device",
+		map[string]string{},
+		map[string]interface{}{
+			"voltage":   float64(229.7),
+			"energy":    float64(12345.678),
+			"power":     float64(-12.25),
+			"alarm":     true,
+			"door_open": true,
+		},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, plugin.Write([]telegraf.Metric{m}))
+
+	// Read the values back using a Modbus client and the converters of the
+	// input plugin
+	handler := mb.NewTCPClientHandler(addr)
+	handler.Timeout = time.Second
+	require.NoError(t, handler.Connect())
+	defer handler.Close()
+	client := mb.NewClient(handler)
+
+	b, err := client.ReadInputRegisters(0, 5)
+	require.NoError(t, err)
+	decode := func(inType, byteOrder, outType string, scale float64, bit uint8, data []byte) interface{} {
+		converter, err := common_modbus.DetermineConverter(inType, byteOrder, outType, scale, bit, "")
+		require.NoError(t, err)
+		return converter(data)
+	}
+	require.InDelta(t, 229.7, decode("INT16", "DCBA", "FLOAT64", 0.1, 0, b[0:2]), 1e-9)
+	require.InDelta(t, 12345.678, decode("UINT64", "DCBA", "FLOAT64", 0.001, 0, b[2:10]), 1e-9)
+
+	b, err = client.ReadHoldingRegisters(0, 3)
+	require.NoError(t, err)
+	require.InDelta(t, -12.25, decode("FLOAT32", "CDAB", "FLOAT64", 0, 0, b[0:4]), 1e-9)
+	require.Equal(t, uint8(1), decode("BIT", "CDAB", "", 0, 9, b[4:6]))
+	require.Equal(t, uint8(0), decode("BIT", "CDAB", "", 0, 8, b[4:6]))
+
+	b, err = client.ReadDiscreteInputs(3, 1)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x01}, b)
+
+	// Clients are able to modify the holding registers
+	_, err = client.WriteSingleRegister(5, 1234)
+	require.NoError(t, err)
+	b, err = client.ReadHoldingRegisters(5, 1)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x04, 0xd2}, b)
+}
+
+// freeAddress returns a local address with a currently unused port
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
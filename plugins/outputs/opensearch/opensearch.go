@@ -11,8 +11,10 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 
@@ -41,6 +43,7 @@ type Opensearch struct {
 	FloatReplacement    float64         `toml:"float_replacement_value"`
 	ForceDocumentID     bool            `toml:"force_document_id"`
 	IndexName           string          `toml:"index_name"`
+	IndexMode           string          `toml:"index_mode"`
 	TemplateName        string          `toml:"template_name"`
 	ManageTemplate      bool            `toml:"manage_template"`
 	OverwriteTemplate   bool            `toml:"overwrite_template"`
@@ -63,6 +66,9 @@ type Opensearch struct {
 //go:embed template.json
 var indexTemplate string
 
+//go:embed template_data_stream.json
+var dataStreamTemplate string
+
 type templatePart struct {
 	TemplatePattern string
 }
@@ -86,6 +92,14 @@ func (o *Opensearch) Init() error {
 		o.FloatHandling = "none"
 	}
 
+	switch o.IndexMode {
+	case "":
+		o.IndexMode = "index"
+	case "index", "data_stream":
+	default:
+		return fmt.Errorf("invalid index_mode %q", o.IndexMode)
+	}
+
 	indexTmpl, err := template.New("index").Parse(o.IndexName)
 	if err != nil {
 		return fmt.Errorf("error parsing index_name template: %w", err)
@@ -237,14 +251,26 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
 	defer cancel()
 
-	for _, metric := range metrics {
+	// Data streams only accept the create action
+	action := "index"
+	if o.IndexMode == "data_stream" {
+		action = "create"
+	}
+
+	results := &bulkResults{
+		werr: internal.PartialWriteError{
+			MetricsAccept: make([]int, 0, len(metrics)),
+		},
+	}
+	for i, metric := range metrics {
 		var name = metric.Name()
 
 		// index name has to be re-evaluated each time for telegraf
 		// to send the metric to the correct time-based index
 		indexName, err := o.GetIndexName(metric)
 		if err != nil {
-			return fmt.Errorf("generating indexname failed: %w", err)
+			results.reject(i, fmt.Errorf("generating indexname failed: %w", err))
+			continue
 		}
 
 		// Handle NaN and inf field-values
@@ -275,15 +301,22 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 
 		body, err := json.Marshal(m)
 		if err != nil {
-			return fmt.Errorf("failed to marshal body: %w", err)
+			results.reject(i, fmt.Errorf("failed to marshal body: %w", err))
+			continue
 		}
 
 		bulkIndxrItem := opensearchutil.BulkIndexerItem{
-			Action:    "index",
-			Index:     indexName,
-			Body:      strings.NewReader(string(body)),
-			OnSuccess: o.onSucc,
-			OnFailure: o.onFail,
+			Action: action,
+			Index:  indexName,
+			Body:   strings.NewReader(string(body)),
+			OnSuccess: func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem) {
+				o.onSucc(ctx, item, res)
+				results.accept(i)
+			},
+			OnFailure: func(ctx context.Context, item opensearchutil.BulkIndexerItem, res opensearchutil.BulkIndexerResponseItem, err error) {
+				o.onFail(ctx, item, res, err)
+				results.fail(i, res, err)
+			},
 		}
 		if o.ForceDocumentID {
 			bulkIndxrItem.DocumentID = getPointID(metric)
@@ -292,7 +325,8 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 		if o.UsePipeline != "" {
 			pipelineName, err := o.getPipelineName(metric)
 			if err != nil {
-				return fmt.Errorf("failed to evaluate pipeline name: %w", err)
+				results.reject(i, fmt.Errorf("failed to evaluate pipeline name: %w", err))
+				continue
 			}
 
 			if pipelineName != "" {
@@ -310,6 +344,7 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 		}
 	}
 
+	var failed uint64
 	for _, bulkIndxr := range indexers {
 		if err := bulkIndxr.Close(ctx); err != nil {
 			return fmt.Errorf("error sending bulk request to OpenSearch: %w", err)
@@ -317,14 +352,61 @@ func (o *Opensearch) Write(metrics []telegraf.Metric) error {
 
 		// Report the indexer statistics
 		stats := bulkIndxr.Stats()
-		if stats.NumFailed > 0 {
-			return fmt.Errorf("failed to index [%d] documents", stats.NumFailed)
-		}
+		failed += stats.NumFailed
+		o.Log.Debugf("Successfully indexed [%d] documents", stats.NumAdded-stats.NumFailed)
+	}
+
+	// Items failing due to request errors are not reported individually and
+	// are kept for the next write
+	return results.err(len(metrics), failed)
+}
+
+// bulkResults collects the per-item results of the bulk indexers
+type bulkResults struct {
+	werr internal.PartialWriteError
+	sync.Mutex
+}
 
-		o.Log.Debugf("Successfully indexed [%d] documents", stats.NumAdded)
+func (r *bulkResults) accept(idx int) {
+	r.Lock()
+	defer r.Unlock()
+	r.werr.MetricsAccept = append(r.werr.MetricsAccept, idx)
+}
+
+func (r *bulkResults) reject(idx int, err error) {
+	r.Lock()
+	defer r.Unlock()
+	r.werr.MetricsReject = append(r.werr.MetricsReject, idx)
+	r.werr.MetricsRejectErrors = append(r.werr.MetricsRejectErrors, err)
+}
+
+// fail classifies a failed item. Items failing due to request errors or with
+// a retryable status are kept for the next write while items failing for
+// other reasons, e.g. due to mapping errors, are rejected.
+func (r *bulkResults) fail(idx int, res opensearchutil.BulkIndexerResponseItem, err error) {
+	switch {
+	case err != nil:
+	case res.Status == http.StatusTooManyRequests || res.Status == http.StatusServiceUnavailable:
+	case res.Status == http.StatusConflict && res.Error.Type == "version_conflict_engine_exception":
+		// The document already exists e.g. because it was written as part of
+		// a previous, partially failed request
+		r.accept(idx)
+	default:
+		r.reject(idx, fmt.Errorf("status %d: %s: %s", res.Status, res.Error.Type, res.Error.Reason))
 	}
+}
 
-	return nil
+func (r *bulkResults) err(total int, failed uint64) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if failed == 0 && len(r.werr.MetricsReject) == 0 {
+		return nil
+	}
+	sort.Ints(r.werr.MetricsAccept)
+	retry := total - len(r.werr.MetricsAccept) - len(r.werr.MetricsReject)
+	r.werr.Err = fmt.Errorf("failed to index [%d] documents, retrying [%d] documents", total-len(r.werr.MetricsAccept), retry)
+	return &r.werr
 }
 
 // BulkIndexer supports pipeline at config level so separate indexer instance for each unique pipeline
@@ -437,18 +519,33 @@ func (o *Opensearch) manageTemplate(ctx context.Context) error {
 			TemplatePattern: templatePattern + "*",
 		}
 
-		t := template.Must(template.New("template").Parse(indexTemplate))
+		// Data streams require a composable index template
+		tmplSource := indexTemplate
+		if o.IndexMode == "data_stream" {
+			tmplSource = dataStreamTemplate
+		}
+
+		t := template.Must(template.New("template").Parse(tmplSource))
 		var tmpl bytes.Buffer
 
 		if err := t.Execute(&tmpl, tp); err != nil {
 			return err
 		}
 
-		indexTempReq := opensearchapi.IndicesPutTemplateRequest{
-			Name: o.TemplateName,
-			Body: strings.NewReader(tmpl.String()),
+		var indexTempResp *opensearchapi.Response
+		if o.IndexMode == "data_stream" {
+			indexTempReq := opensearchapi.IndicesPutIndexTemplateRequest{
+				Name: o.TemplateName,
+				Body: strings.NewReader(tmpl.String()),
+			}
+			indexTempResp, err = indexTempReq.Do(ctx, o.osClient.Transport)
+		} else {
+			indexTempReq := opensearchapi.IndicesPutTemplateRequest{
+				Name: o.TemplateName,
+				Body: strings.NewReader(tmpl.String()),
+			}
+			indexTempResp, err = indexTempReq.Do(ctx, o.osClient.Transport)
 		}
-		indexTempResp, err := indexTempReq.Do(ctx, o.osClient.Transport)
 
 		if err != nil || indexTempResp.StatusCode != 200 {
 			return fmt.Errorf("creating index template %q failed: %w", o.TemplateName, err)
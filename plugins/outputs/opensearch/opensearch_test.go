@@ -1,8 +1,10 @@
 package opensearch
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"text/template"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/wait"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/models"
@@ -407,3 +410,76 @@ func TestDisconnectedServerOnWrite(t *testing.T) {
 	err = e.Write(testutil.MockMetrics())
 	require.Error(t, err)
 }
+
+func TestBulkResponseItems(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/_bulk":
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+				return
+			}
+			if !strings.Contains(string(body), `{"create":{"_index":"metrics-telegraf"}}`) {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Errorf("unexpected bulk request: %s", string(body))
+				return
+			}
+			response := `{
+				"errors": true,
+				"items": [
+					{"create": {"status": 201}},
+					{"create": {"status": 429, "error": {"type": "rejected_execution_exception", "reason": "queue full"}}},
+					{"create": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}},
+					{"create": {"status": 409, "error": {"type": "version_conflict_engine_exception", "reason": "exists"}}}
+				]
+			}`
+			if _, err := w.Write([]byte(response)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		default:
+			if _, err := w.Write([]byte(`{"version": {"number": "2.8.0"}}`)); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				t.Error(err)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	plugin := &Opensearch{
+		URLs:         []string{"http://" + ts.Listener.Addr().String()},
+		IndexName:    "metrics-telegraf",
+		IndexMode:    "data_stream",
+		TemplateName: "telegraf",
+		Timeout:      config.Duration(time.Second * 5),
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	metrics := make([]telegraf.Metric, 0, 4)
+	for i := range 4 {
+		metrics = append(metrics, testutil.TestMetric(i))
+	}
+	err := plugin.Write(metrics)
+
+	var werr *internal.PartialWriteError
+	require.ErrorAs(t, err, &werr)
+	require.Equal(t, []int{0, 3}, werr.MetricsAccept)
+	require.Equal(t, []int{2}, werr.MetricsReject)
+	require.Len(t, werr.MetricsRejectErrors, 1)
+	require.ErrorContains(t, werr.MetricsRejectErrors[0], "failed to parse")
+}
+
+func TestInvalidIndexMode(t *testing.T) {
+	plugin := &Opensearch{
+		URLs:         []string{"http://localhost:9200"},
+		IndexName:    "metrics-telegraf",
+		IndexMode:    "time_series",
+		TemplateName: "telegraf",
+		Log:          testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid index_mode")
+}
@@ -10,8 +10,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/influxdata/influxdb-observability/common"
-	"github.com/influxdata/influxdb-observability/influx2otel"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 	_ "google.golang.org/grpc/encoding/gzip" // Blank import to allow gzip encoding
 	"google.golang.org/grpc/metadata"
@@ -19,6 +17,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
 	"github.com/influxdata/telegraf/plugins/common/proxy"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -45,7 +44,7 @@ type OpenTelemetry struct {
 
 	Log telegraf.Logger `toml:"-"`
 
-	metricsConverter *influx2otel.LineProtocolToOtelMetrics
+	encoder          *common_otlp.MetricsEncoder
 	otlpMetricClient otlpMetricClient
 }
 
@@ -78,7 +77,6 @@ func (*OpenTelemetry) SampleConfig() string {
 }
 
 func (o *OpenTelemetry) Connect() error {
-	logger := &otelLogger{o.Log}
 	if o.ServiceAddress == "" {
 		o.ServiceAddress = defaultServiceAddress
 	}
@@ -105,11 +103,11 @@ func (o *OpenTelemetry) Connect() error {
 		o.Headers["Authorization"] = "Bearer " + o.Coralogix.PrivateKey
 	}
 
-	metricsConverter, err := influx2otel.NewLineProtocolToOtelMetrics(logger)
+	encoder, err := common_otlp.NewMetricsEncoder(o.Log)
 	if err != nil {
 		return err
 	}
-	o.metricsConverter = metricsConverter
+	o.encoder = encoder
 
 	protocol := "grpc"
 	if strings.HasPrefix(o.ServiceAddress, "http://") || strings.HasPrefix(o.ServiceAddress, "https://") {
@@ -171,44 +169,11 @@ func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
 }
 
 func (o *OpenTelemetry) sendBatch(metrics []telegraf.Metric) error {
-	batch := o.metricsConverter.NewBatch()
-	for _, metric := range metrics {
-		var vType common.InfluxMetricValueType
-		switch metric.Type() {
-		case telegraf.Gauge:
-			vType = common.InfluxMetricValueTypeGauge
-		case telegraf.Untyped:
-			vType = common.InfluxMetricValueTypeUntyped
-		case telegraf.Counter:
-			vType = common.InfluxMetricValueTypeSum
-		case telegraf.Histogram:
-			vType = common.InfluxMetricValueTypeHistogram
-		case telegraf.Summary:
-			vType = common.InfluxMetricValueTypeSummary
-		default:
-			o.Log.Warnf("Unrecognized metric type %v", metric.Type())
-			continue
-		}
-		err := batch.AddPoint(metric.Name(), metric.Tags(), metric.Fields(), metric.Time(), vType)
-		if err != nil {
-			o.Log.Warnf("Failed to add point: %v", err)
-			continue
-		}
-	}
-
-	md := pmetricotlp.NewExportRequestFromMetrics(batch.GetMetrics())
+	md := o.encoder.Encode(metrics, o.Attributes)
 	if md.Metrics().ResourceMetrics().Len() == 0 {
 		return nil
 	}
 
-	if len(o.Attributes) > 0 {
-		for i := 0; i < md.Metrics().ResourceMetrics().Len(); i++ {
-			for k, v := range o.Attributes {
-				md.Metrics().ResourceMetrics().At(i).Resource().Attributes().PutStr(k, v)
-			}
-		}
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(o.Timeout))
 	defer cancel()
 
@@ -9,8 +9,6 @@ import (
 	"testing"
 	"time"
 
-	"github.com/influxdata/influxdb-observability/common"
-	"github.com/influxdata/influxdb-observability/influx2otel"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -23,6 +21,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
 	"github.com/influxdata/telegraf/plugins/common/proxy"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -46,14 +45,14 @@ func TestOpenTelemetry(t *testing.T) {
 	m := newMockOtelService(t)
 	t.Cleanup(m.Cleanup)
 
-	metricsConverter, err := influx2otel.NewLineProtocolToOtelMetrics(common.NoopLogger{})
+	encoder, err := common_otlp.NewMetricsEncoder(testutil.Logger{})
 	require.NoError(t, err)
 	plugin := &OpenTelemetry{
-		ServiceAddress:   m.Address(),
-		Timeout:          config.Duration(time.Second),
-		Headers:          map[string]string{"test": "header1"},
-		Attributes:       map[string]string{"attr-key": "attr-val"},
-		metricsConverter: metricsConverter,
+		ServiceAddress: m.Address(),
+		Timeout:        config.Duration(time.Second),
+		Headers:        map[string]string{"test": "header1"},
+		Attributes:     map[string]string{"attr-key": "attr-val"},
+		encoder:        encoder,
 		otlpMetricClient: &gRPCClient{
 			grpcClientConn:       m.GrpcClient(),
 			metricsServiceClient: pmetricotlp.NewGRPCClient(m.GrpcClient()),
@@ -146,17 +145,17 @@ func TestOpenTelemetryHTTPProtobuf(t *testing.T) {
 	}))
 	defer server.Close()
 
-	metricsConverter, err := influx2otel.NewLineProtocolToOtelMetrics(common.NoopLogger{})
+	encoder, err := common_otlp.NewMetricsEncoder(testutil.Logger{})
 	require.NoError(t, err)
 
 	plugin := &OpenTelemetry{
-		ServiceAddress:   server.URL,
-		EncodingType:     "protobuf",
-		Timeout:          config.Duration(time.Second),
-		Attributes:       map[string]string{"attr-key": "attr-val"},
-		Compression:      "none",
-		metricsConverter: metricsConverter,
-		Log:              testutil.Logger{},
+		ServiceAddress: server.URL,
+		EncodingType:   "protobuf",
+		Timeout:        config.Duration(time.Second),
+		Attributes:     map[string]string{"attr-key": "attr-val"},
+		Compression:    "none",
+		encoder:        encoder,
+		Log:            testutil.Logger{},
 	}
 	require.NoError(t, plugin.Connect())
 
@@ -244,17 +243,17 @@ func TestOpenTelemetryHTTPJSON(t *testing.T) {
 	}))
 	defer server.Close()
 
-	metricsConverter, err := influx2otel.NewLineProtocolToOtelMetrics(common.NoopLogger{})
+	encoder, err := common_otlp.NewMetricsEncoder(testutil.Logger{})
 	require.NoError(t, err)
 
 	plugin := &OpenTelemetry{
-		ServiceAddress:   server.URL,
-		EncodingType:     "json",
-		Timeout:          config.Duration(time.Second),
-		Attributes:       map[string]string{"attr-key": "attr-val"},
-		Compression:      "none",
-		metricsConverter: metricsConverter,
-		Log:              testutil.Logger{},
+		ServiceAddress: server.URL,
+		EncodingType:   "json",
+		Timeout:        config.Duration(time.Second),
+		Attributes:     map[string]string{"attr-key": "attr-val"},
+		Compression:    "none",
+		encoder:        encoder,
+		Log:            testutil.Logger{},
 	}
 	require.NoError(t, plugin.Connect())
 
@@ -6,17 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"strconv"
+	"path/filepath"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/arrow/array"
 	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	common_arrow "github.com/influxdata/telegraf/plugins/common/arrow"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -25,20 +29,44 @@ var sampleConfig string
 
 var defaultTimestampFieldName = "timestamp"
 
+var compressionCodecs = map[string]compress.Compression{
+	"":             compress.Codecs.Uncompressed,
+	"none":         compress.Codecs.Uncompressed,
+	"uncompressed": compress.Codecs.Uncompressed,
+	"snappy":       compress.Codecs.Snappy,
+	"gzip":         compress.Codecs.Gzip,
+	"brotli":       compress.Codecs.Brotli,
+	"zstd":         compress.Codecs.Zstd,
+	"lz4":          compress.Codecs.Lz4Raw,
+}
+
+type groupKey struct {
+	name      string
+	partition string
+}
+
 type metricGroup struct {
 	filename string
 	builder  *array.RecordBuilder
 	schema   *arrow.Schema
 	writer   *pqarrow.FileWriter
+	opened   time.Time
 }
 
 type Parquet struct {
 	Directory          string          `toml:"directory"`
+	Partitioning       string          `toml:"partitioning"`
 	RotationInterval   config.Duration `toml:"rotation_interval"`
+	RotationMaxSize    config.Size     `toml:"rotation_max_size"`
 	TimestampFieldName string          `toml:"timestamp_field_name"`
+	Compression        string          `toml:"compression"`
+	RowGroupSize       int64           `toml:"row_group_size"`
 	Log                telegraf.Logger `toml:"-"`
 
-	metricGroups map[string]*metricGroup
+	converter    *common_arrow.Converter
+	partitioning *template.Template
+	properties   *parquet.WriterProperties
+	metricGroups map[groupKey]*metricGroup
 }
 
 func (*Parquet) SampleConfig() string {
@@ -59,7 +87,33 @@ func (p *Parquet) Init() error {
 		return fmt.Errorf("provided directory %q is not a directory", p.Directory)
 	}
 
-	p.metricGroups = make(map[string]*metricGroup)
+	p.converter = &common_arrow.Converter{
+		TimestampColumn: p.TimestampFieldName,
+		Log:             p.Log,
+	}
+
+	if p.Partitioning != "" {
+		tmpl, err := template.New("partitioning").Parse(p.Partitioning)
+		if err != nil {
+			return fmt.Errorf("failed to parse partitioning template: %w", err)
+		}
+		p.partitioning = tmpl
+	}
+
+	codec, found := compressionCodecs[strings.ToLower(p.Compression)]
+	if !found {
+		return fmt.Errorf("invalid compression %q", p.Compression)
+	}
+	options := []parquet.WriterProperty{parquet.WithCompression(codec)}
+
+	if p.RowGroupSize < 0 {
+		return fmt.Errorf("invalid row-group size %d", p.RowGroupSize)
+	} else if p.RowGroupSize > 0 {
+		options = append(options, parquet.WithMaxRowGroupLength(p.RowGroupSize))
+	}
+	p.properties = parquet.NewWriterProperties(options...)
+
+	p.metricGroups = make(map[groupKey]*metricGroup)
 
 	return nil
 }
@@ -71,11 +125,12 @@ func (*Parquet) Connect() error {
 func (p *Parquet) Close() error {
 	var errorOccurred bool
 
-	for _, metrics := range p.metricGroups {
+	for key, metrics := range p.metricGroups {
 		if err := metrics.writer.Close(); err != nil {
 			p.Log.Errorf("failed to close file %q: %v", metrics.filename, err)
 			errorOccurred = true
 		}
+		delete(p.metricGroups, key)
 	}
 
 	if errorOccurred {
@@ -86,43 +141,35 @@ func (p *Parquet) Close() error {
 }
 
 func (p *Parquet) Write(metrics []telegraf.Metric) error {
-	groupedMetrics := make(map[string][]telegraf.Metric)
-	for _, metric := range metrics {
-		groupedMetrics[metric.Name()] = append(groupedMetrics[metric.Name()], metric)
-	}
-
 	now := time.Now()
-	for name, metrics := range groupedMetrics {
-		if _, ok := p.metricGroups[name]; !ok {
-			filename := fmt.Sprintf("%s/%s-%s-%s.parquet", p.Directory, name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
-			schema, err := p.createSchema(metrics)
-			if err != nil {
-				return fmt.Errorf("failed to create schema for file %q: %w", name, err)
-			}
-			writer, err := p.createWriter(name, filename, schema)
-			if err != nil {
-				return fmt.Errorf("failed to create writer for file %q: %w", name, err)
-			}
-			p.metricGroups[name] = &metricGroup{
-				builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
-				filename: filename,
-				schema:   schema,
-				writer:   writer,
-			}
-		}
 
-		if p.RotationInterval != 0 {
-			if err := p.rotateIfNeeded(name); err != nil {
-				return fmt.Errorf("failed to rotate file %q: %w", p.metricGroups[name].filename, err)
+	// Close all files exceeding the rotation limits. This also finalizes the
+	// files of partitions not receiving data anymore.
+	p.rotateIfNeeded(now)
+
+	groupedMetrics := make(map[groupKey][]telegraf.Metric)
+	for _, metric := range metrics {
+		key := groupKey{name: metric.Name()}
+		if p.partitioning != nil {
+			partition, err := p.partition(metric)
+			if err != nil {
+				p.Log.Errorf("Determining partition failed, dropping metric %q: %v", metric.Name(), err)
+				continue
 			}
+			key.partition = partition
 		}
+		groupedMetrics[key] = append(groupedMetrics[key], metric)
+	}
 
-		record, err := p.createRecordBatch(metrics, p.metricGroups[name].builder, p.metricGroups[name].schema)
+	for key, metrics := range groupedMetrics {
+		group, err := p.getGroup(key, metrics, now)
 		if err != nil {
-			return fmt.Errorf("failed to create record for file %q: %w", p.metricGroups[name].filename, err)
+			return err
 		}
-		if err = p.metricGroups[name].writer.WriteBuffered(record); err != nil {
-			return fmt.Errorf("failed to write to file %q: %w", p.metricGroups[name].filename, err)
+
+		record := p.converter.RecordBatch(group.builder, metrics)
+		if err = group.writer.WriteBuffered(record); err != nil {
+			return fmt.Errorf("failed to write to file %q: %w", group.filename, err)
 		}
 		record.Release()
 	}
@@ -130,204 +177,111 @@ func (p *Parquet) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
-func (p *Parquet) rotateIfNeeded(name string) error {
-	fileInfo, err := os.Stat(p.metricGroups[name].filename)
-	if err != nil {
-		return fmt.Errorf("failed to stat file %q: %w", p.metricGroups[name].filename, err)
+// partition renders the partitioning template for the given metric and
+// returns the relative directory of the partition
+func (p *Parquet) partition(metric telegraf.Metric) (string, error) {
+	if wm, ok := metric.(telegraf.UnwrappableMetric); ok {
+		metric = wm.Unwrap()
 	}
 
-	expireTime := fileInfo.ModTime().Add(time.Duration(p.RotationInterval))
-	if time.Now().Before(expireTime) {
-		return nil
+	var buf strings.Builder
+	if err := p.partitioning.Execute(&buf, metric); err != nil {
+		return "", err
 	}
-
-	if err := p.metricGroups[name].writer.Close(); err != nil {
-		return fmt.Errorf("failed to close file for rotation %q: %w", p.metricGroups[name].filename, err)
+	partition := filepath.Clean(buf.String())
+	if !filepath.IsLocal(partition) {
+		return "", fmt.Errorf("partition %q is outside of the directory", buf.String())
 	}
 
-	writer, err := p.createWriter(name, p.metricGroups[name].filename, p.metricGroups[name].schema)
-	if err != nil {
-		return fmt.Errorf("failed to create new writer for file %q: %w", p.metricGroups[name].filename, err)
-	}
-	p.metricGroups[name].writer = writer
-
-	return nil
+	return partition, nil
 }
 
-func (p *Parquet) createRecordBatch(metrics []telegraf.Metric, builder *array.RecordBuilder, schema *arrow.Schema) (arrow.RecordBatch, error) {
-	for index, col := range schema.Fields() {
-		for _, m := range metrics {
-			if p.TimestampFieldName != "" && col.Name == p.TimestampFieldName {
-				builder.Field(index).(*array.Int64Builder).Append(m.Time().UnixNano())
-				continue
-			}
-
-			// Try to get the value from a field first, then from a tag.
-			var value any
-			var ok bool
-			value, ok = m.GetField(col.Name)
-			if !ok {
-				value, ok = m.GetTag(col.Name)
-			}
-
-			// if neither field nor tag exists, append a null value
-			if !ok {
-				switch col.Type {
-				case arrow.PrimitiveTypes.Int8:
-					builder.Field(index).(*array.Int8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int16:
-					builder.Field(index).(*array.Int16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int32:
-					builder.Field(index).(*array.Int32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int64:
-					builder.Field(index).(*array.Int64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint8:
-					builder.Field(index).(*array.Uint8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint16:
-					builder.Field(index).(*array.Uint16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint32:
-					builder.Field(index).(*array.Uint32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint64:
-					builder.Field(index).(*array.Uint64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float32:
-					builder.Field(index).(*array.Float32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float64:
-					builder.Field(index).(*array.Float64Builder).AppendNull()
-				case arrow.BinaryTypes.String:
-					builder.Field(index).(*array.StringBuilder).AppendNull()
-				case arrow.FixedWidthTypes.Boolean:
-					builder.Field(index).(*array.BooleanBuilder).AppendNull()
-				default:
-					return nil, fmt.Errorf("unsupported type: %T", value)
-				}
+// getGroup returns the group for the given key, creating a new file if the
+// group does not exist yet or if the metrics contain columns not in the schema
+// of the current file
+func (p *Parquet) getGroup(key groupKey, metrics []telegraf.Metric, now time.Time) (*metricGroup, error) {
+	group, found := p.metricGroups[key]
 
-				continue
-			}
-
-			switch col.Type {
-			case arrow.PrimitiveTypes.Int8:
-				builder.Field(index).(*array.Int8Builder).Append(value.(int8))
-			case arrow.PrimitiveTypes.Int16:
-				builder.Field(index).(*array.Int16Builder).Append(value.(int16))
-			case arrow.PrimitiveTypes.Int32:
-				builder.Field(index).(*array.Int32Builder).Append(value.(int32))
-			case arrow.PrimitiveTypes.Int64:
-				builder.Field(index).(*array.Int64Builder).Append(value.(int64))
-			case arrow.PrimitiveTypes.Uint8:
-				builder.Field(index).(*array.Uint8Builder).Append(value.(uint8))
-			case arrow.PrimitiveTypes.Uint16:
-				builder.Field(index).(*array.Uint16Builder).Append(value.(uint16))
-			case arrow.PrimitiveTypes.Uint32:
-				builder.Field(index).(*array.Uint32Builder).Append(value.(uint32))
-			case arrow.PrimitiveTypes.Uint64:
-				builder.Field(index).(*array.Uint64Builder).Append(value.(uint64))
-			case arrow.PrimitiveTypes.Float32:
-				builder.Field(index).(*array.Float32Builder).Append(value.(float32))
-			case arrow.PrimitiveTypes.Float64:
-				builder.Field(index).(*array.Float64Builder).Append(value.(float64))
-			case arrow.BinaryTypes.String:
-				builder.Field(index).(*array.StringBuilder).Append(value.(string))
-			case arrow.FixedWidthTypes.Boolean:
-				builder.Field(index).(*array.BooleanBuilder).Append(value.(bool))
-			default:
-				return nil, fmt.Errorf("unsupported type: %T", value)
-			}
+	var schema *arrow.Schema
+	if found {
+		merged, changed, err := p.converter.MergeSchema(group.schema, metrics)
+		if err != nil {
+			p.Log.Warnf("Ignoring new columns: %v", err)
+		}
+		if !changed {
+			return group, nil
 		}
-	}
-
-	record := builder.NewRecordBatch()
-	return record, nil
-}
 
-func (p *Parquet) createSchema(metrics []telegraf.Metric) (*arrow.Schema, error) {
-	rawFields := make(map[string]arrow.DataType, 0)
-	for _, metric := range metrics {
-		for _, field := range metric.FieldList() {
-			if _, ok := rawFields[field.Key]; !ok {
-				arrowType, err := goToArrowType(field.Value)
-				if err != nil {
-					return nil, fmt.Errorf("error converting '%s=%s' field to arrow type: %w", field.Key, field.Value, err)
-				}
-				rawFields[field.Key] = arrowType
-			}
+		// Roll over to a new file with the merged schema as the column set
+		// of a parquet file is fixed
+		p.Log.Debugf("Schema of %q changed, rolling over to a new file", group.filename)
+		if err := group.writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close file %q: %w", group.filename, err)
 		}
-		for _, tag := range metric.TagList() {
-			if _, ok := rawFields[tag.Key]; !ok {
-				rawFields[tag.Key] = arrow.BinaryTypes.String
-			}
+		delete(p.metricGroups, key)
+		schema = merged
+	} else {
+		var err error
+		schema, err = p.converter.Schema(metrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create schema for file %q: %w", key.name, err)
 		}
 	}
 
-	fields := make([]arrow.Field, 0)
-	for key, value := range rawFields {
-		fields = append(fields, arrow.Field{
-			Name: key,
-			Type: value,
-		})
+	directory := filepath.Join(p.Directory, key.partition)
+	if err := os.MkdirAll(directory, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create directory %q: %w", directory, err)
 	}
 
-	if p.TimestampFieldName != "" {
-		fields = append(fields, arrow.Field{
-			Name: p.TimestampFieldName,
-			Type: arrow.PrimitiveTypes.Int64,
-		})
+	filename := common_arrow.NewFilename(directory, key.name, ".parquet", now)
+	writer, err := p.createWriter(filename, schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create writer for file %q: %w", key.name, err)
 	}
+	group = &metricGroup{
+		filename: filename,
+		builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		schema:   schema,
+		writer:   writer,
+		opened:   now,
+	}
+	p.metricGroups[key] = group
 
-	return arrow.NewSchema(fields, nil), nil
+	return group, nil
 }
 
-func (p *Parquet) createWriter(name, filename string, schema *arrow.Schema) (*pqarrow.FileWriter, error) {
-	if _, err := os.Stat(filename); err == nil {
-		now := time.Now()
-		rotatedFilename := fmt.Sprintf("%s/%s-%s-%s.parquet", p.Directory, name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
-		if err := os.Rename(filename, rotatedFilename); err != nil {
-			return nil, fmt.Errorf("failed to rename file %q: %w", filename, err)
+func (p *Parquet) rotateIfNeeded(now time.Time) {
+	rotation := &common_arrow.Rotation{
+		Interval: time.Duration(p.RotationInterval),
+		MaxSize:  int64(p.RotationMaxSize),
+	}
+	for key, group := range p.metricGroups {
+		if !rotation.Due(group.opened, group.writer.TotalCompressedBytes(), now) {
+			continue
 		}
+
+		if err := group.writer.Close(); err != nil {
+			p.Log.Errorf("failed to close file for rotation %q: %v", group.filename, err)
+		}
+		delete(p.metricGroups, key)
 	}
+}
+
+func (p *Parquet) createWriter(filename string, schema *arrow.Schema) (*pqarrow.FileWriter, error) {
 	file, err := os.Create(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file %q: %w", filename, err)
 	}
 
-	writer, err := pqarrow.NewFileWriter(schema, file, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	writer, err := pqarrow.NewFileWriter(schema, file, p.properties, pqarrow.DefaultWriterProps())
 	if err != nil {
+		file.Close()
 		return nil, fmt.Errorf("failed to create parquet writer for file %q: %w", filename, err)
 	}
 
 	return writer, nil
 }
 
-func goToArrowType(value interface{}) (arrow.DataType, error) {
-	switch value.(type) {
-	case int8:
-		return arrow.PrimitiveTypes.Int8, nil
-	case int16:
-		return arrow.PrimitiveTypes.Int16, nil
-	case int32:
-		return arrow.PrimitiveTypes.Int32, nil
-	case int64, int:
-		return arrow.PrimitiveTypes.Int64, nil
-	case uint8:
-		return arrow.PrimitiveTypes.Uint8, nil
-	case uint16:
-		return arrow.PrimitiveTypes.Uint16, nil
-	case uint32:
-		return arrow.PrimitiveTypes.Uint32, nil
-	case uint64, uint:
-		return arrow.PrimitiveTypes.Uint64, nil
-	case float32:
-		return arrow.PrimitiveTypes.Float32, nil
-	case float64:
-		return arrow.PrimitiveTypes.Float64, nil
-	case string:
-		return arrow.BinaryTypes.String, nil
-	case bool:
-		return arrow.FixedWidthTypes.Boolean, nil
-	default:
-		return nil, fmt.Errorf("unsupported type: %T", value)
-	}
-}
-
 func init() {
 	outputs.Add("parquet", func() telegraf.Output {
 		return &Parquet{
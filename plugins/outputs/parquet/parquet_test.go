@@ -6,12 +6,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apache/arrow-go/v18/parquet/compress"
 	"github.com/apache/arrow-go/v18/parquet/file"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
 )
 
 func TestCases(t *testing.T) {
@@ -245,3 +247,157 @@ func TestTimestampDifferentName(t *testing.T) {
 	require.Equal(t, 1, int(metadata.NumRows))
 	require.Equal(t, 2, metadata.Schema.NumColumns())
 }
+
+func TestSchemaEvolution(t *testing.T) {
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	// Write a first batch and a second one with the same columns
+	first := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Now())
+	require.NoError(t, plugin.Write([]telegraf.Metric{first}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{first}))
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// Add a new field and tag and a value with a conflicting type
+	second := metric.New(
+		"test",
+		map[string]string{"host": "a", "region": "eu"},
+		map[string]interface{}{"value": "foo", "count": int64(3)},
+		time.Now(),
+	)
+	require.NoError(t, plugin.Write([]telegraf.Metric{second}))
+	require.NoError(t, plugin.Close())
+
+	files, err = os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// Check the files, the first has the initial schema and the second one
+	// the merged schema
+	columns := make(map[int][]string)
+	for _, f := range files {
+		reader, err := file.OpenParquetFile(filepath.Join(testDir, f.Name()), false)
+		require.NoError(t, err)
+
+		metadata := reader.MetaData()
+		names := make([]string, 0, metadata.Schema.NumColumns())
+		for i := range metadata.Schema.NumColumns() {
+			names = append(names, metadata.Schema.Column(i).Name())
+		}
+		columns[int(metadata.NumRows)] = names
+		reader.Close()
+	}
+	require.Equal(t, map[int][]string{
+		2: {"host", "value", "timestamp"},
+		1: {"host", "value", "timestamp", "count", "region"},
+	}, columns)
+}
+
+func TestPartitioning(t *testing.T) {
+	ts := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, ts),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, ts),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 3.0}, ts),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"value": 4.0}, ts),
+		metric.New("mem", map[string]string{"host": "../../../../../x"}, map[string]interface{}{"value": 5.0}, ts),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		Partitioning:       `name={{.Name}}/date={{.Time.Format "2006-01-02"}}/host={{.Tag "host"}}`,
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	// Collect the written files with their number of rows
+	rows := make(map[string]int)
+	require.NoError(t, filepath.WalkDir(testDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		reader, err := file.OpenParquetFile(path, false)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		rel, err := filepath.Rel(testDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		rows[filepath.ToSlash(rel)] = int(reader.MetaData().NumRows)
+		return nil
+	}))
+	require.Equal(t, map[string]int{
+		"name=cpu/date=2026-10-18/host=a": 1,
+		"name=cpu/date=2026-10-18/host=b": 2,
+		"name=mem/date=2026-10-18/host=a": 1,
+	}, rows)
+}
+
+func TestSizeRotation(t *testing.T) {
+	metrics := make([]telegraf.Metric, 0, 100)
+	for i := range 100 {
+		metrics = append(metrics, metric.New(
+			"test",
+			map[string]string{},
+			map[string]interface{}{"value": float64(i)},
+			time.Now(),
+		))
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		RotationMaxSize:    config.Size(512),
+		RowGroupSize:       10,
+		Compression:        "zstd",
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	// Check the settings are applied to the files
+	for _, f := range files {
+		reader, err := file.OpenParquetFile(filepath.Join(testDir, f.Name()), false)
+		require.NoError(t, err)
+
+		metadata := reader.MetaData()
+		require.Equal(t, 100, int(metadata.NumRows))
+		require.Equal(t, 10, metadata.NumRowGroups())
+		chunk, err := metadata.RowGroup(0).ColumnChunk(0)
+		require.NoError(t, err)
+		require.Equal(t, compress.Codecs.Zstd, chunk.Compression())
+		reader.Close()
+	}
+}
+
+func TestInvalidCompression(t *testing.T) {
+	plugin := &Parquet{
+		Directory:   t.TempDir(),
+		Compression: "lzo",
+	}
+	require.ErrorContains(t, plugin.Init(), "invalid compression")
+}
@@ -0,0 +1,368 @@
+package cef
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Layouts of the date formats allowed by the CEF specification for
+// timestamp extensions in addition to milliseconds since epoch.
+var timestampLayouts = []string{
+	"Jan 2 2006 15:04:05.000 MST",
+	"Jan 2 2006 15:04:05 MST",
+	"Jan 2 2006 15:04:05.000",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 15:04:05.000 MST",
+	"Jan 2 15:04:05 MST",
+	"Jan 2 15:04:05.000",
+	"Jan 2 15:04:05",
+}
+
+// Extension keys of the CEF key dictionary carrying integer values
+var integerKeys = map[string]bool{
+	"cn1":                       true,
+	"cn2":                       true,
+	"cn3":                       true,
+	"cnt":                       true,
+	"destinationTranslatedPort": true,
+	"deviceDirection":           true,
+	"dpid":                      true,
+	"dpt":                       true,
+	"dvcpid":                    true,
+	"flexNumber1":               true,
+	"flexNumber2":               true,
+	"fsize":                     true,
+	"in":                        true,
+	"oldFileSize":               true,
+	"out":                       true,
+	"sourceTranslatedPort":      true,
+	"spid":                      true,
+	"spt":                       true,
+	"type":                      true,
+}
+
+// Extension keys of the CEF key dictionary carrying floating-point values
+var floatKeys = map[string]bool{
+	"cfp1":  true,
+	"cfp2":  true,
+	"cfp3":  true,
+	"cfp4":  true,
+	"dlat":  true,
+	"dlong": true,
+	"slat":  true,
+	"slong": true,
+}
+
+type Parser struct {
+	MetricName   string          `toml:"metric_name"`
+	TagKeys      []string        `toml:"cef_tag_keys"`
+	TimestampKey string          `toml:"cef_timestamp_key"`
+	Timezone     string          `toml:"cef_timezone"`
+	CustomLabels bool            `toml:"cef_custom_labels"`
+	Log          telegraf.Logger `toml:"-"`
+
+	DefaultTags map[string]string `toml:"-"`
+
+	tagKeys  map[string]bool
+	location *time.Location
+}
+
+func (p *Parser) Init() error {
+	if p.MetricName == "" {
+		p.MetricName = "cef"
+	}
+
+	p.location = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.location = loc
+	}
+
+	p.tagKeys = make(map[string]bool, len(p.TagKeys))
+	for _, k := range p.TagKeys {
+		p.tagKeys[k] = true
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(buf)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		m, err := p.parse(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, errors.New("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parse(line string) (telegraf.Metric, error) {
+	// Skip any syslog header preceding the CEF message
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, errors.New("no CEF header found")
+	}
+	header, extension, err := splitHeader(line[start+len("CEF:"):])
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := strconv.ParseInt(header[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEF version %q", header[0])
+	}
+
+	tags := make(map[string]string, len(p.DefaultTags)+4+len(p.tagKeys))
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for i, k := range []string{"device_vendor", "device_product", "device_version", "device_event_class_id"} {
+		if header[i+1] != "" {
+			tags[k] = header[i+1]
+		}
+	}
+
+	fields := map[string]interface{}{
+		"cef_version": version,
+		"name":        header[5],
+	}
+	if severity, err := strconv.ParseInt(header[6], 10, 64); err == nil {
+		fields["severity"] = severity
+	} else {
+		fields["severity"] = header[6]
+	}
+
+	ext := parseExtension(extension)
+
+	timestamp := time.Now()
+	if v, found := ext[p.TimestampKey]; found {
+		t, err := parseTimestamp(v, p.location)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timestamp %q failed: %w", v, err)
+		}
+		timestamp = t
+		delete(ext, p.TimestampKey)
+	}
+
+	// Resolve custom labels e.g. cs1Label=Policy cs1=Block to Policy=Block
+	labels := make(map[string]string)
+	if p.CustomLabels {
+		for k, label := range ext {
+			base, found := strings.CutSuffix(k, "Label")
+			if !found || label == "" {
+				continue
+			}
+			if _, exists := ext[base]; exists {
+				labels[base] = label
+				delete(ext, k)
+			}
+		}
+	}
+
+	for k, v := range ext {
+		var value interface{} = v
+		if integerKeys[k] {
+			if iv, err := strconv.ParseInt(v, 10, 64); err == nil {
+				value = iv
+			}
+		} else if floatKeys[k] {
+			if fv, err := strconv.ParseFloat(v, 64); err == nil {
+				value = fv
+			}
+		}
+
+		key := k
+		if label, found := labels[k]; found {
+			key = label
+		}
+		if p.tagKeys[key] {
+			tags[key] = v
+			continue
+		}
+		fields[key] = value
+	}
+
+	return metric.New(p.MetricName, tags, fields, timestamp), nil
+}
+
+// splitHeader splits the seven pipe-separated header fields from the
+// extension honoring escaped pipes and backslashes.
+func splitHeader(s string) ([]string, string, error) {
+	header := make([]string, 0, 7)
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+				i++
+				current.WriteByte(s[i])
+				continue
+			}
+			current.WriteByte(c)
+		case '|':
+			header = append(header, current.String())
+			current.Reset()
+			if len(header) == 7 {
+				return header, s[i+1:], nil
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return nil, "", fmt.Errorf("incomplete header with %d of 7 fields", len(header)+1)
+}
+
+// parseExtension splits the space-separated key-value pairs of the extension.
+// Values may contain spaces, so a pair ends where the next key starts.
+func parseExtension(s string) map[string]string {
+	type pair struct{ keyStart, eq int }
+
+	// Find all unescaped equal signs preceded by a valid key
+	var pairs []pair
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			keyStart := strings.LastIndexByte(s[:i], ' ') + 1
+			if len(pairs) > 0 && keyStart <= pairs[len(pairs)-1].eq {
+				// Unescaped equal sign within a value
+				continue
+			}
+			if !validKey(s[keyStart:i]) {
+				continue
+			}
+			pairs = append(pairs, pair{keyStart: keyStart, eq: i})
+		}
+	}
+
+	ext := make(map[string]string, len(pairs))
+	for i, p := range pairs {
+		end := len(s)
+		if i+1 < len(pairs) {
+			end = pairs[i+1].keyStart
+		}
+		ext[s[p.keyStart:p.eq]] = unescapeValue(strings.TrimRight(s[p.eq+1:end], " "))
+	}
+	return ext
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '_', c == '.', c == '-', c == '[', c == ']':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func unescapeValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case '\\', '=', '|':
+				b.WriteByte(s[i+1])
+				i++
+				continue
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case 'r':
+				b.WriteByte('\r')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func parseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	for _, layout := range timestampLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			continue
+		}
+		// Formats without year refer to the current year
+		if t.Year() == 0 {
+			t = time.Date(time.Now().In(loc).Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		}
+		return t, nil
+	}
+	return time.Time{}, errors.New("unknown timestamp format")
+}
+
+func init() {
+	parsers.Add("cef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{
+				MetricName:   defaultMetricName,
+				TimestampKey: "rt",
+				CustomLabels: true,
+			}
+		},
+	)
+}
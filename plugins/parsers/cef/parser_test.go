@@ -0,0 +1,273 @@
+package cef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected telegraf.Metric
+	}{
+		{
+			name:  "basic",
+			input: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 rt=1700000000000",
+			expected: metric.New(
+				"cef",
+				map[string]string{
+					"device_vendor":         "Security",
+					"device_product":        "threatmanager",
+					"device_version":        "1.0",
+					"device_event_class_id": "100",
+				},
+				map[string]interface{}{
+					"cef_version": int64(0),
+					"name":        "worm successfully stopped",
+					"severity":    int64(10),
+					"src":         "10.0.0.1",
+					"dst":         "2.1.2.2",
+					"spt":         int64(1232),
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "syslog header",
+			input: "<134>Nov 14 22:13:20 fw01 CEF:1|Acme|Firewall|2.3|deny|Connection denied|High|act=deny proto=TCP rt=1700000000000",
+			expected: metric.New(
+				"cef",
+				map[string]string{
+					"device_vendor":         "Acme",
+					"device_product":        "Firewall",
+					"device_version":        "2.3",
+					"device_event_class_id": "deny",
+				},
+				map[string]interface{}{
+					"cef_version": int64(1),
+					"name":        "Connection denied",
+					"severity":    "High",
+					"act":         "deny",
+					"proto":       "TCP",
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "escaping",
+			input: `CEF:0|security|threat\|manager|1.0|100|detected a \| in message\\|5|act=blocked a \= sign msg=line1\nline2 path=C:\Windows\\System32 rt=1700000000000`,
+			expected: metric.New(
+				"cef",
+				map[string]string{
+					"device_vendor":         "security",
+					"device_product":        "threat|manager",
+					"device_version":        "1.0",
+					"device_event_class_id": "100",
+				},
+				map[string]interface{}{
+					"cef_version": int64(0),
+					"name":        `detected a | in message\`,
+					"severity":    int64(5),
+					"act":         "blocked a = sign",
+					"msg":         "line1\nline2",
+					"path":        `C:\Windows\System32`,
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "custom labels",
+			input: "CEF:0|Acme|IDS|1.0|42|Intrusion|7|cs1Label=Policy Name cs1=Block all cn1Label=Rule ID cn1=4711 cs2=unlabeled cfp1Label=Score cfp1=0.75 rt=1700000000000",
+			expected: metric.New(
+				"cef",
+				map[string]string{
+					"device_vendor":         "Acme",
+					"device_product":        "IDS",
+					"device_version":        "1.0",
+					"device_event_class_id": "42",
+				},
+				map[string]interface{}{
+					"cef_version": int64(0),
+					"name":        "Intrusion",
+					"severity":    int64(7),
+					"Policy Name": "Block all",
+					"Rule ID":     int64(4711),
+					"cs2":         "unlabeled",
+					"Score":       0.75,
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "empty extension",
+			input: "CEF:0|Acme|IDS|1.0||Heartbeat|0|",
+			expected: metric.New(
+				"cef",
+				map[string]string{
+					"device_vendor":  "Acme",
+					"device_product": "IDS",
+					"device_version": "1.0",
+				},
+				map[string]interface{}{
+					"cef_version": int64(0),
+					"name":        "Heartbeat",
+					"severity":    int64(0),
+				},
+				time.Unix(0, 0),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "rt", CustomLabels: true}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			if tt.expected.Time().Unix() == 0 {
+				testutil.RequireMetricEqual(t, tt.expected, actual[0], testutil.IgnoreTime())
+			} else {
+				testutil.RequireMetricEqual(t, tt.expected, actual[0])
+			}
+		})
+	}
+}
+
+func TestParseRawCustomLabels(t *testing.T) {
+	parser := &Parser{}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine("CEF:0|Acme|IDS|1.0|42|Intrusion|7|cs1Label=Policy cs1=Block")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"cef_version": int64(0),
+		"name":        "Intrusion",
+		"severity":    int64(7),
+		"cs1Label":    "Policy",
+		"cs1":         "Block",
+	}, m.Fields())
+}
+
+func TestParseTimestamps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{input: "1700000000123", expected: time.UnixMilli(1700000000123)},
+		{input: "Nov 14 2023 22:13:20", expected: time.Date(2023, 11, 14, 22, 13, 20, 0, berlin)},
+		{input: "Nov 14 2023 22:13:20.123", expected: time.Date(2023, 11, 14, 22, 13, 20, 123000000, berlin)},
+		{input: "Nov 14 2023 22:13:20.123 UTC", expected: time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC)},
+		{input: "Nov 4 22:13:20", expected: time.Date(time.Now().Year(), 11, 4, 22, 13, 20, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "end", Timezone: "Europe/Berlin"}
+			require.NoError(t, parser.Init())
+
+			m, err := parser.ParseLine("CEF:0|Acme|IDS|1.0|42|Intrusion|7|rt=ignored end=" + tt.input + " src=10.1.1.1")
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(m.Time()), "expected %v but got %v", tt.expected, m.Time())
+			require.Equal(t, "ignored", m.Fields()["rt"])
+			require.Equal(t, "10.1.1.1", m.Fields()["src"])
+			require.NotContains(t, m.Fields(), "end")
+		})
+	}
+}
+
+func TestParseTagKeys(t *testing.T) {
+	parser := &Parser{
+		MetricName:   "security",
+		TagKeys:      []string{"dvchost", "Policy"},
+		TimestampKey: "rt",
+		CustomLabels: true,
+	}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"source": "syslog"})
+
+	m, err := parser.ParseLine("CEF:0|Acme|IDS|1.0|42|Intrusion|7|dvchost=fw01 cs1Label=Policy cs1=Block dpt=443")
+	require.NoError(t, err)
+	require.Equal(t, "security", m.Name())
+	require.Equal(t, map[string]string{
+		"source":                "syslog",
+		"device_vendor":         "Acme",
+		"device_product":        "IDS",
+		"device_version":        "1.0",
+		"device_event_class_id": "42",
+		"dvchost":               "fw01",
+		"Policy":                "Block",
+	}, m.Tags())
+	require.Equal(t, int64(443), m.Fields()["dpt"])
+}
+
+func TestParseMultipleLines(t *testing.T) {
+	input := "CEF:0|Acme|IDS|1.0|1|first|1|cnt=1\n\nCEF:0|Acme|IDS|1.0|2|second|2|cnt=2\n"
+
+	parser := &Parser{}
+	require.NoError(t, parser.Init())
+
+	metrics, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, "first", metrics[0].Fields()["name"])
+	require.Equal(t, int64(2), metrics[1].Fields()["cnt"])
+
+	_, err = parser.ParseLine(input)
+	require.ErrorContains(t, err, "more than one metric in line")
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "no header",
+			input:    "LEEF:1.0|Acme|IDS|1.0|42|src=10.0.0.1",
+			expected: "no CEF header found",
+		},
+		{
+			name:     "incomplete header",
+			input:    `CEF:0|Acme|IDS|1.0|42\|Intrusion|7`,
+			expected: "incomplete header with 6 of 7 fields",
+		},
+		{
+			name:     "invalid version",
+			input:    "CEF:x|Acme|IDS|1.0|42|Intrusion|7|",
+			expected: `invalid CEF version "x"`,
+		},
+		{
+			name:     "invalid timestamp",
+			input:    "CEF:0|Acme|IDS|1.0|42|Intrusion|7|rt=yesterday",
+			expected: `parsing timestamp "yesterday" failed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "rt"}
+			require.NoError(t, parser.Init())
+
+			_, err := parser.Parse([]byte(tt.input))
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestInitInvalidTimezone(t *testing.T) {
+	parser := &Parser{Timezone: "Mars/Olympus"}
+	require.ErrorContains(t, parser.Init(), "invalid timezone")
+}
@@ -7,6 +7,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -47,6 +49,8 @@ type Parser struct {
 	MetadataSeparators []string        `toml:"csv_metadata_separators"`
 	MetadataTrimSet    string          `toml:"csv_metadata_trim_set"`
 	ResetMode          string          `toml:"csv_reset_mode"`
+	TypeInferenceRows  int             `toml:"csv_type_inference_rows"`
+	SectionMarker      string          `toml:"csv_section_marker"`
 	Log                telegraf.Logger `toml:"-"`
 
 	DefaultTags map[string]string
@@ -61,6 +65,10 @@ type Parser struct {
 	remainingSkipRows     int
 	remainingHeaderRows   int
 	remainingMetadataRows int
+	sectionPattern        *regexp.Regexp
+	sectionName           string
+	inferredTypes         []string
+	inferredRows          int
 
 	sync.Mutex
 }
@@ -132,6 +140,32 @@ func (p *Parser) Reset() {
 	p.remainingSkipRows = p.SkipRows
 	p.remainingHeaderRows = p.HeaderRowCount
 	p.remainingMetadataRows = p.MetadataRows
+
+	// Reset the section and the inferred types
+	p.sectionName = ""
+	p.inferredTypes = nil
+	p.inferredRows = 0
+}
+
+// startSection resets the header and the inferred types for a new section
+// named by the given section marker line.
+func (p *Parser) startSection(line string) {
+	p.sectionName = ""
+	if match := p.sectionPattern.FindStringSubmatch(line); len(match) > 1 {
+		idx := p.sectionPattern.SubexpIndex("name")
+		if idx < 0 {
+			idx = 1
+		}
+		p.sectionName = strings.TrimSpace(match[idx])
+	}
+
+	p.gotColumnNames = p.gotInitialColumnNames
+	if !p.gotInitialColumnNames {
+		p.ColumnNames = nil
+	}
+	p.remainingHeaderRows = p.HeaderRowCount
+	p.inferredTypes = nil
+	p.inferredRows = 0
 }
 
 func (p *Parser) Init() error {
@@ -175,6 +209,18 @@ func (p *Parser) Init() error {
 		p.location = loc
 	}
 
+	if p.TypeInferenceRows < 0 {
+		return errors.New("csv_type_inference_rows must not be negative")
+	}
+
+	if p.SectionMarker != "" {
+		re, err := regexp.Compile(p.SectionMarker)
+		if err != nil {
+			return fmt.Errorf("invalid section marker: %w", err)
+		}
+		p.sectionPattern = re
+	}
+
 	if p.ResetMode == "" {
 		p.ResetMode = "none"
 	}
@@ -290,7 +336,55 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 			p.metadataTags[k] = v
 		}
 	}
-	csvReader := p.compile(lineReader)
+	if p.sectionPattern != nil {
+		return p.parseSections(lineReader)
+	}
+	return p.parseTable(lineReader)
+}
+
+// parseSections splits the data at the section marker lines and parses each
+// section as a separate table.
+func (p *Parser) parseSections(r *bufio.Reader) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	var section bytes.Buffer
+	flush := func() error {
+		defer section.Reset()
+		if len(bytes.TrimSpace(section.Bytes())) == 0 {
+			return nil
+		}
+		m, err := p.parseTable(&section)
+		metrics = append(metrics, m...)
+		return err
+	}
+
+	for {
+		line, err := r.ReadString('\n')
+		if len(line) > 0 {
+			if p.sectionPattern.MatchString(strings.TrimRight(line, "\r\n")) {
+				if err := flush(); err != nil {
+					return metrics, err
+				}
+				p.startSection(strings.TrimRight(line, "\r\n"))
+			} else {
+				section.WriteString(line)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return metrics, err
+			}
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return metrics, err
+	}
+	return metrics, nil
+}
+
+// parseTable parses the header and data rows of a table
+func (p *Parser) parseTable(r io.Reader) ([]telegraf.Metric, error) {
+	csvReader := p.compile(r)
 	// if there is a header, and we did not get DataColumns
 	// set DataColumns to names extracted from the header
 	// we always reread the header to avoid side effects
@@ -329,6 +423,16 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 		return nil, err
 	}
 
+	// Infer the column types from the first rows if requested
+	if p.TypeInferenceRows > 0 && len(p.ColumnTypes) == 0 {
+		for _, record := range table {
+			if p.inferredRows >= p.TypeInferenceRows {
+				break
+			}
+			p.inferRecord(record)
+		}
+	}
+
 	metrics := make([]telegraf.Metric, 0, len(table))
 	for _, record := range table {
 		m, err := p.parseRecord(record)
@@ -397,29 +501,29 @@ outer:
 					return nil, errors.New("column type: column count exceeded")
 				}
 
-				var val interface{}
-				var err error
+				val, err := convertColumn(p.ColumnTypes[i], value)
+				if err != nil {
+					return nil, err
+				}
+				recordFields[fieldName] = val
+				continue
+			}
 
-				switch p.ColumnTypes[i] {
-				case "int":
-					val, err = strconv.ParseInt(value, 10, 64)
-					if err != nil {
-						return nil, fmt.Errorf("column type: parse int error %w", err)
-					}
-				case "float":
-					val, err = strconv.ParseFloat(value, 64)
-					if err != nil {
-						return nil, fmt.Errorf("column type: parse float error %w", err)
-					}
-				case "bool":
-					val, err = strconv.ParseBool(value)
-					if err != nil {
-						return nil, fmt.Errorf("column type: parse bool error %w", err)
+			// Use the inferred column type if available. Empty values
+			// cannot be represented in non-string columns and are skipped.
+			if i < len(p.inferredTypes) && p.inferredTypes[i] != "" {
+				if value == "" && p.inferredTypes[i] != "string" {
+					continue
+				}
+				val, err := convertColumn(p.inferredTypes[i], value)
+				if err != nil {
+					// Inferred types are only a guess so drop the field
+					// instead of failing the whole row
+					if p.Log != nil {
+						p.Log.Debugf("Dropping field %q: value %q does not match inferred type %q", fieldName, value, p.inferredTypes[i])
 					}
-				default:
-					val = value
+					continue
 				}
-
 				recordFields[fieldName] = val
 				continue
 			}
@@ -449,8 +553,11 @@ outer:
 		}
 	}
 
-	// will default to plugin name
+	// will default to plugin name or the name of the current section
 	measurementName := p.MetricName
+	if p.sectionName != "" {
+		measurementName = p.sectionName
+	}
 	if p.MeasurementColumn != "" {
 		if recordFields[p.MeasurementColumn] != nil && recordFields[p.MeasurementColumn] != "" {
 			measurementName = fmt.Sprintf("%v", recordFields[p.MeasurementColumn])
@@ -471,6 +578,83 @@ outer:
 	return m, nil
 }
 
+// inferRecord updates the inferred column types with the values of the
+// given record. Types are only widened, i.e. from "int" to "float" or from
+// any type to "string".
+func (p *Parser) inferRecord(record []string) {
+	if len(record) < p.SkipColumns {
+		return
+	}
+	record = record[p.SkipColumns:]
+	if len(p.inferredTypes) < len(p.ColumnNames) {
+		p.inferredTypes = append(p.inferredTypes, make([]string, len(p.ColumnNames)-len(p.inferredTypes))...)
+	}
+
+	for i, fieldName := range p.ColumnNames {
+		if i >= len(record) {
+			break
+		}
+		if fieldName == p.TimestampColumn || fieldName == p.MeasurementColumn || slices.Contains(p.TagColumns, fieldName) {
+			continue
+		}
+
+		value := record[i]
+		if p.TrimSpace {
+			value = strings.Trim(value, " ")
+		}
+		if value == "" || slices.Contains(p.SkipValues, value) {
+			continue
+		}
+
+		var typ string
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			typ = "int"
+		} else if _, err := strconv.ParseFloat(value, 64); err == nil {
+			typ = "float"
+		} else if strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+			// Do not use strconv.ParseBool here as it would infer columns
+			// containing e.g. "T" or "F" as boolean
+			typ = "bool"
+		} else {
+			typ = "string"
+		}
+
+		switch current := p.inferredTypes[i]; {
+		case current == "" || current == typ:
+			p.inferredTypes[i] = typ
+		case (current == "int" && typ == "float") || (current == "float" && typ == "int"):
+			p.inferredTypes[i] = "float"
+		default:
+			p.inferredTypes[i] = "string"
+		}
+	}
+	p.inferredRows++
+}
+
+func convertColumn(typ, value string) (interface{}, error) {
+	switch typ {
+	case "int":
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("column type: parse int error %w", err)
+		}
+		return val, nil
+	case "float":
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("column type: parse float error %w", err)
+		}
+		return val, nil
+	case "bool":
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("column type: parse bool error %w", err)
+		}
+		return val, nil
+	}
+	return value, nil
+}
+
 // ParseTimestamp return a timestamp, if there is no timestamp on the csv it
 // will be the current timestamp, else it will try to parse the time according
 // to the format.
@@ -1744,3 +1744,239 @@ func BenchmarkParsing(b *testing.B) {
 func defaultTime() time.Time {
 	return time.Unix(3600, 0)
 }
+
+func TestTypeInference(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:    1,
+		TypeInferenceRows: 3,
+		TagColumns:        []string{"site"},
+		TimestampColumn:   "time",
+		TimestampFormat:   "unix",
+	}
+	require.NoError(t, p.Init())
+
+	testCSV := `time,site,power,status,errors,enabled,note
+1700000000,north,1,1,,true,ok
+1700000060,north,2.5,0,,false,42
+1700000120,south,3,true,4,true,
+1700000180,south,4,on,5,false,fine`
+
+	expected := []telegraf.Metric{
+		metric.New("",
+			map[string]string{"site": "north"},
+			map[string]interface{}{"power": 1.0, "status": "1", "enabled": true, "note": "ok"},
+			time.Unix(1700000000, 0),
+		),
+		metric.New("",
+			map[string]string{"site": "north"},
+			map[string]interface{}{"power": 2.5, "status": "0", "enabled": false, "note": "42"},
+			time.Unix(1700000060, 0),
+		),
+		metric.New("",
+			map[string]string{"site": "south"},
+			map[string]interface{}{"power": 3.0, "status": "true", "errors": int64(4), "enabled": true, "note": ""},
+			time.Unix(1700000120, 0),
+		),
+		metric.New("",
+			map[string]string{"site": "south"},
+			map[string]interface{}{"power": 4.0, "status": "on", "errors": int64(5), "enabled": false, "note": "fine"},
+			time.Unix(1700000180, 0),
+		),
+	}
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+
+	// Values not matching the inferred type after the inference window are
+	// dropped without affecting the remaining fields
+	expected = []telegraf.Metric{
+		metric.New("",
+			map[string]string{"site": "south"},
+			map[string]interface{}{"status": "on", "errors": int64(6), "enabled": true, "note": "x"},
+			time.Unix(1700000240, 0),
+		),
+	}
+	metrics, err = p.Parse([]byte("1700000240,south,high,on,6,true,x"))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestTypeInferenceBoolLetters(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:    1,
+		TypeInferenceRows: 2,
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+
+	testCSV := `grade,passed
+T,TRUE
+F,false
+A,true`
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, metrics, 3)
+	for i, grade := range []string{"T", "F", "A"} {
+		v, found := metrics[i].GetField("grade")
+		require.True(t, found)
+		require.Equal(t, grade, v)
+	}
+	v, found := metrics[0].GetField("passed")
+	require.True(t, found)
+	require.Equal(t, true, v)
+}
+
+func TestTypeInferenceLinewise(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:    1,
+		TypeInferenceRows: 2,
+	}
+	p.SetTimeFunc(defaultTime)
+	require.NoError(t, p.Init())
+
+	lines := []string{"a,b", "1,1", "2.5,x", "3,4"}
+	expected := []map[string]interface{}{
+		{"a": int64(1), "b": int64(1)},
+		{"a": 2.5, "b": "x"},
+		{"a": 3.0, "b": "4"},
+	}
+
+	m, err := p.ParseLine(lines[0])
+	require.NoError(t, err)
+	require.Nil(t, m)
+	for i, line := range lines[1:] {
+		m, err := p.ParseLine(line)
+		require.NoError(t, err)
+		require.Equal(t, expected[i], m.Fields())
+	}
+}
+
+func TestTypeInferenceExplicitTypes(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount:    1,
+		TypeInferenceRows: 10,
+		ColumnTypes:       []string{"string", "float"},
+	}
+	p.SetTimeFunc(defaultTime)
+	require.NoError(t, p.Init())
+
+	metrics, err := p.Parse([]byte("a,b\n1,2\n3,4"))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, map[string]interface{}{"a": "1", "b": 2.0}, metrics[0].Fields())
+	require.Equal(t, map[string]interface{}{"a": "3", "b": 4.0}, metrics[1].Fields())
+}
+
+func TestSections(t *testing.T) {
+	p := &Parser{
+		MetricName:        "export",
+		HeaderRowCount:    1,
+		SectionMarker:     `^\[(?P<name>[^\]]+)\]$`,
+		TypeInferenceRows: 5,
+		TimestampColumn:   "time",
+		TimestampFormat:   "2006-01-02 15:04",
+		Log:               testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+
+	testCSV := `time,serial
+2023-11-14 22:00,SN1234
+
+[inverter]
+time,power,voltage
+2023-11-14 22:00,1200,230.1
+2023-11-14 22:15,1250.5,229.8
+
+[grid]
+time,frequency,state
+2023-11-14 22:00,50.01,on
+2023-11-14 22:15,49.98,on
+`
+	expected := []telegraf.Metric{
+		metric.New("export",
+			map[string]string{},
+			map[string]interface{}{"serial": "SN1234"},
+			time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC),
+		),
+		metric.New("inverter",
+			map[string]string{},
+			map[string]interface{}{"power": 1200.0, "voltage": 230.1},
+			time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC),
+		),
+		metric.New("inverter",
+			map[string]string{},
+			map[string]interface{}{"power": 1250.5, "voltage": 229.8},
+			time.Date(2023, 11, 14, 22, 15, 0, 0, time.UTC),
+		),
+		metric.New("grid",
+			map[string]string{},
+			map[string]interface{}{"frequency": 50.01, "state": "on"},
+			time.Date(2023, 11, 14, 22, 0, 0, 0, time.UTC),
+		),
+		metric.New("grid",
+			map[string]string{},
+			map[string]interface{}{"frequency": 49.98, "state": "on"},
+			time.Date(2023, 11, 14, 22, 15, 0, 0, time.UTC),
+		),
+	}
+
+	metrics, err := p.Parse([]byte(testCSV))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestSectionsLinewise(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount: 1,
+		SectionMarker:  `^# Section: (\w+)`,
+	}
+	p.SetTimeFunc(defaultTime)
+	require.NoError(t, p.Init())
+
+	lines := []string{
+		"# Section: temperature",
+		"sensor,value",
+		"t1,21.5",
+		"# Section: humidity",
+		"sensor,value,unit",
+		"h1,45,%",
+	}
+	var metrics []telegraf.Metric
+	for _, line := range lines {
+		m, err := p.ParseLine(line)
+		require.NoError(t, err)
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+
+	expected := []telegraf.Metric{
+		metric.New("temperature",
+			map[string]string{},
+			map[string]interface{}{"sensor": "t1", "value": 21.5},
+			defaultTime(),
+		),
+		metric.New("humidity",
+			map[string]string{},
+			map[string]interface{}{"sensor": "h1", "value": int64(45), "unit": "%"},
+			defaultTime(),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestSectionsInvalid(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount: 1,
+		SectionMarker:  `^[section`,
+	}
+	require.ErrorContains(t, p.Init(), "invalid section marker")
+
+	p = &Parser{
+		HeaderRowCount:    1,
+		TypeInferenceRows: -1,
+	}
+	require.ErrorContains(t, p.Init(), "csv_type_inference_rows must not be negative")
+}
@@ -0,0 +1,387 @@
+package leef
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+// Layouts of the default date formats for the "devTime" attribute in addition
+// to milliseconds since epoch.
+var timestampLayouts = []string{
+	"Jan 2 2006 15:04:05.000 MST",
+	"Jan 2 2006 15:04:05 MST",
+	"Jan 2 2006 15:04:05.000",
+	"Jan 2 2006 15:04:05",
+}
+
+// Predefined attributes carrying integer values
+var integerKeys = map[string]bool{
+	"dstBytes":       true,
+	"dstPackets":     true,
+	"dstPort":        true,
+	"dstPostNATPort": true,
+	"dstPreNATPort":  true,
+	"sev":            true,
+	"srcBytes":       true,
+	"srcPackets":     true,
+	"srcPort":        true,
+	"srcPostNATPort": true,
+	"srcPreNATPort":  true,
+	"totalPackets":   true,
+}
+
+type Parser struct {
+	MetricName   string          `toml:"metric_name"`
+	TagKeys      []string        `toml:"leef_tag_keys"`
+	TimestampKey string          `toml:"leef_timestamp_key"`
+	Timezone     string          `toml:"leef_timezone"`
+	Delimiter    string          `toml:"leef_delimiter"`
+	Log          telegraf.Logger `toml:"-"`
+
+	DefaultTags map[string]string `toml:"-"`
+
+	tagKeys   map[string]bool
+	location  *time.Location
+	delimiter byte
+}
+
+func (p *Parser) Init() error {
+	if p.MetricName == "" {
+		p.MetricName = "leef"
+	}
+
+	p.location = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.location = loc
+	}
+
+	p.delimiter = '\t'
+	if p.Delimiter != "" {
+		d, err := parseDelimiter(p.Delimiter)
+		if err != nil {
+			return err
+		}
+		p.delimiter = d
+	}
+
+	p.tagKeys = make(map[string]bool, len(p.TagKeys))
+	for _, k := range p.TagKeys {
+		p.tagKeys[k] = true
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(buf)+1)
+	for scanner.Scan() {
+		// Only trim line endings as the default delimiter is a tab
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m, err := p.parse(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, errors.New("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parse(line string) (telegraf.Metric, error) {
+	// Skip any syslog header preceding the LEEF message
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, errors.New("no LEEF header found")
+	}
+	line = line[start+len("LEEF:"):]
+
+	// LEEF 2.0 adds the attribute delimiter as sixth header field
+	n := 5
+	if strings.HasPrefix(line, "2.") {
+		n = 6
+	}
+	header, attributes, err := splitHeader(line, n)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiter := p.delimiter
+	if n == 6 && header[5] != "" {
+		d, err := parseDelimiter(header[5])
+		if err != nil {
+			return nil, err
+		}
+		delimiter = d
+	}
+
+	tags := make(map[string]string, len(p.DefaultTags)+4+len(p.tagKeys))
+	for k, v := range p.DefaultTags {
+		tags[k] = v
+	}
+	for i, k := range []string{"vendor", "product", "product_version", "event_id"} {
+		if header[i+1] != "" {
+			tags[k] = header[i+1]
+		}
+	}
+
+	fields := map[string]interface{}{
+		"leef_version": header[0],
+	}
+
+	attrs := parseAttributes(attributes, delimiter)
+
+	timestamp := time.Now()
+	if v, found := attrs[p.TimestampKey]; found {
+		format := attrs["devTimeFormat"]
+		t, err := parseTimestamp(v, format, p.location)
+		if err != nil {
+			return nil, fmt.Errorf("parsing timestamp %q failed: %w", v, err)
+		}
+		timestamp = t
+		delete(attrs, p.TimestampKey)
+		delete(attrs, "devTimeFormat")
+	}
+
+	for k, v := range attrs {
+		if p.tagKeys[k] {
+			tags[k] = v
+			continue
+		}
+		if integerKeys[k] {
+			if iv, err := strconv.ParseInt(v, 10, 64); err == nil {
+				fields[k] = iv
+				continue
+			}
+		}
+		fields[k] = v
+	}
+
+	return metric.New(p.MetricName, tags, fields, timestamp), nil
+}
+
+// splitHeader splits the given number of pipe-separated header fields from
+// the attributes honoring escaped pipes and backslashes.
+func splitHeader(s string, n int) ([]string, string, error) {
+	header := make([]string, 0, n)
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+				i++
+				current.WriteByte(s[i])
+				continue
+			}
+			current.WriteByte(c)
+		case '|':
+			header = append(header, current.String())
+			current.Reset()
+			if len(header) == n {
+				return header, s[i+1:], nil
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	// LEEF 1.0 messages without attributes may omit the trailing pipe
+	if len(header) == n-1 && n == 5 {
+		return append(header, current.String()), "", nil
+	}
+	return nil, "", fmt.Errorf("incomplete header with %d of %d fields", len(header)+1, n)
+}
+
+// parseAttributes splits the attributes at unescaped delimiters and each
+// attribute at the first unescaped equal sign.
+func parseAttributes(s string, delimiter byte) map[string]string {
+	attrs := make(map[string]string)
+
+	var key, current strings.Builder
+	inValue := false
+	store := func() {
+		if k := strings.TrimSpace(key.String()); k != "" && inValue {
+			attrs[k] = current.String()
+		}
+		key.Reset()
+		current.Reset()
+		inValue = false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		target := &key
+		if inValue {
+			target = &current
+		}
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '\\' || s[i+1] == '=' || s[i+1] == '|' || s[i+1] == delimiter):
+			i++
+			target.WriteByte(s[i])
+		case c == delimiter:
+			store()
+		case c == '=' && !inValue:
+			inValue = true
+		default:
+			target.WriteByte(c)
+		}
+	}
+	store()
+
+	return attrs
+}
+
+// parseDelimiter decodes a delimiter given as single character or as
+// hexadecimal code in the form "x5E" or "0x5E".
+func parseDelimiter(s string) (byte, error) {
+	if len(s) == 1 {
+		return s[0], nil
+	}
+
+	lower := strings.ToLower(s)
+	code, found := strings.CutPrefix(lower, "0x")
+	if !found {
+		code, found = strings.CutPrefix(lower, "x")
+	}
+	if found {
+		if v, err := strconv.ParseUint(code, 16, 8); err == nil {
+			return byte(v), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid delimiter %q", s)
+}
+
+func parseTimestamp(s, format string, loc *time.Location) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	layouts := timestampLayouts
+	if format != "" {
+		layouts = []string{convertJavaLayout(format)}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown timestamp format")
+}
+
+// Conversion of Java SimpleDateFormat pattern letters to Go layout elements,
+// longer patterns must precede their prefixes.
+var javaLayoutElements = []struct {
+	java   string
+	golang string
+}{
+	{"yyyy", "2006"},
+	{"yy", "06"},
+	{"MMMM", "January"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"M", "1"},
+	{"dd", "02"},
+	{"d", "2"},
+	{"EEEE", "Monday"},
+	{"EEE", "Mon"},
+	{"HH", "15"},
+	{"hh", "03"},
+	{"h", "3"},
+	{"mm", "04"},
+	{"m", "4"},
+	{"ss", "05"},
+	{"s", "5"},
+	{"SSSSSSSSS", "000000000"},
+	{"SSSSSS", "000000"},
+	{"SSS", "000"},
+	{"a", "PM"},
+	{"zzz", "MST"},
+	{"z", "MST"},
+	{"XXX", "Z07:00"},
+	{"Z", "-0700"},
+}
+
+func convertJavaLayout(format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		// Quoted literal text
+		if format[i] == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				b.WriteString(format[i+1:])
+				break
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, e := range javaLayoutElements {
+			if strings.HasPrefix(format[i:], e.java) {
+				b.WriteString(e.golang)
+				i += len(e.java)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(format[i])
+			i++
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	parsers.Add("leef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{
+				MetricName:   defaultMetricName,
+				TimestampKey: "devTime",
+			}
+		},
+	)
+}
@@ -0,0 +1,267 @@
+package leef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected telegraf.Metric
+	}{
+		{
+			name:  "leef 1.0",
+			input: "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tsrcPort=81\tdstPort=21\tusrName=joe.black\tdevTime=1700000000000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"vendor":          "Microsoft",
+					"product":         "MSExchange",
+					"product_version": "4.0 SP1",
+					"event_id":        "15345",
+				},
+				map[string]interface{}{
+					"leef_version": "1.0",
+					"src":          "192.0.2.0",
+					"dst":          "172.50.123.1",
+					"sev":          int64(5),
+					"cat":          "anomaly",
+					"srcPort":      int64(81),
+					"dstPort":      int64(21),
+					"usrName":      "joe.black",
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "leef 2.0 with delimiter",
+			input: "<13>Nov 14 22:13:20 fw01 LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=5^msg=A=B^devTime=1700000000000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"vendor":          "Lancope",
+					"product":         "StealthWatch",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"leef_version": "2.0",
+					"src":          "10.0.1.8",
+					"dst":          "10.0.0.5",
+					"sev":          int64(5),
+					"msg":          "A=B",
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "leef 2.0 with hex delimiter",
+			input: "LEEF:2.0|Lancope|StealthWatch|1.0|41|0x7C|src=10.0.1.8|dst=10.0.0.5|devTime=1700000000000",
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"vendor":          "Lancope",
+					"product":         "StealthWatch",
+					"product_version": "1.0",
+					"event_id":        "41",
+				},
+				map[string]interface{}{
+					"leef_version": "2.0",
+					"src":          "10.0.1.8",
+					"dst":          "10.0.0.5",
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+		{
+			name:  "escaping",
+			input: `LEEF:1.0|Acme|Fire\|wall|1.0|deny|msg=a\=b\	c` + "\t" + `path=C:\Windows` + "\t" + `devTime=1700000000000`,
+			expected: metric.New(
+				"leef",
+				map[string]string{
+					"vendor":          "Acme",
+					"product":         "Fire|wall",
+					"product_version": "1.0",
+					"event_id":        "deny",
+				},
+				map[string]interface{}{
+					"leef_version": "1.0",
+					"msg":          "a=b\tc",
+					"path":         `C:\Windows`,
+				},
+				time.Unix(1700000000, 0),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "devTime"}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			require.Len(t, actual, 1)
+			testutil.RequireMetricEqual(t, tt.expected, actual[0])
+		})
+	}
+}
+
+func TestParseTimestamps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Time
+	}{
+		{
+			name:     "epoch milliseconds",
+			input:    "devTime=1700000000123",
+			expected: time.UnixMilli(1700000000123),
+		},
+		{
+			name:     "default format",
+			input:    "devTime=Nov 14 2023 22:13:20",
+			expected: time.Date(2023, 11, 14, 22, 13, 20, 0, berlin),
+		},
+		{
+			name:     "default format with zone",
+			input:    "devTime=Nov 14 2023 22:13:20.250 UTC",
+			expected: time.Date(2023, 11, 14, 22, 13, 20, 250000000, time.UTC),
+		},
+		{
+			name:     "custom format",
+			input:    "devTime=2023-11-14T22:13:20.250+0100\tdevTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSZ",
+			expected: time.Date(2023, 11, 14, 21, 13, 20, 250000000, time.UTC),
+		},
+		{
+			name:     "custom format with day names",
+			input:    "devTimeFormat=EEE, dd MMM yyyy hh:mm:ss a\tdevTime=Tue, 14 Nov 2023 10:13:20 PM",
+			expected: time.Date(2023, 11, 14, 22, 13, 20, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "devTime", Timezone: "Europe/Berlin"}
+			require.NoError(t, parser.Init())
+
+			m, err := parser.ParseLine("LEEF:1.0|Acme|IDS|1.0|42|src=10.1.1.1\t" + tt.input)
+			require.NoError(t, err)
+			require.True(t, tt.expected.Equal(m.Time()), "expected %v but got %v", tt.expected, m.Time())
+			require.Equal(t, map[string]interface{}{"leef_version": "1.0", "src": "10.1.1.1"}, m.Fields())
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	parser := &Parser{
+		MetricName:   "security",
+		TagKeys:      []string{"cat", "src"},
+		TimestampKey: "devTime",
+		Delimiter:    ";",
+	}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"source": "syslog"})
+
+	m, err := parser.ParseLine("LEEF:1.0|Acme|IDS|1.0|42|src=10.1.1.1;cat=scan;sev=3")
+	require.NoError(t, err)
+	require.Equal(t, "security", m.Name())
+	require.Equal(t, map[string]string{
+		"source":          "syslog",
+		"vendor":          "Acme",
+		"product":         "IDS",
+		"product_version": "1.0",
+		"event_id":        "42",
+		"cat":             "scan",
+		"src":             "10.1.1.1",
+	}, m.Tags())
+	require.Equal(t, map[string]interface{}{"leef_version": "1.0", "sev": int64(3)}, m.Fields())
+}
+
+func TestParseWithoutAttributes(t *testing.T) {
+	parser := &Parser{}
+	require.NoError(t, parser.Init())
+
+	for _, input := range []string{"LEEF:1.0|Acme|IDS|1.0|42", "LEEF:1.0|Acme|IDS|1.0|42|"} {
+		m, err := parser.ParseLine(input)
+		require.NoError(t, err)
+		require.Equal(t, "42", m.Tags()["event_id"])
+		require.Equal(t, map[string]interface{}{"leef_version": "1.0"}, m.Fields())
+	}
+}
+
+func TestParseMultipleLines(t *testing.T) {
+	input := "LEEF:1.0|Acme|IDS|1.0|1|sev=1\r\n\r\nLEEF:1.0|Acme|IDS|1.0|2|sev=2\r\n"
+
+	parser := &Parser{}
+	require.NoError(t, parser.Init())
+
+	metrics, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	require.Equal(t, int64(1), metrics[0].Fields()["sev"])
+	require.Equal(t, int64(2), metrics[1].Fields()["sev"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "no header",
+			input:    "CEF:0|Acme|IDS|1.0|42|Intrusion|7|src=10.0.0.1",
+			expected: "no LEEF header found",
+		},
+		{
+			name:     "incomplete header",
+			input:    "LEEF:1.0|Acme|IDS",
+			expected: "incomplete header with 3 of 5 fields",
+		},
+		{
+			name:     "incomplete 2.0 header",
+			input:    "LEEF:2.0|Acme|IDS|1.0|42",
+			expected: "incomplete header with 5 of 6 fields",
+		},
+		{
+			name:     "invalid delimiter",
+			input:    "LEEF:2.0|Acme|IDS|1.0|42|xyz|src=10.0.0.1",
+			expected: `invalid delimiter "xyz"`,
+		},
+		{
+			name:     "invalid timestamp",
+			input:    "LEEF:1.0|Acme|IDS|1.0|42|devTime=yesterday",
+			expected: `parsing timestamp "yesterday" failed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{TimestampKey: "devTime"}
+			require.NoError(t, parser.Init())
+
+			_, err := parser.Parse([]byte(tt.input))
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestInitInvalid(t *testing.T) {
+	parser := &Parser{Timezone: "Mars/Olympus"}
+	require.ErrorContains(t, parser.Init(), "invalid timezone")
+
+	parser = &Parser{Delimiter: "0xZZ"}
+	require.ErrorContains(t, parser.Init(), `invalid delimiter "0xZZ"`)
+}
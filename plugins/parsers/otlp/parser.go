@@ -0,0 +1,140 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb-observability/otel2influx"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+type Parser struct {
+	Signal              string            `toml:"otlp_signal"`
+	MetricsSchema       string            `toml:"otlp_metrics_schema"`
+	LogRecordDimensions []string          `toml:"otlp_log_record_dimensions"`
+	DefaultTags         map[string]string `toml:"-"`
+	Log                 telegraf.Logger   `toml:"-"`
+
+	metrics   *otel2influx.OtelMetricsToLineProtocol
+	logs      *otel2influx.OtelLogsToLineProtocol
+	writer    *common_otlp.Writer
+	collected []telegraf.Metric
+}
+
+func (p *Parser) Init() error {
+	p.writer = common_otlp.NewWriter(func(m telegraf.Metric) {
+		p.collected = append(p.collected, m)
+	})
+
+	switch p.Signal {
+	case "":
+		p.Signal = "metrics"
+		fallthrough
+	case "metrics":
+		if p.MetricsSchema == "" {
+			p.MetricsSchema = "prometheus-v1"
+		}
+		converter, err := common_otlp.NewMetricsConverter(p.Log, p.writer, p.MetricsSchema)
+		if err != nil {
+			return fmt.Errorf("creating metrics converter failed: %w", err)
+		}
+		p.metrics = converter
+	case "logs":
+		converter, err := common_otlp.NewLogsConverter(p.Log, p.writer, p.LogRecordDimensions)
+		if err != nil {
+			return fmt.Errorf("creating logs converter failed: %w", err)
+		}
+		p.logs = converter
+	default:
+		return fmt.Errorf("invalid signal %q", p.Signal)
+	}
+
+	return nil
+}
+
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	// OTLP-JSON payloads are JSON objects while OTLP-protobuf export requests
+	// always start with the tag of the first (length-delimited) field.
+	isJSON := strings.HasPrefix(string(bytes.TrimSpace(buf)), "{")
+
+	p.collected = make([]telegraf.Metric, 0)
+	ctx := context.Background()
+	switch p.Signal {
+	case "metrics":
+		req := pmetricotlp.NewExportRequest()
+		if err := unmarshal(&req, buf, isJSON); err != nil {
+			return nil, fmt.Errorf("unmarshalling metrics request failed: %w", err)
+		}
+		if err := p.metrics.WriteMetrics(ctx, req.Metrics()); err != nil {
+			return nil, err
+		}
+	case "logs":
+		req := plogotlp.NewExportRequest()
+		if err := unmarshal(&req, buf, isJSON); err != nil {
+			return nil, fmt.Errorf("unmarshalling logs request failed: %w", err)
+		}
+		if err := p.logs.WriteLogs(ctx, req.Logs()); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := p.collected
+	p.collected = nil
+	for _, m := range metrics {
+		for k, v := range p.DefaultTags {
+			if !m.HasTag(k) {
+				m.AddTag(k, v)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, errors.New("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, errors.New("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+type unmarshaler interface {
+	UnmarshalProto(data []byte) error
+	UnmarshalJSON(data []byte) error
+}
+
+func unmarshal(req unmarshaler, buf []byte, isJSON bool) error {
+	if isJSON {
+		return req.UnmarshalJSON(buf)
+	}
+	return req.UnmarshalProto(buf)
+}
+
+func init() {
+	parsers.Add("otlp",
+		func(string) telegraf.Parser {
+			return &Parser{}
+		},
+	)
+}
@@ -0,0 +1,243 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var ts = time.Unix(1700000000, 0)
+
+func testMetricsRequest() pmetricotlp.ExportRequest {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	sm := rm.ScopeMetrics().AppendEmpty()
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("cpu_temperature")
+	dp := gauge.SetEmptyGauge().DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("core", "0")
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.SetDoubleValue(42.5)
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("http_requests")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	dp = sum.Sum().DataPoints().AppendEmpty()
+	dp.Attributes().PutStr("method", "GET")
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	dp.SetIntValue(1024)
+
+	return pmetricotlp.NewExportRequestFromMetrics(md)
+}
+
+func testLogsRequest() plogotlp.ExportRequest {
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", "checkout")
+	record := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	record.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+	record.SetSeverityNumber(plog.SeverityNumberWarn)
+	record.SetSeverityText("WARN")
+	record.Body().SetStr("payment declined")
+	record.Attributes().PutStr("order", "1234")
+	record.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	record.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+
+	return plogotlp.NewExportRequestFromLogs(ld)
+}
+
+func TestParseMetrics(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu_temperature",
+			map[string]string{"core": "0", "service.name": "checkout"},
+			map[string]interface{}{"gauge": 42.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"http_requests",
+			map[string]string{"method": "GET", "service.name": "checkout"},
+			map[string]interface{}{"counter": int64(1024)},
+			ts,
+			telegraf.Counter,
+		),
+	}
+
+	req := testMetricsRequest()
+	protobuf, err := req.MarshalProto()
+	require.NoError(t, err)
+	jsonData, err := req.MarshalJSON()
+	require.NoError(t, err)
+
+	for name, buf := range map[string][]byte{"protobuf": protobuf, "json": jsonData} {
+		t.Run(name, func(t *testing.T) {
+			parser := &Parser{Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseMetricsSchemaV2(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New(
+			"prometheus",
+			map[string]string{"core": "0", "service.name": "checkout"},
+			map[string]interface{}{"cpu_temperature": 42.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"prometheus",
+			map[string]string{"method": "GET", "service.name": "checkout"},
+			map[string]interface{}{"http_requests": int64(1024)},
+			ts,
+			telegraf.Counter,
+		),
+	}
+
+	req := testMetricsRequest()
+	buf, err := req.MarshalProto()
+	require.NoError(t, err)
+
+	parser := &Parser{MetricsSchema: "prometheus-v2", Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
+func TestParseLogs(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New(
+			"logs",
+			map[string]string{
+				"service.name": "checkout",
+				"trace_id":     "0102030405060708090a0b0c0d0e0f10",
+				"span_id":      "0102030405060708",
+			},
+			map[string]interface{}{
+				"body":            "payment declined",
+				"severity_number": int64(13),
+				"severity_text":   "WARN",
+				"attributes":      `{"order":"1234"}`,
+			},
+			ts,
+		),
+	}
+
+	req := testLogsRequest()
+	protobuf, err := req.MarshalProto()
+	require.NoError(t, err)
+	jsonData, err := req.MarshalJSON()
+	require.NoError(t, err)
+
+	for name, buf := range map[string][]byte{"protobuf": protobuf, "json": jsonData} {
+		t.Run(name, func(t *testing.T) {
+			parser := &Parser{Signal: "logs", Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestParseLogsDimensions(t *testing.T) {
+	req := testLogsRequest()
+	buf, err := req.MarshalProto()
+	require.NoError(t, err)
+
+	parser := &Parser{
+		Signal:              "logs",
+		LogRecordDimensions: []string{"order"},
+		Log:                 testutil.Logger{},
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Equal(t, map[string]string{
+		"order":    "1234",
+		"trace_id": "0102030405060708090a0b0c0d0e0f10",
+		"span_id":  "0102030405060708",
+	}, actual[0].Tags())
+	require.Equal(t, `{"service.name":"checkout"}`, actual[0].Fields()["attributes"])
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	req := testMetricsRequest()
+	buf, err := req.MarshalProto()
+	require.NoError(t, err)
+
+	parser := &Parser{Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"source": "kafka", "core": "ignored"})
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+	for _, m := range actual {
+		require.Equal(t, "kafka", m.Tags()["source"])
+	}
+	gauge := actual[0]
+	if gauge.Name() != "cpu_temperature" {
+		gauge = actual[1]
+	}
+	require.Equal(t, "0", gauge.Tags()["core"])
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte(`{"resourceMetrics": 42}`))
+	require.ErrorContains(t, err, "unmarshalling metrics request failed")
+
+	_, err = parser.Parse([]byte{0x0a, 0xff})
+	require.ErrorContains(t, err, "unmarshalling metrics request failed")
+}
+
+func TestInitInvalid(t *testing.T) {
+	parser := &Parser{Signal: "traces"}
+	require.ErrorContains(t, parser.Init(), `invalid signal "traces"`)
+
+	parser = &Parser{MetricsSchema: "prometheus-v3"}
+	require.ErrorContains(t, parser.Init(), `invalid metrics schema "prometheus-v3"`)
+
+	parser = &Parser{Signal: "logs", LogRecordDimensions: []string{"a", "a"}}
+	require.ErrorContains(t, parser.Init(), "duplicate record dimension")
+}
+
+func TestParseLine(t *testing.T) {
+	req := testLogsRequest()
+	buf, err := req.MarshalJSON()
+	require.NoError(t, err)
+
+	parser := &Parser{Signal: "logs", Log: testutil.Logger{}}
+	require.NoError(t, parser.Init())
+
+	m, err := parser.ParseLine(string(buf))
+	require.NoError(t, err)
+	require.Equal(t, "logs", m.Name())
+}
@@ -0,0 +1,277 @@
+package avro
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Magic byte of the Confluent wire format
+const magicByte = 0x00
+
+type Serializer struct {
+	SchemaRegistry      string            `toml:"avro_schema_registry"`
+	CaCertPath          string            `toml:"avro_schema_registry_cert"`
+	Schemas             map[string]string `toml:"avro_schemas"`
+	Namespace           string            `toml:"avro_namespace"`
+	SubjectNameStrategy string            `toml:"avro_subject_name_strategy"`
+	Topic               string            `toml:"avro_topic"`
+	AutoRegister        bool              `toml:"avro_auto_register"`
+
+	registry *schemaRegistry
+	schemas  map[string]*schema
+	mu       sync.Mutex
+}
+
+func (s *Serializer) Init() error {
+	switch s.SubjectNameStrategy {
+	case "":
+		s.SubjectNameStrategy = "topic_name"
+	case "topic_name", "record_name", "topic_record_name":
+	default:
+		return fmt.Errorf("invalid subject name strategy %q", s.SubjectNameStrategy)
+	}
+
+	s.schemas = make(map[string]*schema, len(s.Schemas))
+	for name, text := range s.Schemas {
+		sc, err := parseSchema(text)
+		if err != nil {
+			return fmt.Errorf("invalid schema for %q: %w", name, err)
+		}
+		s.schemas[name] = sc
+	}
+
+	if s.SchemaRegistry != "" {
+		registry, err := newSchemaRegistry(s.SchemaRegistry, s.CaCertPath)
+		if err != nil {
+			return err
+		}
+		s.registry = registry
+	}
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.serialize(nil, metric)
+}
+
+// SerializeBatch concatenates the serialized metrics, this is only useful
+// for outputs handling the messages individually.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = s.serialize(buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	sc, err := s.schemaFor(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := sc.record(metric)
+	if err != nil {
+		return nil, fmt.Errorf("metric %q: %w", metric.Name(), err)
+	}
+
+	if s.registry != nil {
+		buf = append(buf, magicByte)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(sc.id))
+	}
+	return sc.codec.BinaryFromNative(buf, record)
+}
+
+// schemaFor returns the schema for the metric, deriving or evolving the
+// schema if necessary
+func (s *Serializer) schemaFor(metric telegraf.Metric) (*schema, error) {
+	sc, found := s.schemas[metric.Name()]
+	if !found {
+		derived, err := deriveSchema(s.Namespace, metric)
+		if err != nil {
+			return nil, fmt.Errorf("deriving schema for %q failed: %w", metric.Name(), err)
+		}
+		sc = derived
+	}
+
+	// Register or lookup the schema on first use
+	if s.registry != nil && sc.id == 0 {
+		id, err := s.resolve(s.subject(metric.Name(), sc), sc.text)
+		if err != nil {
+			return nil, fmt.Errorf("resolving schema for %q failed: %w", metric.Name(), err)
+		}
+		sc.id = id
+	}
+	s.schemas[metric.Name()] = sc
+
+	if !sc.derived {
+		return sc, nil
+	}
+
+	// Extend derived schemas by new tags and fields if allowed
+	columns := newColumns(sc, metric)
+	if len(columns) == 0 {
+		return sc, nil
+	}
+	evolved := &schema{
+		name:      sc.name,
+		namespace: sc.namespace,
+		columns:   append(append(make([]column, 0, len(sc.columns)+len(columns)), sc.columns...), columns...),
+		derived:   true,
+		rejected:  sc.rejected,
+	}
+	if err := evolved.compile(); err != nil {
+		return nil, fmt.Errorf("evolving schema for %q failed: %w", metric.Name(), err)
+	}
+
+	if s.registry != nil {
+		id, err := s.evolve(s.subject(metric.Name(), evolved), evolved.text)
+		if err != nil {
+			if !errors.Is(err, errIncompatible) && !errors.Is(err, errNotFound) {
+				return nil, fmt.Errorf("evolving schema for %q failed: %w", metric.Name(), err)
+			}
+			// Keep the current schema and drop the new tags and fields
+			for _, c := range columns {
+				sc.rejected[columnKey(c.source, c.key)] = true
+			}
+			return sc, nil
+		}
+		evolved.id = id
+	}
+	s.schemas[metric.Name()] = evolved
+
+	return evolved, nil
+}
+
+// resolve returns the ID of the schema, registering the schema if enabled
+func (s *Serializer) resolve(subject, text string) (int, error) {
+	if s.AutoRegister {
+		return s.registry.register(subject, text)
+	}
+	return s.registry.lookup(subject, text)
+}
+
+// evolve returns the ID of the evolved schema if the schema is compatible
+// according to the compatibility level of the subject
+func (s *Serializer) evolve(subject, text string) (int, error) {
+	if !s.AutoRegister {
+		return s.registry.lookup(subject, text)
+	}
+
+	level, err := s.registry.compatibilityLevel(subject)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return 0, err
+	}
+	if !strings.EqualFold(level, "NONE") {
+		ok, err := s.registry.compatible(subject, text)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			return 0, fmt.Errorf("%w with compatibility level %q", errIncompatible, level)
+		}
+	}
+	return s.registry.register(subject, text)
+}
+
+// subject determines the registry subject according to the naming strategy
+func (s *Serializer) subject(name string, sc *schema) string {
+	topic := s.Topic
+	if topic == "" {
+		topic = name
+	}
+
+	switch s.SubjectNameStrategy {
+	case "record_name":
+		return sc.fullname()
+	case "topic_record_name":
+		return topic + "-" + sc.fullname()
+	}
+	return topic + "-value"
+}
+
+// record converts the metric to the native representation of the schema
+func (sc *schema) record(metric telegraf.Metric) (map[string]interface{}, error) {
+	record := make(map[string]interface{}, len(sc.columns))
+	for _, c := range sc.columns {
+		var value interface{}
+		var found bool
+		switch c.source {
+		case sourceTimestamp:
+			value, found = metric.Time().UnixNano(), true
+		case sourceTag:
+			value, found = metric.GetTag(c.key)
+		case sourceField:
+			value, found = metric.GetField(c.key)
+			if !found && !sc.derived {
+				value, found = metric.GetTag(c.key)
+			}
+		}
+
+		if !found {
+			if !c.nullable {
+				return nil, fmt.Errorf("missing value for non-nullable field %q", c.name)
+			}
+			record[c.name] = nil
+			continue
+		}
+
+		v, err := convert(value, c.typ)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", c.name, err)
+		}
+		if c.nullable {
+			v = goavro.Union(c.typ, v)
+		}
+		record[c.name] = v
+	}
+	return record, nil
+}
+
+func convert(value interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case "boolean":
+		return internal.ToBool(value)
+	case "int":
+		return internal.ToInt32(value)
+	case "long":
+		return internal.ToInt64(value)
+	case "float":
+		return internal.ToFloat32(value)
+	case "double":
+		return internal.ToFloat64(value)
+	case "string":
+		return internal.ToString(value)
+	}
+	return nil, fmt.Errorf("unsupported type %q", typ)
+}
+
+func init() {
+	serializers.Add("avro",
+		func() telegraf.Serializer {
+			return &Serializer{
+				Namespace:    "telegraf",
+				AutoRegister: true,
+			}
+		},
+	)
+}
@@ -0,0 +1,402 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// fakeRegistry is a minimal stand-in for a Confluent schema registry
+type fakeRegistry struct {
+	level        string
+	incompatible bool
+
+	schemas  []string
+	subjects map[string][]int
+	checks   int
+	sync.Mutex
+}
+
+func newFakeRegistry(level string) *fakeRegistry {
+	return &fakeRegistry{level: level, subjects: make(map[string][]int)}
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if req.Method == http.MethodPost {
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	path := req.URL.EscapedPath()
+	switch {
+	case strings.HasPrefix(path, "/config/"):
+		reply(w, http.StatusOK, map[string]string{"compatibilityLevel": r.level})
+	case strings.HasPrefix(path, "/compatibility/subjects/"):
+		r.checks++
+		subject := unescape(strings.TrimSuffix(strings.TrimPrefix(path, "/compatibility/subjects/"), "/versions/latest"))
+		if len(r.subjects[subject]) == 0 {
+			reply(w, http.StatusNotFound, map[string]interface{}{"error_code": 40401, "message": "Subject not found"})
+			return
+		}
+		reply(w, http.StatusOK, map[string]bool{"is_compatible": !r.incompatible})
+	case strings.HasSuffix(path, "/versions"):
+		subject := unescape(strings.TrimSuffix(strings.TrimPrefix(path, "/subjects/"), "/versions"))
+		id := r.id(body.Schema)
+		if id == 0 {
+			r.schemas = append(r.schemas, body.Schema)
+			id = len(r.schemas)
+		}
+		r.subjects[subject] = append(r.subjects[subject], id)
+		reply(w, http.StatusOK, map[string]int{"id": id})
+	case strings.HasPrefix(path, "/subjects/"):
+		subject := unescape(strings.TrimPrefix(path, "/subjects/"))
+		id := r.id(body.Schema)
+		for _, sid := range r.subjects[subject] {
+			if sid == id {
+				reply(w, http.StatusOK, map[string]interface{}{"subject": subject, "id": id, "version": 1})
+				return
+			}
+		}
+		reply(w, http.StatusNotFound, map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) id(schema string) int {
+	for i, s := range r.schemas {
+		if s == schema {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func reply(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(code)
+	//nolint:errcheck // Ignore errors in test
+	json.NewEncoder(w).Encode(body)
+}
+
+func unescape(s string) string {
+	return strings.ReplaceAll(s, "%2F", "/")
+}
+
+func TestSerializeWithRegistry(t *testing.T) {
+	registry := newFakeRegistry("BACKWARD")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Namespace:      "telegraf",
+		AutoRegister:   true,
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu-total"},
+		map[string]interface{}{"usage_idle": 98.5, "count": int64(4)},
+		time.Unix(1700000000, 5),
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	id, native := decode(t, registry, buf)
+	require.Equal(t, 1, id)
+	require.Equal(t, map[string]interface{}{
+		"timestamp":  int64(1700000000000000005),
+		"usage_idle": map[string]interface{}{"double": 98.5},
+		"count":      map[string]interface{}{"long": int64(4)},
+		"host":       map[string]interface{}{"string": "server01"},
+		"cpu":        map[string]interface{}{"string": "cpu-total"},
+	}, native)
+	require.Equal(t, []int{1}, registry.subjects["cpu-value"])
+
+	// Metrics with fewer fields use the same schema
+	m = metric.New("cpu", map[string]string{"host": "server02"}, map[string]interface{}{"count": int64(2)}, time.Unix(1700000000, 0))
+	buf, err = serializer.Serialize(m)
+	require.NoError(t, err)
+	id, native = decode(t, registry, buf)
+	require.Equal(t, 1, id)
+	require.Nil(t, native["usage_idle"])
+	require.Nil(t, native["cpu"])
+	require.Zero(t, registry.checks)
+}
+
+func TestSerializeEvolution(t *testing.T) {
+	registry := newFakeRegistry("BACKWARD")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Namespace:      "telegraf",
+		AutoRegister:   true,
+	}
+	require.NoError(t, serializer.Init())
+
+	_, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"used": int64(1)}, time.Unix(0, 0)))
+	require.NoError(t, err)
+
+	buf, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"used": int64(2), "free": int64(3)}, time.Unix(0, 0)))
+	require.NoError(t, err)
+	id, native := decode(t, registry, buf)
+	require.Equal(t, 2, id)
+	require.Equal(t, map[string]interface{}{"long": int64(3)}, native["free"])
+	require.Equal(t, []int{1, 2}, registry.subjects["mem-value"])
+	require.Equal(t, 1, registry.checks)
+}
+
+func TestSerializeEvolutionIncompatible(t *testing.T) {
+	registry := newFakeRegistry("FULL_TRANSITIVE")
+	registry.incompatible = true
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Namespace:      "telegraf",
+		AutoRegister:   true,
+	}
+	require.NoError(t, serializer.Init())
+
+	_, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"used": int64(1)}, time.Unix(0, 0)))
+	require.NoError(t, err)
+
+	// The new field must be dropped and not be checked again
+	for range 2 {
+		buf, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"used": int64(2), "free": int64(3)}, time.Unix(0, 0)))
+		require.NoError(t, err)
+		id, native := decode(t, registry, buf)
+		require.Equal(t, 1, id)
+		require.NotContains(t, native, "free")
+		require.Equal(t, map[string]interface{}{"long": int64(2)}, native["used"])
+	}
+	require.Equal(t, 1, registry.checks)
+	require.Equal(t, []int{1}, registry.subjects["mem-value"])
+}
+
+func TestSerializeEvolutionNoCompatibility(t *testing.T) {
+	registry := newFakeRegistry("NONE")
+	registry.incompatible = true
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Namespace:      "telegraf",
+		AutoRegister:   true,
+	}
+	require.NoError(t, serializer.Init())
+
+	_, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"used": int64(1)}, time.Unix(0, 0)))
+	require.NoError(t, err)
+	buf, err := serializer.Serialize(metric.New("mem", nil, map[string]interface{}{"free": int64(3)}, time.Unix(0, 0)))
+	require.NoError(t, err)
+	id, _ := decode(t, registry, buf)
+	require.Equal(t, 2, id)
+	require.Zero(t, registry.checks)
+}
+
+func TestSubjectNameStrategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		topic    string
+		expected string
+	}{
+		{strategy: "topic_name", expected: "disk-value"},
+		{strategy: "topic_name", topic: "metrics", expected: "metrics-value"},
+		{strategy: "record_name", topic: "metrics", expected: "telegraf.disk"},
+		{strategy: "topic_record_name", topic: "metrics", expected: "metrics-telegraf.disk"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy+"_"+tt.topic, func(t *testing.T) {
+			registry := newFakeRegistry("BACKWARD")
+			server := httptest.NewServer(registry)
+			defer server.Close()
+
+			serializer := &Serializer{
+				SchemaRegistry:      server.URL,
+				SubjectNameStrategy: tt.strategy,
+				Topic:               tt.topic,
+				Namespace:           "telegraf",
+				AutoRegister:        true,
+			}
+			require.NoError(t, serializer.Init())
+
+			_, err := serializer.Serialize(metric.New("disk", nil, map[string]interface{}{"free": int64(1)}, time.Unix(0, 0)))
+			require.NoError(t, err)
+			require.Contains(t, registry.subjects, tt.expected)
+		})
+	}
+}
+
+func TestSerializeProvidedSchema(t *testing.T) {
+	registry := newFakeRegistry("BACKWARD")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	schema := `{
+		"type": "record",
+		"name": "Temperature",
+		"namespace": "sensors",
+		"fields": [
+			{"name": "timestamp", "type": "long"},
+			{"name": "room", "type": "string"},
+			{"name": "value", "type": "float"},
+			{"name": "unit", "type": ["null", "string"], "default": null}
+		]
+	}`
+
+	serializer := &Serializer{
+		SchemaRegistry:      server.URL,
+		Schemas:             map[string]string{"temperature": schema},
+		SubjectNameStrategy: "record_name",
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"temperature",
+		map[string]string{"room": "kitchen"},
+		map[string]interface{}{"value": int64(21), "ignored": true},
+		time.Unix(10, 0),
+	)
+
+	// Lookup fails without the schema being registered
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, "not found")
+
+	serializer.AutoRegister = true
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+	require.Contains(t, registry.subjects, "sensors.Temperature")
+
+	id, native := decode(t, registry, buf)
+	require.Equal(t, 1, id)
+	require.Equal(t, map[string]interface{}{
+		"timestamp": int64(10000000000),
+		"room":      "kitchen",
+		"value":     float32(21),
+		"unit":      nil,
+	}, native)
+
+	// Missing non-nullable fields must result in an error
+	_, err = serializer.Serialize(metric.New("temperature", nil, map[string]interface{}{"value": 1.0}, time.Unix(10, 0)))
+	require.ErrorContains(t, err, `missing value for non-nullable field "room"`)
+}
+
+func TestSerializeWithoutRegistry(t *testing.T) {
+	serializer := &Serializer{Namespace: "telegraf"}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"net.stats",
+		map[string]string{"interface": "eth0"},
+		map[string]interface{}{"bytes-recv": uint64(42), "up": true},
+		time.Unix(0, 100),
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	sc := serializer.schemas["net.stats"]
+	require.NotNil(t, sc)
+	require.Equal(t, "net_stats", sc.name)
+
+	native, remaining, err := sc.codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+	require.Equal(t, map[string]interface{}{
+		"timestamp":  int64(100),
+		"bytes_recv": map[string]interface{}{"long": int64(42)},
+		"up":         map[string]interface{}{"boolean": true},
+		"interface":  map[string]interface{}{"string": "eth0"},
+	}, native)
+}
+
+func TestSerializeBatch(t *testing.T) {
+	registry := newFakeRegistry("BACKWARD")
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	serializer := &Serializer{
+		SchemaRegistry: server.URL,
+		Namespace:      "telegraf",
+		AutoRegister:   true,
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("a", nil, map[string]interface{}{"value": int64(1)}, time.Unix(0, 0)),
+		metric.New("b", nil, map[string]interface{}{"value": "x"}, time.Unix(0, 0)),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	id, native, remaining := decodeOne(t, registry, buf)
+	require.Equal(t, 1, id)
+	require.Equal(t, map[string]interface{}{"long": int64(1)}, native["value"])
+	id, native, remaining = decodeOne(t, registry, remaining)
+	require.Equal(t, 2, id)
+	require.Equal(t, map[string]interface{}{"string": "x"}, native["value"])
+	require.Empty(t, remaining)
+}
+
+func TestInvalidSettings(t *testing.T) {
+	serializer := &Serializer{SubjectNameStrategy: "record"}
+	require.ErrorContains(t, serializer.Init(), "invalid subject name strategy")
+
+	serializer = &Serializer{Schemas: map[string]string{"cpu": `{"type": "string"}`}}
+	require.ErrorContains(t, serializer.Init(), "schema must be a record")
+
+	serializer = &Serializer{Schemas: map[string]string{"cpu": `{"type": "record", "name": "cpu", "fields": [{"name": "x", "type": {"type": "map", "values": "long"}}]}`}}
+	require.ErrorContains(t, serializer.Init(), "unsupported type")
+}
+
+func decode(t *testing.T, registry *fakeRegistry, buf []byte) (int, map[string]interface{}) {
+	t.Helper()
+
+	id, native, remaining := decodeOne(t, registry, buf)
+	require.Empty(t, remaining)
+	return id, native
+}
+
+func decodeOne(t *testing.T, registry *fakeRegistry, buf []byte) (int, map[string]interface{}, []byte) {
+	t.Helper()
+
+	require.Greater(t, len(buf), 5)
+	require.Equal(t, byte(magicByte), buf[0])
+	id := int(binary.BigEndian.Uint32(buf[1:5]))
+
+	registry.Lock()
+	require.LessOrEqual(t, id, len(registry.schemas))
+	codec, err := goavro.NewCodec(registry.schemas[id-1])
+	registry.Unlock()
+	require.NoError(t, err)
+
+	native, remaining, err := codec.NativeFromBinary(buf[5:])
+	require.NoError(t, err)
+	return id, native.(map[string]interface{}), remaining
+}
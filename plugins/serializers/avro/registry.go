@@ -0,0 +1,175 @@
+package avro
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+var errNotFound = errors.New("not found")
+
+var errIncompatible = errors.New("schema incompatible")
+
+// schemaRegistry is a client for the Confluent schema registry API
+type schemaRegistry struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+type registryError struct {
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func newSchemaRegistry(addr, caCertPath string) (*schemaRegistry, error) {
+	var tlsCfg *tls.Config
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsCfg = &tls.Config{
+			RootCAs: caCertPool,
+		}
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry URL failed: %w", err)
+	}
+
+	var username, password string
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	return &schemaRegistry{
+		url:      u.String(),
+		username: username,
+		password: password,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsCfg,
+				MaxIdleConns:    10,
+				IdleConnTimeout: 90 * time.Second,
+			},
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// register registers the schema for the subject and returns the schema ID;
+// an already registered schema results in the existing ID
+func (sr *schemaRegistry) register(subject, schema string) (int, error) {
+	var response struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := sr.do(http.MethodPost, path, map[string]string{"schema": schema}, &response); err != nil {
+		return 0, err
+	}
+	return response.ID, nil
+}
+
+// lookup returns the ID of the schema if registered for the subject
+func (sr *schemaRegistry) lookup(subject, schema string) (int, error) {
+	var response struct {
+		ID int `json:"id"`
+	}
+	path := "/subjects/" + url.PathEscape(subject)
+	if err := sr.do(http.MethodPost, path, map[string]string{"schema": schema}, &response); err != nil {
+		return 0, err
+	}
+	return response.ID, nil
+}
+
+// compatibilityLevel returns the compatibility level of the subject falling
+// back to the global setting
+func (sr *schemaRegistry) compatibilityLevel(subject string) (string, error) {
+	var response struct {
+		Level string `json:"compatibilityLevel"`
+	}
+	path := "/config/" + url.PathEscape(subject) + "?defaultToGlobal=true"
+	if err := sr.do(http.MethodGet, path, nil, &response); err != nil {
+		return "", err
+	}
+	return response.Level, nil
+}
+
+// compatible checks if the schema is compatible with the latest version of
+// the subject according to the subject's compatibility level
+func (sr *schemaRegistry) compatible(subject, schema string) (bool, error) {
+	var response struct {
+		Compatible bool `json:"is_compatible"`
+	}
+	path := "/compatibility/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := sr.do(http.MethodPost, path, map[string]string{"schema": schema}, &response)
+	if errors.Is(err, errNotFound) {
+		// There is nothing to be compatible with
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return response.Compatible, nil
+}
+
+func (sr *schemaRegistry) do(method, path string, body, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, sr.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentType)
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if sr.username != "" {
+		req.SetBasicAuth(sr.username, sr.password)
+	}
+
+	resp, err := sr.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var rerr registryError
+		//nolint:errcheck // The error body is optional
+		json.NewDecoder(resp.Body).Decode(&rerr)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%s %s: %w: %s", method, path, errNotFound, rerr.Message)
+		case http.StatusConflict:
+			return fmt.Errorf("%s %s: %w: %s", method, path, errIncompatible, rerr.Message)
+		}
+		return fmt.Errorf("%s %s: registry returned %q: %s", method, path, resp.Status, rerr.Message)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,298 @@
+package avro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Types supported for the fields of a record
+var primitiveTypes = []string{"boolean", "int", "long", "float", "double", "string"}
+
+const (
+	sourceTimestamp = iota
+	sourceTag
+	sourceField
+)
+
+// column describes a field of the record schema and where its value
+// originates from in the metric
+type column struct {
+	name     string
+	key      string
+	source   int
+	typ      string
+	nullable bool
+}
+
+// schema contains a record schema together with its codec and registry ID
+type schema struct {
+	name      string
+	namespace string
+	columns   []column
+	derived   bool
+	text      string
+	codec     *goavro.Codec
+	id        int
+
+	// Tags and fields part of the schema or rejected during schema evolution
+	known    map[string]bool
+	rejected map[string]bool
+}
+
+func (s *schema) fullname() string {
+	if s.namespace == "" {
+		return s.name
+	}
+	return s.namespace + "." + s.name
+}
+
+func columnKey(source int, key string) string {
+	if source == sourceTag {
+		return "tag:" + key
+	}
+	return "field:" + key
+}
+
+// unknown checks if the tag or field is neither part of the schema nor
+// rejected
+func (s *schema) unknown(source int, key string) bool {
+	k := columnKey(source, key)
+	return !s.known[k] && !s.rejected[k]
+}
+
+// compile generates the schema text and codec from the columns
+func (s *schema) compile() error {
+	type fieldDefinition struct {
+		Name    string           `json:"name"`
+		Type    interface{}      `json:"type"`
+		Default *json.RawMessage `json:"default,omitempty"`
+	}
+	type recordDefinition struct {
+		Type      string            `json:"type"`
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace,omitempty"`
+		Fields    []fieldDefinition `json:"fields"`
+	}
+
+	s.known = make(map[string]bool, len(s.columns))
+	for _, c := range s.columns {
+		s.known[columnKey(c.source, c.key)] = true
+	}
+
+	null := json.RawMessage("null")
+	record := recordDefinition{
+		Type:      "record",
+		Name:      s.name,
+		Namespace: s.namespace,
+		Fields:    make([]fieldDefinition, 0, len(s.columns)),
+	}
+	for _, c := range s.columns {
+		f := fieldDefinition{Name: c.name, Type: c.typ}
+		if c.nullable {
+			f.Type = []string{"null", c.typ}
+			f.Default = &null
+		}
+		record.Fields = append(record.Fields, f)
+	}
+
+	text, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	codec, err := goavro.NewCodec(string(text))
+	if err != nil {
+		return fmt.Errorf("creating codec failed: %w", err)
+	}
+	s.text = string(text)
+	s.codec = codec
+	return nil
+}
+
+// deriveSchema creates a record schema with the timestamp and all tags and
+// fields of the metric. Tags and fields are optional to allow evolving the
+// schema in a compatible way.
+func deriveSchema(namespace string, m telegraf.Metric) (*schema, error) {
+	s := &schema{
+		name:      avroName(m.Name()),
+		namespace: namespace,
+		columns:   []column{{name: "timestamp", source: sourceTimestamp, typ: "long"}},
+		derived:   true,
+		rejected:  make(map[string]bool),
+	}
+	s.columns = append(s.columns, newColumns(s, m)...)
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newColumns returns the columns for the tags and fields of the metric not
+// yet part of the schema
+func newColumns(s *schema, m telegraf.Metric) []column {
+	missing := slices.ContainsFunc(m.FieldList(), func(f *telegraf.Field) bool {
+		return s.unknown(sourceField, f.Key)
+	}) || slices.ContainsFunc(m.TagList(), func(t *telegraf.Tag) bool {
+		return s.unknown(sourceTag, t.Key)
+	})
+	if !missing {
+		return nil
+	}
+
+	var columns []column
+	used := make(map[string]bool, len(s.columns))
+	for _, c := range s.columns {
+		used[c.name] = true
+	}
+
+	// Fields take precedence over tags with the same name, conflicting names
+	// and unsupported types are rejected
+	for _, f := range m.FieldList() {
+		name := avroName(f.Key)
+		if !s.unknown(sourceField, f.Key) {
+			continue
+		}
+		typ := avroType(f.Value)
+		if typ == "" || used[name] {
+			s.rejected[columnKey(sourceField, f.Key)] = true
+			continue
+		}
+		columns = append(columns, column{name: name, key: f.Key, source: sourceField, typ: typ, nullable: true})
+		used[name] = true
+	}
+	for _, t := range m.TagList() {
+		name := avroName(t.Key)
+		if !s.unknown(sourceTag, t.Key) {
+			continue
+		}
+		if used[name] {
+			s.rejected[columnKey(sourceTag, t.Key)] = true
+			continue
+		}
+		columns = append(columns, column{name: name, key: t.Key, source: sourceTag, typ: "string", nullable: true})
+		used[name] = true
+	}
+	return columns
+}
+
+// parseSchema extracts the columns of a user-provided record schema
+func parseSchema(text string) (*schema, error) {
+	var record struct {
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		Fields    []struct {
+			Name string          `json:"name"`
+			Type json.RawMessage `json:"type"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(text), &record); err != nil {
+		return nil, fmt.Errorf("decoding schema failed: %w", err)
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("schema must be a record but is %q", record.Type)
+	}
+
+	codec, err := goavro.NewCodec(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	s := &schema{
+		name:      record.Name,
+		namespace: record.Namespace,
+		text:      text,
+		codec:     codec,
+	}
+	for _, f := range record.Fields {
+		typ, nullable, err := fieldType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		c := column{name: f.Name, key: f.Name, source: sourceField, typ: typ, nullable: nullable}
+		if f.Name == "timestamp" && typ == "long" {
+			c.source = sourceTimestamp
+		}
+		s.columns = append(s.columns, c)
+	}
+	return s, nil
+}
+
+// fieldType determines the primitive type of a field and whether the field
+// is nullable, i.e. a union of null and the type
+func fieldType(raw json.RawMessage) (string, bool, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		if !slices.Contains(primitiveTypes, name) {
+			return "", false, fmt.Errorf("unsupported type %q", name)
+		}
+		return name, false, nil
+	}
+
+	var complexType struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &complexType); err == nil {
+		if !slices.Contains(primitiveTypes, complexType.Type) {
+			return "", false, fmt.Errorf("unsupported type %q", complexType.Type)
+		}
+		return complexType.Type, false, nil
+	}
+
+	var union []json.RawMessage
+	if err := json.Unmarshal(raw, &union); err != nil {
+		return "", false, fmt.Errorf("unsupported type %s", string(raw))
+	}
+	var typ string
+	var nullable bool
+	for _, branch := range union {
+		t, _, err := fieldType(branch)
+		if err != nil {
+			var n string
+			if json.Unmarshal(branch, &n) == nil && n == "null" {
+				nullable = true
+				continue
+			}
+			return "", false, err
+		}
+		if typ != "" {
+			return "", false, errors.New("unions of multiple non-null types are not supported")
+		}
+		typ = t
+	}
+	if typ == "" {
+		return "", false, errors.New("union without non-null type")
+	}
+	return typ, nullable, nil
+}
+
+func avroType(v interface{}) string {
+	switch v.(type) {
+	case int64, uint64:
+		return "long"
+	case float64:
+		return "double"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	return ""
+}
+
+// avroName converts the given name to a valid Avro name
+func avroName(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,267 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	common_otlp "github.com/influxdata/telegraf/plugins/common/otlp"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+type Serializer struct {
+	Encoding   string            `toml:"otlp_encoding"`
+	Signal     string            `toml:"otlp_signal"`
+	Attributes map[string]string `toml:"otlp_resource_attributes"`
+	Log        telegraf.Logger   `toml:"-"`
+
+	encoder *common_otlp.MetricsEncoder
+}
+
+type marshaler interface {
+	MarshalProto() ([]byte, error)
+	MarshalJSON() ([]byte, error)
+}
+
+func (s *Serializer) Init() error {
+	switch s.Encoding {
+	case "":
+		s.Encoding = "protobuf"
+	case "protobuf", "json":
+		// Do nothing, those are valid
+	default:
+		return fmt.Errorf("invalid encoding %q", s.Encoding)
+	}
+
+	switch s.Signal {
+	case "":
+		s.Signal = "metrics"
+	case "metrics", "logs":
+		// Do nothing, those are valid
+	default:
+		return fmt.Errorf("invalid signal %q", s.Signal)
+	}
+
+	encoder, err := common_otlp.NewMetricsEncoder(s.Log)
+	if err != nil {
+		return err
+	}
+	s.encoder = encoder
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var req marshaler
+	switch s.Signal {
+	case "metrics":
+		r := s.encoder.Encode(metrics, s.Attributes)
+		if r.Metrics().ResourceMetrics().Len() == 0 {
+			return nil, nil
+		}
+		req = r
+	case "logs":
+		r := s.logsRequest(metrics)
+		if r.Logs().ResourceLogs().Len() == 0 {
+			return nil, nil
+		}
+		req = r
+	}
+
+	if s.Encoding == "json" {
+		return req.MarshalJSON()
+	}
+	return req.MarshalProto()
+}
+
+// logsRequest converts the metrics to log records, reversing the conversion
+// done by the OTLP parser. Tags following the resource semantic conventions
+// are used as resource attributes while all other tags and fields become
+// log record attributes.
+func (s *Serializer) logsRequest(metrics []telegraf.Metric) plogotlp.ExportRequest {
+	logs := plog.NewLogs()
+	resources := make(map[string]plog.LogRecordSlice)
+	for _, metric := range metrics {
+		resourceAttrs := make(map[string]string, len(s.Attributes))
+		for k, v := range s.Attributes {
+			resourceAttrs[k] = v
+		}
+		recordAttrs := make(map[string]any)
+		var traceID, spanID string
+		for _, tag := range metric.TagList() {
+			switch {
+			case tag.Key == common.AttributeTraceID:
+				traceID = tag.Value
+			case tag.Key == common.AttributeSpanID:
+				spanID = tag.Value
+			case common.ResourceNamespace.MatchString(tag.Key):
+				resourceAttrs[tag.Key] = tag.Value
+			default:
+				recordAttrs[tag.Key] = tag.Value
+			}
+		}
+
+		keys := sortedKeys(resourceAttrs)
+		key := resourceKey(keys, resourceAttrs)
+		records, found := resources[key]
+		if !found {
+			rl := logs.ResourceLogs().AppendEmpty()
+			for _, k := range keys {
+				rl.Resource().Attributes().PutStr(k, resourceAttrs[k])
+			}
+			records = rl.ScopeLogs().AppendEmpty().LogRecords()
+			resources[key] = records
+		}
+
+		record := records.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(metric.Time()))
+		if err := setTraceContext(record, traceID, spanID); err != nil {
+			s.Log.Warnf("Ignoring trace context: %v", err)
+		}
+
+		for _, field := range metric.FieldList() {
+			switch field.Key {
+			case common.AttributeBody:
+				record.Body().SetStr(fmt.Sprint(field.Value))
+			case common.AttributeSeverityText:
+				record.SetSeverityText(fmt.Sprint(field.Value))
+			case common.AttributeSeverityNumber:
+				v, err := internal.ToInt32(field.Value)
+				if err != nil {
+					s.Log.Warnf("Invalid severity number %v: %v", field.Value, err)
+					continue
+				}
+				record.SetSeverityNumber(plog.SeverityNumber(v))
+			case common.AttributeObservedTimeUnixNano:
+				v, err := internal.ToInt64(field.Value)
+				if err != nil {
+					s.Log.Warnf("Invalid observed timestamp %v: %v", field.Value, err)
+					continue
+				}
+				record.SetObservedTimestamp(pcommon.Timestamp(v))
+			case common.AttributeDroppedAttributesCount:
+				v, err := internal.ToInt64(field.Value)
+				if err != nil {
+					s.Log.Warnf("Invalid dropped attributes count %v: %v", field.Value, err)
+					continue
+				}
+				record.SetDroppedAttributesCount(uint32(v))
+			case common.AttributeAttributes:
+				if v, ok := field.Value.(string); ok {
+					if attrs, err := decodeAttributes(v); err == nil {
+						for k, v := range attrs {
+							recordAttrs[k] = v
+						}
+						continue
+					}
+				}
+				recordAttrs[field.Key] = field.Value
+			default:
+				recordAttrs[field.Key] = field.Value
+			}
+		}
+
+		for _, k := range sortedKeys(recordAttrs) {
+			if err := record.Attributes().PutEmpty(k).FromRaw(recordAttrs[k]); err != nil {
+				s.Log.Warnf("Ignoring attribute %q: %v", k, err)
+				record.Attributes().Remove(k)
+			}
+		}
+	}
+
+	return plogotlp.NewExportRequestFromLogs(logs)
+}
+
+// decodeAttributes decodes the JSON encoded attributes keeping integer values
+func decodeAttributes(s string) (map[string]any, error) {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+
+	var attrs map[string]any
+	if err := decoder.Decode(&attrs); err != nil {
+		return nil, err
+	}
+	for k, v := range attrs {
+		attrs[k] = fromJSONNumber(v)
+	}
+	return attrs, nil
+}
+
+func fromJSONNumber(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = fromJSONNumber(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = fromJSONNumber(v[k])
+		}
+	}
+	return value
+}
+
+func setTraceContext(record plog.LogRecord, traceID, spanID string) error {
+	if traceID == "" || spanID == "" {
+		return nil
+	}
+
+	tid, err := hex.DecodeString(traceID)
+	if err != nil || len(tid) != len(pcommon.TraceID{}) {
+		return fmt.Errorf("invalid trace ID %q", traceID)
+	}
+	sid, err := hex.DecodeString(spanID)
+	if err != nil || len(sid) != len(pcommon.SpanID{}) {
+		return fmt.Errorf("invalid span ID %q", spanID)
+	}
+	record.SetTraceID(pcommon.TraceID(tid))
+	record.SetSpanID(pcommon.SpanID(sid))
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func resourceKey(keys []string, attrs map[string]string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(attrs[k])
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+func init() {
+	serializers.Add("otlp",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,245 @@
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/otlp"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+var ts = time.Unix(1700000000, 0)
+
+func TestSerializeMetrics(t *testing.T) {
+	serializer := &Serializer{
+		Attributes: map[string]string{"deployment.environment": "production"},
+		Log:        testutil.Logger{},
+	}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu_temperature",
+			map[string]string{"core": "0"},
+			map[string]interface{}{"gauge": 42.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"http_requests",
+			map[string]string{"method": "GET"},
+			map[string]interface{}{"counter": int64(1024)},
+			ts,
+			telegraf.Counter,
+		),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	req := pmetricotlp.NewExportRequest()
+	require.NoError(t, req.UnmarshalProto(buf))
+	require.Equal(t, 1, req.Metrics().ResourceMetrics().Len())
+	rm := req.Metrics().ResourceMetrics().At(0)
+	env, found := rm.Resource().Attributes().Get("deployment.environment")
+	require.True(t, found)
+	require.Equal(t, "production", env.Str())
+
+	types := make(map[string]pmetric.MetricType)
+	ms := rm.ScopeMetrics().At(0).Metrics()
+	for i := 0; i < ms.Len(); i++ {
+		types[ms.At(i).Name()] = ms.At(i).Type()
+	}
+	require.Equal(t, map[string]pmetric.MetricType{
+		"cpu_temperature": pmetric.MetricTypeGauge,
+		"http_requests":   pmetric.MetricTypeSum,
+	}, types)
+}
+
+func TestSerializeMetricsRoundtrip(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu_temperature",
+			map[string]string{"core": "0", "service.name": "monitor"},
+			map[string]interface{}{"gauge": 42.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"http_requests",
+			map[string]string{"method": "GET"},
+			map[string]interface{}{"counter": int64(1024)},
+			ts,
+			telegraf.Counter,
+		),
+		metric.New(
+			"rpc_duration_seconds",
+			map[string]string{"method": "GET"},
+			map[string]interface{}{"count": float64(3), "sum": 1.5, "0.5": 0.4, "0.9": 0.9},
+			ts,
+			telegraf.Summary,
+		),
+	}
+
+	for _, encoding := range []string{"protobuf", "json"} {
+		t.Run(encoding, func(t *testing.T) {
+			serializer := &Serializer{Encoding: encoding, Log: testutil.Logger{}}
+			require.NoError(t, serializer.Init())
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			parser := &otlp.Parser{Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, metrics, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestSerializeLogs(t *testing.T) {
+	serializer := &Serializer{Signal: "logs", Log: testutil.Logger{}}
+	require.NoError(t, serializer.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New(
+			"logs",
+			map[string]string{
+				"service.name": "checkout",
+				"host":         "web01",
+				"trace_id":     "0102030405060708090a0b0c0d0e0f10",
+				"span_id":      "0102030405060708",
+			},
+			map[string]interface{}{
+				"body":            "payment declined",
+				"severity_number": int64(13),
+				"severity_text":   "WARN",
+				"attributes":      `{"order":1234,"retry":true}`,
+			},
+			ts,
+		),
+		metric.New(
+			"logs",
+			map[string]string{"service.name": "checkout"},
+			map[string]interface{}{"body": "payment accepted", "duration": 0.25},
+			ts.Add(time.Second),
+		),
+		metric.New(
+			"syslog",
+			map[string]string{"service.name": "cart"},
+			map[string]interface{}{"message": "item added"},
+			ts,
+		),
+	}
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	req := plogotlp.NewExportRequest()
+	require.NoError(t, req.UnmarshalProto(buf))
+	resources := req.Logs().ResourceLogs()
+	require.Equal(t, 2, resources.Len())
+
+	checkout := resources.At(0)
+	require.Equal(t, map[string]any{"service.name": "checkout"}, checkout.Resource().Attributes().AsRaw())
+	records := checkout.ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+
+	record := records.At(0)
+	require.Equal(t, ts.UnixNano(), record.Timestamp().AsTime().UnixNano())
+	require.Equal(t, "payment declined", record.Body().Str())
+	require.Equal(t, plog.SeverityNumberWarn, record.SeverityNumber())
+	require.Equal(t, "WARN", record.SeverityText())
+	require.Equal(t, "0102030405060708090a0b0c0d0e0f10", record.TraceID().String())
+	require.Equal(t, "0102030405060708", record.SpanID().String())
+	require.Equal(t, map[string]any{"host": "web01", "order": int64(1234), "retry": true}, record.Attributes().AsRaw())
+
+	record = records.At(1)
+	require.Equal(t, "payment accepted", record.Body().Str())
+	require.True(t, record.TraceID().IsEmpty())
+	require.Equal(t, map[string]any{"duration": 0.25}, record.Attributes().AsRaw())
+
+	cart := resources.At(1)
+	require.Equal(t, map[string]any{"service.name": "cart"}, cart.Resource().Attributes().AsRaw())
+	record = cart.ScopeLogs().At(0).LogRecords().At(0)
+	require.Empty(t, record.Body().AsString())
+	require.Equal(t, map[string]any{"message": "item added"}, record.Attributes().AsRaw())
+}
+
+func TestSerializeLogsRoundtrip(t *testing.T) {
+	expected := []telegraf.Metric{
+		metric.New(
+			"logs",
+			map[string]string{
+				"service.name": "checkout",
+				"trace_id":     "0102030405060708090a0b0c0d0e0f10",
+				"span_id":      "0102030405060708",
+			},
+			map[string]interface{}{
+				"body":            "payment declined",
+				"severity_number": int64(13),
+				"severity_text":   "WARN",
+				"attributes":      `{"host":"web01","order":1234}`,
+			},
+			ts,
+		),
+	}
+
+	for _, encoding := range []string{"protobuf", "json"} {
+		t.Run(encoding, func(t *testing.T) {
+			serializer := &Serializer{Encoding: encoding, Signal: "logs", Log: testutil.Logger{}}
+			require.NoError(t, serializer.Init())
+			buf, err := serializer.SerializeBatch(expected)
+			require.NoError(t, err)
+
+			parser := &otlp.Parser{Signal: "logs", Log: testutil.Logger{}}
+			require.NoError(t, parser.Init())
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestSerializeInvalidTraceContext(t *testing.T) {
+	serializer := &Serializer{Signal: "logs", Log: testutil.Logger{}}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New(
+		"logs",
+		map[string]string{"trace_id": "xyz", "span_id": "0102030405060708"},
+		map[string]interface{}{"body": "hello"},
+		ts,
+	)
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	req := plogotlp.NewExportRequest()
+	require.NoError(t, req.UnmarshalProto(buf))
+	record := req.Logs().ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	require.True(t, record.TraceID().IsEmpty())
+	require.Equal(t, "hello", record.Body().Str())
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	serializer := &Serializer{Log: testutil.Logger{}}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.SerializeBatch(nil)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+}
+
+func TestInitInvalid(t *testing.T) {
+	serializer := &Serializer{Encoding: "xml"}
+	require.ErrorContains(t, serializer.Init(), `invalid encoding "xml"`)
+
+	serializer = &Serializer{Signal: "traces"}
+	require.ErrorContains(t, serializer.Init(), `invalid signal "traces"`)
+}
@@ -0,0 +1,249 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+)
+
+const timestampType = "google.protobuf.Timestamp"
+
+// Mapping defines how a metric element is assigned to a message field.
+type Mapping struct {
+	ReadFrom   string `toml:"read_from"`   // name, time, tag, field, tags, fields
+	Name       string `toml:"name"`        // name of the tag or field
+	Target     string `toml:"target"`      // dot-separated path of the message field
+	TimeFormat string `toml:"time_format"` // for integer time targets: unix, unix_ms, unix_us, unix_ns
+
+	path []protoreflect.FieldDescriptor
+}
+
+func (m *Mapping) init(desc protoreflect.MessageDescriptor) error {
+	// Normalize
+	m.ReadFrom = strings.ToLower(m.ReadFrom)
+
+	// Check input constraints
+	switch m.ReadFrom {
+	case "":
+		m.ReadFrom = "field"
+		fallthrough
+	case "field", "tag":
+		if m.Name == "" {
+			return errors.New("missing name")
+		}
+	case "time":
+		switch m.TimeFormat {
+		case "":
+			m.TimeFormat = "unix"
+		case "unix", "unix_ms", "unix_us", "unix_ns":
+		default:
+			return fmt.Errorf("invalid time format %q", m.TimeFormat)
+		}
+	case "name", "tags", "fields":
+	default:
+		return fmt.Errorf("unknown assignment %q", m.ReadFrom)
+	}
+
+	if m.Target == "" {
+		if m.Name == "" {
+			return errors.New("missing target")
+		}
+		m.Target = m.Name
+	}
+
+	// Resolve the target field
+	parts := strings.Split(m.Target, ".")
+	m.path = make([]protoreflect.FieldDescriptor, 0, len(parts))
+	current := desc
+	for i, part := range parts {
+		fd := current.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			return fmt.Errorf("field %q not found in message %q", part, current.FullName())
+		}
+		m.path = append(m.path, fd)
+		if i == len(parts)-1 {
+			break
+		}
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("field %q of target %q is not a singular message", part, m.Target)
+		}
+		current = fd.Message()
+	}
+
+	// Check the target type
+	fd := m.path[len(m.path)-1]
+	switch m.ReadFrom {
+	case "tags", "fields":
+		if !fd.IsMap() || fd.MapKey().Kind() != protoreflect.StringKind {
+			return fmt.Errorf("target %q must be a map with string keys", m.Target)
+		}
+		if !isScalar(fd.MapValue()) {
+			return fmt.Errorf("target %q must have scalar map values", m.Target)
+		}
+	case "time":
+		if fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("target %q must be singular", m.Target)
+		}
+		if fd.Kind() == protoreflect.MessageKind && fd.Message().FullName() != timestampType {
+			return fmt.Errorf("target %q must be a scalar or %s", m.Target, timestampType)
+		}
+	default:
+		if fd.IsList() || fd.IsMap() || !isScalar(fd) {
+			return fmt.Errorf("target %q must be a singular scalar", m.Target)
+		}
+	}
+
+	return nil
+}
+
+func (m *Mapping) apply(msg protoreflect.Message, metric telegraf.Metric) error {
+	switch m.ReadFrom {
+	case "name":
+		return m.set(msg, metric.Name())
+	case "time":
+		return m.setTime(msg, metric.Time())
+	case "tag":
+		if v, found := metric.GetTag(m.Name); found {
+			return m.set(msg, v)
+		}
+	case "field":
+		if v, found := metric.GetField(m.Name); found {
+			return m.set(msg, v)
+		}
+	case "tags":
+		target := m.parent(msg).Mutable(m.field()).Map()
+		for _, tag := range metric.TagList() {
+			v, err := convert(m.field().MapValue(), tag.Value)
+			if err != nil {
+				return fmt.Errorf("converting tag %q for %q failed: %w", tag.Key, m.Target, err)
+			}
+			target.Set(protoreflect.ValueOfString(tag.Key).MapKey(), v)
+		}
+	case "fields":
+		target := m.parent(msg).Mutable(m.field()).Map()
+		for _, field := range metric.FieldList() {
+			// Skip fields not matching the map's value type
+			if v, err := convert(m.field().MapValue(), field.Value); err == nil {
+				target.Set(protoreflect.ValueOfString(field.Key).MapKey(), v)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Mapping) set(msg protoreflect.Message, value interface{}) error {
+	v, err := convert(m.field(), value)
+	if err != nil {
+		return fmt.Errorf("converting %s %q for %q failed: %w", m.ReadFrom, m.Name, m.Target, err)
+	}
+	m.parent(msg).Set(m.field(), v)
+	return nil
+}
+
+func (m *Mapping) setTime(msg protoreflect.Message, t time.Time) error {
+	fd := m.field()
+	parent := m.parent(msg)
+
+	var value interface{}
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		ts := parent.Mutable(fd).Message()
+		fields := ts.Descriptor().Fields()
+		ts.Set(fields.ByName("seconds"), protoreflect.ValueOfInt64(t.Unix()))
+		ts.Set(fields.ByName("nanos"), protoreflect.ValueOfInt32(int32(t.Nanosecond())))
+		return nil
+	case protoreflect.StringKind:
+		value = t.UTC().Format(time.RFC3339Nano)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		value = float64(t.UnixNano()) / float64(time.Second)
+	default:
+		switch m.TimeFormat {
+		case "unix":
+			value = t.Unix()
+		case "unix_ms":
+			value = t.UnixMilli()
+		case "unix_us":
+			value = t.UnixMicro()
+		case "unix_ns":
+			value = t.UnixNano()
+		}
+	}
+
+	v, err := convert(fd, value)
+	if err != nil {
+		return fmt.Errorf("converting time for %q failed: %w", m.Target, err)
+	}
+	parent.Set(fd, v)
+	return nil
+}
+
+// parent returns the message containing the target field creating all
+// intermediate messages.
+func (m *Mapping) parent(msg protoreflect.Message) protoreflect.Message {
+	for _, fd := range m.path[:len(m.path)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+	return msg
+}
+
+func (m *Mapping) field() protoreflect.FieldDescriptor {
+	return m.path[len(m.path)-1]
+}
+
+func isScalar(fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	}
+	return true
+}
+
+func convert(fd protoreflect.FieldDescriptor, value interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := internal.ToBool(value)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := internal.ToInt32(value)
+		return protoreflect.ValueOfInt32(v), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := internal.ToInt64(value)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := internal.ToUint32(value)
+		return protoreflect.ValueOfUint32(v), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := internal.ToUint64(value)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := internal.ToFloat32(value)
+		return protoreflect.ValueOfFloat32(v), err
+	case protoreflect.DoubleKind:
+		v, err := internal.ToFloat64(value)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		v, err := internal.ToString(value)
+		return protoreflect.ValueOfString(v), err
+	case protoreflect.BytesKind:
+		v, err := internal.ToString(value)
+		return protoreflect.ValueOfBytes([]byte(v)), err
+	case protoreflect.EnumKind:
+		if s, ok := value.(string); ok {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		v, err := internal.ToInt32(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %v", value)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %v", fd.Kind())
+}
@@ -0,0 +1,164 @@
+package protobuf
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+type Serializer struct {
+	Files         []string   `toml:"protobuf_files"`
+	ImportPaths   []string   `toml:"protobuf_import_paths"`
+	DescriptorSet string     `toml:"protobuf_descriptor_set"`
+	MessageType   string     `toml:"protobuf_type"`
+	Mapping       []*Mapping `toml:"protobuf_mapping"`
+	Framing       string     `toml:"protobuf_framing"`
+
+	desc       protoreflect.MessageDescriptor
+	marshaller proto.MarshalOptions
+}
+
+func (s *Serializer) Init() error {
+	switch s.Framing {
+	case "":
+		s.Framing = "none"
+	case "none", "varint", "uint32":
+		// Do nothing, those are valid
+	default:
+		return fmt.Errorf("invalid framing %q", s.Framing)
+	}
+
+	if s.MessageType == "" {
+		return errors.New("protocol-buffer message-type not set")
+	}
+	if len(s.Mapping) == 0 {
+		return errors.New("no mapping defined")
+	}
+
+	registry, err := s.loadDescriptors()
+	if err != nil {
+		return err
+	}
+
+	// Lookup given type in the loaded file descriptors
+	descriptor, err := registry.FindDescriptorByName(protoreflect.FullName(s.MessageType))
+	if err != nil {
+		return fmt.Errorf("looking up message type %q failed: %w", s.MessageType, err)
+	}
+	desc, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return fmt.Errorf("%q is not a message descriptor (%T)", s.MessageType, descriptor)
+	}
+	s.desc = desc
+
+	for i, m := range s.Mapping {
+		if err := m.init(desc); err != nil {
+			return fmt.Errorf("mapping %d check failed: %w", i, err)
+		}
+	}
+	s.marshaller = proto.MarshalOptions{Deterministic: true}
+
+	return nil
+}
+
+func (s *Serializer) loadDescriptors() (*protoregistry.Files, error) {
+	switch {
+	case len(s.Files) > 0 && s.DescriptorSet != "":
+		return nil, errors.New("protocol-buffer files and descriptor set are mutually exclusive")
+	case len(s.Files) > 0:
+		resolver := &protocompile.SourceResolver{ImportPaths: s.ImportPaths}
+		compiler := &protocompile.Compiler{
+			Resolver: protocompile.WithStandardImports(resolver),
+		}
+		files, err := compiler.Compile(context.Background(), s.Files...)
+		if err != nil {
+			return nil, fmt.Errorf("parsing protocol-buffer definition failed: %w", err)
+		}
+
+		var registry protoregistry.Files
+		for _, f := range files {
+			if err := registry.RegisterFile(f); err != nil {
+				return nil, fmt.Errorf("adding file %q to registry failed: %w", f.Path(), err)
+			}
+		}
+		return &registry, nil
+	case s.DescriptorSet != "":
+		buf, err := os.ReadFile(s.DescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("reading descriptor set failed: %w", err)
+		}
+		var set descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(buf, &set); err != nil {
+			return nil, fmt.Errorf("decoding descriptor set failed: %w", err)
+		}
+		registry, err := protodesc.NewFiles(&set)
+		if err != nil {
+			return nil, fmt.Errorf("creating descriptors failed: %w", err)
+		}
+		return registry, nil
+	}
+	return nil, errors.New("protocol-buffer files or descriptor set required")
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, metric)
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if s.Framing == "none" && len(metrics) > 1 {
+		return nil, errors.New("serializing batches requires framing")
+	}
+
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = s.serialize(buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	msg := dynamicpb.NewMessage(s.desc)
+	for _, m := range s.Mapping {
+		if err := m.apply(msg, metric); err != nil {
+			return nil, err
+		}
+	}
+
+	size := s.marshaller.Size(msg)
+	switch s.Framing {
+	case "varint":
+		buf = protowire.AppendVarint(buf, uint64(size))
+	case "uint32":
+		if size > math.MaxUint32 {
+			return nil, fmt.Errorf("message size %d exceeds framing limit", size)
+		}
+		buf = binary.BigEndian.AppendUint32(buf, uint32(size))
+	}
+	return s.marshaller.MarshalAppend(buf, msg)
+}
+
+func init() {
+	serializers.Add("protobuf",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,374 @@
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var ts = time.Unix(1700000000, 123456789)
+
+func testMetric() telegraf.Metric {
+	return metric.New(
+		"sensor",
+		map[string]string{"device": "th-01", "location": "lab", "status": "STATUS_OK"},
+		map[string]interface{}{"temperature": 21.5, "rssi": int64(-67), "active": true, "status": "ok"},
+		ts,
+	)
+}
+
+func testMapping() []*Mapping {
+	return []*Mapping{
+		{ReadFrom: "name", Target: "measurement"},
+		{ReadFrom: "time", Target: "time"},
+		{ReadFrom: "time", Target: "time_ms", TimeFormat: "unix_ms"},
+		{ReadFrom: "tag", Name: "device", Target: "source.device"},
+		{ReadFrom: "tag", Name: "location", Target: "source.location"},
+		{ReadFrom: "tag", Name: "status"},
+		{Name: "temperature"},
+		{Name: "rssi"},
+		{Name: "active"},
+	}
+}
+
+const expectedJSON = `{
+	"measurement": "sensor",
+	"time": "2023-11-14T22:13:20.123456789Z",
+	"timeMs": "1700000000123",
+	"source": {"device": "th-01", "location": "lab"},
+	"temperature": 21.5,
+	"rssi": -67,
+	"status": "STATUS_OK",
+	"active": true
+}`
+
+func TestSerialize(t *testing.T) {
+	serializer := &Serializer{
+		Files:       []string{"testdata/sensor.proto"},
+		MessageType: "telemetry.Reading",
+		Mapping:     testMapping(),
+	}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.Serialize(testMetric())
+	require.NoError(t, err)
+
+	actual := dynamicpb.NewMessage(serializer.desc)
+	require.NoError(t, proto.Unmarshal(buf, actual))
+	requireMessage(t, expectedJSON, actual)
+}
+
+func TestSerializeDescriptorSet(t *testing.T) {
+	// Create a descriptor set equivalent to
+	// protoc --include_imports --descriptor_set_out=sensor.desc sensor.proto
+	compiler := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: []string{"testdata"}}),
+	}
+	files, err := compiler.Compile(context.Background(), "sensor.proto")
+	require.NoError(t, err)
+	var set descriptorpb.FileDescriptorSet
+	imports := files[0].Imports()
+	for i := 0; i < imports.Len(); i++ {
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(imports.Get(i).FileDescriptor))
+	}
+	set.File = append(set.File, protodesc.ToFileDescriptorProto(files[0]))
+	buf, err := proto.Marshal(&set)
+	require.NoError(t, err)
+	fn := filepath.Join(t.TempDir(), "sensor.desc")
+	require.NoError(t, os.WriteFile(fn, buf, 0600))
+
+	serializer := &Serializer{
+		DescriptorSet: fn,
+		MessageType:   "telemetry.Reading",
+		Mapping:       testMapping(),
+	}
+	require.NoError(t, serializer.Init())
+
+	buf, err = serializer.Serialize(testMetric())
+	require.NoError(t, err)
+
+	actual := dynamicpb.NewMessage(serializer.desc)
+	require.NoError(t, proto.Unmarshal(buf, actual))
+	requireMessage(t, expectedJSON, actual)
+}
+
+func TestSerializeMaps(t *testing.T) {
+	serializer := &Serializer{
+		Files:       []string{"testdata/sensor.proto"},
+		MessageType: "telemetry.Reading",
+		Mapping: []*Mapping{
+			{ReadFrom: "name", Target: "measurement"},
+			{ReadFrom: "time", Target: "time_ms", TimeFormat: "unix_ms"},
+			{ReadFrom: "tags", Target: "tags"},
+			{ReadFrom: "fields", Target: "values"},
+		},
+	}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.Serialize(testMetric())
+	require.NoError(t, err)
+
+	actual := dynamicpb.NewMessage(serializer.desc)
+	require.NoError(t, proto.Unmarshal(buf, actual))
+	requireMessage(t, `{
+		"measurement": "sensor",
+		"timeMs": "1700000000123",
+		"tags": {"device": "th-01", "location": "lab", "status": "STATUS_OK"},
+		"values": {"temperature": 21.5, "rssi": -67, "active": 1}
+	}`, actual)
+}
+
+func TestSerializeMissing(t *testing.T) {
+	serializer := &Serializer{
+		Files:       []string{"testdata/sensor.proto"},
+		MessageType: "telemetry.Reading",
+		Mapping:     testMapping(),
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("sensor", nil, map[string]interface{}{"temperature": 19.0}, time.Unix(10, 0))
+	buf, err := serializer.Serialize(m)
+	require.NoError(t, err)
+
+	actual := dynamicpb.NewMessage(serializer.desc)
+	require.NoError(t, proto.Unmarshal(buf, actual))
+	requireMessage(t, `{
+		"measurement": "sensor",
+		"time": "1970-01-01T00:00:10Z",
+		"timeMs": "10000",
+		"temperature": 19
+	}`, actual)
+}
+
+func TestSerializeConversionError(t *testing.T) {
+	serializer := &Serializer{
+		Files:       []string{"testdata/sensor.proto"},
+		MessageType: "telemetry.Reading",
+		Mapping:     testMapping(),
+	}
+	require.NoError(t, serializer.Init())
+
+	m := metric.New("sensor", nil, map[string]interface{}{"temperature": "hot"}, ts)
+	_, err := serializer.Serialize(m)
+	require.ErrorContains(t, err, `converting field "temperature" for "temperature" failed`)
+}
+
+func TestSerializeBatchFraming(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testMetric(),
+		metric.New("sensor", map[string]string{"device": "th-02"}, map[string]interface{}{"temperature": 18.0}, ts),
+	}
+
+	for _, framing := range []string{"varint", "uint32"} {
+		t.Run(framing, func(t *testing.T) {
+			serializer := &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping: []*Mapping{
+					{ReadFrom: "tag", Name: "device", Target: "source.device"},
+					{Name: "temperature"},
+				},
+				Framing: framing,
+			}
+			require.NoError(t, serializer.Init())
+
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			var actual []*dynamicpb.Message
+			switch framing {
+			case "varint":
+				reader := bytes.NewReader(buf)
+				for reader.Len() > 0 {
+					msg := dynamicpb.NewMessage(serializer.desc)
+					require.NoError(t, protodelim.UnmarshalFrom(reader, msg))
+					actual = append(actual, msg)
+				}
+			case "uint32":
+				for len(buf) > 0 {
+					require.GreaterOrEqual(t, len(buf), 4)
+					size := int(buf[0])<<24 | int(buf[1])<<16 | int(buf[2])<<8 | int(buf[3])
+					msg := dynamicpb.NewMessage(serializer.desc)
+					require.NoError(t, proto.Unmarshal(buf[4:4+size], msg))
+					actual = append(actual, msg)
+					buf = buf[4+size:]
+				}
+			}
+			require.Len(t, actual, 2)
+			requireMessage(t, `{"source": {"device": "th-01"}, "temperature": 21.5}`, actual[0])
+			requireMessage(t, `{"source": {"device": "th-02"}, "temperature": 18}`, actual[1])
+
+			// Single metrics are framed the same way
+			single, err := serializer.Serialize(metrics[0])
+			require.NoError(t, err)
+			batch, err := serializer.SerializeBatch(metrics[:1])
+			require.NoError(t, err)
+			require.Equal(t, batch, single)
+		})
+	}
+}
+
+func TestSerializeBatchWithoutFraming(t *testing.T) {
+	serializer := &Serializer{
+		Files:       []string{"testdata/sensor.proto"},
+		MessageType: "telemetry.Reading",
+		Mapping:     testMapping(),
+	}
+	require.NoError(t, serializer.Init())
+
+	_, err := serializer.SerializeBatch([]telegraf.Metric{testMetric(), testMetric()})
+	require.ErrorContains(t, err, "serializing batches requires framing")
+
+	buf, err := serializer.SerializeBatch([]telegraf.Metric{testMetric()})
+	require.NoError(t, err)
+	single, err := serializer.Serialize(testMetric())
+	require.NoError(t, err)
+	require.Equal(t, single, buf)
+}
+
+func TestInitInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "no message type",
+			serializer: &Serializer{Files: []string{"testdata/sensor.proto"}, Mapping: testMapping()},
+			expected:   "message-type not set",
+		},
+		{
+			name:       "no definitions",
+			serializer: &Serializer{MessageType: "telemetry.Reading", Mapping: testMapping()},
+			expected:   "protocol-buffer files or descriptor set required",
+		},
+		{
+			name: "unknown type",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Unknown",
+				Mapping:     testMapping(),
+			},
+			expected: `looking up message type "telemetry.Unknown" failed`,
+		},
+		{
+			name: "no mapping",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+			},
+			expected: "no mapping defined",
+		},
+		{
+			name: "invalid framing",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     testMapping(),
+				Framing:     "json",
+			},
+			expected: `invalid framing "json"`,
+		},
+		{
+			name: "unknown target",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{Name: "humidity"}},
+			},
+			expected: `field "humidity" not found in message "telemetry.Reading"`,
+		},
+		{
+			name: "non-message path",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{Name: "x", Target: "measurement.x"}},
+			},
+			expected: `field "measurement" of target "measurement.x" is not a singular message`,
+		},
+		{
+			name: "repeated target",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{Name: "samples"}},
+			},
+			expected: `target "samples" must be a singular scalar`,
+		},
+		{
+			name: "message target",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{Name: "source"}},
+			},
+			expected: `target "source" must be a singular scalar`,
+		},
+		{
+			name: "tags to non-map",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{ReadFrom: "tags", Target: "measurement"}},
+			},
+			expected: `target "measurement" must be a map with string keys`,
+		},
+		{
+			name: "time to message",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{ReadFrom: "time", Target: "source"}},
+			},
+			expected: `target "source" must be a scalar or google.protobuf.Timestamp`,
+		},
+		{
+			name: "invalid time format",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{ReadFrom: "time", Target: "time_ms", TimeFormat: "rfc3339"}},
+			},
+			expected: `invalid time format "rfc3339"`,
+		},
+		{
+			name: "invalid source",
+			serializer: &Serializer{
+				Files:       []string{"testdata/sensor.proto"},
+				MessageType: "telemetry.Reading",
+				Mapping:     []*Mapping{{ReadFrom: "metric", Target: "measurement"}},
+			},
+			expected: `unknown assignment "metric"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
+
+func requireMessage(t *testing.T, expected string, actual *dynamicpb.Message) {
+	t.Helper()
+
+	msg := dynamicpb.NewMessage(actual.Descriptor())
+	require.NoError(t, protojson.Unmarshal([]byte(expected), msg))
+	require.Truef(t, proto.Equal(msg, actual), "expected %v but got %v", msg, actual)
+}